@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/api/auth"
+	"github.com/keitahigaki/tfdrift-falco/pkg/api/models"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
+	"github.com/spf13/cobra"
+)
+
+// approvalOptions holds the flags shared by every `tfdrift approval` subcommand
+type approvalOptions struct {
+	server string
+	store  string
+	config string
+	actor  string
+	apiKey string
+	token  string
+}
+
+// approvalQueue is where the approval CLI reads and decides requests: a
+// running daemon's API, or the approval store file directly.
+type approvalQueue interface {
+	List(status terraform.ApprovalStatus) ([]*terraform.ApprovalRequest, error)
+	Approve(id, actor string) (*terraform.ApprovalRequest, error)
+	Reject(id, actor, reason string) (*terraform.ApprovalRequest, error)
+	Cleanup(olderThan time.Duration) (int, error)
+	Close() error
+}
+
+// newApprovalCmd creates the approval subcommand
+func newApprovalCmd() *cobra.Command {
+	opts := &approvalOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "approval",
+		Short: "Manage import approval requests",
+		Long: `Manage terraform import approval requests for unmanaged resources.
+
+Requests are queued when auto_import.require_approval is true and
+auto_import.approval_store_path is set. The commands talk to a running
+daemon through its API (--server), or open the approval store file directly
+(--store, or auto_import.approval_store_path from --config) when no daemon
+is running. Every decision is recorded in the audit trail with its actor.`,
+	}
+
+	cmd.PersistentFlags().StringVar(&opts.server, "server", "", "base URL of a running TFDrift-Falco API (e.g. http://localhost:8080)")
+	cmd.PersistentFlags().StringVar(&opts.store, "store", "", "approval store file to open directly when no daemon is running")
+	cmd.PersistentFlags().StringVar(&opts.config, "config", "", "config file providing auto_import settings and the approval store path")
+	cmd.PersistentFlags().StringVar(&opts.actor, "actor", defaultApprovalActor(), "identity recorded in the audit trail (the API records the authenticated user instead)")
+	cmd.PersistentFlags().StringVar(&opts.apiKey, "api-key", os.Getenv("TFDRIFT_API_KEY"), "API key for --server (default $TFDRIFT_API_KEY)")
+	cmd.PersistentFlags().StringVar(&opts.token, "token", os.Getenv("TFDRIFT_API_TOKEN"), "OIDC bearer token for --server (default $TFDRIFT_API_TOKEN)")
+
+	cmd.AddCommand(newApprovalListCmd(opts))
+	cmd.AddCommand(newApprovalApproveCmd(opts))
+	cmd.AddCommand(newApprovalRejectCmd(opts))
+	cmd.AddCommand(newApprovalCleanupCmd(opts))
+
+	return cmd
+}
+
+// newApprovalListCmd lists pending approval requests
+func newApprovalListCmd(opts *approvalOptions) *cobra.Command {
+	var status string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List pending approval requests",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			queue, err := openApprovalQueue(opts)
+			if err != nil {
+				return err
+			}
+			defer queue.Close()
+
+			requests, err := queue.List(terraform.ApprovalStatus(status))
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if len(requests) == 0 {
+				fmt.Fprintln(out, "No approval requests.")
+				return nil
+			}
+			for _, req := range requests {
+				fmt.Fprintln(out, req.FormatApprovalSummary())
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&status, "status", string(terraform.ApprovalPending), "filter by status: pending, approved, rejected, expired (empty = all)")
+	return cmd
+}
+
+// newApprovalApproveCmd approves a specific request
+func newApprovalApproveCmd(opts *approvalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "approve [request-id]",
+		Short: "Approve a specific import request",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			queue, err := openApprovalQueue(opts)
+			if err != nil {
+				return err
+			}
+			defer queue.Close()
+
+			req, err := queue.Approve(args[0], opts.actor)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "✅ Approved %s by %s\n", req.ID, req.ApprovedBy)
+			return nil
+		},
+	}
+}
+
+// newApprovalRejectCmd rejects a specific request
+func newApprovalRejectCmd(opts *approvalOptions) *cobra.Command {
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:   "reject [request-id]",
+		Short: "Reject a specific import request",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			queue, err := openApprovalQueue(opts)
+			if err != nil {
+				return err
+			}
+			defer queue.Close()
+
+			req, err := queue.Reject(args[0], opts.actor, reason)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "❌ Rejected %s by %s\n", req.ID, req.RejectedBy)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&reason, "reason", "", "reason for rejection")
+	return cmd
+}
+
+// newApprovalCleanupCmd cleans up expired requests
+func newApprovalCleanupCmd(opts *approvalOptions) *cobra.Command {
+	var olderThan string
+
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Clean up expired approval requests",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			d, err := time.ParseDuration(olderThan)
+			if err != nil {
+				return fmt.Errorf("invalid --older-than %q: %w", olderThan, err)
+			}
+
+			queue, err := openApprovalQueue(opts)
+			if err != nil {
+				return err
+			}
+			defer queue.Close()
+
+			n, err := queue.Cleanup(d)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Expired %d request(s) pending longer than %s\n", n, d)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "24h", "clean up requests older than this duration")
+	return cmd
+}
+
+// defaultApprovalActor names the local user for the audit trail
+func defaultApprovalActor() string {
+	if u := os.Getenv("USER"); u != "" {
+		return u
+	}
+	return "cli"
+}
+
+// openApprovalQueue picks the daemon API when --server is set, otherwise the
+// approval store named by --store or the config file.
+func openApprovalQueue(opts *approvalOptions) (approvalQueue, error) {
+	if opts.server != "" {
+		return &apiApprovalQueue{
+			baseURL: strings.TrimRight(opts.server, "/") + "/api/v1/approvals",
+			client:  &http.Client{Timeout: 30 * time.Second},
+			apiKey:  opts.apiKey,
+			token:   opts.token,
+		}, nil
+	}
+
+	var cfg *config.Config
+	if opts.config != "" {
+		loaded, err := config.LoadForScan(opts.config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+		cfg = loaded
+	}
+
+	path := opts.store
+	if path == "" && cfg != nil {
+		path = cfg.AutoImport.ApprovalStorePath
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no approval queue: pass --server to reach a running daemon, or --store/--config to open the approval store")
+	}
+
+	store, err := terraform.NewBoltApprovalStore(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w (use --server while the daemon is running)", err)
+	}
+
+	// Approvals made against the store run the import here, with the same
+	// settings the daemon would use
+	importer := terraform.NewImporter(".", false)
+	if cfg != nil {
+		importer = terraform.NewImporterWithBinary(cfg.AutoImport.TerraformDir, cfg.DryRun, cfg.AutoImport.IaCTool())
+	}
+	manager, err := terraform.NewApprovalManagerWithStore(importer, false, store)
+	if err != nil {
+		_ = store.Close()
+		return nil, err
+	}
+	return &storeApprovalQueue{manager: manager}, nil
+}
+
+// storeApprovalQueue decides requests directly in the approval store
+type storeApprovalQueue struct {
+	manager *terraform.ApprovalManager
+}
+
+func (q *storeApprovalQueue) List(status terraform.ApprovalStatus) ([]*terraform.ApprovalRequest, error) {
+	return q.manager.List(status)
+}
+
+func (q *storeApprovalQueue) Approve(id, actor string) (*terraform.ApprovalRequest, error) {
+	result, err := q.manager.ApproveAndExecute(context.Background(), id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil && !result.Success {
+		return nil, fmt.Errorf("import failed: %v", result.Error)
+	}
+	req, _, err := q.manager.Get(id)
+	return req, err
+}
+
+func (q *storeApprovalQueue) Reject(id, actor, reason string) (*terraform.ApprovalRequest, error) {
+	if err := q.manager.RejectBy(id, actor, reason); err != nil {
+		return nil, err
+	}
+	req, _, err := q.manager.Get(id)
+	return req, err
+}
+
+func (q *storeApprovalQueue) Cleanup(olderThan time.Duration) (int, error) {
+	return q.manager.CleanupExpired(olderThan), nil
+}
+
+func (q *storeApprovalQueue) Close() error {
+	return q.manager.Close()
+}
+
+// apiApprovalQueue decides requests through a running daemon's API
+type apiApprovalQueue struct {
+	baseURL string
+	client  *http.Client
+	apiKey  string
+	token   string
+}
+
+func (q *apiApprovalQueue) List(status terraform.ApprovalStatus) ([]*terraform.ApprovalRequest, error) {
+	var all []*terraform.ApprovalRequest
+	for page := 1; ; page++ {
+		query := url.Values{"status": {string(status)}, "page": {fmt.Sprint(page)}, "limit": {"1000"}}
+		var resp struct {
+			Data       []*terraform.ApprovalRequest `json:"data"`
+			TotalPages int                          `json:"total_pages"`
+		}
+		if err := q.do(http.MethodGet, q.baseURL+"?"+query.Encode(), nil, &resp); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Data...)
+		if page >= resp.TotalPages {
+			return all, nil
+		}
+	}
+}
+
+func (q *apiApprovalQueue) Approve(id, actor string) (*terraform.ApprovalRequest, error) {
+	var req terraform.ApprovalRequest
+	err := q.do(http.MethodPost, q.baseURL+"/"+url.PathEscape(id)+"/approve", map[string]string{"actor": actor}, &req)
+	return &req, err
+}
+
+func (q *apiApprovalQueue) Reject(id, actor, reason string) (*terraform.ApprovalRequest, error) {
+	var req terraform.ApprovalRequest
+	err := q.do(http.MethodPost, q.baseURL+"/"+url.PathEscape(id)+"/reject", map[string]string{"actor": actor, "reason": reason}, &req)
+	return &req, err
+}
+
+func (q *apiApprovalQueue) Cleanup(olderThan time.Duration) (int, error) {
+	var resp struct {
+		Expired int `json:"expired"`
+	}
+	err := q.do(http.MethodPost, q.baseURL+"/cleanup?older_than="+url.QueryEscape(olderThan.String()), nil, &resp)
+	return resp.Expired, err
+}
+
+func (q *apiApprovalQueue) Close() error { return nil }
+
+// do sends a request to the approvals API and decodes the data payload into out
+func (q *apiApprovalQueue) do(method, target string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if q.apiKey != "" {
+		httpReq.Header.Set(auth.APIKeyHeader, q.apiKey)
+	}
+	if q.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+q.token)
+	}
+
+	resp, err := q.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to reach TFDrift-Falco API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	envelope := models.APIResponseGeneric[json.RawMessage]{}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("unexpected response from %s (HTTP %d): %w", target, resp.StatusCode, err)
+	}
+	if !envelope.Success || resp.StatusCode >= 300 {
+		msg := http.StatusText(resp.StatusCode)
+		if envelope.Error != nil && envelope.Error.Message != "" {
+			msg = envelope.Error.Message
+		}
+		return fmt.Errorf("approval API error (HTTP %d): %s", resp.StatusCode, msg)
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
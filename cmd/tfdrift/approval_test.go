@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/keitahigaki/tfdrift-falco/pkg/api/handlers"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runApprovalCmd executes `tfdrift approval <args>` and returns its output
+func runApprovalCmd(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := newApprovalCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestApprovalCmd_Store(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "approvals.db")
+
+	// Seed the store the way a daemon would, then release it
+	store, err := terraform.NewBoltApprovalStore(path)
+	require.NoError(t, err)
+	manager, err := terraform.NewApprovalManagerWithStore(terraform.NewImporter(".", true), false, store)
+	require.NoError(t, err)
+	req := manager.RequestApproval("aws_instance", "i-cli", nil, "alice")
+	require.NoError(t, manager.Close())
+
+	out, err := runApprovalCmd(t, "list", "--store", path)
+	require.NoError(t, err)
+	assert.Contains(t, out, req.ID)
+	assert.Contains(t, out, "pending")
+
+	out, err = runApprovalCmd(t, "reject", req.ID, "--store", path, "--actor", "bob", "--reason", "duplicate")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Rejected "+req.ID+" by bob")
+
+	out, err = runApprovalCmd(t, "list", "--store", path)
+	require.NoError(t, err)
+	assert.Contains(t, out, "No approval requests.")
+
+	out, err = runApprovalCmd(t, "list", "--store", path, "--status", "rejected")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Rejected By:     bob")
+	assert.Contains(t, out, "Reason:          duplicate")
+}
+
+func TestApprovalCmd_Server(t *testing.T) {
+	manager := terraform.NewApprovalManager(terraform.NewImporter(".", true), false)
+	req := manager.RequestApproval("aws_iam_role", "arn:aws:iam::123:role/ops", nil, "alice")
+
+	h := handlers.NewApprovalsHandler(manager)
+	r := chi.NewRouter()
+	r.Get("/api/v1/approvals", h.GetApprovals)
+	r.Post("/api/v1/approvals/{id}/approve", h.ApproveRequest)
+	r.Post("/api/v1/approvals/{id}/reject", h.RejectRequest)
+	r.Post("/api/v1/approvals/cleanup", h.CleanupExpired)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	out, err := runApprovalCmd(t, "list", "--server", srv.URL)
+	require.NoError(t, err)
+	assert.Contains(t, out, req.ID)
+
+	out, err = runApprovalCmd(t, "approve", req.ID, "--server", srv.URL, "--actor", "carol")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Approved "+req.ID+" by carol")
+
+	_, err = runApprovalCmd(t, "reject", req.ID, "--server", srv.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 409")
+
+	out, err = runApprovalCmd(t, "cleanup", "--server", srv.URL, "--older-than", "1h")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Expired 0 request(s)")
+}
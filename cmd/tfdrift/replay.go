@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/detector"
+	"github.com/keitahigaki/tfdrift-falco/pkg/falco"
+	"github.com/keitahigaki/tfdrift-falco/pkg/replay"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// newReplayCmd builds the `tfdrift replay` subcommand: run recorded Falco
+// alerts and cloud audit records through the detector offline.
+func newReplayCmd() *cobra.Command {
+	var (
+		replayConfig string
+		replayState  string
+		replayOutput string
+		failOnAlert  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "replay <file-or-directory>",
+		Short: "Run recorded Falco alerts and cloud audit logs through drift detection offline",
+		Long: `replay feeds recorded events through the full detection pipeline — parsing,
+drift rules, policy and alert generation — against Terraform state, without
+Falco or any cloud access. Use it to check whether a past incident would have
+been caught, to reproduce bug reports, and to regression-test rule and policy
+changes.
+
+The recording is a file or a directory of .json, .jsonl or .ndjson files
+(optionally gzipped) holding any mix of:
+  - Falco alerts (json_output / http_output)
+  - raw CloudTrail records or CloudTrail log files ({"Records": [...]})
+  - GCP Cloud Audit Logs entries
+  - Azure Activity Log records
+
+Events are replayed in event-time order and alerts carry the recorded event
+time, so the same recording against the same state always produces the same
+alerts. Notifications, auto-import and remediation are never triggered.
+
+--state replaces the configured Terraform state of every enabled provider with
+a local state file. Without --config, AWS, GCP and Azure are all enabled
+against that file.
+
+Exit code: 0, or with --fail-on-alert the number of alerts (capped at 250).`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadReplayConfig(replayConfig, replayState)
+			if err != nil {
+				return err
+			}
+			alerts, err := runReplay(cmd.Context(), cfg, args[0], replayOutput, cmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			if code := exitCodeForDrift(len(alerts), failOnAlert); code != 0 {
+				os.Exit(code)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&replayConfig, "config", "", "config file (default is config.yaml, optional with --state)")
+	cmd.Flags().StringVar(&replayState, "state", "", "local Terraform state file to replay against; overrides config")
+	cmd.Flags().StringVar(&replayOutput, "output", "human", "output mode: human or json")
+	cmd.Flags().BoolVar(&failOnAlert, "fail-on-alert", false, "exit non-zero (alert count) when alerts are raised")
+	return cmd
+}
+
+// loadReplayConfig reads the config and applies the --state override. A
+// config is only optional when --state names the state to replay against.
+func loadReplayConfig(cfgPath, statePath string) (*config.Config, error) {
+	if cfgPath == "" && statePath != "" {
+		if _, err := os.Stat("config.yaml"); os.IsNotExist(err) {
+			cfg := &config.Config{}
+			cfg.Providers.AWS.Enabled = true
+			cfg.Providers.GCP.Enabled = true
+			cfg.Providers.Azure.Enabled = true
+			overrideReplayState(cfg, statePath)
+			return cfg, nil
+		}
+	}
+	if cfgPath == "" {
+		cfgPath = "config.yaml"
+	}
+	cfg, err := config.LoadForScan(cfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("load config %q: %w", cfgPath, err)
+	}
+	if statePath != "" {
+		overrideReplayState(cfg, statePath)
+	}
+	return cfg, nil
+}
+
+// overrideReplayState points every enabled provider at one local state file
+func overrideReplayState(cfg *config.Config, statePath string) {
+	state := config.TerraformStateConfig{Backend: "local", LocalPath: statePath}
+	cfg.Providers.AWS.State, cfg.Providers.AWS.States = state, nil
+	cfg.Providers.GCP.State, cfg.Providers.GCP.States = state, nil
+	cfg.Providers.Azure.State, cfg.Providers.Azure.States = state, nil
+}
+
+// replayedAlert is one alert in the JSON replay report
+type replayedAlert struct {
+	Source          string             `json:"source"`
+	EventName       string             `json:"event_name"`
+	EventTime       string             `json:"event_time,omitempty"`
+	AlertType       string             `json:"alert_type"`
+	Severity        string             `json:"severity"`
+	Provider        string             `json:"provider,omitempty"`
+	ResourceType    string             `json:"resource_type"`
+	ResourceID      string             `json:"resource_id"`
+	ResourceAddress string             `json:"resource_address,omitempty"`
+	Attribute       string             `json:"attribute"`
+	OldValue        interface{}        `json:"old_value"`
+	NewValue        interface{}        `json:"new_value"`
+	UserIdentity    types.UserIdentity `json:"user_identity"`
+	MatchedRules    []string           `json:"matched_rules,omitempty"`
+	StateName       string             `json:"state_name,omitempty"`
+}
+
+// runReplay loads the recording, replays it and writes the report to out
+func runReplay(ctx context.Context, cfg *config.Config, path, output string, out io.Writer) ([]replayedAlert, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sub, err := falco.NewSubscriber(cfg.Falco)
+	if err != nil {
+		return nil, fmt.Errorf("create falco parser: %w", err)
+	}
+	records, stats, err := replay.Load(path, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]types.Event, len(records))
+	for i, r := range records {
+		events[i] = r.Event
+	}
+
+	// The detector's console output is the human report; JSON stays clean
+	console := out
+	if output == "json" {
+		console = io.Discard
+	}
+	raised, err := detector.Replay(ctx, cfg, events, console)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]replayedAlert, 0, len(raised))
+	for _, ra := range raised {
+		rec, a := records[ra.Event], ra.Alert
+		alerts = append(alerts, replayedAlert{
+			Source:          rec.Source,
+			EventName:       rec.Event.EventName,
+			EventTime:       a.Timestamp,
+			AlertType:       a.AlertType,
+			Severity:        a.Severity,
+			Provider:        a.Provider,
+			ResourceType:    a.ResourceType,
+			ResourceID:      a.ResourceID,
+			ResourceAddress: a.ResourceAddress,
+			Attribute:       a.Attribute,
+			OldValue:        a.OldValue,
+			NewValue:        a.NewValue,
+			UserIdentity:    a.UserIdentity,
+			MatchedRules:    a.MatchedRules,
+			StateName:       a.StateName,
+		})
+	}
+
+	fmt.Fprintln(out, renderReplayReport(stats, len(events), alerts, output))
+	return alerts, nil
+}
+
+// renderReplayReport formats the replay result. The JSON report carries no
+// wall-clock time so replaying the same recording is byte-for-byte stable.
+func renderReplayReport(stats replay.Stats, events int, alerts []replayedAlert, output string) string {
+	if output == "json" {
+		payload := map[string]interface{}{
+			"summary": map[string]int{
+				"records":   stats.Records,
+				"skipped":   stats.Skipped,
+				"events":    events,
+				"alerts":    len(alerts),
+				"drift":     countAlerts(alerts, "drift"),
+				"unmanaged": countAlerts(alerts, "unmanaged"),
+			},
+			"alerts": alerts,
+		}
+		b, err := json.MarshalIndent(payload, "", "  ")
+		if err != nil {
+			return fmt.Sprintf(`{"error":%q}`, err.Error())
+		}
+		return string(b)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nTFDrift replay — %d record(s) read, %d skipped, %d event(s) replayed\n",
+		stats.Records, stats.Skipped, events)
+	if len(alerts) == 0 {
+		b.WriteString("✅ No alerts raised.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "⚠️  %d alert(s) raised — drift=%d unmanaged=%d\n",
+		len(alerts), countAlerts(alerts, "drift"), countAlerts(alerts, "unmanaged"))
+	for _, a := range alerts {
+		resource := a.ResourceAddress
+		if resource == "" {
+			resource = a.ResourceType + " " + a.ResourceID
+		}
+		fmt.Fprintf(&b, "  [%s] %s %s %s (%s) ← %s\n", a.Severity, a.EventTime, a.AlertType, resource, a.Attribute, a.Source)
+	}
+	return b.String()
+}
+
+// countAlerts counts the alerts of one type
+func countAlerts(alerts []replayedAlert, alertType string) int {
+	n := 0
+	for _, a := range alerts {
+		if a.AlertType == alertType {
+			n++
+		}
+	}
+	return n
+}
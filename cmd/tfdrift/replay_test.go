@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/replay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const replayState = `{"version":4,"serial":1,"resources":[{"mode":"managed","type":"aws_instance","name":"web",
+"instances":[{"attributes":{"id":"i-123","instance_type":"t3.micro"}}]}]}`
+
+const replayRecording = `{"time":"2026-10-01T12:05:00Z","rule":"Terraform Managed Resource Modified","source":"aws_cloudtrail","output_fields":{"ct.name":"ModifyInstanceAttribute","ct.request.instanceid":"i-123","ct.request.instancetype":"t3.large","ct.user":"admin"}}
+{"eventTime":"2026-10-01T12:00:00Z","eventSource":"ec2.amazonaws.com","eventName":"ModifyInstanceAttribute","errorCode":"UnauthorizedOperation","requestParameters":{"instanceId":"i-123"}}
+`
+
+func writeReplayFixtures(t *testing.T) (statePath, recordingPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	statePath = filepath.Join(dir, "terraform.tfstate")
+	recordingPath = filepath.Join(dir, "incident.ndjson")
+	require.NoError(t, os.WriteFile(statePath, []byte(replayState), 0o600))
+	require.NoError(t, os.WriteFile(recordingPath, []byte(replayRecording), 0o600))
+	return statePath, recordingPath
+}
+
+func TestRunReplay_JSONIsDeterministic(t *testing.T) {
+	statePath, recordingPath := writeReplayFixtures(t)
+	cfg, err := loadReplayConfig(filepath.Join(t.TempDir(), "missing.yaml"), statePath)
+	assert.Error(t, err, "an explicit --config must exist")
+	assert.Nil(t, cfg)
+
+	cfg, err = loadReplayConfig("", statePath)
+	require.NoError(t, err)
+
+	var first, second bytes.Buffer
+	alerts, err := runReplay(context.Background(), cfg, recordingPath, "json", &first)
+	require.NoError(t, err)
+	_, err = runReplay(context.Background(), cfg, recordingPath, "json", &second)
+	require.NoError(t, err)
+	assert.Equal(t, first.String(), second.String(), "same recording and state must give the same report")
+
+	var report struct {
+		Summary map[string]int  `json:"summary"`
+		Alerts  []replayedAlert `json:"alerts"`
+	}
+	require.NoError(t, json.Unmarshal(first.Bytes(), &report), first.String())
+	assert.Equal(t, 2, report.Summary["records"])
+	assert.Equal(t, 1, report.Summary["skipped"])
+	assert.Equal(t, 1, report.Summary["events"])
+	require.Len(t, report.Alerts, len(alerts))
+	require.NotEmpty(t, alerts)
+
+	a := report.Alerts[0]
+	assert.Equal(t, recordingPath+":1", a.Source)
+	assert.Equal(t, "2026-10-01T12:05:00Z", a.EventTime)
+	assert.Equal(t, "aws_instance.web", a.ResourceAddress)
+	assert.Equal(t, "t3.micro", a.OldValue)
+	assert.Equal(t, "t3.large", a.NewValue)
+}
+
+func TestRenderReplayReport_Human(t *testing.T) {
+	clean := renderReplayReport(replay.Stats{Records: 3, Skipped: 3}, 0, nil, "human")
+	assert.Contains(t, clean, "3 record(s) read, 3 skipped, 0 event(s) replayed")
+	assert.Contains(t, clean, "No alerts raised")
+
+	rep := renderReplayReport(replay.Stats{Records: 1}, 1, []replayedAlert{{
+		Source: "incident.ndjson:1", EventTime: "2026-10-01T12:05:00Z", AlertType: "drift",
+		Severity: "medium", ResourceAddress: "aws_instance.web", Attribute: "instance_type",
+	}}, "human")
+	assert.Contains(t, rep, "1 alert(s) raised — drift=1 unmanaged=0")
+	assert.Contains(t, rep, "aws_instance.web (instance_type) ← incident.ndjson:1")
+}
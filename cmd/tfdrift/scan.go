@@ -8,10 +8,13 @@ import (
 	"strings"
 	"time"
 
-	"github.com/keitahigaki/tfdrift-falco/pkg/aws"
+	"github.com/keitahigaki/tfdrift-falco/pkg/azure"
 	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/provider"
 	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform/encryption"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -33,15 +36,20 @@ func newScanCmd() *cobra.Command {
 		Use:   "scan",
 		Short: "One-shot reconcile: compare live cloud state against Terraform state and report drift",
 		Long: `scan performs a single read-only reconcile between your Terraform state and the
-actual cloud resources (AWS), then reports unmanaged / missing / modified
-resources and exits with a code reflecting the result.
+actual cloud resources of every enabled provider (AWS, GCP, Azure), then reports
+unmanaged / missing / modified resources per provider and exits with a code
+reflecting the combined result.
 
 Unlike the daemon it needs no Falco and no CloudTrail — only read access to the
 Terraform state and the cloud provider. It is deterministic and suited to CI
 (nightly drift gate) and to answering "right now, does reality match my code?".
 
-Exit code: 0 = no drift; otherwise the number of drifted resources (capped at
-250). Use --fail-on-drift=false to always exit 0 and only report.`,
+Azure discovery authenticates with the AZURE_TENANT_ID, AZURE_CLIENT_ID and
+AZURE_CLIENT_SECRET environment variables.
+
+Exit code: 0 = no drift; otherwise the number of drifted resources across all
+providers (capped at 250). A provider that fails to scan makes the command fail.
+Use --fail-on-drift=false to always exit 0 on drift and only report.`,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			code, err := runScan(scanConfig, scanRegions, scanOutput, failOnDrift)
 			if err != nil {
@@ -60,6 +68,28 @@ Exit code: 0 = no drift; otherwise the number of drifted resources (capped at
 	return cmd
 }
 
+// scanTarget is one enabled provider to reconcile: where its Terraform state
+// lives and which part of the cloud to discover.
+type scanTarget struct {
+	name  string
+	state []config.TerraformStateConfig
+	opts  provider.DiscoveryOptions
+	scope []string // regions / projects / subscription, for the report
+
+	decrypter *encryption.Decrypter
+	sensitive config.SensitiveAttributesConfig
+}
+
+// providerScan is one provider's section of the merged scan report.
+type providerScan struct {
+	Provider       string             `json:"provider"`
+	Scope          []string           `json:"scope"`
+	TerraformCount int                `json:"terraform_resources"`
+	CloudCount     int                `json:"cloud_resources"`
+	Drift          *types.DriftResult `json:"drift,omitempty"`
+	Error          string             `json:"error,omitempty"`
+}
+
 // runScan executes the reconcile and returns the process exit code.
 func runScan(cfgPath string, regionsOverride []string, output string, failOnDrift bool) (int, error) {
 	if cfgPath == "" {
@@ -69,59 +99,214 @@ func runScan(cfgPath string, regionsOverride []string, output string, failOnDrif
 	if err != nil {
 		return 0, fmt.Errorf("load config %q: %w", cfgPath, err)
 	}
-	if !cfg.Providers.AWS.Enabled {
-		return 0, fmt.Errorf("scan currently supports AWS only; enable providers.aws in %s", cfgPath)
+
+	registry, targets, err := buildScanTargets(cfg, regionsOverride)
+	if err != nil {
+		return 0, err
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	sm, err := terraform.NewStateManager(cfg.Providers.AWS.State)
-	if err != nil {
-		return 0, fmt.Errorf("create state manager: %w", err)
+	results := scanProviders(ctx, registry, targets)
+	fmt.Println(renderScanReport(results, output))
+
+	if failed := failedProviders(results); len(failed) > 0 {
+		return 0, fmt.Errorf("scan failed for provider(s): %s", strings.Join(failed, ", "))
 	}
-	if err := sm.Load(ctx); err != nil {
-		return 0, fmt.Errorf("load terraform state: %w", err)
+	return exitCodeForDrift(scanDriftTotal(results), failOnDrift), nil
+}
+
+// buildScanTargets registers every enabled provider in a fresh registry and
+// returns the reconcile target for each, in a stable aws/gcp/azure order.
+func buildScanTargets(cfg *config.Config, regionsOverride []string) (*provider.Registry, []scanTarget, error) {
+	registry := provider.NewRegistry()
+	var targets []scanTarget
+	decrypter, err := encryption.NewDecrypter(cfg.StateEncryption)
+	if err != nil {
+		return nil, nil, fmt.Errorf("state encryption: %w", err)
 	}
-	tfResources := sm.GetAllResources()
 
-	regions := regionsOverride
-	if len(regions) == 0 {
-		regions = cfg.Providers.AWS.Regions
+	if cfg.Providers.AWS.Enabled {
+		regions := regionsOverride
+		if len(regions) == 0 {
+			regions = cfg.Providers.AWS.Regions
+		}
+		if len(regions) == 0 {
+			regions = []string{"us-east-1"}
+		}
+		if err := registry.Register(provider.NewAWSProvider(provider.WithAWSRegions(regions))); err != nil {
+			return nil, nil, fmt.Errorf("register AWS provider: %w", err)
+		}
+		targets = append(targets, scanTarget{
+			name:      "aws",
+			state:     cfg.Providers.AWS.StateConfigs(),
+			opts:      provider.DiscoveryOptions{Regions: regions},
+			scope:     regions,
+			decrypter: decrypter,
+			sensitive: cfg.SensitiveAttributes,
+		})
 	}
-	if len(regions) == 0 {
-		regions = []string{"us-east-1"}
+
+	if cfg.Providers.GCP.Enabled {
+		projects := cfg.Providers.GCP.Projects
+		if len(projects) == 0 {
+			return nil, nil, fmt.Errorf("providers.gcp.projects must list at least one project to scan")
+		}
+		if err := registry.Register(provider.NewGCPProvider(provider.WithGCPProjectID(projects[0]))); err != nil {
+			return nil, nil, fmt.Errorf("register GCP provider: %w", err)
+		}
+		targets = append(targets, scanTarget{
+			name:      "gcp",
+			state:     cfg.Providers.GCP.StateConfigs(),
+			opts:      provider.DiscoveryOptions{Projects: projects},
+			scope:     projects,
+			decrypter: decrypter,
+			sensitive: cfg.SensitiveAttributes,
+		})
 	}
 
-	// Discover across all regions first, then compare ONCE: comparing per
-	// region would flag a resource as "missing" whenever it lives in another
-	// region. Dedup by ID so a global resource (IAM/S3) seen in several regions
-	// isn't counted as multiple unmanaged resources.
-	var allAWS []*types.DiscoveredResource
-	seen := make(map[string]bool)
-	for _, region := range regions {
-		dc, err := aws.NewDiscoveryClient(ctx, region)
-		if err != nil {
-			return 0, fmt.Errorf("create discovery client (%s): %w", region, err)
+	if cfg.Providers.Azure.Enabled {
+		az := cfg.Providers.Azure
+		if az.SubscriptionID == "" {
+			return nil, nil, fmt.Errorf("providers.azure.subscription_id is required to scan Azure")
 		}
-		res, err := dc.DiscoverAll(ctx)
-		if err != nil {
-			return 0, fmt.Errorf("discover resources (%s): %w", region, err)
+		opts := []provider.AzureProviderOption{
+			provider.WithAzureSubscriptionID(az.SubscriptionID),
+			provider.WithAzureRegions(az.Regions),
 		}
-		for _, r := range res {
-			if r != nil && !seen[r.ID] {
-				seen[r.ID] = true
-				allAWS = append(allAWS, r)
-			}
+		if az.ResourceGroup != "" {
+			opts = append(opts, provider.WithAzureResourceGroup(az.ResourceGroup))
+		}
+		// Without credentials discovery reports "resource lister is not
+		// configured" in the Azure section instead of aborting the whole scan.
+		if lister, err := azure.NewARMResourceListerFromEnv(); err == nil {
+			opts = append(opts, provider.WithAzureResourceLister(lister))
+		} else {
+			log.Warnf("Azure discovery credentials not configured: %v", err)
 		}
+		if err := registry.Register(provider.NewAzureProvider(opts...)); err != nil {
+			return nil, nil, fmt.Errorf("register Azure provider: %w", err)
+		}
+		scope := []string{"subscription/" + az.SubscriptionID}
+		if az.ResourceGroup != "" {
+			scope = append(scope, "resource_group/"+az.ResourceGroup)
+		}
+		targets = append(targets, scanTarget{
+			name:      "azure",
+			state:     az.StateConfigs(),
+			opts:      provider.DiscoveryOptions{Regions: az.Regions},
+			scope:     append(scope, az.Regions...),
+			decrypter: decrypter,
+			sensitive: cfg.SensitiveAttributes,
+		})
 	}
 
-	drift := aws.CompareStateWithActual(tfResources, allAWS)
+	return registry, targets, nil
+}
 
-	report := renderDriftReport(drift, output, len(tfResources), len(allAWS), regions)
-	fmt.Println(report)
+// scanProviders reconciles every target. A failure in one provider is recorded
+// in its section rather than aborting the others, so one broken credential
+// doesn't hide drift in the remaining clouds.
+func scanProviders(ctx context.Context, registry *provider.Registry, targets []scanTarget) []*providerScan {
+	results := make([]*providerScan, 0, len(targets))
+	for _, t := range targets {
+		res := &providerScan{Provider: t.name, Scope: t.scope}
+		if err := scanProvider(ctx, registry, t, res); err != nil {
+			res.Error = err.Error()
+			log.Errorf("Scan failed for provider %s: %v", t.name, err)
+		}
+		results = append(results, res)
+	}
+	return results
+}
 
-	return exitCodeForDrift(driftTotal(drift), failOnDrift), nil
+func scanProvider(ctx context.Context, registry *provider.Registry, t scanTarget, res *providerScan) error {
+	discoverer, ok := registry.GetDiscoverer(t.name)
+	if !ok {
+		return fmt.Errorf("provider %s does not support resource discovery", t.name)
+	}
+	comparator, ok := registry.GetComparator(t.name)
+	if !ok {
+		return fmt.Errorf("provider %s does not support state comparison", t.name)
+	}
+
+	sm, err := terraform.NewMultiStateManager(t.state)
+	if err != nil {
+		return fmt.Errorf("create state manager: %w", err)
+	}
+	sm.SetDecrypter(t.decrypter)
+	sm.SetSensitiveAttributes(t.sensitive)
+	if err := sm.Load(ctx); err != nil {
+		return fmt.Errorf("load terraform state: %w", err)
+	}
+	tfResources := toTerraformResources(t.name, sm.GetAllResources())
+
+	discovered, err := discoverer.DiscoverResources(ctx, t.opts)
+	if err != nil {
+		return fmt.Errorf("discover resources: %w", err)
+	}
+
+	// Discovery runs across all regions/projects first and is compared ONCE:
+	// comparing per region would flag a resource as "missing" whenever it lives
+	// in another region. Dedup by ID so a global resource (IAM/S3) seen in
+	// several regions isn't counted as multiple unmanaged resources.
+	seen := make(map[string]bool, len(discovered))
+	cloud := make([]*types.DiscoveredResource, 0, len(discovered))
+	for _, r := range discovered {
+		if r != nil && !seen[r.ID] {
+			seen[r.ID] = true
+			cloud = append(cloud, r)
+		}
+	}
+
+	res.TerraformCount = len(tfResources)
+	res.CloudCount = len(cloud)
+	res.Drift = comparator.CompareState(tfResources, cloud, provider.CompareOptions{})
+	redactModified(res.Drift, sm)
+	return nil
+}
+
+// redactModified marks the field diffs of sensitive attributes and masks
+// their values, so neither report mode prints a secret read from state or
+// from the cloud.
+func redactModified(d *types.DriftResult, sm *terraform.StateManager) {
+	if d == nil {
+		return
+	}
+	for _, diff := range d.ModifiedResources {
+		r, ok := sm.GetResourceOfType(diff.ResourceType, diff.ResourceID)
+		if !ok {
+			continue
+		}
+		for i := range diff.Differences {
+			f := &diff.Differences[i]
+			if r.IsSensitive(f.Field) {
+				f.Sensitive = true
+				f.TerraformValue = types.RedactedValue
+				f.ActualValue = types.RedactedValue
+			}
+		}
+		diff.TerraformState = r.RedactValues(diff.TerraformState)
+		diff.ActualState = r.RedactValues(diff.ActualState)
+	}
+}
+
+// toTerraformResources converts indexed state resources to the
+// provider-agnostic form consumed by StateComparator.
+func toTerraformResources(providerName string, resources []*terraform.Resource) []*types.TerraformResource {
+	out := make([]*types.TerraformResource, 0, len(resources))
+	for _, r := range resources {
+		id, _ := r.Attributes["id"].(string)
+		out = append(out, &types.TerraformResource{
+			Type:       r.Type,
+			Name:       r.Name,
+			ID:         id,
+			Provider:   providerName,
+			Attributes: r.Attributes,
+		})
+	}
+	return out
 }
 
 // driftTotal is the number of drifted resources across all categories.
@@ -132,6 +317,26 @@ func driftTotal(d *types.DriftResult) int {
 	return len(d.UnmanagedResources) + len(d.MissingResources) + len(d.ModifiedResources)
 }
 
+// scanDriftTotal is the number of drifted resources across all providers.
+func scanDriftTotal(results []*providerScan) int {
+	total := 0
+	for _, r := range results {
+		total += driftTotal(r.Drift)
+	}
+	return total
+}
+
+// failedProviders lists the providers whose reconcile did not complete.
+func failedProviders(results []*providerScan) []string {
+	var failed []string
+	for _, r := range results {
+		if r.Error != "" {
+			failed = append(failed, r.Provider)
+		}
+	}
+	return failed
+}
+
 // exitCodeForDrift maps a drift count to a process exit code (0 = clean).
 func exitCodeForDrift(total int, failOnDrift bool) int {
 	if total == 0 || !failOnDrift {
@@ -143,25 +348,57 @@ func exitCodeForDrift(total int, failOnDrift bool) int {
 	return total
 }
 
-// renderDriftReport formats the reconcile result. Pure (no IO) so it is unit
-// tested without cloud access.
-func renderDriftReport(d *types.DriftResult, output string, tfCount, awsCount int, regions []string) string {
-	if d == nil {
-		d = &types.DriftResult{}
+// scanSummary aggregates counts for one provider section or the whole report.
+func scanSummary(results ...*providerScan) map[string]int {
+	s := map[string]int{
+		"terraform_resources": 0,
+		"cloud_resources":     0,
+		"unmanaged":           0,
+		"missing":             0,
+		"modified":            0,
+		"total_drift":         0,
+	}
+	for _, r := range results {
+		s["terraform_resources"] += r.TerraformCount
+		s["cloud_resources"] += r.CloudCount
+		if r.Drift != nil {
+			s["unmanaged"] += len(r.Drift.UnmanagedResources)
+			s["missing"] += len(r.Drift.MissingResources)
+			s["modified"] += len(r.Drift.ModifiedResources)
+		}
+		s["total_drift"] += driftTotal(r.Drift)
 	}
+	return s
+}
+
+// renderScanReport formats the merged reconcile result. Pure (no IO) so it is
+// unit tested without cloud access.
+func renderScanReport(results []*providerScan, output string) string {
 	if output == "json" {
+		sections := make([]map[string]interface{}, 0, len(results))
+		for _, r := range results {
+			section := map[string]interface{}{
+				"provider": r.Provider,
+				"scope":    r.Scope,
+				"summary":  scanSummary(r),
+			}
+			if r.Drift != nil {
+				section["drift"] = r.Drift
+			}
+			if r.Error != "" {
+				section["error"] = r.Error
+			}
+			sections = append(sections, section)
+		}
+		failed := failedProviders(results)
+		if failed == nil {
+			failed = []string{}
+		}
 		payload := map[string]interface{}{
-			"timestamp": time.Now().UTC().Format(time.RFC3339),
-			"regions":   regions,
-			"summary": map[string]int{
-				"terraform_resources": tfCount,
-				"cloud_resources":     awsCount,
-				"unmanaged":           len(d.UnmanagedResources),
-				"missing":             len(d.MissingResources),
-				"modified":            len(d.ModifiedResources),
-				"total_drift":         driftTotal(d),
-			},
-			"drift": d,
+			"timestamp":        time.Now().UTC().Format(time.RFC3339),
+			"summary":          scanSummary(results...),
+			"failed_providers": failed,
+			"providers":        sections,
 		}
 		b, err := json.MarshalIndent(payload, "", "  ")
 		if err != nil {
@@ -171,36 +408,70 @@ func renderDriftReport(d *types.DriftResult, output string, tfCount, awsCount in
 	}
 
 	var b strings.Builder
-	total := driftTotal(d)
-	fmt.Fprintf(&b, "TFDrift scan — regions: %s\n", strings.Join(regions, ", "))
-	fmt.Fprintf(&b, "  terraform resources: %d | cloud resources: %d\n", tfCount, awsCount)
-	if total == 0 {
-		b.WriteString("\n✅ No drift: live cloud state matches Terraform state.\n")
-		return b.String()
+	names := make([]string, 0, len(results))
+	for _, r := range results {
+		names = append(names, r.Provider)
 	}
-	fmt.Fprintf(&b, "\n⚠️  Drift detected: %d resource(s) — unmanaged=%d missing=%d modified=%d\n",
-		total, len(d.UnmanagedResources), len(d.MissingResources), len(d.ModifiedResources))
+	fmt.Fprintf(&b, "TFDrift scan — providers: %s\n", strings.Join(names, ", "))
+
+	for _, r := range results {
+		fmt.Fprintf(&b, "\n== %s (%s) ==\n", strings.ToUpper(r.Provider), strings.Join(r.Scope, ", "))
+		if r.Error != "" {
+			fmt.Fprintf(&b, "❌ Scan failed: %s\n", r.Error)
+			continue
+		}
+		fmt.Fprintf(&b, "  terraform resources: %d | cloud resources: %d\n", r.TerraformCount, r.CloudCount)
+		writeDriftDetails(&b, r.Drift)
+	}
+
+	total := scanDriftTotal(results)
+	failed := failedProviders(results)
+	b.WriteString("\n")
+	switch {
+	case len(failed) > 0:
+		fmt.Fprintf(&b, "❌ Scan incomplete: failed provider(s): %s (drift found elsewhere: %d)\n", strings.Join(failed, ", "), total)
+	case total == 0:
+		b.WriteString("✅ No drift: live cloud state matches Terraform state.\n")
+	default:
+		s := scanSummary(results...)
+		fmt.Fprintf(&b, "⚠️  Drift detected: %d resource(s) — unmanaged=%d missing=%d modified=%d\n",
+			total, s["unmanaged"], s["missing"], s["modified"])
+	}
+	return b.String()
+}
+
+// writeDriftDetails renders one provider's drift categories.
+func writeDriftDetails(b *strings.Builder, d *types.DriftResult) {
+	if driftTotal(d) == 0 {
+		b.WriteString("  ✅ No drift\n")
+		return
+	}
+	fmt.Fprintf(b, "  ⚠️  %d drifted — unmanaged=%d missing=%d modified=%d\n",
+		driftTotal(d), len(d.UnmanagedResources), len(d.MissingResources), len(d.ModifiedResources))
 
 	if len(d.UnmanagedResources) > 0 {
-		b.WriteString("\nUnmanaged (in cloud, not in Terraform):\n")
+		b.WriteString("\n  Unmanaged (in cloud, not in Terraform):\n")
 		for _, r := range d.UnmanagedResources {
-			fmt.Fprintf(&b, "  + %s %s (%s)\n", r.Type, r.ID, r.Region)
+			fmt.Fprintf(b, "    + %s %s (%s)\n", r.Type, r.ID, r.Region)
 		}
 	}
 	if len(d.MissingResources) > 0 {
-		b.WriteString("\nMissing (in Terraform, not in cloud):\n")
+		b.WriteString("\n  Missing (in Terraform, not in cloud):\n")
 		for _, r := range d.MissingResources {
-			fmt.Fprintf(&b, "  - %s.%s (%s)\n", r.Type, r.Name, r.ID)
+			fmt.Fprintf(b, "    - %s.%s (%s)\n", r.Type, r.Name, r.ID)
 		}
 	}
 	if len(d.ModifiedResources) > 0 {
-		b.WriteString("\nModified (attribute differences):\n")
+		b.WriteString("\n  Modified (attribute differences):\n")
 		for _, r := range d.ModifiedResources {
-			fmt.Fprintf(&b, "  ~ %s %s\n", r.ResourceType, r.ResourceID)
+			fmt.Fprintf(b, "    ~ %s %s\n", r.ResourceType, r.ResourceID)
 			for _, f := range r.Differences {
-				fmt.Fprintf(&b, "      %s: terraform=%v actual=%v\n", f.Field, f.TerraformValue, f.ActualValue)
+				if f.Sensitive {
+					fmt.Fprintf(b, "        %s: %s\n", f.Field, types.RedactedValue)
+					continue
+				}
+				fmt.Fprintf(b, "        %s: terraform=%v actual=%v\n", f.Field, f.TerraformValue, f.ActualValue)
 			}
 		}
 	}
-	return b.String()
 }
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/provider"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 )
 
@@ -55,16 +61,22 @@ func TestExitCodeForDrift(t *testing.T) {
 	}
 }
 
-func TestRenderDriftReport_HumanCleanVsDrift(t *testing.T) {
-	clean := renderDriftReport(&types.DriftResult{}, "human", 10, 10, []string{"us-east-1"})
+func TestRenderScanReport_HumanCleanVsDrift(t *testing.T) {
+	clean := renderScanReport([]*providerScan{
+		{Provider: "aws", Scope: []string{"us-east-1"}, TerraformCount: 10, CloudCount: 10, Drift: &types.DriftResult{}},
+	}, "human")
 	if !strings.Contains(clean, "No drift") {
 		t.Errorf("clean report should say No drift, got:\n%s", clean)
 	}
 
-	rep := renderDriftReport(sampleDrift(), "human", 10, 11, []string{"us-east-1"})
+	rep := renderScanReport([]*providerScan{
+		{Provider: "aws", Scope: []string{"us-east-1"}, TerraformCount: 10, CloudCount: 11, Drift: sampleDrift()},
+		{Provider: "gcp", Scope: []string{"my-project"}, TerraformCount: 2, CloudCount: 2, Drift: &types.DriftResult{}},
+	}, "human")
 	for _, want := range []string{
 		"Drift detected: 3", "unmanaged=1", "missing=1", "modified=1",
 		"sg-123", "aws_instance.web", "db-1", "instance_class",
+		"== AWS (us-east-1) ==", "== GCP (my-project) ==",
 	} {
 		if !strings.Contains(rep, want) {
 			t.Errorf("human report missing %q; got:\n%s", want, rep)
@@ -72,8 +84,26 @@ func TestRenderDriftReport_HumanCleanVsDrift(t *testing.T) {
 	}
 }
 
-func TestRenderDriftReport_JSONShape(t *testing.T) {
-	out := renderDriftReport(sampleDrift(), "json", 10, 11, []string{"us-east-1", "ap-northeast-1"})
+func TestRenderScanReport_HumanShowsFailedProvider(t *testing.T) {
+	rep := renderScanReport([]*providerScan{
+		{Provider: "aws", Scope: []string{"us-east-1"}, Drift: &types.DriftResult{}},
+		{Provider: "azure", Scope: []string{"subscription/sub-1"}, Error: "discover resources: boom"},
+	}, "human")
+	for _, want := range []string{"Scan failed: discover resources: boom", "failed provider(s): azure"} {
+		if !strings.Contains(rep, want) {
+			t.Errorf("human report missing %q; got:\n%s", want, rep)
+		}
+	}
+	if strings.Contains(rep, "No drift: live cloud state") {
+		t.Errorf("a failed provider must not be reported as clean; got:\n%s", rep)
+	}
+}
+
+func TestRenderScanReport_JSONShape(t *testing.T) {
+	out := renderScanReport([]*providerScan{
+		{Provider: "aws", Scope: []string{"us-east-1", "ap-northeast-1"}, TerraformCount: 10, CloudCount: 11, Drift: sampleDrift()},
+		{Provider: "gcp", Scope: []string{"p1"}, Error: "boom"},
+	}, "json")
 	var parsed map[string]interface{}
 	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
 		t.Fatalf("json output must parse: %v\n%s", err, out)
@@ -85,7 +115,205 @@ func TestRenderDriftReport_JSONShape(t *testing.T) {
 	if summary["total_drift"].(float64) != 3 {
 		t.Errorf("summary.total_drift = %v, want 3", summary["total_drift"])
 	}
-	if _, ok := parsed["drift"]; !ok {
-		t.Errorf("json output must include drift detail")
+	providers, ok := parsed["providers"].([]interface{})
+	if !ok || len(providers) != 2 {
+		t.Fatalf("json output must include one section per provider, got %v", parsed["providers"])
+	}
+	aws := providers[0].(map[string]interface{})
+	if _, ok := aws["drift"]; !ok {
+		t.Errorf("provider section must include drift detail")
+	}
+	gcp := providers[1].(map[string]interface{})
+	if gcp["error"] != "boom" {
+		t.Errorf("failed provider section must carry its error, got %v", gcp["error"])
+	}
+	if failed := parsed["failed_providers"].([]interface{}); len(failed) != 1 || failed[0] != "gcp" {
+		t.Errorf("failed_providers = %v, want [gcp]", failed)
+	}
+}
+
+// fakeScanProvider is a registry entry with canned discovery output and a
+// trivial ID-based comparator, so scanProviders is tested without cloud access.
+type fakeScanProvider struct {
+	name         string
+	discovered   []*types.DiscoveredResource
+	discoverErr  error
+	modified     []*types.ResourceDiff
+	gotDiscovery provider.DiscoveryOptions
+}
+
+func (f *fakeScanProvider) Name() string { return f.name }
+func (f *fakeScanProvider) ParseEvent(string, map[string]string, interface{}) *types.Event {
+	return nil
+}
+func (f *fakeScanProvider) IsRelevantEvent(string) bool              { return false }
+func (f *fakeScanProvider) MapEventToResource(string, string) string { return "" }
+func (f *fakeScanProvider) ExtractChanges(string, map[string]string) map[string]interface{} {
+	return nil
+}
+func (f *fakeScanProvider) SupportedEventCount() int          { return 0 }
+func (f *fakeScanProvider) SupportedResourceTypes() []string  { return nil }
+func (f *fakeScanProvider) SupportedDiscoveryTypes() []string { return nil }
+
+func (f *fakeScanProvider) DiscoverResources(_ context.Context, opts provider.DiscoveryOptions) ([]*types.DiscoveredResource, error) {
+	f.gotDiscovery = opts
+	return f.discovered, f.discoverErr
+}
+
+func (f *fakeScanProvider) CompareState(tf []*types.TerraformResource, actual []*types.DiscoveredResource, _ provider.CompareOptions) *types.DriftResult {
+	res := &types.DriftResult{Provider: f.name, ModifiedResources: f.modified}
+	known := make(map[string]bool)
+	for _, r := range tf {
+		known[r.ID] = true
+	}
+	for _, r := range actual {
+		if !known[r.ID] {
+			res.UnmanagedResources = append(res.UnmanagedResources, r)
+		}
+	}
+	return res
+}
+
+func writeScanState(t *testing.T, resourceType, id string) []config.TerraformStateConfig {
+	t.Helper()
+	state := `{"version":4,"serial":1,"resources":[{"mode":"managed","type":"` + resourceType +
+		`","name":"main","instances":[{"attributes":{"id":"` + id + `"}}]}]}`
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	if err := os.WriteFile(path, []byte(state), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return []config.TerraformStateConfig{{Backend: "local", LocalPath: path}}
+}
+
+func TestScanProviders_MergesPerProviderResults(t *testing.T) {
+	awsFake := &fakeScanProvider{name: "aws", discovered: []*types.DiscoveredResource{
+		{ID: "i-1", Type: "aws_instance"},
+		{ID: "sg-9", Type: "aws_security_group"},
+		{ID: "sg-9", Type: "aws_security_group"}, // same global resource seen in two regions
+	}}
+	gcpFake := &fakeScanProvider{name: "gcp", discovered: []*types.DiscoveredResource{
+		{ID: "net-1", Type: "google_compute_network"},
+	}}
+	azureFake := &fakeScanProvider{name: "azure", discoverErr: errors.New("no credentials")}
+
+	registry := provider.NewRegistry()
+	for _, p := range []provider.Provider{awsFake, gcpFake, azureFake} {
+		if err := registry.Register(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	targets := []scanTarget{
+		{name: "aws", state: writeScanState(t, "aws_instance", "i-1"), opts: provider.DiscoveryOptions{Regions: []string{"us-east-1"}}},
+		{name: "gcp", state: writeScanState(t, "google_compute_network", "net-1"), opts: provider.DiscoveryOptions{Projects: []string{"p1", "p2"}}},
+		{name: "azure", state: writeScanState(t, "azurerm_resource_group", "rg-1")},
+	}
+
+	results := scanProviders(context.Background(), registry, targets)
+	if len(results) != 3 {
+		t.Fatalf("want one result per provider, got %d", len(results))
+	}
+
+	if results[0].Error != "" || driftTotal(results[0].Drift) != 1 || results[0].CloudCount != 2 {
+		t.Errorf("aws: want 1 unmanaged of 2 deduped cloud resources, got %+v", results[0])
+	}
+	if results[1].Error != "" || driftTotal(results[1].Drift) != 0 {
+		t.Errorf("gcp: want clean scan, got %+v", results[1])
+	}
+	if got := gcpFake.gotDiscovery.Projects; len(got) != 2 {
+		t.Errorf("gcp discovery must receive all configured projects, got %v", got)
+	}
+	if !strings.Contains(results[2].Error, "no credentials") {
+		t.Errorf("azure: discovery error must be recorded in its section, got %q", results[2].Error)
+	}
+
+	if got := scanDriftTotal(results); got != 1 {
+		t.Errorf("scanDriftTotal = %d, want 1", got)
+	}
+	if got := failedProviders(results); len(got) != 1 || got[0] != "azure" {
+		t.Errorf("failedProviders = %v, want [azure]", got)
+	}
+}
+
+func TestScanProviders_RedactsSensitiveDiffs(t *testing.T) {
+	state := `{"version":4,"serial":1,"resources":[{"mode":"managed","type":"aws_db_instance","name":"main",` +
+		`"instances":[{"attributes":{"id":"db-1","password":"old-pw","master_username":"admin","instance_class":"db.t3.small"},` +
+		`"sensitive_attributes":[[{"type":"get_attr","value":"password"}]]}]}]}`
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	if err := os.WriteFile(path, []byte(state), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeScanProvider{name: "aws", modified: []*types.ResourceDiff{{
+		ResourceType:   "aws_db_instance",
+		ResourceID:     "db-1",
+		TerraformState: map[string]interface{}{"password": "old-pw", "master_username": "admin"},
+		ActualState:    map[string]interface{}{"password": "new-pw", "master_username": "root"},
+		Differences: []types.FieldDiff{
+			{Field: "password", TerraformValue: "old-pw", ActualValue: "new-pw"},
+			{Field: "master_username", TerraformValue: "admin", ActualValue: "root"},
+			{Field: "instance_class", TerraformValue: "db.t3.small", ActualValue: "db.t3.large"},
+		},
+	}}}
+	registry := provider.NewRegistry()
+	if err := registry.Register(fake); err != nil {
+		t.Fatal(err)
+	}
+
+	results := scanProviders(context.Background(), registry, []scanTarget{{
+		name:      "aws",
+		state:     []config.TerraformStateConfig{{Backend: "local", LocalPath: path}},
+		sensitive: config.SensitiveAttributesConfig{Patterns: []string{"aws_db_instance:master_*"}},
+	}})
+	if results[0].Error != "" {
+		t.Fatalf("scan failed: %s", results[0].Error)
+	}
+
+	for _, output := range []string{"human", "json"} {
+		rep := renderScanReport(results, output)
+		for _, secret := range []string{"old-pw", "new-pw", "admin", "root"} {
+			if strings.Contains(rep, secret) {
+				t.Errorf("%s report leaks %q; got:\n%s", output, secret, rep)
+			}
+		}
+		if !strings.Contains(rep, "db.t3.large") {
+			t.Errorf("%s report must keep non-sensitive diffs; got:\n%s", output, rep)
+		}
+	}
+
+	diffs := results[0].Drift.ModifiedResources[0].Differences
+	if !diffs[0].Sensitive || !diffs[1].Sensitive || diffs[2].Sensitive {
+		t.Errorf("want password and master_username marked sensitive, got %+v", diffs)
+	}
+}
+
+func TestBuildScanTargets_EnabledProvidersInOrder(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.AWS.Enabled = true
+	cfg.Providers.AWS.Regions = []string{"us-east-1"}
+	cfg.Providers.GCP.Enabled = true
+	cfg.Providers.GCP.Projects = []string{"p1"}
+	cfg.Providers.Azure.Enabled = true
+	cfg.Providers.Azure.SubscriptionID = "sub-1"
+
+	registry, targets, err := buildScanTargets(cfg, []string{"eu-west-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registry.Count() != 3 || len(targets) != 3 {
+		t.Fatalf("want 3 registered providers and targets, got %d/%d", registry.Count(), len(targets))
+	}
+	for i, want := range []string{"aws", "gcp", "azure"} {
+		if targets[i].name != want {
+			t.Errorf("targets[%d] = %s, want %s", i, targets[i].name, want)
+		}
+	}
+	if targets[0].opts.Regions[0] != "eu-west-1" {
+		t.Errorf("--region must override AWS regions, got %v", targets[0].opts.Regions)
+	}
+
+	cfg.Providers.GCP.Projects = nil
+	if _, _, err := buildScanTargets(cfg, nil); err == nil {
+		t.Error("GCP without projects must be rejected")
 	}
 }
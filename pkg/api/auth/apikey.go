@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+)
+
+// APIKeyHeader carries a static API key
+const APIKeyHeader = "X-API-Key"
+
+// apiKey is a configured key, stored only as its SHA-256 digest
+type apiKey struct {
+	name   string
+	digest [sha256.Size]byte
+	role   string
+}
+
+// APIKeyAuthenticator accepts static API keys from the X-API-Key header
+type APIKeyAuthenticator struct {
+	keys []apiKey
+}
+
+// Compile-time interface check
+var _ Authenticator = (*APIKeyAuthenticator)(nil)
+
+// NewAPIKeyAuthenticator creates an authenticator for the configured keys
+func NewAPIKeyAuthenticator(keys []config.APIKeyConfig) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{}
+	for _, k := range keys {
+		entry := apiKey{name: k.Name, role: k.Role}
+		if k.KeySHA256 != "" {
+			raw, err := hex.DecodeString(strings.TrimSpace(k.KeySHA256))
+			if err != nil || len(raw) != sha256.Size {
+				return nil, fmt.Errorf("api key %s: key_sha256 must be a hex-encoded SHA-256 digest", k.Name)
+			}
+			copy(entry.digest[:], raw)
+		} else {
+			entry.digest = sha256.Sum256([]byte(k.Key))
+		}
+		a.keys = append(a.keys, entry)
+	}
+	return a, nil
+}
+
+// Authenticate implements Authenticator
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	presented := r.Header.Get(APIKeyHeader)
+	if presented == "" {
+		return nil, ErrNoCredentials
+	}
+
+	digest := sha256.Sum256([]byte(presented))
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(digest[:], k.digest[:]) == 1 {
+			role, _ := parseRole(k.role)
+			return &Identity{UserID: "apikey:" + k.name, Role: role, Method: "api_key"}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid API key")
+}
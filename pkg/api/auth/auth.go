@@ -0,0 +1,78 @@
+// Package auth authenticates API callers and resolves them to an RBAC role.
+//
+// Authenticators inspect a request for their own kind of credential (API
+// key header, bearer token, verified client certificate). The middleware in
+// pkg/api/middleware runs them in order and stores the resulting identity in
+// the request context, where RequireRole and RequirePermission read it.
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/rbac"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request carries
+// none of its credentials, so the next authenticator should be tried
+var ErrNoCredentials = errors.New("no credentials")
+
+// Identity is an authenticated caller
+type Identity struct {
+	UserID string
+	// Role may be empty when the caller authenticated but maps to no role;
+	// role-protected routes then answer 403.
+	Role rbac.Role
+	// Method names the authenticator that accepted the request
+	Method string
+}
+
+// Authenticator resolves a request to an Identity. It returns
+// ErrNoCredentials when its credential is absent and any other error when
+// the credential is present but invalid.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// NewFromConfig builds the authenticators enabled in cfg, in the order API
+// keys, OIDC, mTLS
+func NewFromConfig(cfg config.AuthConfig) ([]Authenticator, error) {
+	var authenticators []Authenticator
+
+	if len(cfg.APIKeys) > 0 {
+		a, err := NewAPIKeyAuthenticator(cfg.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	if cfg.OIDC.Enabled {
+		authenticators = append(authenticators, NewOIDCAuthenticator(cfg.OIDC, nil))
+	}
+	if cfg.MTLS.Enabled {
+		authenticators = append(authenticators, NewMTLSAuthenticator(cfg.MTLS))
+	}
+
+	return authenticators, nil
+}
+
+// parseRole converts a configured role name to an rbac.Role
+func parseRole(name string) (rbac.Role, bool) {
+	role := rbac.Role(name)
+	if _, ok := rbac.RoleHierarchy[role]; !ok {
+		return "", false
+	}
+	return role, true
+}
+
+// highestRole returns the most privileged of roles, or "" if there are none
+func highestRole(roles []rbac.Role) rbac.Role {
+	var best rbac.Role
+	for _, role := range roles {
+		if best == "" || rbac.RoleHierarchy[role] > rbac.RoleHierarchy[best] {
+			best = role
+		}
+	}
+	return best
+}
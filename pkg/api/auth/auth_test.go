@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/rbac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func apiKeyRequest(key string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/drifts", nil)
+	if key != "" {
+		r.Header.Set(APIKeyHeader, key)
+	}
+	return r
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	digest := sha256.Sum256([]byte("ci-secret"))
+	a, err := NewAPIKeyAuthenticator([]config.APIKeyConfig{
+		{Name: "ops", Key: "ops-secret", Role: "admin"},
+		{Name: "ci", KeySHA256: hex.EncodeToString(digest[:]), Role: "editor"},
+	})
+	require.NoError(t, err)
+
+	id, err := a.Authenticate(apiKeyRequest("ops-secret"))
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{UserID: "apikey:ops", Role: rbac.RoleAdmin, Method: "api_key"}, id)
+
+	id, err = a.Authenticate(apiKeyRequest("ci-secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "apikey:ci", id.UserID)
+	assert.Equal(t, rbac.RoleEditor, id.Role)
+
+	_, err = a.Authenticate(apiKeyRequest("wrong"))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoCredentials)
+
+	_, err = a.Authenticate(apiKeyRequest(""))
+	assert.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestNewAPIKeyAuthenticator_InvalidDigest(t *testing.T) {
+	_, err := NewAPIKeyAuthenticator([]config.APIKeyConfig{{Name: "bad", KeySHA256: "abc", Role: "viewer"}})
+	assert.ErrorContains(t, err, "key_sha256")
+}
+
+func tlsRequest(cn string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/drifts", nil)
+	r.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}},
+	}
+	return r
+}
+
+func TestMTLSAuthenticator(t *testing.T) {
+	a := NewMTLSAuthenticator(config.MTLSConfig{
+		Subjects:    map[string]string{"deployer": "editor"},
+		DefaultRole: "viewer",
+	})
+
+	id, err := a.Authenticate(tlsRequest("deployer"))
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{UserID: "cert:deployer", Role: rbac.RoleEditor, Method: "mtls"}, id)
+
+	id, err = a.Authenticate(tlsRequest("dashboard"))
+	require.NoError(t, err)
+	assert.Equal(t, rbac.RoleViewer, id.Role)
+
+	_, err = a.Authenticate(tlsRequest(""))
+	assert.Error(t, err)
+
+	// Plain HTTP, or a certificate that was presented but not verified
+	_, err = a.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.ErrorIs(t, err, ErrNoCredentials)
+	unverified := httptest.NewRequest(http.MethodGet, "/", nil)
+	unverified.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "deployer"}}}}
+	_, err = a.Authenticate(unverified)
+	assert.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestNewFromConfig(t *testing.T) {
+	authenticators, err := NewFromConfig(config.AuthConfig{
+		Enabled: true,
+		APIKeys: []config.APIKeyConfig{{Name: "ops", Key: "k", Role: "admin"}},
+		OIDC:    config.OIDCConfig{Enabled: true, Issuer: "https://issuer.example.com"},
+		MTLS:    config.MTLSConfig{Enabled: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, authenticators, 3)
+	assert.IsType(t, &APIKeyAuthenticator{}, authenticators[0])
+	assert.IsType(t, &OIDCAuthenticator{}, authenticators[1])
+	assert.IsType(t, &MTLSAuthenticator{}, authenticators[2])
+
+	authenticators, err = NewFromConfig(config.AuthConfig{Enabled: true})
+	require.NoError(t, err)
+	assert.Empty(t, authenticators)
+}
+
+func TestHighestRole(t *testing.T) {
+	assert.Equal(t, rbac.RoleAdmin, highestRole([]rbac.Role{rbac.RoleViewer, rbac.RoleAdmin, rbac.RoleEditor}))
+	assert.Equal(t, rbac.Role(""), highestRole(nil))
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/rbac"
+)
+
+// MTLSAuthenticator identifies callers by the subject common name of a
+// client certificate the TLS handshake already verified
+type MTLSAuthenticator struct {
+	subjects    map[string]rbac.Role
+	defaultRole rbac.Role
+}
+
+// Compile-time interface check
+var _ Authenticator = (*MTLSAuthenticator)(nil)
+
+// NewMTLSAuthenticator creates an authenticator from the mTLS config
+func NewMTLSAuthenticator(cfg config.MTLSConfig) *MTLSAuthenticator {
+	a := &MTLSAuthenticator{subjects: make(map[string]rbac.Role)}
+	for subject, name := range cfg.Subjects {
+		if role, ok := parseRole(name); ok {
+			a.subjects[subject] = role
+		}
+	}
+	a.defaultRole, _ = parseRole(cfg.DefaultRole)
+	return a
+}
+
+// Authenticate implements Authenticator
+func (a *MTLSAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	// Only chains verified against the client CA count; a certificate that
+	// was merely presented is ignored
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+
+	cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	if cn == "" {
+		return nil, fmt.Errorf("client certificate has no subject common name")
+	}
+
+	role, ok := a.subjects[cn]
+	if !ok {
+		role = a.defaultRole
+	}
+	return &Identity{UserID: "cert:" + cn, Role: role, Method: "mtls"}, nil
+}
+
+// ServerTLSConfig loads the server certificate and client CA for mTLS.
+// Client certificates are verified when presented but not required, so
+// callers can still use API keys or bearer tokens over the same listener.
+func ServerTLSConfig(cfg config.MTLSConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in client CA file %s", cfg.ClientCAFile)
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}, nil
+}
@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/rbac"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// jwksMinRefresh limits JWKS refetches triggered by unknown key IDs
+	jwksMinRefresh = time.Minute
+	// jwksMaxAge refreshes the cached key set even when every kid is known
+	jwksMaxAge = time.Hour
+	// clockLeeway tolerates clock skew when checking exp/nbf/iat
+	clockLeeway = time.Minute
+)
+
+// supportedAlgorithms are the JWS algorithms accepted from the issuer
+var supportedAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// OIDCAuthenticator validates bearer JWTs issued by an OIDC provider and
+// maps a claim to an RBAC role
+type OIDCAuthenticator struct {
+	cfg         config.OIDCConfig
+	client      *http.Client
+	roleMapping map[string]rbac.Role
+	defaultRole rbac.Role
+	now         func() time.Time
+
+	mu        sync.Mutex
+	jwksURL   string
+	keys      jose.JSONWebKeySet
+	fetchedAt time.Time
+
+	// refreshes lets concurrent requests share one key set fetch
+	refreshes singleflight.Group
+}
+
+// Compile-time interface check
+var _ Authenticator = (*OIDCAuthenticator)(nil)
+
+// NewOIDCAuthenticator creates an OIDC authenticator. The issuer's key set is
+// fetched lazily on the first token, so an unreachable issuer does not stop
+// the API from starting. A nil client uses a client with a 10s timeout.
+func NewOIDCAuthenticator(cfg config.OIDCConfig, client *http.Client) *OIDCAuthenticator {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = "sub"
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "groups"
+	}
+
+	a := &OIDCAuthenticator{
+		cfg:         cfg,
+		client:      client,
+		roleMapping: make(map[string]rbac.Role),
+		now:         time.Now,
+		jwksURL:     cfg.JWKSURL,
+	}
+	for value, name := range cfg.RoleMapping {
+		if role, ok := parseRole(name); ok {
+			a.roleMapping[value] = role
+		}
+	}
+	a.defaultRole, _ = parseRole(cfg.DefaultRole)
+	return a
+}
+
+// Authenticate implements Authenticator
+func (a *OIDCAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	header := r.Header.Get("Authorization")
+	scheme, raw, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || raw == "" {
+		return nil, ErrNoCredentials
+	}
+
+	tok, err := jwt.ParseSigned(strings.TrimSpace(raw), supportedAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("malformed bearer token: %w", err)
+	}
+	if len(tok.Headers) != 1 {
+		return nil, fmt.Errorf("bearer token must have exactly one signature")
+	}
+
+	key, err := a.key(r.Context(), tok.Headers[0].KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims jwt.Claims
+	var extra map[string]interface{}
+	if err := tok.Claims(key, &claims, &extra); err != nil {
+		return nil, fmt.Errorf("invalid token signature: %w", err)
+	}
+
+	// Tokens the issuer signed for other clients must not be accepted
+	expected := jwt.Expected{Issuer: a.cfg.Issuer, AnyAudience: jwt.Audience{a.cfg.Audience}, Time: a.now()}
+	if err := claims.ValidateWithLeeway(expected, clockLeeway); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+	if claims.Expiry == nil {
+		return nil, fmt.Errorf("token has no expiry")
+	}
+
+	userID, _ := extra[a.cfg.UsernameClaim].(string)
+	if userID == "" {
+		userID = claims.Subject
+	}
+	if userID == "" {
+		return nil, fmt.Errorf("token has no %q claim", a.cfg.UsernameClaim)
+	}
+
+	return &Identity{UserID: userID, Role: a.mapRole(extra[a.cfg.RolesClaim]), Method: "oidc"}, nil
+}
+
+// mapRole resolves the roles claim (string or list of strings) to the most
+// privileged role in role_mapping, falling back to the default role. Claim
+// values are never taken as role names themselves: an IdP group that happens
+// to be called "admin" grants nothing unless mapped.
+func (a *OIDCAuthenticator) mapRole(claim interface{}) rbac.Role {
+	var values []string
+	switch v := claim.(type) {
+	case string:
+		values = strings.Fields(v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	var roles []rbac.Role
+	for _, value := range values {
+		if role, ok := a.roleMapping[value]; ok {
+			roles = append(roles, role)
+		}
+	}
+	if role := highestRole(roles); role != "" {
+		return role
+	}
+	return a.defaultRole
+}
+
+// key returns the verification key for kid, refreshing the cached key set
+// when it is stale or the kid is unknown (key rotation). The key set is
+// fetched without holding a.mu, so a slow issuer doesn't block requests
+// whose keys are cached.
+func (a *OIDCAuthenticator) key(ctx context.Context, kid string) (interface{}, error) {
+	a.mu.Lock()
+	if found := a.lookup(kid); found != nil && a.now().Sub(a.fetchedAt) < jwksMaxAge {
+		a.mu.Unlock()
+		return found.Key, nil
+	}
+	a.mu.Unlock()
+
+	_, err, _ := a.refreshes.Do("jwks", func() (interface{}, error) {
+		return nil, a.refresh(ctx)
+	})
+	if err != nil {
+		// Keep serving with the cached keys if the issuer is briefly down
+		log.Warnf("Failed to refresh OIDC key set: %v", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if found := a.lookup(kid); found != nil {
+		return found.Key, nil
+	}
+	return nil, fmt.Errorf("no signing key found for kid %q", kid)
+}
+
+// lookup finds a signing key by kid. An empty kid matches a key set with a
+// single key. Callers must hold a.mu.
+func (a *OIDCAuthenticator) lookup(kid string) *jose.JSONWebKey {
+	if kid == "" {
+		if len(a.keys.Keys) == 1 {
+			return &a.keys.Keys[0]
+		}
+		return nil
+	}
+	for _, k := range a.keys.Key(kid) {
+		if k.Use == "" || k.Use == "sig" {
+			return &k
+		}
+	}
+	return nil
+}
+
+// refresh fetches the key set, discovering its URL from the issuer first
+// if needed, unless it was fetched less than jwksMinRefresh ago. Callers
+// must not hold a.mu.
+func (a *OIDCAuthenticator) refresh(ctx context.Context) error {
+	a.mu.Lock()
+	now := a.now()
+	if !a.fetchedAt.IsZero() && now.Sub(a.fetchedAt) < jwksMinRefresh {
+		a.mu.Unlock()
+		return nil
+	}
+	a.fetchedAt = now
+	jwksURL := a.jwksURL
+	a.mu.Unlock()
+
+	if jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		wellKnown := strings.TrimRight(a.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := a.getJSON(ctx, wellKnown, &discovery); err != nil {
+			return fmt.Errorf("OIDC discovery failed: %w", err)
+		}
+		if discovery.JWKSURI == "" {
+			return fmt.Errorf("OIDC discovery document at %s has no jwks_uri", wellKnown)
+		}
+		jwksURL = discovery.JWKSURI
+		a.mu.Lock()
+		a.jwksURL = jwksURL
+		a.mu.Unlock()
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := a.getJSON(ctx, jwksURL, &keys); err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	a.mu.Lock()
+	a.keys = keys
+	a.mu.Unlock()
+	return nil
+}
+
+// getJSON GETs url and decodes the JSON body into out
+func (a *OIDCAuthenticator) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/rbac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testIssuer is a local OIDC issuer serving discovery and a JWKS
+type testIssuer struct {
+	srv        *httptest.Server
+	keys       map[string]*rsa.PrivateKey
+	jwksHits   atomic.Int32
+	publishing []string      // kids currently published in the JWKS
+	stall      chan struct{} // when set, JWKS responses wait for it to close
+}
+
+func newTestIssuer(t *testing.T, kids ...string) *testIssuer {
+	t.Helper()
+	iss := &testIssuer{keys: make(map[string]*rsa.PrivateKey), publishing: kids}
+	for _, kid := range kids {
+		iss.addKey(t, kid)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"jwks_uri": iss.srv.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		iss.jwksHits.Add(1)
+		if iss.stall != nil {
+			<-iss.stall
+		}
+		var set jose.JSONWebKeySet
+		for _, kid := range iss.publishing {
+			set.Keys = append(set.Keys, jose.JSONWebKey{Key: &iss.keys[kid].PublicKey, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"})
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	})
+	iss.srv = httptest.NewServer(mux)
+	t.Cleanup(iss.srv.Close)
+	return iss
+}
+
+func (iss *testIssuer) addKey(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	iss.keys[kid] = key
+}
+
+// sign issues a token signed with kid's key
+func (iss *testIssuer) sign(t *testing.T, kid string, claims jwt.Claims, extra map[string]interface{}) string {
+	t.Helper()
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: iss.keys[kid]},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid),
+	)
+	require.NoError(t, err)
+	raw, err := jwt.Signed(signer).Claims(claims).Claims(extra).Serialize()
+	require.NoError(t, err)
+	return raw
+}
+
+func (iss *testIssuer) claims(subject string) jwt.Claims {
+	now := time.Now()
+	return jwt.Claims{
+		Issuer:   iss.srv.URL,
+		Subject:  subject,
+		Audience: jwt.Audience{"tfdrift"},
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+}
+
+func bearerRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/drifts", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func newTestOIDC(iss *testIssuer) *OIDCAuthenticator {
+	return NewOIDCAuthenticator(config.OIDCConfig{
+		Enabled:       true,
+		Issuer:        iss.srv.URL,
+		Audience:      "tfdrift",
+		UsernameClaim: "email",
+		RoleMapping: map[string]string{
+			"platform-admins": "admin",
+			"sre":             "editor",
+		},
+		DefaultRole: "viewer",
+	}, iss.srv.Client())
+}
+
+func TestOIDCAuthenticator_MapsClaimsToRole(t *testing.T) {
+	iss := newTestIssuer(t, "k1")
+	a := newTestOIDC(iss)
+
+	tests := []struct {
+		name   string
+		groups interface{}
+		want   rbac.Role
+	}{
+		{"highest mapped group wins", []string{"sre", "platform-admins"}, rbac.RoleAdmin},
+		{"single mapped group", []string{"sre"}, rbac.RoleEditor},
+		{"unmapped role name grants nothing", []string{"admin"}, rbac.RoleViewer},
+		{"unmapped role name in string claim", "editor", rbac.RoleViewer},
+		{"unmapped falls back to default", []string{"marketing"}, rbac.RoleViewer},
+		{"missing claim falls back to default", nil, rbac.RoleViewer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extra := map[string]interface{}{"email": "alice@example.com"}
+			if tt.groups != nil {
+				extra["groups"] = tt.groups
+			}
+			id, err := a.Authenticate(bearerRequest(iss.sign(t, "k1", iss.claims("u-1"), extra)))
+			require.NoError(t, err)
+			assert.Equal(t, "alice@example.com", id.UserID)
+			assert.Equal(t, tt.want, id.Role)
+			assert.Equal(t, "oidc", id.Method)
+		})
+	}
+}
+
+func TestOIDCAuthenticator_RejectsInvalidTokens(t *testing.T) {
+	iss := newTestIssuer(t, "k1")
+	a := newTestOIDC(iss)
+
+	expired := iss.claims("u-1")
+	expired.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+	wrongAudience := iss.claims("u-1")
+	wrongAudience.Audience = jwt.Audience{"someone-else"}
+
+	wrongIssuer := iss.claims("u-1")
+	wrongIssuer.Issuer = "https://evil.example.com"
+
+	noAudience := iss.claims("u-1")
+	noAudience.Audience = nil
+
+	noExpiry := iss.claims("u-1")
+	noExpiry.Expiry = nil
+
+	for name, claims := range map[string]jwt.Claims{
+		"expired":        expired,
+		"wrong audience": wrongAudience,
+		"no audience":    noAudience,
+		"wrong issuer":   wrongIssuer,
+		"no expiry":      noExpiry,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := a.Authenticate(bearerRequest(iss.sign(t, "k1", claims, nil)))
+			require.Error(t, err)
+			assert.NotErrorIs(t, err, ErrNoCredentials)
+		})
+	}
+
+	t.Run("signed by unknown key", func(t *testing.T) {
+		iss.addKey(t, "rogue")
+		_, err := a.Authenticate(bearerRequest(iss.sign(t, "rogue", iss.claims("u-1"), nil)))
+		assert.ErrorContains(t, err, "no signing key")
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		_, err := a.Authenticate(bearerRequest("not.a.jwt"))
+		assert.ErrorContains(t, err, "malformed bearer token")
+	})
+
+	t.Run("no bearer header", func(t *testing.T) {
+		_, err := a.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.ErrorIs(t, err, ErrNoCredentials)
+	})
+}
+
+func TestOIDCAuthenticator_KeyRotation(t *testing.T) {
+	iss := newTestIssuer(t, "k1")
+	a := newTestOIDC(iss)
+	clock := time.Now()
+	a.now = func() time.Time { return clock }
+
+	_, err := a.Authenticate(bearerRequest(iss.sign(t, "k1", iss.claims("u-1"), nil)))
+	require.NoError(t, err)
+	_, err = a.Authenticate(bearerRequest(iss.sign(t, "k1", iss.claims("u-1"), nil)))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), iss.jwksHits.Load(), "known keys are served from cache")
+
+	// The issuer rotates to k2; an unknown kid refetches once the minimum
+	// refresh interval has passed
+	iss.addKey(t, "k2")
+	iss.publishing = []string{"k1", "k2"}
+	_, err = a.Authenticate(bearerRequest(iss.sign(t, "k2", iss.claims("u-1"), nil)))
+	assert.Error(t, err, "refetch is rate limited")
+
+	clock = clock.Add(jwksMinRefresh)
+	_, err = a.Authenticate(bearerRequest(iss.sign(t, "k2", iss.claims("u-1"), nil)))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), iss.jwksHits.Load())
+}
+
+func TestOIDCAuthenticator_RefreshDoesNotBlockCachedKeys(t *testing.T) {
+	iss := newTestIssuer(t, "k1")
+	a := newTestOIDC(iss)
+	clock := time.Now()
+	a.now = func() time.Time { return clock }
+
+	_, err := a.Authenticate(bearerRequest(iss.sign(t, "k1", iss.claims("u-1"), nil)))
+	require.NoError(t, err)
+
+	// A token with a new kid refetches from an issuer that hangs
+	iss.addKey(t, "k2")
+	iss.publishing = []string{"k1", "k2"}
+	iss.stall = make(chan struct{})
+	release := sync.OnceFunc(func() { close(iss.stall) })
+	t.Cleanup(release)
+	clock = clock.Add(jwksMinRefresh)
+	k2Token := iss.sign(t, "k2", iss.claims("u-2"), nil)
+	k1Token := iss.sign(t, "k1", iss.claims("u-1"), nil)
+	rotated := make(chan error, 1)
+	go func() {
+		_, err := a.Authenticate(bearerRequest(k2Token))
+		rotated <- err
+	}()
+	require.Eventually(t, func() bool { return iss.jwksHits.Load() == 2 }, 5*time.Second, time.Millisecond)
+
+	// Cached keys are served while the fetch is in flight
+	cached := make(chan error, 1)
+	go func() {
+		_, err := a.Authenticate(bearerRequest(k1Token))
+		cached <- err
+	}()
+	select {
+	case err := <-cached:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("a cached key waited for the key set fetch")
+	}
+
+	release()
+	assert.NoError(t, <-rotated)
+}
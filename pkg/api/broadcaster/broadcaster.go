@@ -56,8 +56,10 @@ func (b *Broadcaster) Broadcast(event Event) {
 	}
 }
 
-// BroadcastDriftAlert broadcasts a drift alert event
+// BroadcastDriftAlert broadcasts a drift alert event, with sensitive values
+// redacted
 func (b *Broadcaster) BroadcastDriftAlert(alert types.DriftAlert) {
+	alert = *alert.Redacted()
 	event := Event{
 		Type:      "drift",
 		Timestamp: alert.Timestamp,
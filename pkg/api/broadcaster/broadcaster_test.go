@@ -182,6 +182,25 @@ func TestBroadcaster_BroadcastDriftAlert(t *testing.T) {
 	assert.Equal(t, "EC2", received.Payload["resource_type"])
 }
 
+func TestBroadcaster_BroadcastDriftAlert_RedactsSensitiveValues(t *testing.T) {
+	bc := NewBroadcaster()
+	ch := make(chan Event, 1)
+	bc.Subscribe(ch)
+
+	bc.BroadcastDriftAlert(types.DriftAlert{
+		ResourceType: "aws_db_instance",
+		Attribute:    "password",
+		OldValue:     "old-secret",
+		NewValue:     "new-secret",
+		Sensitive:    true,
+	})
+
+	received := <-ch
+	assert.Equal(t, "password", received.Payload["attribute"])
+	assert.Equal(t, types.RedactedValue, received.Payload["old_value"])
+	assert.Equal(t, types.RedactedValue, received.Payload["new_value"])
+}
+
 func TestBroadcaster_SubscriberCountAccuracy(t *testing.T) {
 	bc := NewBroadcaster()
 
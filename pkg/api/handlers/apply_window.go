@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/detector"
+	log "github.com/sirupsen/logrus"
+)
+
+// ApplyWindowsHandler lets CI pipelines announce terraform applies, so drift
+// on the state being applied is held until the apply has finished
+type ApplyWindowsHandler struct {
+	windows *detector.ApplyWindows
+}
+
+// NewApplyWindowsHandler creates a new apply windows handler
+func NewApplyWindowsHandler(windows *detector.ApplyWindows) *ApplyWindowsHandler {
+	return &ApplyWindowsHandler{
+		windows: windows,
+	}
+}
+
+// applyWindowRequest is the body of start/finish requests
+type applyWindowRequest struct {
+	// State is the state entry's name, or its location (s3://bucket/key,
+	// a local path, ...); empty when only one state is loaded
+	State string `json:"state"`
+	Actor string `json:"actor"`
+	// Serial is the state serial the apply wrote (finish only)
+	Serial int `json:"serial"`
+}
+
+// GetApplyWindows handles GET /api/v1/apply-windows
+func (h *ApplyWindowsHandler) GetApplyWindows(w http.ResponseWriter, r *http.Request) {
+	log.Debug("GET /api/v1/apply-windows")
+	windows := h.windows.List()
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"windows": windows,
+		"count":   len(windows),
+	})
+}
+
+// StartApplyWindow handles POST /api/v1/apply-windows/start
+func (h *ApplyWindowsHandler) StartApplyWindow(w http.ResponseWriter, r *http.Request) {
+	log.Debug("POST /api/v1/apply-windows/start")
+	req, ok := decodeApplyWindowRequest(w, r)
+	if !ok {
+		return
+	}
+
+	window, err := h.windows.Start(req.State, resolveActor(r, approvalDecision{Actor: req.Actor}))
+	if err != nil {
+		respondApplyWindowError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, window)
+}
+
+// FinishApplyWindow handles POST /api/v1/apply-windows/finish. The state is
+// reloaded and held events are evaluated in the background.
+func (h *ApplyWindowsHandler) FinishApplyWindow(w http.ResponseWriter, r *http.Request) {
+	log.Debug("POST /api/v1/apply-windows/finish")
+	req, ok := decodeApplyWindowRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Serial < 0 {
+		respondError(w, http.StatusBadRequest, "serial must not be negative")
+		return
+	}
+
+	window, err := h.windows.Finish(req.State, req.Serial)
+	if err != nil {
+		respondApplyWindowError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusAccepted, window)
+}
+
+func decodeApplyWindowRequest(w http.ResponseWriter, r *http.Request) (applyWindowRequest, bool) {
+	var req applyWindowRequest
+	if r.Body == nil {
+		return req, true
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return req, false
+	}
+	return req, true
+}
+
+func respondApplyWindowError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, detector.ErrUnknownState), errors.Is(err, detector.ErrNoApplyWindow):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, detector.ErrStateRequired), errors.Is(err, detector.ErrAmbiguousState):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/detector"
+)
+
+func newApplyWindowsRouter(t *testing.T) (http.Handler, string) {
+	t.Helper()
+	statePath := filepath.Join(t.TempDir(), "terraform.tfstate")
+	state := `{"version": 4, "serial": 7, "lineage": "l", "resources": []}`
+	if err := os.WriteFile(statePath, []byte(state), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Providers.AWS.Enabled = true
+	cfg.Providers.AWS.State.Backend = "local"
+	cfg.Providers.AWS.State.LocalPath = statePath
+	det, err := detector.New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create detector: %v", err)
+	}
+	if err := det.GetStateManager().Load(context.Background()); err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+
+	h := NewApplyWindowsHandler(det.GetApplyWindows())
+	r := chi.NewRouter()
+	r.Get("/api/v1/apply-windows", h.GetApplyWindows)
+	r.Post("/api/v1/apply-windows/start", h.StartApplyWindow)
+	r.Post("/api/v1/apply-windows/finish", h.FinishApplyWindow)
+	return r, statePath
+}
+
+func TestApplyWindowsHandler_StartListFinish(t *testing.T) {
+	router, statePath := newApplyWindowsRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/apply-windows/start",
+		strings.NewReader(`{"actor":"pipeline-42"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	window := decodeData(t, rec)
+	if window["state"] != statePath || window["actor"] != "pipeline-42" || window["serial"] != float64(7) {
+		t.Errorf("unexpected window: %v", window)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/apply-windows", nil))
+	if data := decodeData(t, rec); data["count"] != float64(1) {
+		t.Errorf("expected one open window, got %v", data)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/apply-windows/finish",
+		strings.NewReader(`{"state":"`+statePath+`","serial":7}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if window := decodeData(t, rec); window["closing"] != true {
+		t.Errorf("expected a closing window, got %v", window)
+	}
+}
+
+func TestApplyWindowsHandler_Errors(t *testing.T) {
+	router, _ := newApplyWindowsRouter(t)
+
+	tests := []struct {
+		path string
+		body string
+		want int
+	}{
+		{"/api/v1/apply-windows/start", `{"state":"s3://unknown/terraform.tfstate"}`, http.StatusNotFound},
+		{"/api/v1/apply-windows/start", `{`, http.StatusBadRequest},
+		{"/api/v1/apply-windows/finish", `{}`, http.StatusNotFound},
+		{"/api/v1/apply-windows/finish", `{"serial":-1}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("POST %s %s: expected %d, got %d", tt.path, tt.body, tt.want, rec.Code)
+		}
+	}
+}
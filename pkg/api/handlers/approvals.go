@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	apimiddleware "github.com/keitahigaki/tfdrift-falco/pkg/api/middleware"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
+	log "github.com/sirupsen/logrus"
+)
+
+// ApprovalsHandler handles import approval queue requests
+type ApprovalsHandler struct {
+	manager *terraform.ApprovalManager
+}
+
+// NewApprovalsHandler creates a new approvals handler. A nil manager (auto
+// import disabled) makes every endpoint answer 404.
+func NewApprovalsHandler(manager *terraform.ApprovalManager) *ApprovalsHandler {
+	return &ApprovalsHandler{
+		manager: manager,
+	}
+}
+
+// approvalDecision is the optional body of approve/reject requests
+type approvalDecision struct {
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+// GetApprovals handles GET /api/v1/approvals
+func (h *ApprovalsHandler) GetApprovals(w http.ResponseWriter, r *http.Request) {
+	log.Debug("GET /api/v1/approvals")
+	if !h.enabled(w) {
+		return
+	}
+
+	params := ParsePagination(r, 50)
+	status := terraform.ApprovalStatus(r.URL.Query().Get("status"))
+
+	requests, err := h.manager.List(status)
+	if err != nil {
+		log.Errorf("Failed to list approval requests: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to list approval requests")
+		return
+	}
+
+	response := PaginatedResponseData(Paginate(requests, params), params, len(requests))
+	respondJSON(w, http.StatusOK, response)
+}
+
+// GetApproval handles GET /api/v1/approvals/:id
+func (h *ApprovalsHandler) GetApproval(w http.ResponseWriter, r *http.Request) {
+	requestID := approvalID(r)
+	log.Debugf("GET /api/v1/approvals/%s", requestID)
+	if !h.enabled(w) {
+		return
+	}
+
+	request, ok := h.lookup(w, requestID)
+	if !ok {
+		return
+	}
+	audit, err := h.manager.AuditTrail(request.ID)
+	if err != nil {
+		log.Errorf("Failed to read audit trail for %s: %v", request.ID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to read audit trail")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"request": request,
+		"audit":   audit,
+	})
+}
+
+// GetAuditTrail handles GET /api/v1/approvals/audit
+func (h *ApprovalsHandler) GetAuditTrail(w http.ResponseWriter, r *http.Request) {
+	log.Debug("GET /api/v1/approvals/audit")
+	if !h.enabled(w) {
+		return
+	}
+
+	params := ParsePagination(r, 50)
+	audit, err := h.manager.AuditTrail(r.URL.Query().Get("request_id"))
+	if err != nil {
+		log.Errorf("Failed to read approval audit trail: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to read audit trail")
+		return
+	}
+
+	response := PaginatedResponseData(Paginate(audit, params), params, len(audit))
+	respondJSON(w, http.StatusOK, response)
+}
+
+// ApproveRequest handles POST /api/v1/approvals/:id/approve. The import runs
+// in the background; its outcome is recorded in the audit trail.
+func (h *ApprovalsHandler) ApproveRequest(w http.ResponseWriter, r *http.Request) {
+	requestID := approvalID(r)
+	log.Debugf("POST /api/v1/approvals/%s/approve", requestID)
+	if !h.enabled(w) {
+		return
+	}
+
+	decision, ok := decodeDecision(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := h.lookup(w, requestID); !ok {
+		return
+	}
+
+	request, err := h.manager.Approve(requestID, resolveActor(r, decision))
+	if err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	approved := *request
+
+	if err := h.manager.ExecuteApprovedAsync(requestID); err != nil {
+		log.Errorf("Failed to execute approved import %s: %v", requestID, err)
+	}
+
+	respondJSON(w, http.StatusAccepted, &approved)
+}
+
+// RejectRequest handles POST /api/v1/approvals/:id/reject
+func (h *ApprovalsHandler) RejectRequest(w http.ResponseWriter, r *http.Request) {
+	requestID := approvalID(r)
+	log.Debugf("POST /api/v1/approvals/%s/reject", requestID)
+	if !h.enabled(w) {
+		return
+	}
+
+	decision, ok := decodeDecision(w, r)
+	if !ok {
+		return
+	}
+	request, ok := h.lookup(w, requestID)
+	if !ok {
+		return
+	}
+
+	if err := h.manager.RejectBy(request.ID, resolveActor(r, decision), decision.Reason); err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, request)
+}
+
+// CleanupExpired handles POST /api/v1/approvals/cleanup?older_than=24h
+func (h *ApprovalsHandler) CleanupExpired(w http.ResponseWriter, r *http.Request) {
+	log.Debug("POST /api/v1/approvals/cleanup")
+	if !h.enabled(w) {
+		return
+	}
+
+	olderThan := 24 * time.Hour
+	if v := r.URL.Query().Get("older_than"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			respondError(w, http.StatusBadRequest, "Invalid older_than duration")
+			return
+		}
+		olderThan = d
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"expired": h.manager.CleanupExpired(olderThan),
+	})
+}
+
+// approvalID returns the unescaped {id} path parameter. Request IDs embed
+// cloud resource IDs, which may contain escaped slashes.
+func approvalID(r *http.Request) string {
+	id := chi.URLParam(r, "id")
+	if unescaped, err := url.PathUnescape(id); err == nil {
+		return unescaped
+	}
+	return id
+}
+
+// enabled writes a 404 when the approval workflow is not running
+func (h *ApprovalsHandler) enabled(w http.ResponseWriter) bool {
+	if h.manager == nil {
+		respondError(w, http.StatusNotFound, "Approval workflow not enabled (auto_import.enabled is false)")
+		return false
+	}
+	return true
+}
+
+// lookup fetches a request by ID, writing 404/500 on failure
+func (h *ApprovalsHandler) lookup(w http.ResponseWriter, requestID string) (*terraform.ApprovalRequest, bool) {
+	request, exists, err := h.manager.Get(requestID)
+	if err != nil {
+		log.Errorf("Failed to read approval request %s: %v", requestID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to read approval request")
+		return nil, false
+	}
+	if !exists {
+		respondError(w, http.StatusNotFound, "Approval request not found")
+		return nil, false
+	}
+	return request, true
+}
+
+// decodeDecision parses the optional approve/reject body
+func decodeDecision(w http.ResponseWriter, r *http.Request) (approvalDecision, bool) {
+	var decision approvalDecision
+	if r.Body == nil {
+		return decision, true
+	}
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return decision, false
+	}
+	return decision, true
+}
+
+// resolveActor names who made a decision. An authenticated caller always
+// wins over the self-reported actor in the body, so the audit trail can't
+// be spoofed once authentication is enabled.
+func resolveActor(r *http.Request, decision approvalDecision) string {
+	if userID, ok := apimiddleware.GetUserID(r.Context()); ok && userID != "" {
+		return userID
+	}
+	if actor := strings.TrimSpace(decision.Actor); actor != "" {
+		return actor
+	}
+	return "api"
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	apimiddleware "github.com/keitahigaki/tfdrift-falco/pkg/api/middleware"
+	"github.com/keitahigaki/tfdrift-falco/pkg/rbac"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
+)
+
+func newApprovalsRouter(manager *terraform.ApprovalManager) http.Handler {
+	h := NewApprovalsHandler(manager)
+	r := chi.NewRouter()
+	r.Get("/api/v1/approvals", h.GetApprovals)
+	r.Get("/api/v1/approvals/audit", h.GetAuditTrail)
+	r.Get("/api/v1/approvals/{id}", h.GetApproval)
+	r.Post("/api/v1/approvals/{id}/approve", h.ApproveRequest)
+	r.Post("/api/v1/approvals/{id}/reject", h.RejectRequest)
+	r.Post("/api/v1/approvals/cleanup", h.CleanupExpired)
+	return r
+}
+
+func decodeData(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var resp struct {
+		Success bool                   `json:"success"`
+		Data    map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp.Data
+}
+
+func TestApprovalsHandler_Disabled(t *testing.T) {
+	router := newApprovalsRouter(nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/approvals", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 when auto-import is disabled, got %d", rec.Code)
+	}
+}
+
+func TestApprovalsHandler_ListAndGet(t *testing.T) {
+	manager := terraform.NewApprovalManager(terraform.NewImporter(".", true), false)
+	req := manager.RequestApproval("aws_iam_role", "arn:aws:iam::123:role/ops", nil, "alice")
+	router := newApprovalsRouter(manager)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/approvals?status=pending", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	data := decodeData(t, rec)
+	if data["total"].(float64) != 1 {
+		t.Errorf("expected 1 pending request, got %v", data["total"])
+	}
+
+	// IDs embed resource IDs with slashes, so clients path-escape them
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/approvals/"+url.PathEscape(req.ID), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	data = decodeData(t, rec)
+	if got := data["request"].(map[string]interface{})["id"]; got != req.ID {
+		t.Errorf("expected request %q, got %v", req.ID, got)
+	}
+	if audit := data["audit"].([]interface{}); len(audit) != 1 {
+		t.Errorf("expected 1 audit entry, got %d", len(audit))
+	}
+}
+
+func TestApprovalsHandler_Reject(t *testing.T) {
+	manager := terraform.NewApprovalManager(terraform.NewImporter(".", true), false)
+	req := manager.RequestApproval("aws_instance", "i-123", nil, "alice")
+	router := newApprovalsRouter(manager)
+
+	body := strings.NewReader(`{"actor":"bob","reason":"not ours"}`)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/approvals/"+req.ID+"/reject", body))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	data := decodeData(t, rec)
+	if data["status"] != string(terraform.ApprovalRejected) || data["rejected_by"] != "bob" || data["reject_reason"] != "not ours" {
+		t.Errorf("unexpected rejected request: %v", data)
+	}
+
+	// A second decision conflicts
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/approvals/"+req.ID+"/approve", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for decided request, got %d", rec.Code)
+	}
+}
+
+func TestApprovalsHandler_ApproveUsesAuthenticatedUser(t *testing.T) {
+	manager := terraform.NewApprovalManager(terraform.NewImporter(".", true), false)
+	req := manager.RequestApproval("aws_instance", "i-456", nil, "alice")
+	router := newApprovalsRouter(manager)
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/approvals/"+req.ID+"/approve", strings.NewReader(`{"actor":"mallory"}`))
+	httpReq = apimiddleware.SetUserContext(httpReq, "carol", rbac.RoleEditor)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httpReq)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := decodeData(t, rec)["approved_by"]; got != "carol" {
+		t.Errorf("expected authenticated user to be recorded, got %v", got)
+	}
+}
+
+func TestApprovalsHandler_NotFound(t *testing.T) {
+	manager := terraform.NewApprovalManager(terraform.NewImporter(".", true), false)
+	router := newApprovalsRouter(manager)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/approvals/missing/approve", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
@@ -47,6 +47,7 @@ func (h *DriftsHandler) GetDrifts(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		redacted := drift.Redacted()
 		driftData := map[string]interface{}{
 			"id":            drift.ResourceID,
 			"severity":      drift.Severity,
@@ -54,8 +55,8 @@ func (h *DriftsHandler) GetDrifts(w http.ResponseWriter, r *http.Request) {
 			"resource_name": drift.ResourceName,
 			"resource_id":   drift.ResourceID,
 			"attribute":     drift.Attribute,
-			"old_value":     drift.OldValue,
-			"new_value":     drift.NewValue,
+			"old_value":     redacted.OldValue,
+			"new_value":     redacted.NewValue,
 			"user_identity": drift.UserIdentity,
 			"matched_rules": drift.MatchedRules,
 			"timestamp":     drift.Timestamp,
@@ -83,6 +84,7 @@ func (h *DriftsHandler) GetDrift(w http.ResponseWriter, r *http.Request) {
 	// Find drift by ID
 	for _, drift := range allDrifts {
 		if drift.ResourceID == driftID {
+			redacted := drift.Redacted()
 			driftData := map[string]interface{}{
 				"id":            drift.ResourceID,
 				"severity":      drift.Severity,
@@ -90,8 +92,8 @@ func (h *DriftsHandler) GetDrift(w http.ResponseWriter, r *http.Request) {
 				"resource_name": drift.ResourceName,
 				"resource_id":   drift.ResourceID,
 				"attribute":     drift.Attribute,
-				"old_value":     drift.OldValue,
-				"new_value":     drift.NewValue,
+				"old_value":     redacted.OldValue,
+				"new_value":     redacted.NewValue,
 				"user_identity": drift.UserIdentity,
 				"matched_rules": drift.MatchedRules,
 				"timestamp":     drift.Timestamp,
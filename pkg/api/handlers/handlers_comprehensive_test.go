@@ -6,14 +6,18 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/keitahigaki/tfdrift-falco/pkg/api/models"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
 	"github.com/keitahigaki/tfdrift-falco/pkg/graph"
 	"github.com/keitahigaki/tfdrift-falco/pkg/provider"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 )
 
@@ -1078,6 +1082,86 @@ func TestDriftsHandler_GetDrift_Success(t *testing.T) {
 	}
 }
 
+func TestDriftsHandler_GetDrift_RedactsSensitiveValues(t *testing.T) {
+	store := graph.NewStore()
+
+	store.AddDrift(types.DriftAlert{
+		ResourceID:   "db-1",
+		ResourceType: "aws_db_instance",
+		Severity:     "high",
+		Attribute:    "password",
+		OldValue:     "old-secret",
+		NewValue:     "new-secret",
+		Sensitive:    true,
+		Timestamp:    time.Now().Format(time.RFC3339),
+	})
+
+	handler := NewDriftsHandler(store)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "db-1")
+	ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+	req := httptest.NewRequest("GET", "/api/v1/drifts/db-1", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	handler.GetDrift(w, req)
+
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("sensitive value leaked: %s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), types.RedactedValue) {
+		t.Errorf("expected %q in response, got %s", types.RedactedValue, w.Body.String())
+	}
+}
+
+func TestStateHandler_GetResource_RedactsSensitiveAttributes(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "terraform.tfstate")
+	state := `{
+  "version": 4,
+  "serial": 1,
+  "resources": [{
+    "mode": "managed",
+    "type": "aws_db_instance",
+    "name": "main",
+    "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+    "instances": [{
+      "attributes": {"id": "db-1", "username": "admin", "password": "s3cret-pw"},
+      "sensitive_attributes": [[{"type": "get_attr", "value": "password"}]]
+    }]
+  }]
+}`
+	if err := os.WriteFile(statePath, []byte(state), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sm, err := terraform.NewStateManager(config.TerraformStateConfig{Backend: "local", LocalPath: statePath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewStateHandler(sm)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "db-1")
+	ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+	req := httptest.NewRequest("GET", "/api/v1/state/resource/db-1", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	handler.GetResource(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if strings.Contains(w.Body.String(), "s3cret-pw") {
+		t.Errorf("sensitive value leaked: %s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "admin") {
+		t.Errorf("expected non-sensitive attributes in response, got %s", w.Body.String())
+	}
+}
+
 func TestDriftsHandler_GetDrift_NotFound(t *testing.T) {
 	store := graph.NewStore()
 	handler := NewDriftsHandler(store)
@@ -56,11 +56,14 @@ func (h *StateHandler) GetResources(w http.ResponseWriter, r *http.Request) {
 	allResources := make([]map[string]interface{}, 0, len(resources))
 	for _, resource := range resources {
 		allResources = append(allResources, map[string]interface{}{
+			"address":    resource.Address,
+			"module":     resource.Module,
+			"index_key":  resource.IndexKey,
 			"type":       resource.Type,
 			"name":       resource.Name,
 			"provider":   resource.Provider,
 			"mode":       resource.Mode,
-			"attributes": resource.Attributes,
+			"attributes": resource.RedactedAttributes(),
 		})
 	}
 
@@ -84,11 +87,14 @@ func (h *StateHandler) GetResource(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resourceData := map[string]interface{}{
+		"address":    resource.Address,
+		"module":     resource.Module,
+		"index_key":  resource.IndexKey,
 		"type":       resource.Type,
 		"name":       resource.Name,
 		"provider":   resource.Provider,
 		"mode":       resource.Mode,
-		"attributes": resource.Attributes,
+		"attributes": resource.RedactedAttributes(),
 	}
 
 	respondJSON(w, http.StatusOK, resourceData)
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/api/auth"
+	"github.com/keitahigaki/tfdrift-falco/pkg/rbac"
+	log "github.com/sirupsen/logrus"
+)
+
+// Authenticate returns middleware that runs the authenticators in order and
+// stores the first identity found in the request context for RequireRole and
+// RequirePermission. The identity's role is also assigned in engine so
+// permission checks by user ID resolve.
+//
+// Requests without any credentials pass through unauthenticated; routes
+// that need a role reject them. Credentials that are present but invalid
+// are rejected here with 401.
+func Authenticate(engine *rbac.Engine, authenticators ...auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, a := range authenticators {
+				identity, err := a.Authenticate(r)
+				if errors.Is(err, auth.ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					log.Debugf("Authentication failed for %s %s: %v", r.Method, r.URL.Path, err)
+					respondWithError(w, http.StatusUnauthorized, "Unauthorized", "Invalid credentials")
+					return
+				}
+
+				if engine != nil && identity.Role != "" {
+					engine.AssignRole(identity.UserID, identity.Role)
+				}
+				next.ServeHTTP(w, SetUserContext(r, identity.UserID, identity.Role))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/api/auth"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/rbac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticate_FeedsRequireRole(t *testing.T) {
+	oldConfig := GlobalRBACConfig
+	defer func() { GlobalRBACConfig = oldConfig }()
+
+	engine := rbac.NewEngine()
+	SetRBACConfig(&RBACConfig{Enabled: true, Engine: engine})
+
+	apiKeys, err := auth.NewAPIKeyAuthenticator([]config.APIKeyConfig{
+		{Name: "viewer", Key: "viewer-key", Role: "viewer"},
+		{Name: "editor", Key: "editor-key", Role: "editor"},
+	})
+	require.NoError(t, err)
+
+	var gotUser string
+	handler := Authenticate(engine, apiKeys)(RequireRole(rbac.RoleEditor)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotUser, _ = GetUserID(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}),
+	))
+
+	tests := []struct {
+		name string
+		key  string
+		want int
+	}{
+		{"no credentials", "", http.StatusUnauthorized},
+		{"invalid key", "nope", http.StatusUnauthorized},
+		{"insufficient role", "viewer-key", http.StatusForbidden},
+		{"sufficient role", "editor-key", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/approvals/x/approve", nil)
+			if tt.key != "" {
+				req.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+
+	assert.Equal(t, "apikey:editor", gotUser)
+	role, ok := engine.GetUserRole("apikey:editor")
+	assert.True(t, ok)
+	assert.Equal(t, rbac.RoleEditor, role)
+}
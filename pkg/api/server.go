@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/keitahigaki/tfdrift-falco/pkg/api/auth"
 	"github.com/keitahigaki/tfdrift-falco/pkg/api/broadcaster"
 	"github.com/keitahigaki/tfdrift-falco/pkg/api/handlers"
 	apimiddleware "github.com/keitahigaki/tfdrift-falco/pkg/api/middleware"
@@ -16,11 +17,15 @@ import (
 	"github.com/keitahigaki/tfdrift-falco/pkg/config"
 	"github.com/keitahigaki/tfdrift-falco/pkg/detector"
 	"github.com/keitahigaki/tfdrift-falco/pkg/graph"
+	"github.com/keitahigaki/tfdrift-falco/pkg/history"
 	"github.com/keitahigaki/tfdrift-falco/pkg/rbac"
 	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
 	log "github.com/sirupsen/logrus"
 )
 
+// historyPruneInterval is how often the history retention policy is applied
+const historyPruneInterval = 10 * time.Minute
+
 // Server represents the API server
 type Server struct {
 	cfg          *config.Config
@@ -50,7 +55,7 @@ func NewServer(cfg *config.Config, det *detector.Detector, port int, version str
 		cfg:          cfg,
 		detector:     det,
 		broadcaster:  bc,
-		graphStore:   graph.NewStore(),
+		graphStore:   newGraphStore(cfg),
 		stateManager: det.GetStateManager(),
 		wsHandler:    wsHandler,
 		sseHandler:   sseHandler,
@@ -77,6 +82,21 @@ func NewServer(cfg *config.Config, det *detector.Detector, port int, version str
 	return s
 }
 
+// newGraphStore creates the graph store on the configured history backend,
+// falling back to in-memory history if the backend can't be opened so the
+// API still comes up.
+func newGraphStore(cfg *config.Config) *graph.Store {
+	if cfg == nil {
+		return graph.NewStore()
+	}
+	h, err := history.New(cfg.History)
+	if err != nil {
+		log.Errorf("Failed to open %s history backend, keeping history in memory: %v", cfg.History.Backend, err)
+		h = history.NewMemoryBackend(history.RetentionFromConfig(cfg.History))
+	}
+	return graph.NewStoreWithHistory(h)
+}
+
 // setupRouter configures all routes and middleware
 func (s *Server) setupRouter() {
 	r := chi.NewRouter()
@@ -94,99 +114,159 @@ func (s *Server) setupRouter() {
 	r.Use(apimiddleware.InputValidation(apimiddleware.DefaultValidationConfig()))
 	r.Use(apimiddleware.RateLimit(apimiddleware.DefaultRateLimitConfig()))
 
-	// Health check (no /api/v1 prefix for simplicity)
-	healthHandler := handlers.NewHealthHandlerWithReadiness(s.version, s.detector.FalcoReadiness)
-	r.Get("/health", healthHandler.GetHealth)
-
-	// API v1 routes
-	r.Route("/api/v1", func(r chi.Router) {
-		// Health check also available under /api/v1
-		r.Get("/health", healthHandler.GetHealth)
+	// Falco HTTP-output receiver (ADR-006). Only mounted when the Falco
+	// transport is "http". It sits outside the API authentication below:
+	// senders prove themselves with the falco.receiver HMAC secret, bearer
+	// token or client certificate, and a shared bearer token must not be
+	// mistaken for an OIDC token.
+	if s.detector != nil && s.detector.UsesHTTPFalcoTransport() {
+		if s.cfg != nil && !s.cfg.Falco.Receiver.Secured() {
+			log.Warn("Falco HTTP receiver accepts unauthenticated alerts; set falco.receiver.hmac_secret, bearer_token or require_client_cert")
+		}
+		r.Post("/api/v1/falco/events", s.detector.FalcoHTTPHandler())
+	}
 
-		// SSE endpoint (Phase 4) - no timeout middleware for streaming
-		r.Get("/stream", s.sseHandler.HandleSSE)
+	r.Group(func(r chi.Router) {
+		// Authentication feeds the identity consumed by the RBAC checks below
+		s.setupAuth(r)
 
-		// Falco HTTP-output receiver (ADR-006). Only mounted when the Falco
-		// transport is "http"; Falco POSTs one JSON alert per request here.
-		// Auth/source-restriction for this ingress is folded into the API-wide
-		// auth work (#341); until then the API binds to localhost only (#328).
-		if s.detector != nil && s.detector.UsesHTTPFalcoTransport() {
-			r.Post("/falco/events", s.detector.FalcoHTTPHandler())
-		}
+		// Health check (no /api/v1 prefix for simplicity)
+		healthHandler := handlers.NewHealthHandlerWithReadiness(s.version, s.detector.FalcoReadiness)
+		r.Get("/health", healthHandler.GetHealth)
 
-		// Regular API routes with timeout
-		r.Group(func(r chi.Router) {
-			// Timeout for non-streaming API routes
-			r.Use(middleware.Timeout(60 * time.Second))
+		// API v1 routes
+		r.Route("/api/v1", func(r chi.Router) {
+			// Health check also available under /api/v1
+			r.Get("/health", healthHandler.GetHealth)
 
-			// Graph endpoints (read-only, requires Viewer role)
-			r.Group(func(r chi.Router) {
-				r.Use(apimiddleware.RequireRole(rbac.RoleViewer))
-				graphHandler := handlers.NewGraphHandler(s.graphStore)
-				r.Get("/graph", graphHandler.GetGraph)
-				r.Get("/graph/nodes", graphHandler.GetNodes)
-				r.Get("/graph/edges", graphHandler.GetEdges)
-
-				// Graph Query endpoints (Neo4j-style, read-only)
-				graphQueryHandler := handlers.NewGraphQueryHandler(s.graphStore)
-				r.Get("/graph/nodes/{id}", graphQueryHandler.GetNode)
-				r.Get("/graph/path", graphQueryHandler.GetPath)
-				r.Get("/graph/impact/{id}", graphQueryHandler.GetImpactRadius)
-				r.Get("/graph/dependencies/{id}", graphQueryHandler.GetDependencies)
-				r.Get("/graph/dependents/{id}", graphQueryHandler.GetDependents)
-				r.Get("/graph/critical", graphQueryHandler.GetCriticalNodes)
-				r.Get("/graph/neighbors/{id}", graphQueryHandler.GetNeighbors)
-				r.Get("/graph/relationships/{id}", graphQueryHandler.GetRelationships)
-				r.Get("/graph/stats", graphQueryHandler.GetGraphStats)
-
-				// State endpoints (read-only)
-				stateHandler := handlers.NewStateHandler(s.stateManager)
-				r.Get("/state", stateHandler.GetState)
-				r.Get("/state/resources", stateHandler.GetResources)
-				r.Get("/state/resource/{id}", stateHandler.GetResource)
-
-				// Events endpoints (read-only)
-				eventsHandler := handlers.NewEventsHandler(s.graphStore)
-				r.Get("/events", eventsHandler.GetEvents)
-				r.Get("/events/{id}", eventsHandler.GetEvent)
-
-				// Drifts endpoints (read-only)
-				driftsHandler := handlers.NewDriftsHandler(s.graphStore)
-				r.Get("/drifts", driftsHandler.GetDrifts)
-				r.Get("/drifts/{id}", driftsHandler.GetDrift)
-
-				// Stats endpoints (read-only)
-				statsHandler := handlers.NewStatsHandler(s.graphStore)
-				r.Get("/stats", statsHandler.GetStats)
-
-				// Discovery endpoints (read-only, requires Viewer)
-				discoveryHandler := handlers.NewDiscoveryHandler(s.stateManager)
-				r.Get("/discovery/scan", discoveryHandler.DiscoverAWSResources)
-				r.Get("/discovery/drift", discoveryHandler.DetectDrift)
-				r.Get("/discovery/drift/summary", discoveryHandler.GetDriftSummary)
-
-				// Provider status endpoints (read-only, requires Viewer)
-				providerStatusHandler := handlers.NewProviderStatusHandler(s.detector.GetProviderRegistry())
-				r.Get("/providers", providerStatusHandler.GetProviderStatus)
-				r.Get("/providers/status", providerStatusHandler.GetProviderStatus)
-				r.Get("/providers/summary", providerStatusHandler.GetProviderSummary)
-			})
+			// SSE endpoint (Phase 4) - no timeout middleware for streaming
+			r.Get("/stream", s.sseHandler.HandleSSE)
 
-			// Graph match endpoint requires Editor role
+			// Regular API routes with timeout
 			r.Group(func(r chi.Router) {
-				r.Use(apimiddleware.RequireRole(rbac.RoleEditor))
-				graphQueryHandler := handlers.NewGraphQueryHandler(s.graphStore)
-				r.Post("/graph/match", graphQueryHandler.MatchPattern)
+				// Timeout for non-streaming API routes
+				r.Use(middleware.Timeout(60 * time.Second))
+
+				// Graph endpoints (read-only, requires Viewer role)
+				r.Group(func(r chi.Router) {
+					r.Use(apimiddleware.RequireRole(rbac.RoleViewer))
+					graphHandler := handlers.NewGraphHandler(s.graphStore)
+					r.Get("/graph", graphHandler.GetGraph)
+					r.Get("/graph/nodes", graphHandler.GetNodes)
+					r.Get("/graph/edges", graphHandler.GetEdges)
+
+					// Graph Query endpoints (Neo4j-style, read-only)
+					graphQueryHandler := handlers.NewGraphQueryHandler(s.graphStore)
+					r.Get("/graph/nodes/{id}", graphQueryHandler.GetNode)
+					r.Get("/graph/path", graphQueryHandler.GetPath)
+					r.Get("/graph/impact/{id}", graphQueryHandler.GetImpactRadius)
+					r.Get("/graph/dependencies/{id}", graphQueryHandler.GetDependencies)
+					r.Get("/graph/dependents/{id}", graphQueryHandler.GetDependents)
+					r.Get("/graph/critical", graphQueryHandler.GetCriticalNodes)
+					r.Get("/graph/neighbors/{id}", graphQueryHandler.GetNeighbors)
+					r.Get("/graph/relationships/{id}", graphQueryHandler.GetRelationships)
+					r.Get("/graph/stats", graphQueryHandler.GetGraphStats)
+
+					// State endpoints (read-only)
+					stateHandler := handlers.NewStateHandler(s.stateManager)
+					r.Get("/state", stateHandler.GetState)
+					r.Get("/state/resources", stateHandler.GetResources)
+					r.Get("/state/resource/{id}", stateHandler.GetResource)
+
+					// Events endpoints (read-only)
+					eventsHandler := handlers.NewEventsHandler(s.graphStore)
+					r.Get("/events", eventsHandler.GetEvents)
+					r.Get("/events/{id}", eventsHandler.GetEvent)
+
+					// Drifts endpoints (read-only)
+					driftsHandler := handlers.NewDriftsHandler(s.graphStore)
+					r.Get("/drifts", driftsHandler.GetDrifts)
+					r.Get("/drifts/{id}", driftsHandler.GetDrift)
+
+					// Stats endpoints (read-only)
+					statsHandler := handlers.NewStatsHandler(s.graphStore)
+					r.Get("/stats", statsHandler.GetStats)
+
+					// Discovery endpoints (read-only, requires Viewer)
+					discoveryHandler := handlers.NewDiscoveryHandler(s.stateManager)
+					r.Get("/discovery/scan", discoveryHandler.DiscoverAWSResources)
+					r.Get("/discovery/drift", discoveryHandler.DetectDrift)
+					r.Get("/discovery/drift/summary", discoveryHandler.GetDriftSummary)
+
+					// Provider status endpoints (read-only, requires Viewer)
+					providerStatusHandler := handlers.NewProviderStatusHandler(s.detector.GetProviderRegistry())
+					r.Get("/providers", providerStatusHandler.GetProviderStatus)
+					r.Get("/providers/status", providerStatusHandler.GetProviderStatus)
+					r.Get("/providers/summary", providerStatusHandler.GetProviderSummary)
+
+					// Import approval queue (read-only)
+					approvalsHandler := handlers.NewApprovalsHandler(s.detector.GetApprovalManager())
+					r.Get("/approvals", approvalsHandler.GetApprovals)
+					r.Get("/approvals/audit", approvalsHandler.GetAuditTrail)
+					r.Get("/approvals/{id}", approvalsHandler.GetApproval)
+
+					// Apply windows announced by CI pipelines
+					applyWindowsHandler := handlers.NewApplyWindowsHandler(s.detector.GetApplyWindows())
+					r.Get("/apply-windows", applyWindowsHandler.GetApplyWindows)
+				})
+
+				// Graph match endpoint requires Editor role
+				r.Group(func(r chi.Router) {
+					r.Use(apimiddleware.RequireRole(rbac.RoleEditor))
+					graphQueryHandler := handlers.NewGraphQueryHandler(s.graphStore)
+					r.Post("/graph/match", graphQueryHandler.MatchPattern)
+
+					// Approval decisions run imports, so they require Editor too
+					approvalsHandler := handlers.NewApprovalsHandler(s.detector.GetApprovalManager())
+					r.Post("/approvals/{id}/approve", approvalsHandler.ApproveRequest)
+					r.Post("/approvals/{id}/reject", approvalsHandler.RejectRequest)
+					r.Post("/approvals/cleanup", approvalsHandler.CleanupExpired)
+
+					// Announcing an apply holds drift alerts, so it requires Editor
+					applyWindowsHandler := handlers.NewApplyWindowsHandler(s.detector.GetApplyWindows())
+					r.Post("/apply-windows/start", applyWindowsHandler.StartApplyWindow)
+					r.Post("/apply-windows/finish", applyWindowsHandler.FinishApplyWindow)
+				})
 			})
 		})
-	})
 
-	// WebSocket endpoint (Phase 3)
-	r.Get("/ws", s.wsHandler.HandleWebSocket)
+		// WebSocket endpoint (Phase 3)
+		r.Get("/ws", s.wsHandler.HandleWebSocket)
+	})
 
 	s.router = r
 }
 
+// setupAuth installs the configured authenticators and, when auth is
+// enabled, turns on RBAC enforcement. If the authenticators can't be built
+// no credential is accepted, so enforced routes fail closed.
+func (s *Server) setupAuth(r chi.Router) {
+	if s.cfg == nil {
+		return
+	}
+
+	if s.cfg.Auth.Enabled {
+		apimiddleware.SetRBACConfig(&apimiddleware.RBACConfig{
+			Enabled: true,
+			Engine:  rbac.NewEngine(),
+		})
+		log.Info("API authentication enabled; RBAC is enforced")
+	}
+
+	authenticators, err := auth.NewFromConfig(s.cfg.Auth)
+	if err != nil {
+		log.Errorf("Failed to set up API authentication, rejecting all credentials: %v", err)
+		authenticators = nil
+	}
+	if len(authenticators) == 0 {
+		if s.cfg.Auth.Enabled {
+			log.Warn("auth.enabled is set but no authentication method is configured; role-protected routes will reject every request")
+		}
+		return
+	}
+	r.Use(apimiddleware.Authenticate(apimiddleware.GetRBACConfig().Engine, authenticators...))
+}
+
 // Start starts the API server
 func (s *Server) Start(ctx context.Context) error {
 	addr := fmt.Sprintf(":%d", s.port)
@@ -198,19 +278,50 @@ func (s *Server) Start(ctx context.Context) error {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// mTLS serves TLS and verifies client certificates against the client CA
+	scheme, wsScheme := "http", "ws"
+	if s.cfg != nil && s.cfg.Auth.MTLS.Enabled {
+		tlsCfg, err := auth.ServerTLSConfig(s.cfg.Auth.MTLS)
+		if err != nil {
+			return fmt.Errorf("failed to configure mTLS: %w", err)
+		}
+		server.TLSConfig = tlsCfg
+		scheme, wsScheme = "https", "wss"
+	}
+
 	log.Infof("Starting API server on %s", addr)
-	log.Infof("Health check: http://localhost%s/health", addr)
-	log.Infof("API base URL: http://localhost%s/api/v1", addr)
-	log.Infof("WebSocket URL: ws://localhost%s/ws", addr)
-	log.Infof("SSE Stream URL: http://localhost%s/api/v1/stream", addr)
+	log.Infof("Health check: %s://localhost%s/health", scheme, addr)
+	log.Infof("API base URL: %s://localhost%s/api/v1", scheme, addr)
+	log.Infof("WebSocket URL: %s://localhost%s/ws", wsScheme, addr)
+	log.Infof("SSE Stream URL: %s://localhost%s/api/v1/stream", scheme, addr)
 
 	// Start the server in a goroutine
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("API server error: %v", err)
 		}
 	}()
 
+	// Apply history retention even while no new records arrive
+	go func() {
+		ticker := time.NewTicker(historyPruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.graphStore.Prune()
+			}
+		}
+	}()
+
 	// Wait for context cancellation
 	<-ctx.Done()
 	log.Info("Shutting down API server...")
@@ -224,6 +335,10 @@ func (s *Server) Start(ctx context.Context) error {
 		return err
 	}
 
+	if err := s.graphStore.Close(); err != nil {
+		log.Errorf("Failed to close history store: %v", err)
+	}
+
 	log.Info("API server stopped")
 	return nil
 }
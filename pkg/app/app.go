@@ -11,6 +11,7 @@ import (
 	"github.com/keitahigaki/tfdrift-falco/pkg/api"
 	"github.com/keitahigaki/tfdrift-falco/pkg/config"
 	"github.com/keitahigaki/tfdrift-falco/pkg/detector"
+	"github.com/keitahigaki/tfdrift-falco/pkg/output"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -65,6 +66,17 @@ func (a *App) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to initialize detector: %w", err)
 	}
 
+	// Emit structured drift events when requested (--output json|both)
+	if a.cfg.OutputMode != "" && a.cfg.OutputMode != string(output.ModeHuman) {
+		mode, err := output.ParseMode(a.cfg.OutputMode)
+		if err != nil {
+			return err
+		}
+		out := output.NewManager(mode)
+		defer out.Close()
+		det.SetOutputManager(out)
+	}
+
 	// Run detector or API server
 	if a.cfg.ServerMode {
 		return a.runAPIServer(ctx, det)
@@ -2,7 +2,9 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -12,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/elasticache"
 	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/smithy-go"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
@@ -161,9 +164,70 @@ func (d *DiscoveryClient) DiscoverAll(ctx context.Context) ([]*DiscoveredResourc
 	return allResources, nil
 }
 
+// DiscoverResource reads the current state of a single resource of a
+// discoverable type, identified as in DiscoverAll (instance/group/VPC/subnet
+// ID, DB instance identifier, cluster or replication group name, load
+// balancer ARN). It returns nil when the resource no longer exists.
+func (d *DiscoveryClient) DiscoverResource(ctx context.Context, resourceType, id string) (*DiscoveredResource, error) {
+	var resources []*DiscoveredResource
+	var err error
+	switch resourceType {
+	case "aws_vpc":
+		resources, err = d.describeVPCs(ctx, &ec2.DescribeVpcsInput{VpcIds: []string{id}})
+	case "aws_subnet":
+		resources, err = d.describeSubnets(ctx, &ec2.DescribeSubnetsInput{SubnetIds: []string{id}})
+	case "aws_security_group":
+		resources, err = d.describeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{GroupIds: []string{id}})
+	case "aws_instance":
+		resources, err = d.describeEC2Instances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{id}})
+	case "aws_db_instance":
+		resources, err = d.describeRDSInstances(ctx, &rds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String(id)})
+	case "aws_eks_cluster":
+		var cluster *DiscoveredResource
+		if cluster, err = d.describeEKSCluster(ctx, id); cluster != nil {
+			resources = append(resources, cluster)
+		}
+	case "aws_elasticache_replication_group":
+		resources, err = d.describeElastiCacheClusters(ctx, &elasticache.DescribeReplicationGroupsInput{ReplicationGroupId: aws.String(id)})
+	case "aws_lb":
+		resources, err = d.describeLoadBalancers(ctx, &elasticloadbalancingv2.DescribeLoadBalancersInput{LoadBalancerArns: []string{id}})
+	default:
+		return nil, fmt.Errorf("resource type %s is not discoverable", resourceType)
+	}
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for _, r := range resources {
+		if r.ID == id || r.ARN == id {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+// isNotFound reports whether err is an AWS API error for a resource that
+// doesn't exist (InvalidInstanceID.NotFound, DBInstanceNotFoundFault, ...)
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	code := apiErr.ErrorCode()
+	return strings.HasSuffix(code, "NotFound") || strings.HasSuffix(code, "NotFoundFault") ||
+		code == "ResourceNotFoundException"
+}
+
 // discoverVPCs discovers all VPCs in the region
 func (d *DiscoveryClient) discoverVPCs(ctx context.Context) ([]*DiscoveredResource, error) {
-	result, err := d.ec2Client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{})
+	return d.describeVPCs(ctx, &ec2.DescribeVpcsInput{})
+}
+
+func (d *DiscoveryClient) describeVPCs(ctx context.Context, input *ec2.DescribeVpcsInput) ([]*DiscoveredResource, error) {
+	result, err := d.ec2Client.DescribeVpcs(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to describe VPCs: %w", err)
 	}
@@ -189,7 +253,11 @@ func (d *DiscoveryClient) discoverVPCs(ctx context.Context) ([]*DiscoveredResour
 
 // discoverSubnets discovers all subnets in the region
 func (d *DiscoveryClient) discoverSubnets(ctx context.Context) ([]*DiscoveredResource, error) {
-	result, err := d.ec2Client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{})
+	return d.describeSubnets(ctx, &ec2.DescribeSubnetsInput{})
+}
+
+func (d *DiscoveryClient) describeSubnets(ctx context.Context, input *ec2.DescribeSubnetsInput) ([]*DiscoveredResource, error) {
+	result, err := d.ec2Client.DescribeSubnets(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to describe Subnets: %w", err)
 	}
@@ -218,7 +286,11 @@ func (d *DiscoveryClient) discoverSubnets(ctx context.Context) ([]*DiscoveredRes
 
 // discoverSecurityGroups discovers all security groups in the region
 func (d *DiscoveryClient) discoverSecurityGroups(ctx context.Context) ([]*DiscoveredResource, error) {
-	result, err := d.ec2Client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{})
+	return d.describeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{})
+}
+
+func (d *DiscoveryClient) describeSecurityGroups(ctx context.Context, input *ec2.DescribeSecurityGroupsInput) ([]*DiscoveredResource, error) {
+	result, err := d.ec2Client.DescribeSecurityGroups(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to describe Security Groups: %w", err)
 	}
@@ -248,7 +320,11 @@ func (d *DiscoveryClient) discoverSecurityGroups(ctx context.Context) ([]*Discov
 
 // discoverEC2Instances discovers all EC2 instances in the region
 func (d *DiscoveryClient) discoverEC2Instances(ctx context.Context) ([]*DiscoveredResource, error) {
-	result, err := d.ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+	return d.describeEC2Instances(ctx, &ec2.DescribeInstancesInput{})
+}
+
+func (d *DiscoveryClient) describeEC2Instances(ctx context.Context, input *ec2.DescribeInstancesInput) ([]*DiscoveredResource, error) {
+	result, err := d.ec2Client.DescribeInstances(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to describe EC2 Instances: %w", err)
 	}
@@ -16,6 +16,7 @@ import (
 	elbTypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
 	"github.com/aws/aws-sdk-go-v2/service/rds"
 	rdsTypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/aws/smithy-go"
 )
 
 // TestDiscoverVPCs_Success tests successful VPC discovery
@@ -606,3 +607,78 @@ func TestNewDiscoveryClientWithServices(t *testing.T) {
 		t.Errorf("expected region us-west-2, got %s", client.region)
 	}
 }
+
+// TestDiscoverResource_SecurityGroup tests that a single resource is described by ID
+func TestDiscoverResource_SecurityGroup(t *testing.T) {
+	var requested []string
+	mockEC2 := &MockEC2{
+		DescribeSecurityGroupsFunc: func(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+			requested = params.GroupIds
+			return &ec2.DescribeSecurityGroupsOutput{
+				SecurityGroups: []ec2Types.SecurityGroup{
+					{GroupId: aws.String("sg-1"), GroupName: aws.String("web"), VpcId: aws.String("vpc-1")},
+				},
+			}, nil
+		},
+	}
+
+	client := NewDiscoveryClientWithServices("us-east-1", mockEC2, &MockRDS{}, &MockEKS{}, &MockElastiCache{}, &MockELB{})
+
+	resource, err := client.DiscoverResource(context.Background(), "aws_security_group", "sg-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(requested) != 1 || requested[0] != "sg-1" {
+		t.Errorf("expected DescribeSecurityGroups for sg-1 only, got %v", requested)
+	}
+	if resource == nil || resource.ID != "sg-1" || resource.Attributes["name"] != "web" {
+		t.Errorf("unexpected resource: %+v", resource)
+	}
+}
+
+// TestDiscoverResource_NotFound tests that a deleted resource is reported as nil, not an error
+func TestDiscoverResource_NotFound(t *testing.T) {
+	mockEC2 := &MockEC2{
+		DescribeInstancesFunc: func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+			return nil, &smithy.GenericAPIError{Code: "InvalidInstanceID.NotFound", Message: "not found"}
+		},
+	}
+	mockEKS := &MockEKS{
+		DescribeClusterFunc: func(ctx context.Context, params *eks.DescribeClusterInput, optFns ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
+			return nil, &smithy.GenericAPIError{Code: "ResourceNotFoundException"}
+		},
+	}
+
+	client := NewDiscoveryClientWithServices("us-east-1", mockEC2, &MockRDS{}, mockEKS, &MockElastiCache{}, &MockELB{})
+
+	for _, tc := range []struct{ resourceType, id string }{
+		{"aws_instance", "i-gone"},
+		{"aws_eks_cluster", "gone"},
+	} {
+		resource, err := client.DiscoverResource(context.Background(), tc.resourceType, tc.id)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.resourceType, err)
+		}
+		if resource != nil {
+			t.Errorf("%s: expected nil resource, got %+v", tc.resourceType, resource)
+		}
+	}
+}
+
+// TestDiscoverResource_Errors tests API failures and unsupported types
+func TestDiscoverResource_Errors(t *testing.T) {
+	mockRDS := &MockRDS{
+		DescribeDBInstancesFunc: func(ctx context.Context, params *rds.DescribeDBInstancesInput, optFns ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+			return nil, errors.New("access denied")
+		},
+	}
+
+	client := NewDiscoveryClientWithServices("us-east-1", &MockEC2{}, mockRDS, &MockEKS{}, &MockElastiCache{}, &MockELB{})
+
+	if _, err := client.DiscoverResource(context.Background(), "aws_db_instance", "db-1"); err == nil {
+		t.Error("expected error for failed API call, got nil")
+	}
+	if _, err := client.DiscoverResource(context.Background(), "aws_iam_role", "role"); err == nil {
+		t.Error("expected error for undiscoverable type, got nil")
+	}
+}
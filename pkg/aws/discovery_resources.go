@@ -15,7 +15,11 @@ import (
 
 // discoverRDSInstances discovers all RDS instances in the region
 func (d *DiscoveryClient) discoverRDSInstances(ctx context.Context) ([]*DiscoveredResource, error) {
-	result, err := d.rdsClient.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{})
+	return d.describeRDSInstances(ctx, &rds.DescribeDBInstancesInput{})
+}
+
+func (d *DiscoveryClient) describeRDSInstances(ctx context.Context, input *rds.DescribeDBInstancesInput) ([]*DiscoveredResource, error) {
+	result, err := d.rdsClient.DescribeDBInstances(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to describe RDS Instances: %w", err)
 	}
@@ -65,54 +69,67 @@ func (d *DiscoveryClient) discoverEKSClusters(ctx context.Context) ([]*Discovere
 	var resources []*DiscoveredResource
 	for _, clusterName := range listResult.Clusters {
 		// Then describe each cluster to get details
-		descResult, err := d.eksClient.DescribeCluster(ctx, &eks.DescribeClusterInput{
-			Name: aws.String(clusterName),
-		})
+		cluster, err := d.describeEKSCluster(ctx, clusterName)
 		if err != nil {
-			return nil, fmt.Errorf("failed to describe EKS Cluster %s: %w", clusterName, err)
+			return nil, err
 		}
+		resources = append(resources, cluster)
+	}
 
-		cluster := descResult.Cluster
-		tags := cluster.Tags
+	return resources, nil
+}
 
-		var subnetIDs []string
-		if cluster.ResourcesVpcConfig != nil {
-			for _, subnet := range cluster.ResourcesVpcConfig.SubnetIds {
-				subnetIDs = append(subnetIDs, subnet)
-			}
-		}
+// describeEKSCluster describes one EKS cluster by name
+func (d *DiscoveryClient) describeEKSCluster(ctx context.Context, clusterName string) (*DiscoveredResource, error) {
+	descResult, err := d.eksClient.DescribeCluster(ctx, &eks.DescribeClusterInput{
+		Name: aws.String(clusterName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe EKS Cluster %s: %w", clusterName, err)
+	}
 
-		var securityGroupIDs []string
-		if cluster.ResourcesVpcConfig != nil {
-			for _, sg := range cluster.ResourcesVpcConfig.SecurityGroupIds {
-				securityGroupIDs = append(securityGroupIDs, sg)
-			}
+	cluster := descResult.Cluster
+	tags := cluster.Tags
+
+	var subnetIDs []string
+	if cluster.ResourcesVpcConfig != nil {
+		for _, subnet := range cluster.ResourcesVpcConfig.SubnetIds {
+			subnetIDs = append(subnetIDs, subnet)
 		}
+	}
 
-		resources = append(resources, &DiscoveredResource{
-			ID:     aws.ToString(cluster.Name),
-			Type:   "aws_eks_cluster",
-			ARN:    aws.ToString(cluster.Arn),
-			Name:   aws.ToString(cluster.Name),
-			Region: d.region,
-			Attributes: map[string]interface{}{
-				"version":            aws.ToString(cluster.Version),
-				"role_arn":           aws.ToString(cluster.RoleArn),
-				"status":             string(cluster.Status),
-				"subnet_ids":         subnetIDs,
-				"security_group_ids": securityGroupIDs,
-				"endpoint":           aws.ToString(cluster.Endpoint),
-			},
-			Tags: tags,
-		})
+	var securityGroupIDs []string
+	if cluster.ResourcesVpcConfig != nil {
+		for _, sg := range cluster.ResourcesVpcConfig.SecurityGroupIds {
+			securityGroupIDs = append(securityGroupIDs, sg)
+		}
 	}
 
-	return resources, nil
+	return &DiscoveredResource{
+		ID:     aws.ToString(cluster.Name),
+		Type:   "aws_eks_cluster",
+		ARN:    aws.ToString(cluster.Arn),
+		Name:   aws.ToString(cluster.Name),
+		Region: d.region,
+		Attributes: map[string]interface{}{
+			"version":            aws.ToString(cluster.Version),
+			"role_arn":           aws.ToString(cluster.RoleArn),
+			"status":             string(cluster.Status),
+			"subnet_ids":         subnetIDs,
+			"security_group_ids": securityGroupIDs,
+			"endpoint":           aws.ToString(cluster.Endpoint),
+		},
+		Tags: tags,
+	}, nil
 }
 
 // discoverElastiCacheClusters discovers all ElastiCache replication groups in the region
 func (d *DiscoveryClient) discoverElastiCacheClusters(ctx context.Context) ([]*DiscoveredResource, error) {
-	result, err := d.elasticache.DescribeReplicationGroups(ctx, &elasticache.DescribeReplicationGroupsInput{})
+	return d.describeElastiCacheClusters(ctx, &elasticache.DescribeReplicationGroupsInput{})
+}
+
+func (d *DiscoveryClient) describeElastiCacheClusters(ctx context.Context, input *elasticache.DescribeReplicationGroupsInput) ([]*DiscoveredResource, error) {
+	result, err := d.elasticache.DescribeReplicationGroups(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to describe ElastiCache Replication Groups: %w", err)
 	}
@@ -150,7 +167,11 @@ func (d *DiscoveryClient) discoverElastiCacheClusters(ctx context.Context) ([]*D
 
 // discoverLoadBalancers discovers all Application/Network Load Balancers in the region
 func (d *DiscoveryClient) discoverLoadBalancers(ctx context.Context) ([]*DiscoveredResource, error) {
-	result, err := d.elbClient.DescribeLoadBalancers(ctx, &elasticloadbalancingv2.DescribeLoadBalancersInput{})
+	return d.describeLoadBalancers(ctx, &elasticloadbalancingv2.DescribeLoadBalancersInput{})
+}
+
+func (d *DiscoveryClient) describeLoadBalancers(ctx context.Context, input *elasticloadbalancingv2.DescribeLoadBalancersInput) ([]*DiscoveredResource, error) {
+	result, err := d.elbClient.DescribeLoadBalancers(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to describe Load Balancers: %w", err)
 	}
@@ -0,0 +1,160 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLoginURL      = "https://login.microsoftonline.com"
+	defaultManagementURL = "https://management.azure.com"
+	armResourcesAPIVer   = "2021-04-01"
+)
+
+// ARMResourceLister implements ResourceLister against the Azure Resource
+// Manager REST API using a service principal (client credentials). It avoids
+// pulling the full Azure SDK into the binary for a single list call.
+type ARMResourceLister struct {
+	tenantID      string
+	clientID      string
+	clientSecret  string
+	loginURL      string
+	managementURL string
+	httpClient    *http.Client
+}
+
+// Compile-time interface check
+var _ ResourceLister = (*ARMResourceLister)(nil)
+
+// NewARMResourceLister creates a lister authenticating as the given service principal.
+func NewARMResourceLister(tenantID, clientID, clientSecret string) (*ARMResourceLister, error) {
+	if tenantID == "" || clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("azure tenant ID, client ID and client secret are required")
+	}
+	return &ARMResourceLister{
+		tenantID:      tenantID,
+		clientID:      clientID,
+		clientSecret:  clientSecret,
+		loginURL:      defaultLoginURL,
+		managementURL: defaultManagementURL,
+		httpClient:    &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// NewARMResourceListerFromEnv creates a lister from the standard
+// AZURE_TENANT_ID / AZURE_CLIENT_ID / AZURE_CLIENT_SECRET environment variables.
+func NewARMResourceListerFromEnv() (*ARMResourceLister, error) {
+	return NewARMResourceLister(
+		os.Getenv("AZURE_TENANT_ID"),
+		os.Getenv("AZURE_CLIENT_ID"),
+		os.Getenv("AZURE_CLIENT_SECRET"),
+	)
+}
+
+// WithEndpoints overrides the login and management endpoints (sovereign
+// clouds, tests).
+func (l *ARMResourceLister) WithEndpoints(loginURL, managementURL string) *ARMResourceLister {
+	l.loginURL = strings.TrimRight(loginURL, "/")
+	l.managementURL = strings.TrimRight(managementURL, "/")
+	return l
+}
+
+// ListResources lists every resource in the subscription (or resource group),
+// following nextLink pagination.
+func (l *ARMResourceLister) ListResources(ctx context.Context, subscriptionID string, resourceGroup string) ([]*Resource, error) {
+	token, err := l.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/subscriptions/%s", url.PathEscape(subscriptionID))
+	if resourceGroup != "" {
+		path += fmt.Sprintf("/resourceGroups/%s", url.PathEscape(resourceGroup))
+	}
+	next := fmt.Sprintf("%s%s/resources?api-version=%s", l.managementURL, path, armResourcesAPIVer)
+
+	var resources []*Resource
+	for next != "" {
+		var page struct {
+			Value    []*Resource `json:"value"`
+			NextLink string      `json:"nextLink"`
+		}
+		if err := l.getJSON(ctx, next, token, &page); err != nil {
+			return nil, err
+		}
+		resources = append(resources, page.Value...)
+		next = page.NextLink
+	}
+	return resources, nil
+}
+
+// accessToken exchanges the service principal credentials for a token of
+// the configured management endpoint.
+func (l *ARMResourceLister) accessToken(ctx context.Context) (string, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {l.clientID},
+		"client_secret": {l.clientSecret},
+		"scope":         {l.managementURL + "/.default"},
+	}
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", l.loginURL, url.PathEscape(l.tenantID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := l.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request Azure access token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("azure token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("failed to decode Azure token response: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return "", fmt.Errorf("azure token response contained no access_token")
+	}
+	return tok.AccessToken, nil
+}
+
+func (l *ARMResourceLister) getJSON(ctx context.Context, rawURL, token string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create ARM request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := l.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ARM request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("ARM API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode ARM response: %w", err)
+	}
+	return nil
+}
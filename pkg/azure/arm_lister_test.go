@@ -0,0 +1,91 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newARMTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token"):
+			require.NoError(t, r.ParseForm())
+			// The token is for the configured management endpoint
+			assert.Equal(t, srv.URL+"/.default", r.Form.Get("scope"))
+			if r.Form.Get("client_secret") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "tok"})
+		case r.Header.Get("Authorization") != "Bearer tok":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Query().Get("page") == "2":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": []map[string]interface{}{
+					{"id": "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa1",
+						"name": "sa1", "type": "Microsoft.Storage/storageAccounts", "location": "eastus"},
+				},
+			})
+		default:
+			assert.Equal(t, "/subscriptions/sub-1/resourceGroups/rg/resources", r.URL.Path)
+			assert.Equal(t, armResourcesAPIVer, r.URL.Query().Get("api-version"))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": []map[string]interface{}{
+					{"id": "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm1",
+						"name": "vm1", "type": "Microsoft.Compute/virtualMachines", "location": "eastus",
+						"tags": map[string]string{"env": "prod"}},
+				},
+				"nextLink": srv.URL + "/subscriptions/sub-1/resourceGroups/rg/resources?page=2",
+			})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestARMResourceLister_ListResourcesFollowsNextLink(t *testing.T) {
+	srv := newARMTestServer(t)
+	lister, err := NewARMResourceLister("tenant", "client", "secret")
+	require.NoError(t, err)
+	lister.WithEndpoints(srv.URL, srv.URL)
+
+	resources, err := lister.ListResources(context.Background(), "sub-1", "rg")
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+	assert.Equal(t, "vm1", resources[0].Name)
+	assert.Equal(t, "prod", resources[0].Tags["env"])
+	assert.Equal(t, "sa1", resources[1].Name)
+
+	// The lister plugs straight into DiscoveryClient.
+	dc, err := NewDiscoveryClient("sub-1", nil, lister)
+	require.NoError(t, err)
+	discovered, err := dc.WithResourceGroup("rg").DiscoverAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, discovered, 2)
+}
+
+func TestARMResourceLister_TokenFailure(t *testing.T) {
+	srv := newARMTestServer(t)
+	lister, err := NewARMResourceLister("tenant", "client", "wrong")
+	require.NoError(t, err)
+	lister.WithEndpoints(srv.URL, srv.URL)
+
+	_, err = lister.ListResources(context.Background(), "sub-1", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestNewARMResourceLister_RequiresCredentials(t *testing.T) {
+	_, err := NewARMResourceLister("", "client", "secret")
+	assert.Error(t, err)
+}
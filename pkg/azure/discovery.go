@@ -197,6 +197,36 @@ func (d *DiscoveryClient) DiscoverAll(ctx context.Context) ([]*DiscoveredResourc
 	return allResources, nil
 }
 
+// DiscoverResource reads the current state of a single resource by its ARM
+// ID. Only the resource group in the ID is listed. It returns nil when the
+// resource no longer exists.
+func (d *DiscoveryClient) DiscoverResource(ctx context.Context, resourceType, id string) (*DiscoveredResource, error) {
+	if d.resourceLister == nil {
+		return nil, fmt.Errorf("resource lister is not configured; Azure SDK credentials may be required")
+	}
+	rg := extractResourceGroupFromID(id)
+	if rg == "" || !strings.Contains(strings.ToLower(id), "/providers/") {
+		// Resource groups themselves aren't returned by the resource listing
+		return nil, fmt.Errorf("%s is not a discoverable Azure resource ID", id)
+	}
+
+	azureResources, err := d.resourceLister.ListResources(ctx, d.subscriptionID, rg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Azure resources: %w", err)
+	}
+	for _, res := range azureResources {
+		if !strings.EqualFold(res.ID, id) {
+			continue
+		}
+		discovered := d.convertResource(res)
+		if discovered == nil || discovered.Type != resourceType {
+			return nil, fmt.Errorf("resource type %s is not discoverable", resourceType)
+		}
+		return discovered, nil
+	}
+	return nil, nil
+}
+
 // convertResource converts an Azure ARM resource to a DiscoveredResource.
 // Returns nil if the resource type is not supported for Terraform mapping.
 func (d *DiscoveryClient) convertResource(res *Resource) *DiscoveredResource {
@@ -2,6 +2,7 @@ package azure
 
 import (
 	"context"
+	"strings"
 	"testing"
 )
 
@@ -845,11 +846,13 @@ func TestDiscoveryClient_DiscoverAll_WithError(t *testing.T) {
 
 // Mock ResourceLister for testing
 type MockResourceLister struct {
-	resources []*Resource
-	err       error
+	resources    []*Resource
+	err          error
+	listedGroups []string
 }
 
 func (m *MockResourceLister) ListResources(ctx context.Context, subscriptionID string, resourceGroup string) ([]*Resource, error) {
+	m.listedGroups = append(m.listedGroups, resourceGroup)
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -874,3 +877,42 @@ func TestDiscoverAll_HollowWithoutSDK(t *testing.T) {
 			"if Azure SDK discovery is now implemented, update README + PROJECT_ROADMAP to match")
 	}
 }
+
+func TestDiscoverResource(t *testing.T) {
+	vmID := "/subscriptions/sub-123/resourceGroups/rg-app/providers/Microsoft.Compute/virtualMachines/vm-1"
+	lister := &MockResourceLister{resources: []*Resource{
+		{ID: "/subscriptions/sub-123/resourceGroups/rg-app/providers/Microsoft.Compute/virtualMachines/vm-2", Name: "vm-2", Type: "Microsoft.Compute/virtualMachines"},
+		{ID: vmID, Name: "vm-1", Type: "Microsoft.Compute/virtualMachines", Location: "japaneast",
+			Properties: map[string]interface{}{"hardwareProfile": map[string]interface{}{"vmSize": "Standard_D2s_v3"}}},
+	}}
+	client, err := NewDiscoveryClient("sub-123", nil, lister)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ARM IDs are case-insensitive; Activity Log often upper-cases the group
+	r, err := client.DiscoverResource(context.Background(), "azurerm_virtual_machine", strings.ToUpper(vmID))
+	if err != nil {
+		t.Fatalf("DiscoverResource failed: %v", err)
+	}
+	if r == nil || r.ID != vmID || r.Attributes["vm_size"] != "Standard_D2s_v3" {
+		t.Errorf("unexpected resource: %+v", r)
+	}
+	if len(lister.listedGroups) != 1 || !strings.EqualFold(lister.listedGroups[0], "rg-app") {
+		t.Errorf("expected only rg-app to be listed, got %v", lister.listedGroups)
+	}
+
+	r, err = client.DiscoverResource(context.Background(), "azurerm_virtual_machine", vmID+"-gone")
+	if err != nil || r != nil {
+		t.Errorf("deleted resource: got %+v, %v; want nil, nil", r, err)
+	}
+
+	if _, err := client.DiscoverResource(context.Background(), "azurerm_resource_group", "/subscriptions/sub-123/resourceGroups/rg-app"); err == nil {
+		t.Error("expected error for a resource group ID")
+	}
+
+	noLister, _ := NewDiscoveryClient("sub-123", nil, nil)
+	if _, err := noLister.DiscoverResource(context.Background(), "azurerm_virtual_machine", vmID); err == nil {
+		t.Error("expected error without a resource lister")
+	}
+}
@@ -0,0 +1,255 @@
+package cloudtrail
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/falcosecurity/client-go/pkg/api/outputs"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPollInterval = 60 * time.Second
+	// pollOverlap re-examines files this far behind the newest one seen, so
+	// uploads that finish out of order are not skipped
+	pollOverlap = 15 * time.Minute
+	// retryDelay is the pause after a failed receive
+	retryDelay = 5 * time.Second
+)
+
+// Parser turns a Falco-shaped CloudTrail event into a drift event, or nil
+// when it is not drift-relevant. *falco.Subscriber satisfies it.
+type Parser interface {
+	ParseFalcoOutput(res *outputs.Response) *types.Event
+}
+
+// Collector reads CloudTrail log files and feeds their drift-relevant
+// records onto the detector's event channel
+type Collector struct {
+	store        ObjectStore
+	queue        Queue // nil: poll the store
+	parser       Parser
+	prefix       string
+	pollInterval time.Duration
+
+	// Polling state: files last modified before since are history, and
+	// seen holds the files processed within the overlap window
+	since     time.Time
+	watermark time.Time
+	seen      map[string]time.Time
+}
+
+// NewCollector creates a collector reading store. With a queue, files are
+// read as notifications arrive; without one, store is listed under prefix
+// every pollInterval and only files written after the collector started
+// are processed.
+func NewCollector(store ObjectStore, queue Queue, parser Parser, prefix string, pollInterval time.Duration) *Collector {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	// S3 reports LastModified in whole seconds
+	now := time.Now().Truncate(time.Second)
+	return &Collector{
+		store:        store,
+		queue:        queue,
+		parser:       parser,
+		prefix:       prefix,
+		pollInterval: pollInterval,
+		since:        now,
+		watermark:    now,
+		seen:         make(map[string]time.Time),
+	}
+}
+
+// NewFromConfig creates a collector for the cloudtrail config section,
+// reading a local directory or S3 (with SQS notifications when a queue is
+// configured)
+func NewFromConfig(ctx context.Context, cfg config.CloudTrailConfig, parser Parser) (*Collector, error) {
+	interval := time.Duration(cfg.PollIntervalSec) * time.Second
+	if cfg.Dir != "" {
+		return NewCollector(NewDirStore(cfg.Dir), nil, parser, cfg.Prefix, interval), nil
+	}
+
+	opts := []func(*awsconfig.LoadOptions) error{}
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	s3Client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	store := NewS3Store(s3Client, cfg.Bucket)
+
+	var queue Queue
+	if cfg.SQSQueueURL != "" {
+		sqsClient := sqs.NewFromConfig(awsCfg, func(o *sqs.Options) {
+			if cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(cfg.Endpoint)
+			}
+		})
+		queue = NewSQSQueue(sqsClient, cfg.SQSQueueURL)
+	}
+
+	return NewCollector(store, queue, parser, cfg.Prefix, interval), nil
+}
+
+// Run collects until ctx is cancelled. Failures to list, receive or read a
+// file are logged and retried rather than ending collection.
+func (c *Collector) Run(ctx context.Context, eventCh chan<- types.Event) error {
+	if c.queue != nil {
+		log.Info("Starting CloudTrail collector (sqs notifications)")
+		return c.runQueue(ctx, eventCh)
+	}
+
+	log.Infof("Starting CloudTrail collector (polling every %s)", c.pollInterval)
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := c.poll(ctx, eventCh); err != nil && ctx.Err() == nil {
+			log.Warnf("CloudTrail poll failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// runQueue processes notifications, deleting each message once every file
+// it announces has been read. Messages whose files fail stay on the queue
+// and are redelivered after the visibility timeout.
+func (c *Collector) runQueue(ctx context.Context, eventCh chan<- types.Event) error {
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		msgs, err := c.queue.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Warnf("CloudTrail queue: %v", err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		for _, msg := range msgs {
+			c.handleMessage(ctx, msg, eventCh)
+		}
+	}
+}
+
+// handleMessage processes the files a message announces and deletes it
+// unless one of them failed
+func (c *Collector) handleMessage(ctx context.Context, msg Message, eventCh chan<- types.Event) {
+	objects, err := parseNotification(msg.Body)
+	if err != nil {
+		// Redelivering a malformed message cannot help
+		log.Warnf("CloudTrail queue: dropping message: %v", err)
+	}
+
+	for _, obj := range objects {
+		if !strings.HasPrefix(obj.Key, c.prefix) {
+			continue
+		}
+		if err := c.processFile(ctx, obj, eventCh); err != nil {
+			log.Warnf("CloudTrail queue: %v (message will be redelivered)", err)
+			return
+		}
+	}
+
+	if err := c.queue.Delete(ctx, msg); err != nil {
+		log.Warnf("CloudTrail queue: %v", err)
+	}
+}
+
+// poll lists the store and processes files written since the last poll
+func (c *Collector) poll(ctx context.Context, eventCh chan<- types.Event) error {
+	objects, err := c.store.List(ctx, c.prefix)
+	if err != nil {
+		return err
+	}
+
+	cutoff := c.watermark.Add(-pollOverlap)
+	newest := c.watermark
+	for _, obj := range objects {
+		if obj.LastModified.Before(c.since) || obj.LastModified.Before(cutoff) {
+			continue
+		}
+		if _, ok := c.seen[obj.Key]; ok {
+			continue
+		}
+		if err := c.processFile(ctx, obj, eventCh); err != nil {
+			// Retried on the next poll
+			log.Warnf("CloudTrail poll: %v", err)
+			continue
+		}
+		c.seen[obj.Key] = obj.LastModified
+		if obj.LastModified.After(newest) {
+			newest = obj.LastModified
+		}
+	}
+
+	c.watermark = newest
+	for key, modified := range c.seen {
+		if modified.Before(newest.Add(-pollOverlap)) {
+			delete(c.seen, key)
+		}
+	}
+	return nil
+}
+
+// processFile reads one log file and queues its drift-relevant records
+func (c *Collector) processFile(ctx context.Context, obj Object, eventCh chan<- types.Event) error {
+	body, err := c.store.Open(ctx, obj)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = body.Close() }()
+
+	records, err := DecodeLogFile(body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", obj.Key, err)
+	}
+
+	queued := 0
+	for i := range records {
+		if records[i].Failed() {
+			continue
+		}
+		event := c.parser.ParseFalcoOutput(records[i].Response())
+		if event == nil {
+			continue
+		}
+		select {
+		case eventCh <- *event:
+			queued++
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	log.Debugf("CloudTrail %s: %d records, %d drift events", obj.Key, len(records), queued)
+	return nil
+}
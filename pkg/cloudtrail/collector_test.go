@@ -0,0 +1,180 @@
+package cloudtrail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/keitahigaki/tfdrift-falco/pkg/falco"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const failedRecord = `{
+	"eventTime": "2026-10-01T12:01:00Z",
+	"eventSource": "ec2.amazonaws.com",
+	"eventName": "ModifyInstanceAttribute",
+	"errorCode": "UnauthorizedOperation",
+	"requestParameters": {"instanceId": "i-0failed"}
+}`
+
+const readOnlyRecord = `{
+	"eventTime": "2026-10-01T12:02:00Z",
+	"eventSource": "ec2.amazonaws.com",
+	"eventName": "DescribeInstances",
+	"readOnly": true
+}`
+
+func drain(ch chan types.Event) []types.Event {
+	var events []types.Event
+	for {
+		select {
+		case e := <-ch:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestCollector_PollDirectory(t *testing.T) {
+	dir := t.TempDir()
+	logDir := filepath.Join(dir, "AWSLogs", "123", "CloudTrail", "us-east-1")
+	require.NoError(t, os.MkdirAll(logDir, 0o755))
+
+	// Written before the collector started: history, not ingested
+	old := filepath.Join(logDir, "old.json.gz")
+	require.NoError(t, os.WriteFile(old, logFileBytes(t, modifyInstanceRecord), 0o644))
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(old, past, past))
+
+	c := NewCollector(NewDirStore(dir), nil, falco.NewSubscriberWithDefaults(), "AWSLogs/", time.Second)
+	eventCh := make(chan types.Event, 10)
+
+	newFile := filepath.Join(logDir, "new.json.gz")
+	require.NoError(t, os.WriteFile(newFile, logFileBytes(t, modifyInstanceRecord, failedRecord, readOnlyRecord), 0o644))
+	// Not relying on the filesystem's timestamp granularity
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(newFile, later, later))
+
+	require.NoError(t, c.poll(context.Background(), eventCh))
+	events := drain(eventCh)
+	require.Len(t, events, 1, "failed and read-only calls are not drift")
+	assert.Equal(t, "aws", events[0].Provider)
+	assert.Equal(t, "i-0abc", events[0].ResourceID)
+	assert.Equal(t, "aws_instance", events[0].ResourceType)
+
+	// Files are read once
+	require.NoError(t, c.poll(context.Background(), eventCh))
+	assert.Empty(t, drain(eventCh))
+}
+
+func TestCollector_RunStopsOnCancel(t *testing.T) {
+	c := NewCollector(NewDirStore(t.TempDir()), nil, falco.NewSubscriberWithDefaults(), "", time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx, make(chan types.Event)) }()
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
+
+// fakeQueue serves a fixed set of messages once and records deletions
+type fakeQueue struct {
+	msgs    []Message
+	deleted []string
+}
+
+func (q *fakeQueue) Receive(ctx context.Context) ([]Message, error) {
+	if len(q.msgs) == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	msgs := q.msgs
+	q.msgs = nil
+	return msgs, nil
+}
+
+func (q *fakeQueue) Delete(_ context.Context, msg Message) error {
+	q.deleted = append(q.deleted, msg.handle)
+	return nil
+}
+
+// mockS3 serves objects from memory
+type mockS3 struct {
+	objects map[string][]byte // "bucket/key" -> body
+}
+
+func (m *mockS3) ListObjectsV2(_ context.Context, in *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	out := &s3.ListObjectsV2Output{}
+	for k := range m.objects {
+		bucket, key, _ := strings.Cut(k, "/")
+		if bucket == aws.ToString(in.Bucket) && strings.HasPrefix(key, aws.ToString(in.Prefix)) {
+			out.Contents = append(out.Contents, s3types.Object{Key: aws.String(key), LastModified: aws.Time(time.Now())})
+		}
+	}
+	return out, nil
+}
+
+func (m *mockS3) GetObject(_ context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	body, ok := m.objects[aws.ToString(in.Bucket)+"/"+aws.ToString(in.Key)]
+	if !ok {
+		return nil, errors.New("NoSuchKey")
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func TestCollector_SQSNotifications(t *testing.T) {
+	const key = "AWSLogs/123/CloudTrail/us-east-1/2026/10/01/file.json.gz"
+	store := &S3Store{client: &mockS3{objects: map[string][]byte{
+		"trail/" + key: logFileBytes(t, modifyInstanceRecord),
+	}}}
+	queue := &fakeQueue{msgs: []Message{
+		{Body: `{"s3Bucket":"trail","s3ObjectKey":["` + key + `"]}`, handle: "ok"},
+		{Body: `{"s3Bucket":"trail","s3ObjectKey":["AWSLogs/123/CloudTrail/us-east-1/missing.json.gz"]}`, handle: "missing"},
+		{Body: `not json`, handle: "malformed"},
+	}}
+
+	c := NewCollector(store, queue, falco.NewSubscriberWithDefaults(), "AWSLogs/", 0)
+	eventCh := make(chan types.Event, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.Run(ctx, eventCh), context.DeadlineExceeded)
+
+	events := drain(eventCh)
+	require.Len(t, events, 1)
+	assert.Equal(t, "i-0abc", events[0].ResourceID)
+	// The message whose file could not be read stays on the queue
+	assert.Equal(t, []string{"ok", "malformed"}, queue.deleted)
+}
+
+func TestS3Store_List(t *testing.T) {
+	store := &S3Store{bucket: "trail", client: &mockS3{objects: map[string][]byte{
+		"trail/AWSLogs/123/CloudTrail/us-east-1/a.json.gz":        nil,
+		"trail/AWSLogs/123/CloudTrail-Digest/us-east-1/d.json.gz": nil,
+		"other/AWSLogs/123/CloudTrail/us-east-1/b.json.gz":        nil,
+	}}}
+
+	objects, err := store.List(context.Background(), "AWSLogs/")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "AWSLogs/123/CloudTrail/us-east-1/a.json.gz", objects[0].Key)
+	assert.Equal(t, "trail", objects[0].Bucket)
+}
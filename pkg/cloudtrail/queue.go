@@ -0,0 +1,144 @@
+package cloudtrail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// Message is a queue message announcing new log files
+type Message struct {
+	Body   string
+	handle string
+}
+
+// Queue delivers notifications of new log files. Messages that are not
+// deleted are delivered again.
+type Queue interface {
+	Receive(ctx context.Context) ([]Message, error)
+	Delete(ctx context.Context, msg Message) error
+}
+
+// sqsAPI defines the SQS operations used by SQSQueue
+type sqsAPI interface {
+	ReceiveMessage(ctx context.Context, input *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, input *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+}
+
+// sqsWaitSeconds is the long-poll duration of each receive
+const sqsWaitSeconds = 20
+
+// SQSQueue receives notifications from an SQS queue
+type SQSQueue struct {
+	client   sqsAPI
+	queueURL string
+}
+
+// NewSQSQueue creates a queue reading queueURL with client
+func NewSQSQueue(client *sqs.Client, queueURL string) *SQSQueue {
+	return &SQSQueue{client: client, queueURL: queueURL}
+}
+
+// Receive long-polls for up to 10 messages
+func (q *SQSQueue) Receive(ctx context.Context) ([]Message, error) {
+	out, err := q.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(q.queueURL),
+		MaxNumberOfMessages: 10,
+		WaitTimeSeconds:     sqsWaitSeconds,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive from %s: %w", q.queueURL, err)
+	}
+
+	msgs := make([]Message, 0, len(out.Messages))
+	for _, m := range out.Messages {
+		msgs = append(msgs, Message{Body: aws.ToString(m.Body), handle: aws.ToString(m.ReceiptHandle)})
+	}
+	return msgs, nil
+}
+
+// Delete acknowledges a processed message
+func (q *SQSQueue) Delete(ctx context.Context, msg Message) error {
+	_, err := q.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(q.queueURL),
+		ReceiptHandle: aws.String(msg.handle),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete message from %s: %w", q.queueURL, err)
+	}
+	return nil
+}
+
+// notification covers the message shapes that announce new log files: S3
+// event notifications, the trail's own SNS notification, EventBridge
+// "Object Created" events, and any of them wrapped in an SNS envelope
+type notification struct {
+	// SNS envelope
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+
+	// S3 event notification
+	Records []struct {
+		S3 struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+
+	// CloudTrail SNS notification
+	S3Bucket    string   `json:"s3Bucket"`
+	S3ObjectKey []string `json:"s3ObjectKey"`
+
+	// EventBridge
+	Detail struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key string `json:"key"`
+		} `json:"object"`
+	} `json:"detail"`
+}
+
+// parseNotification returns the log files a message announces. Messages
+// that announce nothing (e.g. s3:TestEvent) return no objects.
+func parseNotification(body string) ([]Object, error) {
+	var n notification
+	if err := json.Unmarshal([]byte(body), &n); err != nil {
+		return nil, fmt.Errorf("failed to decode notification: %w", err)
+	}
+	if n.Type == "Notification" && n.Message != "" {
+		return parseNotification(n.Message)
+	}
+
+	var objects []Object
+	add := func(bucket, key string) {
+		if isLogFile(key) {
+			objects = append(objects, Object{Bucket: bucket, Key: key})
+		}
+	}
+
+	for _, r := range n.Records {
+		// S3 event notifications URL-encode object keys
+		key, err := url.QueryUnescape(r.S3.Object.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object key %q: %w", r.S3.Object.Key, err)
+		}
+		add(r.S3.Bucket.Name, key)
+	}
+	for _, key := range n.S3ObjectKey {
+		add(n.S3Bucket, key)
+	}
+	if n.Detail.Object.Key != "" {
+		add(n.Detail.Bucket.Name, n.Detail.Object.Key)
+	}
+	return objects, nil
+}
@@ -0,0 +1,52 @@
+package cloudtrail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNotification(t *testing.T) {
+	const key = "AWSLogs/123/CloudTrail/us-east-1/2026/10/01/file.json.gz"
+
+	tests := []struct {
+		name string
+		body string
+		want []Object
+	}{
+		{
+			name: "s3 event notification",
+			body: `{"Records":[{"eventSource":"aws:s3","s3":{"bucket":{"name":"trail"},"object":{"key":"AWSLogs/123/CloudTrail/us-east-1/2026/10/01/file+1.json.gz"}}}]}`,
+			want: []Object{{Bucket: "trail", Key: "AWSLogs/123/CloudTrail/us-east-1/2026/10/01/file 1.json.gz"}},
+		},
+		{
+			name: "cloudtrail sns notification in envelope",
+			body: `{"Type":"Notification","Message":"{\"s3Bucket\":\"trail\",\"s3ObjectKey\":[\"` + key + `\"]}"}`,
+			want: []Object{{Bucket: "trail", Key: key}},
+		},
+		{
+			name: "eventbridge object created",
+			body: `{"detail-type":"Object Created","detail":{"bucket":{"name":"trail"},"object":{"key":"` + key + `"}}}`,
+			want: []Object{{Bucket: "trail", Key: key}},
+		},
+		{
+			name: "digest files are ignored",
+			body: `{"s3Bucket":"trail","s3ObjectKey":["AWSLogs/123/CloudTrail-Digest/us-east-1/d.json.gz"]}`,
+		},
+		{
+			name: "s3 test event",
+			body: `{"Service":"Amazon S3","Event":"s3:TestEvent","Bucket":"trail"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNotification(tt.body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := parseNotification("{")
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package cloudtrail
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const modifyInstanceRecord = `{
+	"eventVersion": "1.08",
+	"eventID": "4f2c9a1e",
+	"eventTime": "2026-10-01T12:00:00Z",
+	"eventSource": "ec2.amazonaws.com",
+	"eventName": "ModifyInstanceAttribute",
+	"awsRegion": "us-east-1",
+	"sourceIPAddress": "203.0.113.10",
+	"userAgent": "aws-cli/2.15.0",
+	"userIdentity": {
+		"type": "AssumedRole",
+		"principalId": "AROAEXAMPLE:alice@example.com",
+		"arn": "arn:aws:sts::123456789012:assumed-role/Admin/alice@example.com",
+		"accountId": "123456789012"
+	},
+	"requestParameters": {
+		"instanceId": "i-0abc",
+		"instanceType": {"value": "t3.large"},
+		"dryRun": null
+	},
+	"responseElements": {"_return": true}
+}`
+
+// logFileBytes wraps records in a CloudTrail log document, gzipped like the
+// files CloudTrail delivers to S3
+func logFileBytes(t *testing.T, records ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(`{"Records":[` + strings.Join(records, ",") + `]}`))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestDecodeLogFile(t *testing.T) {
+	records, err := DecodeLogFile(bytes.NewReader(logFileBytes(t, modifyInstanceRecord)))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "ModifyInstanceAttribute", records[0].EventName)
+	assert.Equal(t, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), records[0].EventTime)
+
+	// Uncompressed files decode too
+	records, err = DecodeLogFile(strings.NewReader(`{"Records":[` + modifyInstanceRecord + `]}`))
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	_, err = DecodeLogFile(strings.NewReader("not json"))
+	assert.ErrorContains(t, err, "failed to decode log file")
+}
+
+func TestRecordResponse(t *testing.T) {
+	var r Record
+	require.NoError(t, json.Unmarshal([]byte(modifyInstanceRecord), &r))
+
+	res := r.Response()
+	assert.Equal(t, falcoSource, res.Source)
+	assert.Equal(t, r.EventTime, res.Time.AsTime())
+
+	fields := res.OutputFields
+	assert.Equal(t, "ModifyInstanceAttribute", fields["ct.name"])
+	assert.Equal(t, "ec2.amazonaws.com", fields["ct.src"])
+	assert.Equal(t, "AssumedRole", fields["ct.user.type"])
+	assert.Equal(t, "123456789012", fields["ct.user.accountid"])
+	assert.Equal(t, "i-0abc", fields["ct.request.instanceid"])
+	assert.JSONEq(t, `{"value":"t3.large"}`, fields["ct.request.instancetype"])
+	assert.JSONEq(t, `{"_return":true}`, fields["ct.response"])
+	assert.NotContains(t, fields, "ct.request.dryrun", "null parameters are omitted")
+	assert.NotContains(t, fields, "ct.user", "empty values are omitted")
+
+	var req map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(fields["ct.request"]), &req))
+	assert.Equal(t, "i-0abc", req["instanceId"])
+}
+
+func TestIsLogFile(t *testing.T) {
+	assert.True(t, isLogFile("AWSLogs/123/CloudTrail/us-east-1/2026/10/01/123_CloudTrail_us-east-1_20261001T1200Z_abc.json.gz"))
+	assert.True(t, isLogFile("local/events.json"))
+	assert.False(t, isLogFile("AWSLogs/123/CloudTrail-Digest/us-east-1/2026/10/01/digest.json.gz"))
+	assert.False(t, isLogFile("AWSLogs/123/CloudTrail/us-east-1/"))
+}
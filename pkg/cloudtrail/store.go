@@ -0,0 +1,143 @@
+package cloudtrail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Object is a log file in an ObjectStore
+type Object struct {
+	// Bucket overrides the store's default bucket (SQS notifications name
+	// the bucket they come from)
+	Bucket       string
+	Key          string
+	LastModified time.Time
+}
+
+// ObjectStore lists and reads CloudTrail log files
+type ObjectStore interface {
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Open(ctx context.Context, obj Object) (io.ReadCloser, error)
+}
+
+// s3API defines the S3 operations used by S3Store
+type s3API interface {
+	ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	GetObject(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3Store reads log files from an S3 bucket
+type S3Store struct {
+	client s3API
+	bucket string
+}
+
+// NewS3Store creates a store for bucket using client
+func NewS3Store(client *s3.Client, bucket string) *S3Store {
+	return &S3Store{client: client, bucket: bucket}
+}
+
+// List returns the log files under prefix
+func (s *S3Store) List(ctx context.Context, prefix string) ([]Object, error) {
+	if s.bucket == "" {
+		return nil, fmt.Errorf("no bucket configured")
+	}
+
+	var objects []Object
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list s3://%s/%s: %w", s.bucket, prefix, err)
+		}
+		for _, o := range page.Contents {
+			key := aws.ToString(o.Key)
+			if !isLogFile(key) {
+				continue
+			}
+			objects = append(objects, Object{Bucket: s.bucket, Key: key, LastModified: aws.ToTime(o.LastModified)})
+		}
+	}
+	return objects, nil
+}
+
+// Open reads a log file
+func (s *S3Store) Open(ctx context.Context, obj Object) (io.ReadCloser, error) {
+	bucket := obj.Bucket
+	if bucket == "" {
+		bucket = s.bucket
+	}
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(obj.Key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get s3://%s/%s: %w", bucket, obj.Key, err)
+	}
+	return out.Body, nil
+}
+
+// DirStore reads log files from a local directory tree, laid out like the
+// bucket or flat
+type DirStore struct {
+	root string
+}
+
+// NewDirStore creates a store rooted at dir
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{root: dir}
+}
+
+// List returns the log files under prefix, a slash-separated path relative
+// to the root
+func (d *DirStore) List(_ context.Context, prefix string) ([]Object, error) {
+	base := filepath.Join(d.root, filepath.FromSlash(prefix))
+
+	var objects []Object
+	err := filepath.WalkDir(base, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(d.root, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !isLogFile(key) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: key, LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", base, err)
+	}
+	return objects, nil
+}
+
+// Open reads a log file
+func (d *DirStore) Open(_ context.Context, obj Object) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(d.root, filepath.FromSlash(obj.Key)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", obj.Key, err)
+	}
+	return f, nil
+}
@@ -2,8 +2,12 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
 	"os"
+	"path"
+	"regexp"
+	"strings"
 
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
@@ -13,6 +17,7 @@ import (
 type Config struct {
 	Providers     ProvidersConfig     `yaml:"providers"`
 	Falco         FalcoConfig         `yaml:"falco"`
+	CloudTrail    CloudTrailConfig    `yaml:"cloudtrail"`
 	DriftRules    []DriftRule         `yaml:"drift_rules"`
 	Notifications NotificationsConfig `yaml:"notifications"`
 	Logging       LoggingConfig       `yaml:"logging"`
@@ -20,7 +25,26 @@ type Config struct {
 	Telemetry     TelemetryConfig     `yaml:"telemetry"`
 	Remediation   RemediationConfig   `yaml:"remediation"`
 	GitHub        GitHubConfig        `yaml:"github"`
+	VCS           VCSConfig           `yaml:"vcs"`
 	Policy        PolicyConfig        `yaml:"policy"`
+	History       HistoryConfig       `yaml:"history"`
+	Dedup         DedupConfig         `yaml:"dedup"`
+	Processing    ProcessingConfig    `yaml:"processing"`
+	Verify        VerifyConfig        `yaml:"verify"`
+	TrustedAgents TrustedAgentsConfig `yaml:"trusted_agents" mapstructure:"trusted_agents"`
+	ApplyWindows  ApplyWindowsConfig  `yaml:"apply_windows" mapstructure:"apply_windows"`
+	Auth          AuthConfig          `yaml:"auth"`
+
+	// StateEncryption decrypts OpenTofu-encrypted state of every backend
+	StateEncryption StateEncryptionConfig `yaml:"state_encryption" mapstructure:"state_encryption"`
+
+	// StateHistory keeps earlier state versions so delayed events are
+	// compared with the state of their own time
+	StateHistory StateHistoryConfig `yaml:"state_history" mapstructure:"state_history"`
+
+	// SensitiveAttributes redacts attributes in addition to those the state
+	// marks sensitive
+	SensitiveAttributes SensitiveAttributesConfig `yaml:"sensitive_attributes" mapstructure:"sensitive_attributes"`
 
 	// StateRefreshIntervalSec re-reads the Terraform state every N seconds so a
 	// long-running detector sees legitimate `terraform apply`s instead of
@@ -52,9 +76,15 @@ type ProvidersConfig struct {
 
 // AWSConfig contains AWS-specific settings
 type AWSConfig struct {
-	Enabled bool                 `yaml:"enabled"`
-	Regions []string             `yaml:"regions"`
-	State   TerraformStateConfig `yaml:"state"`
+	Enabled bool                   `yaml:"enabled"`
+	Regions []string               `yaml:"regions"`
+	State   TerraformStateConfig   `yaml:"state"`
+	States  []TerraformStateConfig `yaml:"states"`
+}
+
+// StateConfigs returns every Terraform state configured for AWS.
+func (c AWSConfig) StateConfigs() []TerraformStateConfig {
+	return mergeStateConfigs(c.State, c.States)
 }
 
 // TerraformStateConfig contains Terraform state settings
@@ -62,6 +92,21 @@ type TerraformStateConfig struct {
 	Backend   string `yaml:"backend" mapstructure:"backend"`
 	LocalPath string `yaml:"local_path" mapstructure:"local_path"`
 
+	// Name labels this state in alerts and the API. Defaults to the backend
+	// location (path, bucket/key, ...).
+	Name string `yaml:"name" mapstructure:"name"`
+
+	// Workspace is the Terraform workspace the state belongs to. When empty it
+	// is derived from the backend's workspace key layout, else "default".
+	Workspace string `yaml:"workspace" mapstructure:"workspace"`
+
+	// KeyPattern expands this entry into one state per matching file/object:
+	// a glob over local paths, S3 keys, GCS object names or Azure blob names
+	// in the configured bucket/container, or over HCP Terraform workspace
+	// names in the organization. "*" stays within one path segment,
+	// "**" spans segments (e.g. "stacks/**/terraform.tfstate", "env:/*/app.tfstate").
+	KeyPattern string `yaml:"key_pattern" mapstructure:"key_pattern"`
+
 	// S3 backend settings
 	S3Bucket string `yaml:"s3_bucket" mapstructure:"s3_bucket"`
 	S3Key    string `yaml:"s3_key" mapstructure:"s3_key"`
@@ -77,22 +122,81 @@ type TerraformStateConfig struct {
 	AzureBlobName       string `yaml:"azure_blob_name" mapstructure:"azure_blob_name"`
 	AzureAccessKey      string `yaml:"azure_access_key" mapstructure:"azure_access_key"`
 	AzureSASToken       string `yaml:"azure_sas_token" mapstructure:"azure_sas_token"`
+
+	// HCP Terraform / Terraform Enterprise ("remote" or "cloud") backend
+	// settings. The token falls back to TF_TOKEN_<hostname>.
+	RemoteHostname     string `yaml:"remote_hostname" mapstructure:"remote_hostname"`
+	RemoteOrganization string `yaml:"remote_organization" mapstructure:"remote_organization"`
+	RemoteWorkspace    string `yaml:"remote_workspace" mapstructure:"remote_workspace"`
+	RemoteToken        string `yaml:"remote_token" mapstructure:"remote_token"`
+
+	// Generic HTTP backend settings. Username/password fall back to
+	// TF_HTTP_USERNAME / TF_HTTP_PASSWORD; a token is sent as a bearer token.
+	HTTPAddress  string `yaml:"http_address" mapstructure:"http_address"`
+	HTTPUsername string `yaml:"http_username" mapstructure:"http_username"`
+	HTTPPassword string `yaml:"http_password" mapstructure:"http_password"`
+	HTTPToken    string `yaml:"http_token" mapstructure:"http_token"`
+
+	// Consul KV backend settings. Address and token fall back to
+	// CONSUL_HTTP_ADDR / CONSUL_HTTP_TOKEN.
+	ConsulAddress string `yaml:"consul_address" mapstructure:"consul_address"`
+	ConsulPath    string `yaml:"consul_path" mapstructure:"consul_path"`
+	ConsulToken   string `yaml:"consul_token" mapstructure:"consul_token"`
+
+	// PostgreSQL ("pg") backend settings. The connection string falls back
+	// to PG_CONN_STR, the schema to PG_SCHEMA_NAME, then "terraform_remote_state".
+	PGConnStr    string `yaml:"pg_conn_str" mapstructure:"pg_conn_str"`
+	PGSchemaName string `yaml:"pg_schema_name" mapstructure:"pg_schema_name"`
+
+	// Kubernetes Secret backend settings. Without a host the in-cluster
+	// service account is used.
+	KubernetesSecretSuffix string `yaml:"kubernetes_secret_suffix" mapstructure:"kubernetes_secret_suffix"`
+	KubernetesNamespace    string `yaml:"kubernetes_namespace" mapstructure:"kubernetes_namespace"`
+	KubernetesHost         string `yaml:"kubernetes_host" mapstructure:"kubernetes_host"`
+	KubernetesToken        string `yaml:"kubernetes_token" mapstructure:"kubernetes_token"`
+	KubernetesCACert       string `yaml:"kubernetes_ca_cert" mapstructure:"kubernetes_ca_cert"`
+}
+
+// mergeStateConfigs combines the single `state` entry with the `states` list.
+// The single entry is kept when set (so existing configs keep working) and is
+// the only entry, with its defaults, when no list is given.
+func mergeStateConfigs(single TerraformStateConfig, list []TerraformStateConfig) []TerraformStateConfig {
+	if len(list) == 0 {
+		return []TerraformStateConfig{single}
+	}
+	merged := make([]TerraformStateConfig, 0, len(list)+1)
+	if single != (TerraformStateConfig{}) {
+		merged = append(merged, single)
+	}
+	return append(merged, list...)
 }
 
 // GCPConfig contains GCP-specific settings
 type GCPConfig struct {
-	Enabled  bool                 `yaml:"enabled"`
-	Projects []string             `yaml:"projects"`
-	State    TerraformStateConfig `yaml:"state"`
+	Enabled  bool                   `yaml:"enabled"`
+	Projects []string               `yaml:"projects"`
+	State    TerraformStateConfig   `yaml:"state"`
+	States   []TerraformStateConfig `yaml:"states"`
+}
+
+// StateConfigs returns every Terraform state configured for GCP.
+func (c GCPConfig) StateConfigs() []TerraformStateConfig {
+	return mergeStateConfigs(c.State, c.States)
 }
 
 // AzureConfig contains Azure-specific settings
 type AzureConfig struct {
-	Enabled        bool                 `yaml:"enabled"`
-	SubscriptionID string               `yaml:"subscription_id"`
-	Regions        []string             `yaml:"regions"`
-	ResourceGroup  string               `yaml:"resource_group"`
-	State          TerraformStateConfig `yaml:"state"`
+	Enabled        bool                   `yaml:"enabled"`
+	SubscriptionID string                 `yaml:"subscription_id"`
+	Regions        []string               `yaml:"regions"`
+	ResourceGroup  string                 `yaml:"resource_group"`
+	State          TerraformStateConfig   `yaml:"state"`
+	States         []TerraformStateConfig `yaml:"states"`
+}
+
+// StateConfigs returns every Terraform state configured for Azure.
+func (c AzureConfig) StateConfigs() []TerraformStateConfig {
+	return mergeStateConfigs(c.State, c.States)
 }
 
 // FalcoConfig contains Falco integration settings
@@ -112,6 +216,38 @@ type FalcoConfig struct {
 	CertFile   string `yaml:"cert_file" mapstructure:"cert_file"`
 	KeyFile    string `yaml:"key_file" mapstructure:"key_file"`
 	CARootFile string `yaml:"ca_root_file" mapstructure:"ca_root_file"`
+
+	// Receiver secures the HTTP receiver alerts are POSTed to
+	Receiver FalcoReceiverConfig `yaml:"receiver" mapstructure:"receiver"`
+
+	// ChangeRules are change-extraction rule files, or directories of them,
+	// loaded on top of the built-in rules
+	ChangeRules []string `yaml:"change_rules" mapstructure:"change_rules"`
+}
+
+// FalcoReceiverConfig authenticates senders of the HTTP transport (Falco
+// http_output, Falcosidekick webhook). A request passes with a valid HMAC
+// signature or bearer token when either is configured, and additionally
+// needs a verified client certificate when RequireClientCert is set.
+type FalcoReceiverConfig struct {
+	// HMACSecret verifies a hex HMAC-SHA256 of the request body, optionally
+	// prefixed "sha256=", sent in HMACHeader
+	HMACSecret string `yaml:"hmac_secret" mapstructure:"hmac_secret"`
+	// HMACHeader defaults to X-TFDrift-Signature
+	HMACHeader string `yaml:"hmac_header" mapstructure:"hmac_header"`
+	// BearerToken is expected as "Authorization: Bearer <token>"
+	BearerToken string `yaml:"bearer_token" mapstructure:"bearer_token"`
+	// RequireClientCert requires a client certificate verified against
+	// auth.mtls.client_ca_file
+	RequireClientCert bool `yaml:"require_client_cert" mapstructure:"require_client_cert"`
+	// ClientSubjects limits client certificates by subject common name;
+	// empty accepts any verified certificate
+	ClientSubjects []string `yaml:"client_subjects" mapstructure:"client_subjects"`
+}
+
+// Secured reports whether the receiver checks senders at all
+func (r FalcoReceiverConfig) Secured() bool {
+	return r.HMACSecret != "" || r.BearerToken != "" || r.RequireClientCert
 }
 
 // UsesHTTPTransport reports whether Falco alerts arrive over the HTTP receiver
@@ -178,6 +314,11 @@ type AutoImportConfig struct {
 	Tool             string   `yaml:"tool" mapstructure:"tool"`
 	AllowedResources []string `yaml:"allowed_resources"`
 	RequireApproval  bool     `yaml:"require_approval"`
+	// ApprovalStorePath persists approval requests and their audit trail in
+	// an embedded database file. When set, requests requiring approval are
+	// queued for `tfdrift approval` and /api/v1/approvals instead of being
+	// prompted for on the console. Empty keeps requests in memory.
+	ApprovalStorePath string `yaml:"approval_store_path" mapstructure:"approval_store_path"`
 }
 
 // IaCTool returns the configured IaC CLI binary name, defaulting to
@@ -194,6 +335,13 @@ type RemediationConfig struct {
 	Enabled   bool `yaml:"enabled"`
 	CreatePRs bool `yaml:"create_prs"`
 	DryRun    bool `yaml:"dry_run"`
+	// SourceDir is a local checkout of the Terraform root module the PRs
+	// target. When set, drift fixes edit the resource's own block in place
+	// instead of adding a standalone snippet file.
+	SourceDir string `yaml:"source_dir" mapstructure:"source_dir"`
+	// SourceRepoPath is SourceDir's path inside the repository (e.g.
+	// "envs/prod"); empty means the repository root
+	SourceRepoPath string `yaml:"source_repo_path" mapstructure:"source_repo_path"`
 }
 
 // GitHubConfig contains GitHub integration settings
@@ -205,12 +353,346 @@ type GitHubConfig struct {
 	Token   string `yaml:"token"`
 }
 
+// VCSConfig selects the code host remediation pull/merge requests are
+// opened on. It takes precedence over the github section when enabled.
+type VCSConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+	// Provider is "github" (default), "gitlab", "bitbucket" (Bitbucket
+	// Server / Data Center) or "gitea"
+	Provider string `yaml:"provider" mapstructure:"provider"`
+	// URL is the server root of a self-hosted instance. Required for
+	// bitbucket and gitea; defaults to github.com / gitlab.com otherwise.
+	URL string `yaml:"url" mapstructure:"url"`
+	// Owner is the GitHub/Gitea owner, GitLab namespace (group/subgroup) or
+	// Bitbucket project key
+	Owner string `yaml:"owner" mapstructure:"owner"`
+	// Repo is the repository name (Bitbucket: repository slug)
+	Repo   string `yaml:"repo" mapstructure:"repo"`
+	Branch string `yaml:"branch" mapstructure:"branch"`
+	Token  string `yaml:"token" mapstructure:"token"`
+}
+
+// RemediationVCS returns the code host settings remediation PRs use: the
+// vcs section when enabled, else the legacy github section. ok is false
+// when neither is enabled.
+func (c *Config) RemediationVCS() (vcs VCSConfig, ok bool) {
+	if c.VCS.Enabled {
+		vcs = c.VCS
+		if vcs.Provider == "" {
+			vcs.Provider = "github"
+		}
+		return vcs, true
+	}
+	if c.GitHub.Enabled {
+		return VCSConfig{
+			Enabled:  true,
+			Provider: "github",
+			Owner:    c.GitHub.Owner,
+			Repo:     c.GitHub.Repo,
+			Branch:   c.GitHub.Branch,
+			Token:    c.GitHub.Token,
+		}, true
+	}
+	return VCSConfig{}, false
+}
+
+// validate checks an enabled vcs section
+func (v VCSConfig) validate() error {
+	if !v.Enabled {
+		return nil
+	}
+	switch v.Provider {
+	case "", "github", "gitlab":
+	case "bitbucket", "gitea":
+		if v.URL == "" {
+			return fmt.Errorf("vcs.url is required for provider %q", v.Provider)
+		}
+	default:
+		return fmt.Errorf("vcs.provider must be \"github\", \"gitlab\", \"bitbucket\" or \"gitea\", got %q", v.Provider)
+	}
+	if v.Owner == "" || v.Repo == "" {
+		return fmt.Errorf("vcs.owner and vcs.repo are required")
+	}
+	return nil
+}
+
+// CloudTrailConfig reads CloudTrail log files directly from S3, for accounts
+// where the Falco cloudtrail plugin cannot run. New files are discovered
+// from SQS notifications when SQSQueueURL is set, otherwise by polling the
+// bucket.
+type CloudTrailConfig struct {
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+	// Bucket and Prefix locate the trail's log files. With SQS the bucket
+	// comes from each notification and Bucket may be empty.
+	Bucket string `yaml:"bucket" mapstructure:"bucket"`
+	Prefix string `yaml:"prefix" mapstructure:"prefix"`
+	Region string `yaml:"region" mapstructure:"region"`
+	// SQSQueueURL receives the bucket's S3 event notifications or the
+	// trail's SNS notifications
+	SQSQueueURL string `yaml:"sqs_queue_url" mapstructure:"sqs_queue_url"`
+	// PollIntervalSec is how often the bucket or directory is listed when
+	// no queue is configured (default 60)
+	PollIntervalSec int `yaml:"poll_interval" mapstructure:"poll_interval"`
+	// Endpoint overrides the S3 and SQS endpoint, e.g. for LocalStack
+	Endpoint string `yaml:"endpoint" mapstructure:"endpoint"`
+	// Dir reads log files from a local directory instead of S3
+	Dir string `yaml:"dir" mapstructure:"dir"`
+}
+
+// validate checks an enabled cloudtrail section
+func (c CloudTrailConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Dir != "" && (c.Bucket != "" || c.SQSQueueURL != "") {
+		return fmt.Errorf("cloudtrail.dir cannot be combined with cloudtrail.bucket or cloudtrail.sqs_queue_url")
+	}
+	if c.Dir == "" && c.Bucket == "" && c.SQSQueueURL == "" {
+		return fmt.Errorf("cloudtrail.bucket, cloudtrail.sqs_queue_url or cloudtrail.dir is required")
+	}
+	if c.PollIntervalSec < 0 {
+		return fmt.Errorf("cloudtrail.poll_interval must not be negative")
+	}
+	return nil
+}
+
 // PolicyConfig contains OPA/Rego policy engine settings
 type PolicyConfig struct {
 	Enabled   bool   `yaml:"enabled"`
 	PolicyDir string `yaml:"policy_dir"` // Directory containing .rego files
 }
 
+// HistoryConfig controls where detected drifts, events and unmanaged-resource
+// alerts are kept for the API/dashboard, and for how long.
+type HistoryConfig struct {
+	// Backend is "memory" (default, lost on restart) or "bolt" (embedded file)
+	Backend string `yaml:"backend" mapstructure:"backend"`
+	// Path is the database file for the bolt backend
+	Path string `yaml:"path" mapstructure:"path"`
+	// MaxAgeHours drops records older than this. 0 = keep forever.
+	MaxAgeHours int `yaml:"max_age_hours" mapstructure:"max_age_hours"`
+	// MaxRecords caps each record kind (drifts, events, unmanaged). 0 = no cap.
+	MaxRecords int `yaml:"max_records" mapstructure:"max_records"`
+}
+
+// ProcessingConfig controls the event worker pool. Events are sharded by
+// resource ID, so events for one resource are handled in order while
+// different resources are handled concurrently.
+type ProcessingConfig struct {
+	// Workers is the number of shards processing events. 0 = 4.
+	Workers int `yaml:"workers" mapstructure:"workers"`
+	// QueueSize is the queue depth of each worker. 0 = 100.
+	QueueSize int `yaml:"queue_size" mapstructure:"queue_size"`
+	// Overflow decides what happens to an event whose worker queue is full:
+	// "block" (default) waits, applying backpressure to the event sources,
+	// "drop" discards it, "spill" writes it to disk until the worker catches up
+	Overflow string `yaml:"overflow" mapstructure:"overflow"`
+	// SpillDir holds the spill files. Empty = the system temp directory.
+	SpillDir string `yaml:"spill_dir" mapstructure:"spill_dir"`
+}
+
+// VerifyConfig controls live verification: after a mutating event on a
+// managed resource of a discoverable type, the resource is read back from
+// the cloud API and all of its attributes are compared with Terraform state,
+// instead of relying on the partial view in the event's request parameters.
+type VerifyConfig struct {
+	// Enabled turns on live verification
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+	// DebounceSeconds waits this long after the last event for a resource
+	// before reading it, so a burst of changes is verified once. 0 = 5.
+	DebounceSeconds int `yaml:"debounce_seconds" mapstructure:"debounce_seconds"`
+	// TimeoutSeconds bounds each cloud API read. 0 = 30.
+	TimeoutSeconds int `yaml:"timeout_seconds" mapstructure:"timeout_seconds"`
+}
+
+// TrustedAgentsConfig lists the identities allowed to change infrastructure,
+// typically the CI pipeline running terraform apply. Their events are
+// expected changes: the provider's Terraform state is re-read instead of
+// alerting.
+type TrustedAgentsConfig struct {
+	// AWSRoleARNs are IAM role (or user) ARNs; sessions of an assumed role match
+	AWSRoleARNs []string `yaml:"aws_role_arns" mapstructure:"aws_role_arns"`
+	// GCPServiceAccounts are service account emails
+	GCPServiceAccounts []string `yaml:"gcp_service_accounts" mapstructure:"gcp_service_accounts"`
+	// AzurePrincipals are Activity Log callers: object/application IDs or UPNs
+	AzurePrincipals []string `yaml:"azure_principals" mapstructure:"azure_principals"`
+	// UserAgents are regular expressions matched against the caller's user
+	// agent, e.g. "Terraform/" or "OpenTofu/". They narrow the identities
+	// above (both must match) and are rejected on their own.
+	UserAgents []string `yaml:"user_agents" mapstructure:"user_agents"`
+}
+
+// ApplyWindowsConfig controls apply windows: a pipeline announces through
+// the API that it is applying a state, and drift on that state's resources
+// is held until the apply finishes and the new state is loaded.
+type ApplyWindowsConfig struct {
+	// MaxDurationMinutes closes a window the pipeline never finished. 0 = 60.
+	MaxDurationMinutes int `yaml:"max_duration_minutes" mapstructure:"max_duration_minutes"`
+}
+
+// StateEncryptionConfig lists the key providers OpenTofu state encryption
+// was configured with. Encrypted state is decrypted with the provider its
+// metadata names, falling back to the others (key rotation); unencrypted
+// state is read as-is.
+type StateEncryptionConfig struct {
+	KeyProviders []StateKeyProviderConfig `yaml:"key_providers" mapstructure:"key_providers"`
+}
+
+// StateKeyProviderConfig mirrors one OpenTofu key_provider block
+type StateKeyProviderConfig struct {
+	// Type is "pbkdf2" or "static"
+	Type string `yaml:"type" mapstructure:"type"`
+	// Name is the key provider's name in the OpenTofu configuration
+	Name string `yaml:"name" mapstructure:"name"`
+	// Passphrase of a pbkdf2 provider (at least 16 characters). The salt,
+	// iterations and hash function are read from the state's metadata.
+	Passphrase string `yaml:"passphrase" mapstructure:"passphrase"`
+	// Key of a static provider, hex-encoded
+	Key string `yaml:"key" mapstructure:"key"`
+}
+
+func (e StateEncryptionConfig) validate() error {
+	for i, kp := range e.KeyProviders {
+		field := fmt.Sprintf("state_encryption.key_providers[%d]", i)
+		if kp.Name == "" {
+			return fmt.Errorf("%s.name is required", field)
+		}
+		switch kp.Type {
+		case "pbkdf2":
+			if len(kp.Passphrase) < 16 {
+				return fmt.Errorf("%s.passphrase must be at least 16 characters", field)
+			}
+		case "static":
+			key, err := hex.DecodeString(kp.Key)
+			if err != nil {
+				return fmt.Errorf("%s.key must be hex-encoded: %w", field, err)
+			}
+			if n := len(key); n != 16 && n != 24 && n != 32 {
+				return fmt.Errorf("%s.key must be 16, 24 or 32 bytes, got %d", field, n)
+			}
+		default:
+			return fmt.Errorf("%s.type must be \"pbkdf2\" or \"static\", got %q", field, kp.Type)
+		}
+	}
+	return nil
+}
+
+// StateHistoryConfig controls which state an event is compared with. Events
+// (CloudTrail in particular) arrive minutes late; an apply in between must
+// not make them look like drift, or hide drift, against the newer state.
+type StateHistoryConfig struct {
+	// Disabled compares every event with the current state
+	Disabled bool `yaml:"disabled" mapstructure:"disabled"`
+	// Versions is how many earlier state versions are kept in memory. 0 = 1.
+	// Each version costs about as much memory as the current state's index.
+	Versions int `yaml:"versions" mapstructure:"versions"`
+	// BackendVersions reads versions older than the kept ones from S3 object
+	// versions or GCS object generations (bucket versioning required)
+	BackendVersions bool `yaml:"backend_versions" mapstructure:"backend_versions"`
+}
+
+// SensitiveAttributesConfig lists attributes whose values are redacted in
+// alerts, API responses and generated code, in addition to those Terraform
+// marks sensitive in state.
+type SensitiveAttributesConfig struct {
+	// Patterns match dotted attribute paths ("password", "tags.Secret",
+	// "connection.0.password") and everything below them. "*" matches within
+	// one segment, "**" any number of segments ("**.password"). A
+	// "<resource_type>:" prefix, itself a glob, limits a pattern to matching
+	// resources ("aws_db_instance:master_*").
+	Patterns []string `yaml:"patterns" mapstructure:"patterns"`
+}
+
+func (s SensitiveAttributesConfig) validate() error {
+	for _, pattern := range s.Patterns {
+		resourceType, attr, found := strings.Cut(pattern, ":")
+		if !found {
+			resourceType, attr = "", pattern
+		}
+		if attr == "" {
+			return fmt.Errorf("sensitive_attributes.patterns: pattern %q has no attribute path", pattern)
+		}
+		for _, glob := range append(strings.Split(attr, "."), resourceType) {
+			if _, err := path.Match(glob, ""); err != nil {
+				return fmt.Errorf("sensitive_attributes.patterns: invalid pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// DedupConfig controls how long processed event IDs (CloudTrail eventID,
+// GCP insertId, Azure correlationId/eventDataId) are remembered so events
+// delivered twice are only processed once.
+type DedupConfig struct {
+	// Disabled processes every delivery, duplicates included
+	Disabled bool `yaml:"disabled" mapstructure:"disabled"`
+	// Backend is "memory" (default, lost on restart) or "bolt" (embedded file)
+	Backend string `yaml:"backend" mapstructure:"backend"`
+	// Path is the database file for the bolt backend
+	Path string `yaml:"path" mapstructure:"path"`
+	// TTLMinutes is how long an event ID is remembered. 0 = 60.
+	TTLMinutes int `yaml:"ttl_minutes" mapstructure:"ttl_minutes"`
+	// MaxEntries caps remembered IDs, forgetting the oldest. 0 = 100000.
+	MaxEntries int `yaml:"max_entries" mapstructure:"max_entries"`
+}
+
+// AuthConfig configures how API callers are authenticated. Each enabled
+// method resolves a caller to a user ID and an RBAC role ("admin",
+// "editor" or "viewer"); the first method whose credentials are present
+// decides.
+type AuthConfig struct {
+	// Enabled enforces RBAC on the API. Unauthenticated requests to
+	// role-protected routes are rejected.
+	Enabled bool           `yaml:"enabled" mapstructure:"enabled"`
+	APIKeys []APIKeyConfig `yaml:"api_keys" mapstructure:"api_keys"`
+	OIDC    OIDCConfig     `yaml:"oidc" mapstructure:"oidc"`
+	MTLS    MTLSConfig     `yaml:"mtls" mapstructure:"mtls"`
+}
+
+// APIKeyConfig is a static API key sent in the X-API-Key header
+type APIKeyConfig struct {
+	// Name identifies the key's holder and becomes the user ID
+	Name string `yaml:"name" mapstructure:"name"`
+	// Key is the plaintext key. Prefer KeySHA256 so the config holds no secret.
+	Key string `yaml:"key" mapstructure:"key"`
+	// KeySHA256 is the hex-encoded SHA-256 of the key
+	KeySHA256 string `yaml:"key_sha256" mapstructure:"key_sha256"`
+	Role      string `yaml:"role" mapstructure:"role"`
+}
+
+// OIDCConfig validates OIDC/JWT bearer tokens against the issuer's JWKS
+type OIDCConfig struct {
+	Enabled  bool   `yaml:"enabled" mapstructure:"enabled"`
+	Issuer   string `yaml:"issuer" mapstructure:"issuer"`
+	Audience string `yaml:"audience" mapstructure:"audience"`
+	// JWKSURL overrides the jwks_uri from the issuer's discovery document
+	JWKSURL string `yaml:"jwks_url" mapstructure:"jwks_url"`
+	// UsernameClaim names the claim used as user ID (default "sub")
+	UsernameClaim string `yaml:"username_claim" mapstructure:"username_claim"`
+	// RolesClaim names the string or string-list claim mapped to roles
+	// (default "groups")
+	RolesClaim string `yaml:"roles_claim" mapstructure:"roles_claim"`
+	// RoleMapping maps claim values to roles. Only mapped values grant a
+	// role; the most privileged match wins.
+	RoleMapping map[string]string `yaml:"role_mapping" mapstructure:"role_mapping"`
+	// DefaultRole applies to valid tokens with no mapped claim value
+	DefaultRole string `yaml:"default_role" mapstructure:"default_role"`
+}
+
+// MTLSConfig serves the API over TLS and authenticates client certificates
+// signed by ClientCAFile by their subject common name
+type MTLSConfig struct {
+	Enabled      bool   `yaml:"enabled" mapstructure:"enabled"`
+	CertFile     string `yaml:"cert_file" mapstructure:"cert_file"`
+	KeyFile      string `yaml:"key_file" mapstructure:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file" mapstructure:"client_ca_file"`
+	// Subjects maps client certificate common names to roles
+	Subjects map[string]string `yaml:"subjects" mapstructure:"subjects"`
+	// DefaultRole applies to verified certificates not listed in Subjects
+	DefaultRole string `yaml:"default_role" mapstructure:"default_role"`
+}
+
 // Load loads and validates configuration for normal (Falco-connected) operation.
 func Load(path string) (*Config, error) {
 	return load(path, (*Config).Validate)
@@ -259,9 +741,13 @@ func (c *Config) Validate() error {
 		return err
 	}
 
-	// Validate Falco configuration
+	// Validate Falco configuration. The CloudTrail collector can stand in for
+	// Falco in accounts without the cloudtrail plugin.
 	if !c.Falco.Enabled {
-		return fmt.Errorf("falco must be enabled - TFDrift-Falco requires Falco gRPC connection")
+		if c.CloudTrail.Enabled {
+			return nil
+		}
+		return fmt.Errorf("falco must be enabled - TFDrift-Falco requires Falco gRPC connection (or the cloudtrail collector)")
 	}
 	// hostname/port only apply to the legacy gRPC transport; the HTTP transport
 	// receives alerts on the API server and needs neither (ADR-006).
@@ -272,6 +758,8 @@ func (c *Config) Validate() error {
 		if c.Falco.Port == 0 {
 			return fmt.Errorf("falco port must be specified")
 		}
+	} else if c.Falco.Receiver.RequireClientCert && !c.Auth.MTLS.Enabled {
+		return fmt.Errorf("falco.receiver.require_client_cert needs auth.mtls to be enabled")
 	}
 
 	return nil
@@ -294,6 +782,79 @@ func (c *Config) validateProvidersAndTooling() error {
 		}
 	}
 
+	switch c.History.Backend {
+	case "", "memory":
+	case "bolt":
+		if c.History.Path == "" {
+			return fmt.Errorf("history.path is required when history.backend is \"bolt\"")
+		}
+	default:
+		return fmt.Errorf("history.backend must be \"memory\" or \"bolt\", got %q", c.History.Backend)
+	}
+	if c.History.MaxAgeHours < 0 || c.History.MaxRecords < 0 {
+		return fmt.Errorf("history.max_age_hours and history.max_records must not be negative")
+	}
+
+	switch c.Dedup.Backend {
+	case "", "memory":
+	case "bolt":
+		if c.Dedup.Path == "" {
+			return fmt.Errorf("dedup.path is required when dedup.backend is \"bolt\"")
+		}
+	default:
+		return fmt.Errorf("dedup.backend must be \"memory\" or \"bolt\", got %q", c.Dedup.Backend)
+	}
+	if c.Dedup.TTLMinutes < 0 || c.Dedup.MaxEntries < 0 {
+		return fmt.Errorf("dedup.ttl_minutes and dedup.max_entries must not be negative")
+	}
+
+	switch c.Processing.Overflow {
+	case "", "block", "drop", "spill":
+	default:
+		return fmt.Errorf("processing.overflow must be \"block\", \"drop\" or \"spill\", got %q", c.Processing.Overflow)
+	}
+	if c.Processing.Workers < 0 || c.Processing.QueueSize < 0 {
+		return fmt.Errorf("processing.workers and processing.queue_size must not be negative")
+	}
+	if c.Verify.DebounceSeconds < 0 || c.Verify.TimeoutSeconds < 0 {
+		return fmt.Errorf("verify.debounce_seconds and verify.timeout_seconds must not be negative")
+	}
+	if c.ApplyWindows.MaxDurationMinutes < 0 {
+		return fmt.Errorf("apply_windows.max_duration_minutes must not be negative")
+	}
+	if c.StateHistory.Versions < 0 {
+		return fmt.Errorf("state_history.versions must not be negative")
+	}
+	ta := c.TrustedAgents
+	if len(ta.UserAgents) > 0 && len(ta.AWSRoleARNs)+len(ta.GCPServiceAccounts)+len(ta.AzurePrincipals) == 0 {
+		return fmt.Errorf("trusted_agents.user_agents requires a trusted identity (aws_role_arns, gcp_service_accounts or azure_principals)")
+	}
+	for _, pattern := range c.TrustedAgents.UserAgents {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("trusted_agents.user_agents: invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	if err := c.StateEncryption.validate(); err != nil {
+		return err
+	}
+
+	if err := c.SensitiveAttributes.validate(); err != nil {
+		return err
+	}
+
+	if err := c.VCS.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Auth.validate(); err != nil {
+		return err
+	}
+
+	if err := c.CloudTrail.validate(); err != nil {
+		return err
+	}
+
 	// Validate IaC tool selection (empty is allowed and means terraform)
 	if c.AutoImport.Tool != "" && c.AutoImport.Tool != "terraform" && c.AutoImport.Tool != "tofu" {
 		return fmt.Errorf("auto_import.tool must be \"terraform\" or \"tofu\", got %q", c.AutoImport.Tool)
@@ -302,6 +863,67 @@ func (c *Config) validateProvidersAndTooling() error {
 	return nil
 }
 
+// validate checks that every configured role is a known RBAC role and that
+// each enabled method has what it needs
+func (a AuthConfig) validate() error {
+	checkRole := func(field, role string, optional bool) error {
+		switch role {
+		case "admin", "editor", "viewer":
+			return nil
+		case "":
+			if optional {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be \"admin\", \"editor\" or \"viewer\", got %q", field, role)
+	}
+
+	for i, k := range a.APIKeys {
+		if k.Name == "" {
+			return fmt.Errorf("auth.api_keys[%d].name is required", i)
+		}
+		if (k.Key == "") == (k.KeySHA256 == "") {
+			return fmt.Errorf("auth.api_keys[%d] (%s) needs exactly one of key or key_sha256", i, k.Name)
+		}
+		if err := checkRole(fmt.Sprintf("auth.api_keys[%d].role", i), k.Role, false); err != nil {
+			return err
+		}
+	}
+
+	if a.OIDC.Enabled {
+		if a.OIDC.Issuer == "" {
+			return fmt.Errorf("auth.oidc.issuer is required when OIDC is enabled")
+		}
+		if a.OIDC.Audience == "" {
+			return fmt.Errorf("auth.oidc.audience is required when OIDC is enabled")
+		}
+		for value, role := range a.OIDC.RoleMapping {
+			if err := checkRole(fmt.Sprintf("auth.oidc.role_mapping[%s]", value), role, false); err != nil {
+				return err
+			}
+		}
+		if err := checkRole("auth.oidc.default_role", a.OIDC.DefaultRole, true); err != nil {
+			return err
+		}
+	}
+
+	if a.MTLS.Enabled {
+		if a.MTLS.CertFile == "" || a.MTLS.KeyFile == "" || a.MTLS.ClientCAFile == "" {
+			return fmt.Errorf("auth.mtls requires cert_file, key_file and client_ca_file")
+		}
+		for subject, role := range a.MTLS.Subjects {
+			if err := checkRole(fmt.Sprintf("auth.mtls.subjects[%s]", subject), role, false); err != nil {
+				return err
+			}
+		}
+		if err := checkRole("auth.mtls.default_role", a.MTLS.DefaultRole, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Save saves configuration to file
 func (c *Config) Save(path string) error {
 	data, err := yaml.Marshal(c)
@@ -197,6 +197,26 @@ func TestValidate_FalcoHTTPTransportNeedsNoHostname(t *testing.T) {
 	assert.NoError(t, cfg.Validate())
 }
 
+func TestValidate_FalcoReceiverClientCertNeedsMTLS(t *testing.T) {
+	cfg := &Config{
+		Providers: ProvidersConfig{
+			AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}},
+		},
+		Falco: FalcoConfig{
+			Enabled:   true,
+			Transport: "http",
+			Receiver:  FalcoReceiverConfig{RequireClientCert: true},
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "require_client_cert")
+
+	cfg.Auth.MTLS = MTLSConfig{Enabled: true, CertFile: "s.crt", KeyFile: "s.key", ClientCAFile: "ca.crt"}
+	assert.NoError(t, cfg.Validate())
+}
+
 func TestValidate_FalcoMissingPort(t *testing.T) {
 	cfg := &Config{
 		Providers: ProvidersConfig{
@@ -1250,3 +1270,311 @@ func TestValidateForScan_DoesNotRequireFalco(t *testing.T) {
 	assert.NoError(t, cfg.ValidateForScan(), "scan must not require Falco")
 	assert.Error(t, cfg.Validate(), "full validate still requires Falco")
 }
+
+func TestLoad_MultipleStates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+providers:
+  aws:
+    enabled: true
+    regions: [us-east-1]
+    states:
+      - name: network
+        backend: s3
+        s3_bucket: state
+        s3_key: network/terraform.tfstate
+      - backend: s3
+        s3_bucket: state
+        key_pattern: "env:/*/app.tfstate"
+        workspace: ""
+falco:
+  enabled: true
+  hostname: localhost
+  port: 5060
+`), 0o600))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	states := cfg.Providers.AWS.StateConfigs()
+	require.Len(t, states, 2, "an unset single state must not add an implicit ./terraform.tfstate")
+	assert.Equal(t, "network", states[0].Name)
+	assert.Equal(t, "env:/*/app.tfstate", states[1].KeyPattern)
+}
+
+func TestStateConfigs_SingleStateOnly(t *testing.T) {
+	c := AWSConfig{State: TerraformStateConfig{Backend: "local", LocalPath: "a.tfstate"}}
+	assert.Equal(t, []TerraformStateConfig{c.State}, c.StateConfigs())
+
+	// With no configuration at all the zero entry is kept, i.e. the local default
+	assert.Len(t, GCPConfig{}.StateConfigs(), 1)
+
+	c.States = []TerraformStateConfig{{Backend: "local", LocalPath: "b.tfstate"}}
+	assert.Len(t, c.StateConfigs(), 2)
+}
+
+func TestValidate_History(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			Providers: ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+			Falco:     FalcoConfig{Enabled: true, Hostname: "localhost", Port: 5060},
+		}
+	}
+
+	cfg := base()
+	assert.NoError(t, cfg.Validate())
+
+	cfg.History = HistoryConfig{Backend: "bolt"}
+	assert.Error(t, cfg.Validate(), "bolt needs a path")
+
+	cfg.History = HistoryConfig{Backend: "bolt", Path: "/var/lib/tfdrift/history.db", MaxAgeHours: 720}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.History = HistoryConfig{Backend: "sqlite"}
+	assert.Error(t, cfg.Validate())
+
+	cfg.History = HistoryConfig{MaxRecords: -1}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestValidate_Dedup(t *testing.T) {
+	cfg := &Config{
+		Providers: ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+		Falco:     FalcoConfig{Enabled: true, Hostname: "localhost", Port: 5060},
+	}
+
+	cfg.Dedup = DedupConfig{Backend: "bolt"}
+	assert.Error(t, cfg.Validate(), "bolt needs a path")
+
+	cfg.Dedup = DedupConfig{Backend: "bolt", Path: "/var/lib/tfdrift/dedup.db", TTLMinutes: 120}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Dedup = DedupConfig{Backend: "redis"}
+	assert.Error(t, cfg.Validate())
+
+	cfg.Dedup = DedupConfig{MaxEntries: -1}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestValidate_Processing(t *testing.T) {
+	cfg := &Config{
+		Providers: ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+		Falco:     FalcoConfig{Enabled: true, Hostname: "localhost", Port: 5060},
+	}
+
+	cfg.Processing = ProcessingConfig{Workers: 8, QueueSize: 500, Overflow: "spill"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Processing = ProcessingConfig{Overflow: "discard"}
+	assert.Error(t, cfg.Validate())
+
+	cfg.Processing = ProcessingConfig{Workers: -1}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestValidate_Verify(t *testing.T) {
+	cfg := &Config{
+		Providers: ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+		Falco:     FalcoConfig{Enabled: true, Hostname: "localhost", Port: 5060},
+	}
+
+	cfg.Verify = VerifyConfig{Enabled: true, DebounceSeconds: 10, TimeoutSeconds: 60}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Verify = VerifyConfig{Enabled: true, DebounceSeconds: -1}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestValidate_TrustedAgents(t *testing.T) {
+	cfg := &Config{
+		Providers: ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+		Falco:     FalcoConfig{Enabled: true, Hostname: "localhost", Port: 5060},
+	}
+
+	cfg.TrustedAgents = TrustedAgentsConfig{
+		AWSRoleARNs: []string{"arn:aws:iam::123456789012:role/terraform-ci"},
+		UserAgents:  []string{`Terraform/\d`, "OpenTofu/"},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.TrustedAgents.UserAgents = []string{"Terraform/("}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "trusted_agents.user_agents")
+
+	cfg.TrustedAgents = TrustedAgentsConfig{UserAgents: []string{"Terraform/"}}
+	err = cfg.Validate()
+	require.Error(t, err, "a user agent alone must not trust events")
+	assert.Contains(t, err.Error(), "requires a trusted identity")
+}
+
+func TestValidate_ApplyWindows(t *testing.T) {
+	cfg := &Config{
+		Providers: ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+		Falco:     FalcoConfig{Enabled: true, Hostname: "localhost", Port: 5060},
+	}
+
+	cfg.ApplyWindows = ApplyWindowsConfig{MaxDurationMinutes: 90}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.ApplyWindows = ApplyWindowsConfig{MaxDurationMinutes: -1}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestValidate_StateHistory(t *testing.T) {
+	cfg := &Config{
+		Providers: ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+		Falco:     FalcoConfig{Enabled: true, Hostname: "localhost", Port: 5060},
+	}
+
+	cfg.StateHistory = StateHistoryConfig{Versions: 10, BackendVersions: true}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.StateHistory = StateHistoryConfig{Versions: -1}
+	assert.ErrorContains(t, cfg.Validate(), "state_history.versions")
+}
+
+func TestValidate_SensitiveAttributes(t *testing.T) {
+	cfg := &Config{
+		Providers: ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+		Falco:     FalcoConfig{Enabled: true, Hostname: "localhost", Port: 5060},
+	}
+
+	cfg.SensitiveAttributes = SensitiveAttributesConfig{Patterns: []string{"password", "**.secret_*", "aws_db_instance:master_*"}}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.SensitiveAttributes = SensitiveAttributesConfig{Patterns: []string{"tags.[Secret"}}
+	assert.ErrorContains(t, cfg.Validate(), "sensitive_attributes.patterns")
+
+	cfg.SensitiveAttributes = SensitiveAttributesConfig{Patterns: []string{"aws_db_instance:"}}
+	assert.ErrorContains(t, cfg.Validate(), "no attribute path")
+}
+
+func TestValidate_StateEncryption(t *testing.T) {
+	cfg := &Config{
+		Providers: ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+		Falco:     FalcoConfig{Enabled: true, Hostname: "localhost", Port: 5060},
+	}
+
+	tests := []struct {
+		name    string
+		kp      StateKeyProviderConfig
+		wantErr string
+	}{
+		{"pbkdf2", StateKeyProviderConfig{Type: "pbkdf2", Name: "main", Passphrase: "correct horse battery staple"}, ""},
+		{"static", StateKeyProviderConfig{Type: "static", Name: "k", Key: "000102030405060708090a0b0c0d0e0f"}, ""},
+		{"missing name", StateKeyProviderConfig{Type: "static", Key: "000102030405060708090a0b0c0d0e0f"}, "name is required"},
+		{"short passphrase", StateKeyProviderConfig{Type: "pbkdf2", Name: "main", Passphrase: "short"}, "at least 16 characters"},
+		{"bad key length", StateKeyProviderConfig{Type: "static", Name: "k", Key: "0001"}, "16, 24 or 32 bytes"},
+		{"unknown type", StateKeyProviderConfig{Type: "aws_kms", Name: "k"}, "state_encryption.key_providers[0].type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.StateEncryption = StateEncryptionConfig{KeyProviders: []StateKeyProviderConfig{tt.kp}}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Auth(t *testing.T) {
+	base := func(auth AuthConfig) *Config {
+		return &Config{
+			Providers: ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+			Falco:     FalcoConfig{Enabled: true, Hostname: "localhost", Port: 5060},
+			Auth:      auth,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		auth    AuthConfig
+		wantErr string
+	}{
+		{"valid api key", AuthConfig{APIKeys: []APIKeyConfig{{Name: "ops", Key: "k", Role: "admin"}}}, ""},
+		{"api key without name", AuthConfig{APIKeys: []APIKeyConfig{{Key: "k", Role: "admin"}}}, "name is required"},
+		{"api key with both secrets", AuthConfig{APIKeys: []APIKeyConfig{{Name: "ops", Key: "k", KeySHA256: "ab", Role: "admin"}}}, "exactly one of key or key_sha256"},
+		{"api key with unknown role", AuthConfig{APIKeys: []APIKeyConfig{{Name: "ops", Key: "k", Role: "root"}}}, "auth.api_keys[0].role"},
+		{"oidc without issuer", AuthConfig{OIDC: OIDCConfig{Enabled: true}}, "auth.oidc.issuer"},
+		{"oidc without audience", AuthConfig{OIDC: OIDCConfig{Enabled: true, Issuer: "https://i"}}, "auth.oidc.audience"},
+		{"oidc bad mapping", AuthConfig{OIDC: OIDCConfig{Enabled: true, Issuer: "https://i", Audience: "tfdrift", RoleMapping: map[string]string{"sre": "owner"}}}, "role_mapping[sre]"},
+		{"valid oidc", AuthConfig{OIDC: OIDCConfig{Enabled: true, Issuer: "https://i", Audience: "tfdrift", RoleMapping: map[string]string{"sre": "editor"}, DefaultRole: "viewer"}}, ""},
+		{"mtls without files", AuthConfig{MTLS: MTLSConfig{Enabled: true}}, "auth.mtls requires"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := base(tt.auth).Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRemediationVCS(t *testing.T) {
+	_, ok := (&Config{}).RemediationVCS()
+	assert.False(t, ok)
+
+	legacy := &Config{GitHub: GitHubConfig{Enabled: true, Owner: "o", Repo: "r", Branch: "main", Token: "t"}}
+	vcs, ok := legacy.RemediationVCS()
+	require.True(t, ok)
+	assert.Equal(t, VCSConfig{Enabled: true, Provider: "github", Owner: "o", Repo: "r", Branch: "main", Token: "t"}, vcs)
+
+	both := &Config{
+		GitHub: legacy.GitHub,
+		VCS:    VCSConfig{Enabled: true, Provider: "gitlab", Owner: "infra", Repo: "network"},
+	}
+	vcs, ok = both.RemediationVCS()
+	require.True(t, ok)
+	assert.Equal(t, "gitlab", vcs.Provider, "vcs section takes precedence")
+}
+
+func TestValidate_VCS(t *testing.T) {
+	base := func(vcs VCSConfig) *Config {
+		return &Config{
+			Providers: ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+			Falco:     FalcoConfig{Enabled: true, Hostname: "localhost", Port: 5060},
+			VCS:       vcs,
+		}
+	}
+
+	assert.NoError(t, base(VCSConfig{Enabled: true, Provider: "gitlab", Owner: "infra", Repo: "network"}).Validate())
+	assert.NoError(t, base(VCSConfig{Provider: "svn"}).Validate(), "disabled section is not validated")
+
+	err := base(VCSConfig{Enabled: true, Provider: "svn", Owner: "o", Repo: "r"}).Validate()
+	assert.ErrorContains(t, err, "vcs.provider")
+	err = base(VCSConfig{Enabled: true, Provider: "gitea", Owner: "o", Repo: "r"}).Validate()
+	assert.ErrorContains(t, err, "vcs.url is required")
+	err = base(VCSConfig{Enabled: true, Provider: "github", Owner: "o"}).Validate()
+	assert.ErrorContains(t, err, "vcs.owner and vcs.repo")
+}
+
+func TestValidate_CloudTrail(t *testing.T) {
+	base := func(ct CloudTrailConfig) *Config {
+		return &Config{
+			Providers:  ProvidersConfig{AWS: AWSConfig{Enabled: true, Regions: []string{"us-east-1"}}},
+			CloudTrail: ct,
+		}
+	}
+
+	// The collector alone is a valid event source
+	assert.NoError(t, base(CloudTrailConfig{Enabled: true, Bucket: "trail-logs"}).Validate())
+	assert.NoError(t, base(CloudTrailConfig{Enabled: true, SQSQueueURL: "https://sqs.us-east-1.amazonaws.com/123/ct"}).Validate())
+	assert.NoError(t, base(CloudTrailConfig{Enabled: true, Dir: "./logs"}).Validate())
+
+	err := base(CloudTrailConfig{}).Validate()
+	assert.ErrorContains(t, err, "falco must be enabled")
+	err = base(CloudTrailConfig{Enabled: true}).Validate()
+	assert.ErrorContains(t, err, "cloudtrail.bucket, cloudtrail.sqs_queue_url or cloudtrail.dir is required")
+	err = base(CloudTrailConfig{Enabled: true, Dir: "./logs", Bucket: "trail-logs"}).Validate()
+	assert.ErrorContains(t, err, "cannot be combined")
+	err = base(CloudTrailConfig{Enabled: true, Bucket: "trail-logs", PollIntervalSec: -1}).Validate()
+	assert.ErrorContains(t, err, "poll_interval")
+}
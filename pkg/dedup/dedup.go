@@ -0,0 +1,250 @@
+// Package dedup remembers the provider-native IDs of processed cloud events
+// for a bounded time, so an event delivered twice (Falco restarts, a plugin
+// re-reading a log file) is only processed once.
+package dedup
+
+import (
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// DefaultTTL is how long an event ID is remembered when unset
+	DefaultTTL = time.Hour
+	// DefaultMaxEntries caps remembered IDs when unset
+	DefaultMaxEntries = 100000
+)
+
+var bucketSeen = []byte("seen")
+
+// entry is a remembered key and when it is forgotten
+type entry struct {
+	key     string
+	expires time.Time
+}
+
+// Cache is a TTL cache of event keys, bounded in size. With a database it
+// writes through to disk so it survives restarts. Entries all share one
+// TTL, so insertion order is expiry order and both expiry and eviction trim
+// the front of the list.
+type Cache struct {
+	ttl     time.Duration
+	max     int
+	entries map[string]*list.Element
+	order   *list.List
+	db      *bolt.DB
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// New creates the cache selected by cfg
+func New(cfg config.DedupConfig) (*Cache, error) {
+	ttl := time.Duration(cfg.TTLMinutes) * time.Minute
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryCache(ttl, maxEntries), nil
+	case "bolt":
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("dedup.path is required for the bolt backend")
+		}
+		return NewBoltCache(cfg.Path, ttl, maxEntries)
+	default:
+		return nil, fmt.Errorf("unsupported dedup backend: %s", cfg.Backend)
+	}
+}
+
+// NewMemoryCache creates a cache that is lost on restart
+func NewMemoryCache(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		max:     maxEntries,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// NewBoltCache opens (or creates) a persistent cache at path and loads the
+// keys that have not expired yet
+func NewBoltCache(path string, ttl time.Duration, maxEntries int) (*Cache, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create dedup directory: %w", err)
+		}
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dedup database %s: %w", path, err)
+	}
+
+	c := NewMemoryCache(ttl, maxEntries)
+	c.db = db
+
+	var loaded []entry
+	now := c.now()
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(bucketSeen)
+		if err != nil {
+			return err
+		}
+		var expired [][]byte
+		err = bucket.ForEach(func(k, v []byte) error {
+			if len(v) != 8 {
+				expired = append(expired, k)
+				return nil
+			}
+			expires := time.Unix(0, int64(binary.BigEndian.Uint64(v)))
+			if !expires.After(now) {
+				expired = append(expired, k)
+				return nil
+			}
+			loaded = append(loaded, entry{key: string(k), expires: expires})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to load dedup database: %w", err)
+	}
+
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].expires.Before(loaded[j].expires) })
+	for _, e := range loaded {
+		c.entries[e.key] = c.order.PushBack(e)
+	}
+	if evicted := c.trim(now); len(evicted) > 0 {
+		c.forget(evicted)
+	}
+
+	log.Infof("Dedup store opened at %s (%d event IDs)", path, len(c.entries))
+	return c, nil
+}
+
+// Seen reports whether key was recorded within the TTL, and records it if
+// not. An empty key is never a duplicate.
+func (c *Cache) Seen(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	evicted := c.trim(now)
+	if _, ok := c.entries[key]; ok {
+		c.forget(evicted)
+		return true
+	}
+
+	e := entry{key: key, expires: now.Add(c.ttl)}
+	c.entries[key] = c.order.PushBack(e)
+	for len(c.entries) > c.max {
+		evicted = append(evicted, c.removeFront())
+	}
+	c.persist(e, evicted)
+	return false
+}
+
+// Len returns the number of remembered keys, including any past their TTL
+// that have not been trimmed yet
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Close releases the database, if any
+func (c *Cache) Close() error {
+	if c.db == nil {
+		return nil
+	}
+	return c.db.Close()
+}
+
+// trim drops expired entries and entries over the size cap, returning
+// their keys. Callers hold mu.
+func (c *Cache) trim(now time.Time) []string {
+	var evicted []string
+	for front := c.order.Front(); front != nil; front = c.order.Front() {
+		if front.Value.(entry).expires.After(now) && len(c.entries) <= c.max {
+			break
+		}
+		evicted = append(evicted, c.removeFront())
+	}
+	return evicted
+}
+
+func (c *Cache) removeFront() string {
+	e := c.order.Remove(c.order.Front()).(entry)
+	delete(c.entries, e.key)
+	return e.key
+}
+
+// persist writes a new key and deletes evicted ones in one transaction. A
+// failed write only costs dedup across a restart, so it is logged.
+func (c *Cache) persist(added entry, evicted []string) {
+	if c.db == nil {
+		return
+	}
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketSeen)
+		for _, k := range evicted {
+			if err := bucket.Delete([]byte(k)); err != nil {
+				return err
+			}
+		}
+		var v [8]byte
+		binary.BigEndian.PutUint64(v[:], uint64(added.expires.UnixNano()))
+		return bucket.Put([]byte(added.key), v[:])
+	})
+	if err != nil {
+		log.Warnf("Failed to persist dedup key %s: %v", added.key, err)
+	}
+}
+
+// forget deletes evicted keys from disk
+func (c *Cache) forget(evicted []string) {
+	if c.db == nil || len(evicted) == 0 {
+		return
+	}
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketSeen)
+		for _, k := range evicted {
+			if err := bucket.Delete([]byte(k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warnf("Failed to remove expired dedup keys: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package dedup
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock returns a controllable time source
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func TestSeen_RecordsAndExpires(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	c := NewMemoryCache(time.Minute, 10)
+	c.now = clock.now
+
+	assert.False(t, c.Seen("aws:event:1"), "first delivery is new")
+	assert.True(t, c.Seen("aws:event:1"), "second delivery is a duplicate")
+	assert.False(t, c.Seen(""), "events without an ID are never duplicates")
+	assert.False(t, c.Seen(""))
+
+	clock.t = clock.t.Add(2 * time.Minute)
+	assert.False(t, c.Seen("aws:event:1"), "forgotten after the TTL")
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestSeen_EvictsOldestOverCap(t *testing.T) {
+	c := NewMemoryCache(time.Hour, 2)
+
+	c.Seen("a")
+	c.Seen("b")
+	c.Seen("c")
+
+	assert.Equal(t, 2, c.Len())
+	assert.True(t, c.Seen("c"))
+	assert.False(t, c.Seen("a"), "oldest key was evicted")
+}
+
+func TestBoltCache_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.db")
+
+	c, err := NewBoltCache(path, time.Hour, 10)
+	require.NoError(t, err)
+	assert.False(t, c.Seen("gcp:abc"))
+	require.NoError(t, c.Close())
+
+	c, err = NewBoltCache(path, time.Hour, 10)
+	require.NoError(t, err)
+	defer c.Close()
+	assert.True(t, c.Seen("gcp:abc"), "key persisted across reopen")
+	assert.False(t, c.Seen("gcp:def"))
+}
+
+func TestBoltCache_DropsExpiredOnLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.db")
+
+	c, err := NewBoltCache(path, time.Millisecond, 10)
+	require.NoError(t, err)
+	c.Seen("azure:x/y")
+	require.NoError(t, c.Close())
+
+	time.Sleep(5 * time.Millisecond)
+	c, err = NewBoltCache(path, time.Millisecond, 10)
+	require.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestNew(t *testing.T) {
+	c, err := New(config.DedupConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTTL, c.ttl)
+	assert.Equal(t, DefaultMaxEntries, c.max)
+
+	_, err = New(config.DedupConfig{Backend: "bolt"})
+	assert.Error(t, err)
+
+	_, err = New(config.DedupConfig{Backend: "redis"})
+	assert.Error(t, err)
+}
@@ -3,22 +3,72 @@ package detector
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
 
+	"github.com/falcosecurity/client-go/pkg/api/outputs"
 	"github.com/keitahigaki/tfdrift-falco/pkg/api/broadcaster"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 	log "github.com/sirupsen/logrus"
 )
 
-// sendAlert sends a drift alert
+// stdout is where human-readable alerts are printed
+func (d *Detector) stdout() io.Writer {
+	if d.console != nil {
+		return d.console
+	}
+	return os.Stdout
+}
+
+// printConsole writes lines to the console as one block, so the output of
+// events handled concurrently by different workers doesn't interleave
+func (d *Detector) printConsole(lines ...string) {
+	d.consoleMu.Lock()
+	defer d.consoleMu.Unlock()
+	for _, line := range lines {
+		fmt.Fprintln(d.stdout(), line)
+	}
+}
+
+// eventTimestamp returns when the event happened (RFC3339), taken from the
+// recorded event rather than the clock so replayed alerts are reproducible.
+// Empty when the source carries no time.
+func eventTimestamp(event *types.Event) string {
+	switch raw := event.RawEvent.(type) {
+	case map[string]interface{}:
+		if eventTime, ok := raw["eventTime"].(string); ok {
+			return eventTime
+		}
+	case *outputs.Response:
+		if raw != nil && raw.Time != nil {
+			return raw.Time.AsTime().UTC().Format(time.RFC3339)
+		}
+	}
+	return ""
+}
+
+// eventTime is eventTimestamp parsed; zero when the event carries no time
+func eventTime(event *types.Event) time.Time {
+	t, err := time.Parse(time.RFC3339, eventTimestamp(event))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// sendAlert sends a drift alert. Sensitive values are redacted everywhere it
+// goes, the history store included.
 func (d *Detector) sendAlert(alert *types.DriftAlert) {
+	alert = alert.Redacted()
+
 	// Format and display the drift in console
 	consoleDiff := d.formatter.FormatConsole(alert)
-	fmt.Println(consoleDiff)
+	d.printConsole(consoleDiff)
 
 	// Also log in traditional format
-	log.Warnf("DRIFT DETECTED: %s.%s - %s: %v → %v",
-		alert.ResourceType, alert.ResourceName, alert.Attribute,
+	log.Warnf("DRIFT DETECTED: %s - %s: %v → %v",
+		alert.Address(), alert.Attribute,
 		alert.OldValue, alert.NewValue)
 
 	// Broadcast to WebSocket clients
@@ -30,6 +80,7 @@ func (d *Detector) sendAlert(alert *types.DriftAlert) {
 				"severity":      alert.Severity,
 				"resource_type": alert.ResourceType,
 				"resource_name": alert.ResourceName,
+				"address":       alert.Address(),
 				"resource_id":   alert.ResourceID,
 				"attribute":     alert.Attribute,
 				"old_value":     alert.OldValue,
@@ -37,6 +88,10 @@ func (d *Detector) sendAlert(alert *types.DriftAlert) {
 				"user_identity": alert.UserIdentity,
 				"matched_rules": alert.MatchedRules,
 				"timestamp":     alert.Timestamp,
+				"provider":      alert.Provider,
+				"state_name":    alert.StateName,
+				"workspace":     alert.TerraformWorkspace,
+				"state_backend": alert.StateBackend,
 			},
 		})
 	}
@@ -47,18 +102,20 @@ func (d *Detector) sendAlert(alert *types.DriftAlert) {
 		log.Debug("Added drift alert to graph store")
 	}
 
+	d.emitDriftEvent(alert)
+
 	if d.cfg.DryRun {
 		log.Info("[DRY-RUN] Alert notification skipped")
 
 		// In dry-run, also show other formats as examples
-		fmt.Println("\n=== Unified Diff Format ===")
-		fmt.Println(d.formatter.FormatUnifiedDiff(alert))
-
-		fmt.Println("\n=== Side-by-Side Format ===")
-		fmt.Println(d.formatter.FormatSideBySide(alert))
-
-		fmt.Println("\n=== Markdown Format (for Slack/GitHub) ===")
-		fmt.Println(d.formatter.FormatMarkdown(alert))
+		d.printConsole(
+			"\n=== Unified Diff Format ===",
+			d.formatter.FormatUnifiedDiff(alert),
+			"\n=== Side-by-Side Format ===",
+			d.formatter.FormatSideBySide(alert),
+			"\n=== Markdown Format (for Slack/GitHub) ===",
+			d.formatter.FormatMarkdown(alert),
+		)
 
 		return
 	}
@@ -70,13 +127,7 @@ func (d *Detector) sendAlert(alert *types.DriftAlert) {
 
 // sendUnmanagedResourceAlert sends an alert for unmanaged resources
 func (d *Detector) sendUnmanagedResourceAlert(event *types.Event) {
-	// Extract timestamp from raw event
-	timestamp := ""
-	if rawEvent, ok := event.RawEvent.(map[string]interface{}); ok {
-		if eventTime, ok := rawEvent["eventTime"].(string); ok {
-			timestamp = eventTime
-		}
-	}
+	timestamp := eventTimestamp(event)
 
 	alert := &types.UnmanagedResourceAlert{
 		Severity:     "warning", // Default severity for unmanaged resources
@@ -84,14 +135,14 @@ func (d *Detector) sendUnmanagedResourceAlert(event *types.Event) {
 		ResourceID:   event.ResourceID,
 		EventName:    event.EventName,
 		UserIdentity: event.UserIdentity,
-		Changes:      event.Changes,
+		Changes:      d.sensitive.Redact(event.ResourceType, event.Changes),
 		Timestamp:    timestamp,
 		Reason:       fmt.Sprintf("Resource %s (%s) is not found in Terraform state", event.ResourceID, event.ResourceType),
 	}
 
 	// Format and display
 	consoleOutput := d.formatter.FormatUnmanagedResource(alert)
-	fmt.Println(consoleOutput)
+	d.printConsole(consoleOutput)
 
 	// Also log
 	log.Warnf("UNMANAGED RESOURCE: %s (%s) - Event: %s by %s",
@@ -121,15 +172,7 @@ func (d *Detector) sendUnmanagedResourceAlert(event *types.Event) {
 		log.Debug("Added unmanaged resource alert to graph store")
 	}
 
-	if d.cfg.DryRun {
-		log.Info("[DRY-RUN] Unmanaged resource alert notification skipped")
-		fmt.Println("\n=== Markdown Format (for Slack) ===")
-		fmt.Println(d.formatter.FormatUnmanagedResourceMarkdown(alert))
-		return
-	}
-
-	// Send to notification channels
-	// Convert UnmanagedResourceAlert to DriftAlert for notifier
+	// Convert UnmanagedResourceAlert to DriftAlert for the outputs and notifier
 	driftAlert := &types.DriftAlert{
 		Severity:     alert.Severity,
 		ResourceType: alert.ResourceType,
@@ -141,9 +184,31 @@ func (d *Detector) sendUnmanagedResourceAlert(event *types.Event) {
 		UserIdentity: alert.UserIdentity,
 		Timestamp:    alert.Timestamp,
 		MatchedRules: []string{fmt.Sprintf("unmanaged-resource: %s", alert.EventName)},
+		AlertType:    "unmanaged",
+		Provider:     event.Provider,
 	}
 
+	d.emitDriftEvent(driftAlert)
+
+	if d.cfg.DryRun {
+		log.Info("[DRY-RUN] Unmanaged resource alert notification skipped")
+		d.printConsole("\n=== Markdown Format (for Slack) ===", d.formatter.FormatUnmanagedResourceMarkdown(alert))
+		return
+	}
+
+	// Send to notification channels
 	if err := d.notifier.Send(driftAlert); err != nil {
 		log.Errorf("Failed to send unmanaged resource alert: %v", err)
 	}
 }
+
+// emitDriftEvent writes the alert as a structured drift event to the output
+// manager, when one is set
+func (d *Detector) emitDriftEvent(alert *types.DriftAlert) {
+	if d.output == nil {
+		return
+	}
+	if err := d.output.EmitDriftEvent(types.NewDriftEventFromAlert(alert)); err != nil {
+		log.Errorf("Failed to emit drift event: %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/telemetry"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultApplyWindowMaxDuration closes a window the pipeline never finished
+	DefaultApplyWindowMaxDuration = time.Hour
+	// applyWindowReloadTimeout bounds waiting for the state to reach the
+	// serial the pipeline reported
+	applyWindowReloadTimeout = 2 * time.Minute
+	// applyWindowReloadInterval is the pause between state reloads while
+	// the backend doesn't serve the new serial yet
+	applyWindowReloadInterval = 5 * time.Second
+)
+
+var (
+	// ErrUnknownState is returned for a state name no state manager loaded
+	ErrUnknownState = errors.New("no Terraform state with that name is loaded")
+	// ErrStateRequired is returned when no state is named and several are loaded
+	ErrStateRequired = errors.New("state is required when several Terraform states are loaded")
+	// ErrAmbiguousState is returned for a state name several loaded states
+	// share; their locations tell them apart
+	ErrAmbiguousState = errors.New("several Terraform states match that name; use the state's location")
+	// ErrNoApplyWindow is returned when finishing a state with no open window
+	ErrNoApplyWindow = errors.New("no apply window is open for the state")
+)
+
+// ApplyWindow is a terraform apply a pipeline announced on one state. While
+// it is open, events on the state's resources are held instead of alerted.
+type ApplyWindow struct {
+	State     string    `json:"state"`
+	Provider  string    `json:"provider,omitempty"`
+	Actor     string    `json:"actor"`
+	StartedAt time.Time `json:"started_at"`
+	// Serial is the state serial when the window opened
+	Serial int `json:"serial"`
+	// HeldEvents counts the events waiting for the window to close
+	HeldEvents int `json:"held_events"`
+	// Closing is set once the apply finished and the state is being reloaded
+	Closing bool `json:"closing"`
+}
+
+type applyWindow struct {
+	ApplyWindow
+	sm     *terraform.StateManager
+	held   []types.Event
+	expiry *time.Timer
+}
+
+// ApplyWindows tracks open apply windows and the events held by them. When
+// a window closes the state is reloaded and the held events are evaluated
+// again, so only changes that still differ from the new state raise alerts.
+type ApplyWindows struct {
+	d              *Detector
+	maxDuration    time.Duration
+	reloadTimeout  time.Duration
+	reloadInterval time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*applyWindow
+}
+
+func newApplyWindows(d *Detector, cfg config.ApplyWindowsConfig) *ApplyWindows {
+	a := &ApplyWindows{
+		d:              d,
+		maxDuration:    DefaultApplyWindowMaxDuration,
+		reloadTimeout:  applyWindowReloadTimeout,
+		reloadInterval: applyWindowReloadInterval,
+		windows:        make(map[string]*applyWindow),
+	}
+	if cfg.MaxDurationMinutes > 0 {
+		a.maxDuration = time.Duration(cfg.MaxDurationMinutes) * time.Minute
+	}
+	return a
+}
+
+// Start opens a window on a state. An empty name means the only loaded
+// state. Starting a window that is already open returns it unchanged, so a
+// retried pipeline step is harmless.
+func (a *ApplyWindows) Start(state, actor string) (ApplyWindow, error) {
+	providerName, sm, state, err := a.d.findState(state)
+	if err != nil {
+		return ApplyWindow{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if w, ok := a.windows[state]; ok && !w.Closing {
+		return a.snapshot(w), nil
+	}
+
+	w := &applyWindow{
+		ApplyWindow: ApplyWindow{
+			State:     state,
+			Provider:  providerName,
+			Actor:     actor,
+			StartedAt: time.Now().UTC(),
+			Serial:    stateSerial(sm, state),
+		},
+		sm: sm,
+	}
+	// A previous window still reloading re-evaluates its own held events;
+	// they are held again by this one
+	w.expiry = time.AfterFunc(a.maxDuration, func() { a.expire(state, w) })
+	a.windows[state] = w
+	log.Infof("Apply window opened on %s by %s (serial %d): holding drift on its resources", state, actor, w.Serial)
+	return a.snapshot(w), nil
+}
+
+// Finish closes the window on a state once the pipeline wrote serial. The
+// state is reloaded until it reaches serial (0 = whatever it serves now) and
+// the held events are evaluated in the background.
+func (a *ApplyWindows) Finish(state string, serial int) (ApplyWindow, error) {
+	_, _, state, err := a.d.findState(state)
+	if err != nil {
+		return ApplyWindow{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	w, ok := a.windows[state]
+	if !ok {
+		return ApplyWindow{}, ErrNoApplyWindow
+	}
+	if !w.Closing {
+		w.Closing = true
+		w.expiry.Stop()
+		go a.close(w, serial)
+	}
+	return a.snapshot(w), nil
+}
+
+// List returns the open windows, oldest first
+func (a *ApplyWindows) List() []ApplyWindow {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	windows := make([]ApplyWindow, 0, len(a.windows))
+	for _, w := range a.windows {
+		windows = append(windows, a.snapshot(w))
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].StartedAt.Before(windows[j].StartedAt) })
+	return windows
+}
+
+// hold keeps an event for later if it touches a state being applied: a
+// managed resource of that state, or a resource not in state yet (the apply
+// may be creating it) of the same provider
+func (a *ApplyWindows) hold(event types.Event, resource *terraform.Resource) bool {
+	if a == nil {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var target *applyWindow
+	if resource != nil {
+		target = a.windows[resource.StateName]
+	} else {
+		for _, w := range a.windows {
+			if w.sm == a.d.stateManagerFor(event.Provider) && (target == nil || w.StartedAt.Before(target.StartedAt)) {
+				target = w
+			}
+		}
+	}
+	if target == nil {
+		return false
+	}
+	target.held = append(target.held, event)
+	log.Debugf("Holding %s on %s until the apply on %s finishes", event.EventName, event.ResourceID, target.State)
+	return true
+}
+
+// expire closes a window the pipeline never finished
+func (a *ApplyWindows) expire(state string, w *applyWindow) {
+	a.mu.Lock()
+	if a.windows[state] != w || w.Closing {
+		a.mu.Unlock()
+		return
+	}
+	w.Closing = true
+	a.mu.Unlock()
+
+	log.Warnf("Apply window on %s was not finished within %s, closing it", state, a.maxDuration)
+	a.close(w, 0)
+}
+
+// close reloads the window's state and re-evaluates the events it held
+func (a *ApplyWindows) close(w *applyWindow, serial int) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.reloadTimeout)
+	defer cancel()
+	a.reload(ctx, w, serial)
+
+	a.mu.Lock()
+	held := w.held
+	w.held = nil
+	if a.windows[w.State] == w {
+		delete(a.windows, w.State)
+	}
+	a.mu.Unlock()
+
+	log.Infof("Apply window on %s closed at serial %d, re-evaluating %d held event(s)", w.State, stateSerial(w.sm, w.State), len(held))
+	for _, event := range held {
+		ctx, span := telemetry.StartSpan(context.Background(), "detector.reevaluate_held_event")
+		// Held events are compared with the state the apply wrote
+		a.d.evaluateEvent(ctx, span, event, time.Time{})
+		span.End()
+	}
+}
+
+// reload re-reads the state until it reaches serial. A backend that is slow
+// to serve the new state is retried; on timeout the held events are
+// evaluated against whatever was loaded last.
+func (a *ApplyWindows) reload(ctx context.Context, w *applyWindow, serial int) {
+	for {
+		err := w.sm.Refresh(ctx)
+		if err != nil {
+			log.Warnf("Reloading state %s after apply failed: %v", w.State, err)
+		} else if current := stateSerial(w.sm, w.State); current >= serial {
+			if a.d.graphStore != nil {
+				a.d.graphStore.RebuildGraphDB()
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Warnf("State %s did not reach serial %d within %s; re-evaluating held events against serial %d",
+				w.State, serial, a.reloadTimeout, stateSerial(w.sm, w.State))
+			return
+		case <-time.After(a.reloadInterval):
+		}
+	}
+}
+
+func (a *ApplyWindows) snapshot(w *applyWindow) ApplyWindow {
+	s := w.ApplyWindow
+	s.HeldEvents = len(w.held)
+	return s
+}
+
+// findState resolves a state name or location to the provider and state
+// manager that loaded it, and the state's name. An empty name resolves to
+// the only loaded state.
+func (d *Detector) findState(name string) (string, *terraform.StateManager, string, error) {
+	managers := d.stateManagers
+	if len(managers) == 0 {
+		managers = map[string]*terraform.StateManager{"": d.stateManager}
+	}
+
+	type match struct {
+		provider string
+		sm       *terraform.StateManager
+		state    string
+	}
+	var found []match
+	for providerName, sm := range managers {
+		if sm == nil {
+			continue
+		}
+		for _, source := range sm.GetStates() {
+			if name == "" || source.Name == name || source.Location == name {
+				found = append(found, match{providerName, sm, source.Name})
+			}
+		}
+	}
+	switch {
+	case len(found) == 0:
+		return "", nil, "", ErrUnknownState
+	case len(found) > 1 && name == "":
+		return "", nil, "", ErrStateRequired
+	case len(found) > 1:
+		return "", nil, "", ErrAmbiguousState
+	}
+	return found[0].provider, found[0].sm, found[0].state, nil
+}
+
+// stateSerial returns the serial of a loaded state
+func stateSerial(sm *terraform.StateManager, name string) int {
+	for _, source := range sm.GetStates() {
+		if source.Name == name {
+			return source.Serial
+		}
+	}
+	if metadata := sm.GetStateMetadata(); metadata != nil {
+		return metadata.Serial
+	}
+	return 0
+}
@@ -0,0 +1,184 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stateAfterApply is stateOneResource after an apply resized i-aaa and
+// created i-bbb
+const stateAfterApply = `{
+  "version": 4, "terraform_version": "1.9.0", "serial": %d, "lineage": "l", "outputs": {},
+  "resources": [
+    { "mode": "managed", "type": "aws_instance", "name": "a",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [ { "attributes": { "id": "i-aaa", "instance_type": "t3.small" } } ] },
+    { "mode": "managed", "type": "aws_instance", "name": "b",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [ { "attributes": { "id": "i-bbb", "instance_type": "t3.small" } } ] }
+  ]
+}`
+
+func newApplyWindowDetector(t *testing.T) (*Detector, *syncSpy, string) {
+	t.Helper()
+	statePath := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(statePath, []byte(stateOneResource), 0o600))
+
+	cfg := &config.Config{}
+	cfg.Providers.AWS.Enabled = true
+	cfg.Providers.AWS.Regions = []string{"us-east-1"}
+	cfg.Providers.AWS.State.Backend = "local"
+	cfg.Providers.AWS.State.LocalPath = statePath
+	cfg.Dedup.Disabled = true
+
+	d, err := New(cfg)
+	require.NoError(t, err)
+	spy := &syncSpy{}
+	d.notifier = spy
+	require.NoError(t, d.loadAllState(context.Background()))
+	d.applyWindows.reloadInterval = 10 * time.Millisecond
+	return d, spy, statePath
+}
+
+func writeStateSerial(t *testing.T, path string, serial int) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(stateAfterApply, serial)), 0o600))
+}
+
+func TestApplyWindow_HoldsAndReevaluates(t *testing.T) {
+	d, spy, statePath := newApplyWindowDetector(t)
+	windows := d.GetApplyWindows()
+
+	window, err := windows.Start("", "pipeline-42")
+	require.NoError(t, err)
+	assert.Equal(t, statePath, window.State)
+	assert.Equal(t, "aws", window.Provider)
+	assert.Equal(t, 1, window.Serial)
+
+	// The apply resizes i-aaa and creates i-bbb; meanwhile someone enables
+	// termination protection on i-aaa by hand
+	d.handleEvent(modifyEvent("i-aaa", map[string]interface{}{"instance_type": "t3.small"}))
+	d.handleEvent(modifyEvent("i-bbb", map[string]interface{}{"instance_type": "t3.small"}))
+	d.handleEvent(modifyEvent("i-aaa", map[string]interface{}{"disable_api_termination": true}))
+	assert.Empty(t, spy.alerts(), "drift is held while the apply runs")
+	require.Len(t, windows.List(), 1)
+	assert.Equal(t, 3, windows.List()[0].HeldEvents)
+
+	// The backend serves the new state one reload late
+	writeStateSerial(t, statePath, 2)
+	closing, err := windows.Finish("", 3)
+	require.NoError(t, err)
+	assert.True(t, closing.Closing)
+	time.Sleep(30 * time.Millisecond)
+	assert.Len(t, windows.List(), 1, "the window stays open until serial 3 is loaded")
+	writeStateSerial(t, statePath, 3)
+
+	require.Eventually(t, func() bool { return len(spy.alerts()) > 0 }, 2*time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, windows.List())
+	alerts := spy.alerts()
+	require.Len(t, alerts, 1, "only the change the apply didn't make is drift")
+	assert.Equal(t, "i-aaa", alerts[0].ResourceID)
+	assert.Equal(t, "disable_api_termination", alerts[0].Attribute)
+
+	// After the window events are evaluated right away
+	d.handleEvent(modifyEvent("i-bbb", map[string]interface{}{"instance_type": "t3.large"}))
+	assert.Len(t, spy.alerts(), 2)
+}
+
+func TestApplyWindow_ExpiresWhenNeverFinished(t *testing.T) {
+	d, spy, _ := newApplyWindowDetector(t)
+	d.applyWindows.maxDuration = 30 * time.Millisecond
+
+	_, err := d.applyWindows.Start("", "pipeline-43")
+	require.NoError(t, err)
+	d.handleEvent(modifyEvent("i-aaa", map[string]interface{}{"instance_type": "t3.large"}))
+	assert.Empty(t, spy.alerts())
+
+	require.Eventually(t, func() bool { return len(spy.alerts()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	assert.Empty(t, d.applyWindows.List())
+}
+
+func TestApplyWindow_Errors(t *testing.T) {
+	d, _, statePath := newApplyWindowDetector(t)
+	windows := d.GetApplyWindows()
+
+	_, err := windows.Start("s3://other/terraform.tfstate", "ci")
+	assert.ErrorIs(t, err, ErrUnknownState)
+	_, err = windows.Finish(statePath, 2)
+	assert.ErrorIs(t, err, ErrNoApplyWindow)
+
+	first, err := windows.Start(statePath, "ci")
+	require.NoError(t, err)
+	again, err := windows.Start(statePath, "ci-retry")
+	require.NoError(t, err)
+	assert.Equal(t, first, again, "starting an open window again returns it")
+}
+
+func TestApplyWindow_NamedStateByNameOrLocation(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(statePath, []byte(stateOneResource), 0o600))
+
+	cfg := &config.Config{}
+	cfg.Providers.AWS.Enabled = true
+	cfg.Providers.AWS.Regions = []string{"us-east-1"}
+	cfg.Providers.AWS.State.Name = "prod-network"
+	cfg.Providers.AWS.State.Backend = "local"
+	cfg.Providers.AWS.State.LocalPath = statePath
+
+	d, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, d.loadAllState(context.Background()))
+	windows := d.GetApplyWindows()
+
+	byName, err := windows.Start("prod-network", "ci")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-network", byName.State)
+
+	byLocation, err := windows.Start(statePath, "ci")
+	require.NoError(t, err)
+	assert.Equal(t, byName, byLocation, "the location names the same state as its name")
+}
+
+func TestApplyWindow_AmbiguousStateName(t *testing.T) {
+	dir := t.TempDir()
+	awsPath := filepath.Join(dir, "aws.tfstate")
+	gcpPath := filepath.Join(dir, "gcp.tfstate")
+	require.NoError(t, os.WriteFile(awsPath, []byte(stateOneResource), 0o600))
+	require.NoError(t, os.WriteFile(gcpPath, []byte(stateOneResource), 0o600))
+
+	cfg := &config.Config{}
+	cfg.Providers.AWS.Enabled = true
+	cfg.Providers.AWS.Regions = []string{"us-east-1"}
+	cfg.Providers.AWS.State.Name = "prod"
+	cfg.Providers.AWS.State.Backend = "local"
+	cfg.Providers.AWS.State.LocalPath = awsPath
+	cfg.Providers.GCP.Enabled = true
+	cfg.Providers.GCP.Projects = []string{"my-project"}
+	cfg.Providers.GCP.State.Name = "prod"
+	cfg.Providers.GCP.State.Backend = "local"
+	cfg.Providers.GCP.State.LocalPath = gcpPath
+
+	d, err := New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, d.loadAllState(context.Background()))
+	windows := d.GetApplyWindows()
+
+	_, err = windows.Start("prod", "ci")
+	assert.ErrorIs(t, err, ErrAmbiguousState)
+	_, err = windows.Start("", "ci")
+	assert.ErrorIs(t, err, ErrStateRequired)
+
+	w, err := windows.Start(gcpPath, "ci")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", w.State)
+	assert.Equal(t, "gcp", w.Provider, "the provider is the one that loaded the matched state")
+}
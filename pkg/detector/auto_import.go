@@ -26,9 +26,16 @@ func (d *Detector) handleAutoImport(ctx context.Context, event *types.Event) {
 	var err error
 
 	// Handle based on approval mode
-	if d.cfg.AutoImport.RequireApproval {
-		// Manual approval mode - prompt user
+	if d.cfg.AutoImport.RequireApproval && !d.approvalManager.IsInteractive() {
+		// Queued approval mode - wait for the approval CLI or API
+		log.Infof("Import of %s queued for approval: tfdrift approval approve %s", event.ResourceID, request.ID)
+		return
+	} else if d.cfg.AutoImport.RequireApproval {
+		// Manual approval mode - prompt user. The prompt holds the console
+		// so alerts from other workers don't print over the question.
+		d.consoleMu.Lock()
 		approved, promptErr := d.approvalManager.PromptForApproval(ctx, request)
+		d.consoleMu.Unlock()
 		if promptErr != nil {
 			log.Errorf("Failed to prompt for approval: %v", promptErr)
 			return
@@ -36,7 +43,7 @@ func (d *Detector) handleAutoImport(ctx context.Context, event *types.Event) {
 
 		if approved {
 			fmt.Printf("🚀 Executing: %s\n", request.ImportCommand.String())
-			result, err = d.approvalManager.ApproveAndExecute(ctx, request.ID, "console-user")
+			result, err = d.approvalManager.ExecuteApproved(ctx, request.ID)
 		} else {
 			log.Info("Import rejected by user")
 			return
@@ -166,10 +166,13 @@ func TestHandleAutoImport_WithApprovalRequired_NonInteractive(t *testing.T) {
 		},
 	}
 
-	// Should handle non-interactive mode gracefully (prompt will fail, should log error)
+	// Non-interactive mode leaves the request queued for the approval CLI/API
 	assert.NotPanics(t, func() {
 		detector.handleAutoImport(context.Background(), event)
 	})
+	pending := approvalManager.ListPending()
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "i-noninteractive-123", pending[0].ResourceID)
 }
 
 func TestHandleAutoImport_WithGeneratedCode(t *testing.T) {
@@ -0,0 +1,64 @@
+package detector
+
+import (
+	"github.com/falcosecurity/client-go/pkg/api/outputs"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// eventKey returns the provider-native identity of the cloud event behind
+// an alert: CloudTrail eventID (or requestID), GCP insertId, Azure
+// correlationId plus eventDataId. Empty when the source carries none, in
+// which case the event is never treated as a duplicate.
+func eventKey(event *types.Event) string {
+	switch raw := event.RawEvent.(type) {
+	case *outputs.Response:
+		if raw == nil {
+			return ""
+		}
+		fields := raw.OutputFields
+		switch raw.Source {
+		case "aws_cloudtrail":
+			if id := fields["ct.id"]; id != "" {
+				return "aws:event:" + id
+			}
+			if id := fields["ct.requestid"]; id != "" {
+				return "aws:request:" + id
+			}
+		case "gcpaudit":
+			if id := fields["gcp.insertId"]; id != "" {
+				return "gcp:" + id
+			}
+		case "azure_activity":
+			correlationID := fields["azure.correlationId"]
+			if correlationID == "" {
+				return ""
+			}
+			if id := fields["azure.eventDataId"]; id != "" {
+				return "azure:" + correlationID + "/" + id
+			}
+			// The phases of one operation (Started, Succeeded) share a
+			// correlation ID; without eventDataId keep them apart by status
+			return "azure:" + correlationID + "/" + fields["azure.operationName"] + "/" + fields["azure.status"]
+		}
+	case map[string]interface{}:
+		if id, ok := raw["eventID"].(string); ok && id != "" {
+			return "aws:event:" + id
+		}
+	}
+	return ""
+}
+
+// isDuplicate reports whether the event was already processed, recording
+// it otherwise
+func (d *Detector) isDuplicate(event *types.Event) bool {
+	if d.dedup == nil {
+		return false
+	}
+	key := eventKey(event)
+	if !d.dedup.Seen(key) {
+		return false
+	}
+	log.Infof("Skipping duplicate event %s (%s on %s)", key, event.EventName, event.ResourceID)
+	return true
+}
@@ -0,0 +1,63 @@
+package detector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/falcosecurity/client-go/pkg/api/outputs"
+	"github.com/keitahigaki/tfdrift-falco/pkg/dedup"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventKey(t *testing.T) {
+	falcoEvent := func(source string, fields map[string]string) *types.Event {
+		return &types.Event{RawEvent: &outputs.Response{Source: source, OutputFields: fields}}
+	}
+
+	tests := []struct {
+		name  string
+		event *types.Event
+		want  string
+	}{
+		{"cloudtrail event id", falcoEvent("aws_cloudtrail", map[string]string{"ct.id": "e-1", "ct.requestid": "r-1"}), "aws:event:e-1"},
+		{"cloudtrail request id", falcoEvent("aws_cloudtrail", map[string]string{"ct.requestid": "r-1"}), "aws:request:r-1"},
+		{"gcp insert id", falcoEvent("gcpaudit", map[string]string{"gcp.insertId": "abc"}), "gcp:abc"},
+		{"azure event data id", falcoEvent("azure_activity", map[string]string{"azure.correlationId": "c", "azure.eventDataId": "d"}), "azure:c/d"},
+		{"azure without event data id", falcoEvent("azure_activity", map[string]string{
+			"azure.correlationId": "c", "azure.operationName": "op", "azure.status": "Succeeded",
+		}), "azure:c/op/Succeeded"},
+		{"no id", falcoEvent("aws_cloudtrail", map[string]string{}), ""},
+		{"map raw event", &types.Event{RawEvent: map[string]interface{}{"eventID": "e-2"}}, "aws:event:e-2"},
+		{"no raw event", &types.Event{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, eventKey(tt.event))
+		})
+	}
+}
+
+func TestHandleEvent_DuplicateEventAlertsOnce(t *testing.T) {
+	d, spy := newTestDetector(t, nil, map[string]interface{}{"id": "i-123", "instance_type": "t2.micro"})
+	d.dedup = dedup.NewMemoryCache(time.Hour, 100)
+
+	event := modifyEvent("i-123", map[string]interface{}{"instance_type": "t2.large"})
+	event.RawEvent = &outputs.Response{
+		Source:       "aws_cloudtrail",
+		OutputFields: map[string]string{"ct.id": "11111111-2222-3333-4444-555555555555"},
+	}
+
+	d.handleEvent(event)
+	d.handleEvent(event)
+	require.Len(t, spy.sent, 1, "a redelivered event must not alert twice")
+
+	other := event
+	other.RawEvent = &outputs.Response{
+		Source:       "aws_cloudtrail",
+		OutputFields: map[string]string{"ct.id": "66666666-7777-8888-9999-000000000000"},
+	}
+	d.handleEvent(other)
+	assert.Len(t, spy.sent, 2, "a distinct event for the same resource still alerts")
+}
@@ -1,19 +1,27 @@
 package detector
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/keitahigaki/tfdrift-falco/pkg/api/broadcaster"
+	"github.com/keitahigaki/tfdrift-falco/pkg/azure"
+	"github.com/keitahigaki/tfdrift-falco/pkg/cloudtrail"
 	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/dedup"
 	"github.com/keitahigaki/tfdrift-falco/pkg/diff"
 	"github.com/keitahigaki/tfdrift-falco/pkg/falco"
 	"github.com/keitahigaki/tfdrift-falco/pkg/graph"
 	"github.com/keitahigaki/tfdrift-falco/pkg/notifier"
+	"github.com/keitahigaki/tfdrift-falco/pkg/output"
 	"github.com/keitahigaki/tfdrift-falco/pkg/policy"
 	"github.com/keitahigaki/tfdrift-falco/pkg/provider"
 	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform/encryption"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 	log "github.com/sirupsen/logrus"
 )
@@ -60,6 +68,7 @@ type Detector struct {
 	stateManagers    map[string]*terraform.StateManager // Multi-provider state managers
 	providerRegistry *provider.Registry                 // Provider registry for handling multiple clouds
 	falcoSubscriber  *falco.Subscriber
+	cloudTrail       *cloudtrail.Collector // Direct CloudTrail ingestion (optional)
 	notifier         alertNotifier
 	formatter        *diff.Formatter
 	importer         *terraform.Importer
@@ -67,7 +76,16 @@ type Detector struct {
 	broadcaster      *broadcaster.Broadcaster
 	graphStore       *graph.Store
 	policyEngine     *policy.Engine
+	dedup            *dedup.Cache                // nil = every delivery is processed
+	verifier         *verifier                   // nil = live verification disabled
+	trustedAgents    *falco.TrustedAgents        // nil = every change is checked for drift
+	refresher        *stateRefresher             // state re-reads requested by trusted changes
+	applyWindows     *ApplyWindows               // drift held while a pipeline applies a state
+	output           *output.Manager             // structured drift events; nil = console only
+	sensitive        terraform.SensitivePatterns // configured sensitive_attributes
 	eventCh          chan types.Event
+	console          io.Writer // human-readable alert output; nil = stdout
+	consoleMu        sync.Mutex
 	wg               sync.WaitGroup
 }
 
@@ -82,7 +100,7 @@ func New(cfg *config.Config) (*Detector, error) {
 	// AWS provider
 	var defaultStateManager *terraform.StateManager
 	if cfg.Providers.AWS.Enabled {
-		sm, err := terraform.NewStateManager(cfg.Providers.AWS.State)
+		sm, err := terraform.NewMultiStateManager(cfg.Providers.AWS.StateConfigs())
 		if err != nil {
 			return nil, fmt.Errorf("failed to create AWS state manager: %w", err)
 		}
@@ -100,7 +118,7 @@ func New(cfg *config.Config) (*Detector, error) {
 
 	// GCP provider
 	if cfg.Providers.GCP.Enabled {
-		sm, err := terraform.NewStateManager(cfg.Providers.GCP.State)
+		sm, err := terraform.NewMultiStateManager(cfg.Providers.GCP.StateConfigs())
 		if err != nil {
 			return nil, fmt.Errorf("failed to create GCP state manager: %w", err)
 		}
@@ -111,14 +129,18 @@ func New(cfg *config.Config) (*Detector, error) {
 			defaultStateManager = sm
 		}
 
-		if err := registry.Register(provider.NewGCPProvider()); err != nil {
+		var gcpOpts []provider.GCPProviderOption
+		if len(cfg.Providers.GCP.Projects) > 0 {
+			gcpOpts = append(gcpOpts, provider.WithGCPProjectID(cfg.Providers.GCP.Projects[0]))
+		}
+		if err := registry.Register(provider.NewGCPProvider(gcpOpts...)); err != nil {
 			return nil, fmt.Errorf("failed to register GCP provider: %w", err)
 		}
 	}
 
 	// Azure provider
 	if cfg.Providers.Azure.Enabled {
-		sm, err := terraform.NewStateManager(cfg.Providers.Azure.State)
+		sm, err := terraform.NewMultiStateManager(cfg.Providers.Azure.StateConfigs())
 		if err != nil {
 			return nil, fmt.Errorf("failed to create Azure state manager: %w", err)
 		}
@@ -129,17 +151,57 @@ func New(cfg *config.Config) (*Detector, error) {
 			defaultStateManager = sm
 		}
 
-		if err := registry.Register(provider.NewAzureProvider()); err != nil {
+		var azureOpts []provider.AzureProviderOption
+		if cfg.Providers.Azure.SubscriptionID != "" {
+			azureOpts = append(azureOpts, provider.WithAzureSubscriptionID(cfg.Providers.Azure.SubscriptionID))
+		}
+		// Live verification reads Azure resources through the ARM API
+		if cfg.Verify.Enabled {
+			if lister, err := azure.NewARMResourceListerFromEnv(); err == nil {
+				azureOpts = append(azureOpts, provider.WithAzureResourceLister(lister))
+			} else {
+				log.Warnf("Azure credentials not configured, Azure events are not verified live: %v", err)
+			}
+		}
+		if err := registry.Register(provider.NewAzureProvider(azureOpts...)); err != nil {
 			return nil, fmt.Errorf("failed to register Azure provider: %w", err)
 		}
 	}
 
+	// Let each provider index the extra keys (ARNs, API paths, ...) its
+	// events may use to reference a managed resource
+	for name, sm := range stateManagers {
+		if keyer, ok := registry.GetStateKeyer(name); ok {
+			sm.AddKeyFunc(keyer.StateKeys)
+		}
+	}
+
+	// OpenTofu-encrypted state is decrypted between backend and parsing
+	decrypter, err := encryption.NewDecrypter(cfg.StateEncryption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure state encryption: %w", err)
+	}
+	for _, sm := range stateManagers {
+		sm.SetDecrypter(decrypter)
+		sm.SetHistory(cfg.StateHistory)
+		sm.SetSensitiveAttributes(cfg.SensitiveAttributes)
+	}
+
 	// Initialize Falco subscriber
 	falcoSub, err := falco.NewSubscriber(cfg.Falco)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create falco subscriber: %w", err)
 	}
 
+	// CloudTrail logs read straight from S3 go through the same AWS parsing
+	var cloudTrail *cloudtrail.Collector
+	if cfg.CloudTrail.Enabled {
+		cloudTrail, err = cloudtrail.NewFromConfig(context.Background(), cfg.CloudTrail, falcoSub)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cloudtrail collector: %w", err)
+		}
+	}
+
 	// Initialize notifier
 	notifierManager, err := notifier.NewManager(cfg.Notifications)
 	if err != nil {
@@ -160,12 +222,26 @@ func New(cfg *config.Config) (*Detector, error) {
 			cfg.AutoImport.IaCTool(),
 		)
 
-		// Check if interactive mode should be enabled
-		interactiveMode := cfg.AutoImport.RequireApproval
-		approvalManager = terraform.NewApprovalManager(importer, interactiveMode)
+		// Prompt on the console unless approvals go through the durable queue
+		interactiveMode := cfg.AutoImport.RequireApproval && cfg.AutoImport.ApprovalStorePath == ""
+		if cfg.AutoImport.ApprovalStorePath != "" {
+			store, err := terraform.NewBoltApprovalStore(cfg.AutoImport.ApprovalStorePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open approval store: %w", err)
+			}
+			approvalManager, err = terraform.NewApprovalManagerWithStore(importer, interactiveMode, store)
+			if err != nil {
+				_ = store.Close()
+				return nil, fmt.Errorf("failed to load approval requests: %w", err)
+			}
+		} else {
+			approvalManager = terraform.NewApprovalManager(importer, interactiveMode)
+		}
 
 		log.Info("Auto-import feature enabled")
-		if cfg.AutoImport.RequireApproval {
+		if cfg.AutoImport.RequireApproval && !interactiveMode {
+			log.Infof("Approval workflow: MANUAL (queued in %s; use `tfdrift approval`)", cfg.AutoImport.ApprovalStorePath)
+		} else if cfg.AutoImport.RequireApproval {
 			log.Info("Approval workflow: MANUAL (interactive prompts)")
 		} else if len(cfg.AutoImport.AllowedResources) > 0 {
 			log.Infof("Approval workflow: AUTO (whitelist: %v)", cfg.AutoImport.AllowedResources)
@@ -186,21 +262,52 @@ func New(cfg *config.Config) (*Detector, error) {
 		}
 	}
 
+	// Remember event IDs so redelivered events are processed once
+	var dedupCache *dedup.Cache
+	if !cfg.Dedup.Disabled {
+		dedupCache, err = dedup.New(cfg.Dedup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dedup cache: %w", err)
+		}
+	}
+
+	// Changes by Terraform itself (CI identities, Terraform user agents)
+	// refresh the state instead of alerting
+	trustedAgents, err := falco.NewTrustedAgents(cfg.TrustedAgents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trusted agents: %w", err)
+	}
+
 	log.Infof("Initialized %d cloud provider(s): %v", registry.Count(), registry.Names())
 
-	return &Detector{
+	d := &Detector{
 		cfg:              cfg,
 		stateManager:     defaultStateManager,
 		stateManagers:    stateManagers,
 		providerRegistry: registry,
 		falcoSubscriber:  falcoSub,
+		cloudTrail:       cloudTrail,
 		notifier:         notifierManager,
 		formatter:        formatter,
 		importer:         importer,
 		approvalManager:  approvalManager,
 		policyEngine:     policyEngine,
-		eventCh:          make(chan types.Event, 100),
-	}, nil
+		dedup:            dedupCache,
+		trustedAgents:    trustedAgents,
+		refresher:        newStateRefresher(),
+		sensitive:        terraform.CompileSensitivePatterns(cfg.SensitiveAttributes),
+		eventCh:          make(chan types.Event, processingSettings(cfg.Processing).QueueSize),
+	}
+	d.applyWindows = newApplyWindows(d, cfg.ApplyWindows)
+	for name, sm := range stateManagers {
+		provider := name
+		sm.OnChange(func(delta terraform.StateDelta) { d.broadcastStateChange(provider, delta) })
+	}
+	if cfg.Verify.Enabled {
+		d.verifier = newVerifier(cfg.Verify, d.verifyResource)
+		log.Infof("Live verification enabled (debounce %s)", d.verifier.debounce)
+	}
+	return d, nil
 }
 
 // GetStateManager returns the state manager for API access
@@ -208,6 +315,29 @@ func (d *Detector) GetStateManager() *terraform.StateManager {
 	return d.stateManager
 }
 
+// stateManagerFor returns the state manager holding the given provider's
+// resources, so a GCP event is checked against GCP state rather than whichever
+// provider happens to be the legacy default. Events without a provider, and
+// detectors built without the per-provider map, fall back to the default.
+// Returns nil when the event's provider has no state configured.
+func (d *Detector) stateManagerFor(providerName string) *terraform.StateManager {
+	if providerName == "" || len(d.stateManagers) == 0 {
+		return d.stateManager
+	}
+	return d.stateManagers[strings.ToLower(providerName)]
+}
+
+// GetApplyWindows returns the apply windows announced by pipelines
+func (d *Detector) GetApplyWindows() *ApplyWindows {
+	return d.applyWindows
+}
+
+// GetApprovalManager returns the import approval manager, or nil when
+// auto-import is disabled
+func (d *Detector) GetApprovalManager() *terraform.ApprovalManager {
+	return d.approvalManager
+}
+
 // GetProviderRegistry returns the provider registry
 func (d *Detector) GetProviderRegistry() *provider.Registry {
 	return d.providerRegistry
@@ -238,9 +368,18 @@ func (d *Detector) SetPolicyEngine(pe *policy.Engine) {
 	d.policyEngine = pe
 }
 
+// SetOutputManager sets the manager that emits a structured drift event
+// (JSON or human-readable) for every alert
+func (d *Detector) SetOutputManager(m *output.Manager) {
+	d.output = m
+}
+
 // SetGraphStore sets the graph store for drift visualization
 func (d *Detector) SetGraphStore(gs *graph.Store) {
 	d.graphStore = gs
+	if gs != nil {
+		gs.SetSensitivePatterns(d.sensitive)
+	}
 }
 
 // GetGraphStore returns the graph store
@@ -2,6 +2,7 @@ package detector
 
 import (
 	"reflect"
+	"sort"
 
 	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
 )
@@ -13,11 +14,18 @@ type AttributeDrift struct {
 	NewValue  interface{}
 }
 
-// detectDrifts detects attribute changes
+// detectDrifts detects attribute changes, in attribute name order
 func (d *Detector) detectDrifts(resource *terraform.Resource, changes map[string]interface{}) []AttributeDrift {
 	var drifts []AttributeDrift
 
-	for key, newValue := range changes {
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		newValue := changes[key]
 		oldValue, exists := resource.Attributes[key]
 		// reflect.DeepEqual, not !=: change values can be slices/maps
 		// (e.g. security-group rules, subnets), and comparing those with !=
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/keitahigaki/tfdrift-falco/pkg/policy"
 	"github.com/keitahigaki/tfdrift-falco/pkg/telemetry"
@@ -29,8 +30,56 @@ func (d *Detector) handleEvent(event types.Event) {
 
 	log.Debugf("Processing event: %s - %s", event.EventName, event.ResourceID)
 
-	// Look up resource in Terraform state
-	resource, exists := d.stateManager.GetResource(event.ResourceID)
+	if d.isDuplicate(&event) {
+		span.AddEvent("duplicate_event")
+		return
+	}
+
+	// Terraform's own API calls (the CI pipeline applying a plan) are
+	// expected changes: re-read the state they update instead of alerting
+	if agent, ok := d.trustedAgents.Match(&event); ok {
+		span.AddEvent("trusted_change", trace.WithAttributes(
+			attribute.String("agent", agent),
+		))
+		log.Infof("Expected change by trusted agent %s: %s on %s, refreshing %s state",
+			agent, event.EventName, event.ResourceID, event.Provider)
+		d.refresher.request(event.Provider)
+		telemetry.SetOK(span)
+		return
+	}
+
+	// Compare with the state that was current when the event happened, not
+	// with one an apply wrote while the event was on its way
+	d.evaluateEvent(ctx, span, event, eventTime(&event))
+}
+
+// evaluateEvent checks one event against the Terraform state current at the
+// given time (zero: the current state) and alerts on what differs. Events
+// held during an apply window come back through here.
+func (d *Detector) evaluateEvent(ctx context.Context, span trace.Span, event types.Event, at time.Time) {
+	// Look up resource in the Terraform state of the event's own provider:
+	// "unmanaged" only means something relative to that provider's state.
+	sm := d.stateManagerFor(event.Provider)
+	if sm == nil {
+		span.AddEvent("provider_state_not_configured", trace.WithAttributes(
+			telemetry.AttrProvider.String(event.Provider),
+		))
+		log.Debugf("No Terraform state configured for provider %q, skipping event %s on %s",
+			event.Provider, event.EventName, event.ResourceID)
+		telemetry.SetOK(span)
+		return
+	}
+
+	resource, exists := sm.GetResourceAt(ctx, event.ResourceType, event.ResourceID, at)
+
+	// While a pipeline applies the resource's state, hold the event until
+	// the state it writes has been loaded
+	if d.applyWindows.hold(event, resource) {
+		span.AddEvent("held_for_apply_window")
+		telemetry.SetOK(span)
+		return
+	}
+
 	if !exists {
 		span.AddEvent("unmanaged_resource", trace.WithAttributes(
 			attribute.String("resource_id", event.ResourceID),
@@ -62,6 +111,25 @@ func (d *Detector) handleEvent(event types.Event) {
 		return
 	}
 
+	// Read the resource back from the cloud and compare every attribute,
+	// rather than the partial view in the event's request parameters
+	if d.shouldVerify(&event, resource) {
+		// The cloud is read now, so the read is compared with the current state
+		if current, ok := sm.GetResourceOfType(event.ResourceType, event.ResourceID); ok {
+			resource = current
+		}
+		span.AddEvent("verify_scheduled")
+		d.verifier.schedule(resource, event)
+		telemetry.SetOK(span)
+		return
+	}
+
+	d.reportEventDrift(ctx, span, resource, &event)
+}
+
+// reportEventDrift alerts on the changes extracted from an event on a
+// managed resource
+func (d *Detector) reportEventDrift(ctx context.Context, span trace.Span, resource *terraform.Resource, event *types.Event) {
 	// Compare with state
 	_, detectSpan := telemetry.StartSpan(ctx, "detector.detect_drifts",
 		trace.WithAttributes(
@@ -84,7 +152,7 @@ func (d *Detector) handleEvent(event types.Event) {
 		// false-positive; in the live pipeline only relevant/mutating events
 		// reach here anyway.)
 		if len(event.Changes) == 0 && isMutatingEvent(event.EventName) {
-			d.sendCoarseDriftAlert(ctx, resource, &event)
+			d.sendCoarseDriftAlert(ctx, resource, event)
 			telemetry.SetOK(span)
 			return
 		}
@@ -97,78 +165,80 @@ func (d *Detector) handleEvent(event types.Event) {
 		telemetry.AttrDriftCount.Int(len(drifts)),
 	))
 
-	// Evaluate rules
 	for _, drift := range drifts {
-		// A detected change must never be silently dropped just because the
-		// user did not configure a matching drift_rule. drift_rules only
-		// classify severity; absence of a rule means "unclassified", not
-		// "ignore". Previously an unmatched drift hit `continue` and vanished.
-		matchedRules := d.evaluateRules(resource.Type, drift.Attribute)
-		severity := "medium" // default for an unclassified but real change
-		if len(matchedRules) > 0 {
-			severity = d.getSeverity(matchedRules)
-		}
+		d.raiseDriftAlert(ctx, span, resource, event, drift)
+	}
+}
 
-		// Extract timestamp safely
-		timestamp := ""
-		if rawEvent, ok := event.RawEvent.(map[string]interface{}); ok {
-			if eventTime, ok := rawEvent["eventTime"].(string); ok {
-				timestamp = eventTime
-			}
-		}
+// raiseDriftAlert classifies one attribute drift by the drift rules and
+// policy, and sends the alert unless policy allows the change
+func (d *Detector) raiseDriftAlert(ctx context.Context, span trace.Span, resource *terraform.Resource, event *types.Event, drift AttributeDrift) {
+	// A detected change must never be silently dropped just because the
+	// user did not configure a matching drift_rule. drift_rules only
+	// classify severity; absence of a rule means "unclassified", not
+	// "ignore". Previously an unmatched drift hit `continue` and vanished.
+	matchedRules := d.evaluateRules(resource.Type, drift.Attribute)
+	severity := "medium" // default for an unclassified but real change
+	if len(matchedRules) > 0 {
+		severity = d.getSeverity(matchedRules)
+	}
 
-		alert := &types.DriftAlert{
-			Severity:     severity,
-			ResourceType: resource.Type,
-			ResourceName: resource.Name,
-			ResourceID:   event.ResourceID,
-			Attribute:    drift.Attribute,
-			OldValue:     drift.OldValue,
-			NewValue:     drift.NewValue,
-			UserIdentity: event.UserIdentity,
-			MatchedRules: matchedRules,
-			Timestamp:    timestamp,
-			AlertType:    "drift", // Mark as drift alert
-		}
+	timestamp := eventTimestamp(event)
 
-		// Evaluate policy before alerting
-		policyResult := d.evaluatePolicy(ctx, alert)
-		if policyResult != nil {
-			// Override severity if policy says so
-			if policyResult.Severity != "" {
-				alert.Severity = policyResult.Severity
-				severity = policyResult.Severity
-			}
-
-			switch policyResult.Decision {
-			case policy.DecisionAllow:
-				log.Debugf("Policy allows drift on %s.%s, skipping alert", alert.ResourceType, alert.Attribute)
-				span.AddEvent("policy_allow", trace.WithAttributes(
-					attribute.String("reason", policyResult.Reason),
-				))
-				continue
-			case policy.DecisionDeny:
-				log.Warnf("Policy DENY: %s — %s", alert.ResourceID, policyResult.Reason)
-				span.AddEvent("policy_deny", trace.WithAttributes(
-					attribute.String("reason", policyResult.Reason),
-				))
-			}
-		}
+	alert := &types.DriftAlert{
+		Severity:     severity,
+		ResourceType: resource.Type,
+		ResourceName: resource.Name,
+		ResourceID:   event.ResourceID,
+		Attribute:    drift.Attribute,
+		OldValue:     drift.OldValue,
+		NewValue:     drift.NewValue,
+		UserIdentity: event.UserIdentity,
+		MatchedRules: matchedRules,
+		Timestamp:    timestamp,
+		AlertType:    "drift", // Mark as drift alert
 
-		span.AddEvent("alert_sent", trace.WithAttributes(
-			telemetry.AttrSeverity.String(severity),
-			attribute.String("attribute", fmt.Sprintf("%v", drift.Attribute)),
-		))
+		ResourceAddress:    resource.Address,
+		Provider:           event.Provider,
+		StateName:          resource.StateName,
+		TerraformWorkspace: resource.Workspace,
+		StateBackend:       resource.Backend,
+		Sensitive:          resource.IsSensitive(drift.Attribute),
+	}
 
-		d.sendAlert(alert)
+	// Evaluate policy before alerting
+	policyResult := d.evaluatePolicy(ctx, alert)
+	if policyResult != nil {
+		// Override severity if policy says so
+		if policyResult.Severity != "" {
+			alert.Severity = policyResult.Severity
+			severity = policyResult.Severity
+		}
 
-		// Generate remediation proposal for drift (if policy says remediate, or if remediation is enabled)
-		if policyResult != nil && policyResult.Decision == policy.DecisionRemediate {
-			d.handleRemediation(ctx, alert)
-		} else {
-			d.handleRemediation(ctx, alert)
+		switch policyResult.Decision {
+		case policy.DecisionAllow:
+			log.Debugf("Policy allows drift on %s.%s, skipping alert", alert.ResourceType, alert.Attribute)
+			span.AddEvent("policy_allow", trace.WithAttributes(
+				attribute.String("reason", policyResult.Reason),
+			))
+			return
+		case policy.DecisionDeny:
+			log.Warnf("Policy DENY: %s — %s", alert.ResourceID, policyResult.Reason)
+			span.AddEvent("policy_deny", trace.WithAttributes(
+				attribute.String("reason", policyResult.Reason),
+			))
 		}
 	}
+
+	span.AddEvent("alert_sent", trace.WithAttributes(
+		telemetry.AttrSeverity.String(severity),
+		attribute.String("attribute", fmt.Sprintf("%v", drift.Attribute)),
+	))
+
+	d.sendAlert(alert)
+
+	// Generate remediation proposal for drift (if policy says remediate, or if remediation is enabled)
+	d.handleRemediation(ctx, alert, policyResult)
 }
 
 // readOnlyEventPrefixes are CloudTrail verb prefixes that never mutate state.
@@ -198,13 +268,6 @@ func isMutatingEvent(eventName string) bool {
 // modified out-of-band by <event>" is the drift signal; attribute-level detail
 // is enrichment that change_extractor adds for events it understands.
 func (d *Detector) sendCoarseDriftAlert(ctx context.Context, resource *terraform.Resource, event *types.Event) {
-	timestamp := ""
-	if rawEvent, ok := event.RawEvent.(map[string]interface{}); ok {
-		if eventTime, ok := rawEvent["eventTime"].(string); ok {
-			timestamp = eventTime
-		}
-	}
-
 	alert := &types.DriftAlert{
 		Severity:     "medium",
 		ResourceType: resource.Type,
@@ -214,8 +277,14 @@ func (d *Detector) sendCoarseDriftAlert(ctx context.Context, resource *terraform
 		OldValue:     nil,
 		NewValue:     event.EventName,
 		UserIdentity: event.UserIdentity,
-		Timestamp:    timestamp,
+		Timestamp:    eventTimestamp(event),
 		AlertType:    "drift",
+
+		ResourceAddress:    resource.Address,
+		Provider:           event.Provider,
+		StateName:          resource.StateName,
+		TerraformWorkspace: resource.Workspace,
+		StateBackend:       resource.Backend,
 	}
 
 	// Respect policy allow decisions so this path stays consistent with the
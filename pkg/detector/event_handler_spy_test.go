@@ -1,14 +1,17 @@
 package detector
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/keitahigaki/tfdrift-falco/pkg/api/broadcaster"
 	"github.com/keitahigaki/tfdrift-falco/pkg/config"
 	"github.com/keitahigaki/tfdrift-falco/pkg/diff"
+	"github.com/keitahigaki/tfdrift-falco/pkg/output"
 	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 	"github.com/stretchr/testify/require"
@@ -154,13 +157,13 @@ func TestHandleEvent_CoarseAlertWhenNoExtractedChanges(t *testing.T) {
 	// #324 ceiling: a mutating event hits a managed resource but there's no
 	// change_extractor case for it (empty Changes). Instead of silently
 	// reporting "no drift", a coarse "resource modified" alert must fire.
-	d, spy := newTestDetector(t, nil, map[string]interface{}{"id": "sg-123", "ingress": "existing"})
+	d, spy := newTestDetector(t, nil, map[string]interface{}{"id": "i-123", "instance_type": "t2.micro"})
 
 	ev := types.Event{
 		Provider:     "aws",
-		EventName:    "AuthorizeSecurityGroupIngress",
-		ResourceType: "aws_security_group",
-		ResourceID:   "sg-123",
+		EventName:    "ModifyInstanceMetadataOptions",
+		ResourceType: "aws_instance",
+		ResourceID:   "i-123",
 		UserIdentity: types.UserIdentity{UserName: "alice"},
 		Changes:      map[string]interface{}{}, // extractor produced nothing
 		RawEvent:     map[string]interface{}{"eventTime": "2026-07-22T00:00:00Z"},
@@ -168,7 +171,7 @@ func TestHandleEvent_CoarseAlertWhenNoExtractedChanges(t *testing.T) {
 	d.handleEvent(ev)
 
 	require.Len(t, spy.sent, 1, "a mutating event on a managed resource must alert even with no extracted attribute changes")
-	require.Equal(t, "AuthorizeSecurityGroupIngress", spy.sent[0].NewValue, "coarse alert records the triggering event")
+	require.Equal(t, "ModifyInstanceMetadataOptions", spy.sent[0].NewValue, "coarse alert records the triggering event")
 	require.Equal(t, "alice", spy.sent[0].UserIdentity.UserName)
 }
 
@@ -195,3 +198,63 @@ func TestIsMutatingEvent(t *testing.T) {
 		require.False(t, isMutatingEvent(e), "%s should be read-only", e)
 	}
 }
+
+func TestHandleEvent_RedactsSensitiveAttributes(t *testing.T) {
+	d, spy := newTestDetector(t, nil, map[string]interface{}{"id": "i-123", "user_data": "export DB_PASSWORD=hunter2"})
+	d.stateManager.SetSensitiveAttributes(config.SensitiveAttributesConfig{Patterns: []string{"user_data"}})
+	require.NoError(t, d.stateManager.Load(context.Background()))
+	d.broadcaster = broadcaster.NewBroadcaster()
+	events := make(chan broadcaster.Event, 4)
+	d.broadcaster.Subscribe(events)
+
+	d.handleEvent(modifyEvent("i-123", map[string]interface{}{"user_data": "export DB_PASSWORD=letmein"}))
+
+	require.Len(t, spy.sent, 1)
+	a := spy.sent[0]
+	require.True(t, a.Sensitive)
+	require.Equal(t, "user_data", a.Attribute)
+	require.Equal(t, types.RedactedValue, a.OldValue)
+	require.Equal(t, types.RedactedValue, a.NewValue)
+
+	drift := <-events
+	require.Equal(t, "drift", drift.Type)
+	require.Equal(t, types.RedactedValue, drift.Payload["old_value"])
+	require.Equal(t, types.RedactedValue, drift.Payload["new_value"])
+}
+
+func TestHandleEvent_KeyOfOtherTypeIsUnmanaged(t *testing.T) {
+	// A bucket named like the managed instance's name must not raise drift
+	// against the instance; it is an unmanaged resource
+	d, spy := newTestDetector(t, nil, map[string]interface{}{"id": "i-123", "name": "foo", "instance_type": "t2.micro"})
+
+	d.handleEvent(types.Event{
+		Provider:     "aws",
+		EventName:    "CreateBucket",
+		ResourceType: "aws_s3_bucket",
+		ResourceID:   "foo",
+		UserIdentity: types.UserIdentity{UserName: "alice"},
+		Changes:      map[string]interface{}{"instance_type": "t2.large"},
+		RawEvent:     map[string]interface{}{"eventTime": "2026-07-22T00:00:00Z"},
+	})
+
+	require.Len(t, spy.sent, 1)
+	require.Equal(t, "unmanaged", spy.sent[0].AlertType)
+	require.Equal(t, "aws_s3_bucket", spy.sent[0].ResourceType)
+}
+
+func TestHandleEvent_EmitsDriftEventWithStateContext(t *testing.T) {
+	d, spy := newTestDetector(t, nil, map[string]interface{}{"id": "i-123", "instance_type": "t2.micro"})
+	var out bytes.Buffer
+	m := output.NewManager(output.ModeJSON)
+	m.SetJSONWriter(&out)
+	d.SetOutputManager(m)
+
+	d.handleEvent(modifyEvent("i-123", map[string]interface{}{"instance_type": "t2.large"}))
+
+	require.Len(t, spy.sent, 1)
+	var event types.DriftEvent
+	require.NoError(t, json.Unmarshal(out.Bytes(), &event))
+	require.Equal(t, "i-123", event.ResourceID)
+	require.Equal(t, "local", event.StateBackend)
+	require.Equal(t, "alice", event.User)
+}
@@ -2,22 +2,23 @@ package detector
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/keitahigaki/tfdrift-falco/pkg/api/broadcaster"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
 	log "github.com/sirupsen/logrus"
 )
 
 // Start starts the drift detection process
 func (d *Detector) Start(ctx context.Context) error {
 	log.Info("Loading Terraform state...")
-	if err := d.stateManager.Load(ctx); err != nil {
-		return fmt.Errorf("failed to load terraform state: %w", err)
+	if err := d.loadAllState(ctx); err != nil {
+		return err
 	}
 
-	resourceCount := d.stateManager.ResourceCount()
-	log.Infof("Loaded Terraform state: %d resources", resourceCount)
-
 	// Rebuild graph database with loaded resources
 	if d.graphStore == nil {
 		log.Warn("GraphStore is nil in detector, cannot rebuild graph database")
@@ -27,6 +28,11 @@ func (d *Detector) Start(ctx context.Context) error {
 		log.Info("Rebuilt graph database with loaded resources")
 	}
 
+	// Run imports approved before a restart that never ran
+	if d.approvalManager != nil {
+		d.approvalManager.ResumeApproved()
+	}
+
 	// Start event collectors
 	d.wg.Add(1)
 	go func() {
@@ -53,12 +59,65 @@ func (d *Detector) Start(ctx context.Context) error {
 		}()
 	}
 
+	// Re-read a provider's state when a trusted agent changes its resources
+	if d.trustedAgents != nil {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.refresher.run(ctx, d.refreshProviderState)
+		}()
+	}
+
 	// Wait for context cancellation
 	<-ctx.Done()
 
 	// Wait for goroutines to finish
 	d.wg.Wait()
 
+	if d.verifier != nil {
+		d.verifier.stop()
+	}
+
+	if d.approvalManager != nil {
+		if err := d.approvalManager.Close(); err != nil {
+			log.Warnf("Failed to close approval store: %v", err)
+		}
+	}
+	if d.dedup != nil {
+		if err := d.dedup.Close(); err != nil {
+			log.Warnf("Failed to close dedup store: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// loadAllState loads the Terraform state of every enabled provider, so events
+// routed to a non-default provider are compared against loaded state rather
+// than an empty index. Detectors built without the per-provider map load the
+// legacy default only.
+func (d *Detector) loadAllState(ctx context.Context) error {
+	if len(d.stateManagers) == 0 {
+		if err := d.stateManager.Load(ctx); err != nil {
+			return fmt.Errorf("failed to load terraform state: %w", err)
+		}
+		log.Infof("Loaded Terraform state: %d resources", d.stateManager.ResourceCount())
+		return nil
+	}
+
+	names := make([]string, 0, len(d.stateManagers))
+	for name := range d.stateManagers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sm := d.stateManagers[name]
+		if err := sm.Load(ctx); err != nil {
+			return fmt.Errorf("failed to load terraform state for provider %s: %w", name, err)
+		}
+		log.Infof("Loaded Terraform state for %s: %d resources", name, sm.ResourceCount())
+	}
 	return nil
 }
 
@@ -85,10 +144,17 @@ func (d *Detector) refreshStatePeriodically(ctx context.Context) {
 // Extracted from the ticker loop so the refresh is unit-testable without waiting
 // on wall-clock time (#331).
 func (d *Detector) refreshAllState(ctx context.Context) {
+	var changed bool
 	for name, sm := range d.stateManagers {
-		if err := sm.Refresh(ctx); err != nil {
+		smChanged, err := sm.Reload(ctx)
+		if err != nil {
 			log.Warnf("State refresh failed for provider %s: %v", name, err)
 		}
+		changed = changed || smChanged
+	}
+	if !changed {
+		log.Debug("Terraform state unchanged")
+		return
 	}
 	if d.graphStore != nil {
 		d.graphStore.RebuildGraphDB()
@@ -96,11 +162,52 @@ func (d *Detector) refreshAllState(ctx context.Context) {
 	log.Debug("Terraform state refreshed")
 }
 
-// startCollectors starts the Falco event source. With the HTTP transport
-// (ADR-006) there is no outbound stream to maintain — Falco POSTs alerts to the
-// receiver route mounted by the API server — so this goroutine just parks until
+// broadcastStateChange publishes the resource-level delta of a state whose
+// serial changed as a "state_change" event
+func (d *Detector) broadcastStateChange(provider string, delta terraform.StateDelta) {
+	if d.broadcaster == nil {
+		return
+	}
+	d.broadcaster.Broadcast(broadcaster.Event{
+		Type:      "state_change",
+		Timestamp: time.Now().Format(time.RFC3339),
+		Payload: map[string]interface{}{
+			"provider":        provider,
+			"state":           delta.State,
+			"backend":         delta.Backend,
+			"workspace":       delta.Workspace,
+			"lineage":         delta.Lineage,
+			"previous_serial": delta.PreviousSerial,
+			"serial":          delta.Serial,
+			"added":           delta.Added,
+			"removed":         delta.Removed,
+			"changed":         delta.Changed,
+		},
+	})
+}
+
+// startCollectors starts the event sources. The CloudTrail collector, when
+// configured, runs alongside Falco. With the HTTP transport (ADR-006) there
+// is no outbound stream to maintain — Falco POSTs alerts to the receiver
+// route mounted by the API server — so this goroutine just parks until
 // shutdown. With the legacy gRPC transport it runs the reconnecting Sub loop.
 func (d *Detector) startCollectors(ctx context.Context) error {
+	if d.cloudTrail != nil {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			if err := d.cloudTrail.Run(ctx, d.eventCh); err != nil && !errors.Is(err, context.Canceled) {
+				log.Errorf("CloudTrail collector error: %v", err)
+			}
+		}()
+	}
+
+	if !d.cfg.Falco.Enabled && d.cloudTrail != nil {
+		log.Info("Falco disabled: ingesting events from the CloudTrail collector only")
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
 	if d.cfg.Falco.UsesHTTPTransport() {
 		log.Info("Falco transport=http: ingesting alerts via the HTTP receiver route")
 		<-ctx.Done()
@@ -114,18 +221,19 @@ func (d *Detector) startCollectors(ctx context.Context) error {
 	return nil
 }
 
-// processEvents processes events from the event channel
+// processEvents hands events from the event channel to the workers until
+// the context is cancelled
 func (d *Detector) processEvents(ctx context.Context) {
-	log.Info("Event processor started")
-
-	for {
-		select {
-		case <-ctx.Done():
-			log.Info("Event processor stopping...")
-			return
-
-		case event := <-d.eventCh:
-			d.handleEvent(event)
-		}
+	pool, err := newShardPool(d.cfg.Processing, d.handleEvent)
+	if err != nil {
+		log.Errorf("Event spill unavailable, blocking on full queues instead: %v", err)
+		fallback := d.cfg.Processing
+		fallback.Overflow = overflowBlock
+		pool, _ = newShardPool(fallback, d.handleEvent)
 	}
+	defer pool.close()
+
+	log.Infof("Event processor started (%d workers, overflow=%s)", len(pool.shards), pool.overflow)
+	pool.run(ctx, d.eventCh)
+	log.Info("Event processor stopping...")
 }
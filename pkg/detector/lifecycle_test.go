@@ -2,9 +2,12 @@ package detector
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/keitahigaki/tfdrift-falco/pkg/cloudtrail"
 	"github.com/keitahigaki/tfdrift-falco/pkg/config"
 	"github.com/keitahigaki/tfdrift-falco/pkg/diff"
 	"github.com/keitahigaki/tfdrift-falco/pkg/falco"
@@ -144,6 +147,39 @@ func TestStartCollectors_Integration(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestStartCollectors_CloudTrailOnly(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		CloudTrail: config.CloudTrailConfig{Enabled: true, Dir: dir, PollIntervalSec: 1},
+	}
+	collector, err := cloudtrail.NewFromConfig(context.Background(), cfg.CloudTrail, falco.NewSubscriberWithDefaults())
+	require.NoError(t, err)
+
+	detector := &Detector{cfg: cfg, cloudTrail: collector, eventCh: make(chan types.Event, 10)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- detector.startCollectors(ctx) }()
+
+	// Log files delivered while running are ingested without Falco
+	logFile := `{"Records":[{"eventSource":"ec2.amazonaws.com","eventName":"ModifyInstanceAttribute","requestParameters":{"instanceId":"i-0abc"}}]}`
+	path := filepath.Join(dir, "trail.json")
+	require.NoError(t, os.WriteFile(path, []byte(logFile), 0o644))
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	select {
+	case event := <-detector.eventCh:
+		assert.Equal(t, "i-0abc", event.ResourceID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event from the CloudTrail collector")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	detector.wg.Wait()
+}
+
 func TestStart_Integration(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -0,0 +1,169 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/diff"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeProviderState writes a one-resource tfstate and returns its backend config.
+func writeProviderState(t *testing.T, resourceType string, attrs map[string]interface{}) config.TerraformStateConfig {
+	t.Helper()
+	state := map[string]interface{}{
+		"version": 4,
+		"serial":  1,
+		"resources": []map[string]interface{}{{
+			"mode":      "managed",
+			"type":      resourceType,
+			"name":      "main",
+			"instances": []map[string]interface{}{{"attributes": attrs}},
+		}},
+	}
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return config.TerraformStateConfig{Backend: "local", LocalPath: path}
+}
+
+// newMultiProviderDetector wires AWS, GCP and Azure state managers (each
+// holding one resource) with AWS as the legacy default, mirroring New().
+func newMultiProviderDetector(t *testing.T) (*Detector, *spyNotifier) {
+	t.Helper()
+	states := map[string]config.TerraformStateConfig{
+		"aws": writeProviderState(t, "aws_instance", map[string]interface{}{
+			"id": "i-123", "instance_type": "t3.micro",
+		}),
+		"gcp": writeProviderState(t, "google_compute_instance", map[string]interface{}{
+			"id": "projects/p1/zones/us-central1-a/instances/vm-1", "machine_type": "e2-small",
+		}),
+		"azure": writeProviderState(t, "azurerm_resource_group", map[string]interface{}{
+			"id": "/subscriptions/sub-1/resourceGroups/rg-1", "location": "eastus",
+		}),
+	}
+
+	managers := make(map[string]*terraform.StateManager)
+	for name, cfg := range states {
+		sm, err := terraform.NewStateManager(cfg)
+		require.NoError(t, err)
+		managers[name] = sm
+	}
+
+	spy := &spyNotifier{}
+	d := &Detector{
+		cfg:           &config.Config{},
+		stateManager:  managers["aws"],
+		stateManagers: managers,
+		formatter:     diff.NewFormatter(false),
+		notifier:      spy,
+	}
+	require.NoError(t, d.loadAllState(context.Background()))
+	return d, spy
+}
+
+func TestHandleEvent_RoutesEachProviderToItsOwnState(t *testing.T) {
+	d, spy := newMultiProviderDetector(t)
+
+	events := []types.Event{
+		{
+			Provider: "aws", EventName: "ModifyInstanceAttribute", ResourceType: "aws_instance",
+			ResourceID: "i-123", Changes: map[string]interface{}{"instance_type": "t3.large"},
+		},
+		{
+			Provider: "gcp", EventName: "v1.compute.instances.setMachineType", ResourceType: "google_compute_instance",
+			ResourceID: "projects/p1/zones/us-central1-a/instances/vm-1", Changes: map[string]interface{}{"machine_type": "e2-large"},
+		},
+		{
+			Provider: "azure", EventName: "Microsoft.Resources/subscriptions/resourceGroups/write", ResourceType: "azurerm_resource_group",
+			ResourceID: "/subscriptions/sub-1/resourceGroups/rg-1", Changes: map[string]interface{}{"location": "westus"},
+		},
+	}
+	for _, e := range events {
+		d.handleEvent(e)
+	}
+
+	require.Len(t, spy.sent, 3, "each provider's managed resource must yield a drift alert, not an unmanaged one")
+	byType := make(map[string]*types.DriftAlert)
+	for _, a := range spy.sent {
+		byType[a.ResourceType] = a
+	}
+	assert.Equal(t, "t3.micro", byType["aws_instance"].OldValue)
+	assert.Equal(t, "e2-small", byType["google_compute_instance"].OldValue)
+	assert.Equal(t, "eastus", byType["azurerm_resource_group"].OldValue)
+	for _, a := range spy.sent {
+		assert.NotEqual(t, "not-managed", a.OldValue, "%s was wrongly treated as unmanaged", a.ResourceType)
+		assert.Equal(t, "local", a.StateBackend, "alerts carry the state the resource came from")
+		assert.Equal(t, "default", a.TerraformWorkspace)
+		assert.NotEmpty(t, a.StateName)
+	}
+	assert.Equal(t, "gcp", byType["google_compute_instance"].Provider)
+	assert.Equal(t, "aws_instance.main", byType["aws_instance"].ResourceAddress)
+}
+
+func TestHandleEvent_UnmanagedIsDecidedPerProvider(t *testing.T) {
+	d, spy := newMultiProviderDetector(t)
+
+	// The AWS instance ID exists — but only in AWS state. A GCP event naming
+	// the same ID must be judged against GCP state, i.e. unmanaged.
+	d.handleEvent(types.Event{
+		Provider: "gcp", EventName: "v1.compute.instances.insert", ResourceType: "google_compute_instance",
+		ResourceID: "i-123",
+	})
+
+	require.Len(t, spy.sent, 1)
+	assert.Equal(t, "not-managed", spy.sent[0].OldValue)
+	assert.Equal(t, "google_compute_instance", spy.sent[0].ResourceType)
+}
+
+func TestHandleEvent_ProviderWithoutStateIsSkipped(t *testing.T) {
+	d, spy := newMultiProviderDetector(t)
+	delete(d.stateManagers, "azure")
+
+	d.handleEvent(types.Event{
+		Provider: "azure", EventName: "Microsoft.Resources/subscriptions/resourceGroups/write",
+		ResourceType: "azurerm_resource_group", ResourceID: "/subscriptions/sub-1/resourceGroups/other",
+	})
+
+	assert.Empty(t, spy.sent, "an event from a provider with no configured state can't be classified and must not alert")
+}
+
+func TestStateManagerFor_Fallbacks(t *testing.T) {
+	d, _ := newMultiProviderDetector(t)
+
+	assert.Same(t, d.stateManagers["gcp"], d.stateManagerFor("gcp"))
+	assert.Same(t, d.stateManagers["azure"], d.stateManagerFor("Azure"), "provider names are matched case-insensitively")
+	assert.Same(t, d.stateManager, d.stateManagerFor(""), "events without a provider use the default state")
+	assert.Nil(t, d.stateManagerFor("oci"))
+
+	legacy := &Detector{stateManager: d.stateManager}
+	assert.Same(t, d.stateManager, legacy.stateManagerFor("gcp"), "detectors without the per-provider map use the default")
+}
+
+func TestHandleEvent_MatchesResourceBySecondaryKey(t *testing.T) {
+	sm, err := terraform.NewStateManager(writeProviderState(t, "aws_iam_role", map[string]interface{}{
+		"id": "deploy", "arn": "arn:aws:iam::123456789012:role/deploy", "max_session_duration": float64(3600),
+	}))
+	require.NoError(t, err)
+	require.NoError(t, sm.Load(context.Background()))
+
+	spy := &spyNotifier{}
+	d := &Detector{cfg: &config.Config{}, stateManager: sm, formatter: diff.NewFormatter(false), notifier: spy}
+	d.handleEvent(types.Event{
+		Provider: "aws", EventName: "UpdateRole", ResourceType: "aws_iam_role",
+		ResourceID: "arn:aws:iam::123456789012:role/deploy",
+		Changes:    map[string]interface{}{"max_session_duration": float64(7200)},
+	})
+
+	require.Len(t, spy.sent, 1)
+	assert.NotEqual(t, "not-managed", spy.sent[0].OldValue, "an event naming the role by ARN must match the role indexed by name")
+	assert.Equal(t, "aws_iam_role.main", spy.sent[0].ResourceAddress)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/keitahigaki/tfdrift-falco/pkg/api/broadcaster"
+	"github.com/keitahigaki/tfdrift-falco/pkg/policy"
 	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 	"github.com/keitahigaki/tfdrift-falco/pkg/vcs"
@@ -13,14 +14,27 @@ import (
 )
 
 // handleRemediation generates a remediation proposal for a drift alert,
-// optionally creates a GitHub PR, and broadcasts the proposal.
-func (d *Detector) handleRemediation(ctx context.Context, alert *types.DriftAlert) {
+// optionally opens a pull/merge request, and broadcasts the proposal.
+// policyResult (may be nil) picks whether the source takes the cloud value
+// or ignores the attribute.
+func (d *Detector) handleRemediation(ctx context.Context, alert *types.DriftAlert, policyResult *policy.EvalResult) {
 	if !d.cfg.Remediation.Enabled {
 		return
 	}
 
 	gen := terraform.NewRemediationGeneratorWithTool(d.cfg.AutoImport.IaCTool())
-	proposal := gen.GenerateForDrift(alert)
+	if d.cfg.Remediation.SourceDir != "" {
+		gen = terraform.NewRemediationGeneratorWithSource(
+			d.cfg.AutoImport.IaCTool(),
+			terraform.NewSourcePatcher(d.cfg.Remediation.SourceDir, d.cfg.Remediation.SourceRepoPath),
+		)
+	}
+
+	mode := terraform.PatchUpdateValue
+	if policyResult != nil && policyResult.Remediation == policy.RemediationIgnoreChanges {
+		mode = terraform.PatchIgnoreChanges
+	}
+	proposal := gen.GenerateForDriftWithMode(alert, mode)
 	if proposal == nil {
 		return
 	}
@@ -31,8 +45,8 @@ func (d *Detector) handleRemediation(ctx context.Context, alert *types.DriftAler
 		"severity":      proposal.Severity,
 	}).Info("Remediation proposal generated")
 
-	// Create GitHub PR if configured
-	if d.cfg.Remediation.CreatePRs && d.cfg.GitHub.Enabled {
+	// Open a pull/merge request if configured
+	if _, ok := d.cfg.RemediationVCS(); d.cfg.Remediation.CreatePRs && ok {
 		d.createRemediationPR(ctx, proposal)
 	}
 
@@ -50,6 +64,7 @@ func (d *Detector) handleRemediation(ctx context.Context, alert *types.DriftAler
 				"import_command": proposal.ImportCommand,
 				"plan_command":   proposal.PlanCommand,
 				"pr_url":         proposal.PRUrl,
+				"patch_mode":     proposal.PatchMode,
 			},
 		})
 	}
@@ -62,6 +77,7 @@ func (d *Detector) handleUnmanagedRemediation(ctx context.Context, event *types.
 	}
 
 	gen := terraform.NewRemediationGeneratorWithTool(d.cfg.AutoImport.IaCTool())
+	gen.SetSensitivePatterns(d.sensitive)
 	proposal := gen.GenerateForUnmanaged(event)
 	if proposal == nil {
 		return
@@ -72,7 +88,7 @@ func (d *Detector) handleUnmanagedRemediation(ctx context.Context, event *types.
 		"resource_type": proposal.ResourceType,
 	}).Info("Unmanaged resource remediation proposal generated")
 
-	if d.cfg.Remediation.CreatePRs && d.cfg.GitHub.Enabled {
+	if _, ok := d.cfg.RemediationVCS(); d.cfg.Remediation.CreatePRs && ok {
 		d.createRemediationPR(ctx, proposal)
 	}
 
@@ -95,29 +111,42 @@ func (d *Detector) handleUnmanagedRemediation(ctx context.Context, event *types.
 }
 
 func (d *Detector) createRemediationPR(ctx context.Context, proposal *types.RemediationProposal) {
+	vcsCfg, _ := d.cfg.RemediationVCS()
+
 	if d.cfg.Remediation.DryRun {
-		log.Info("Dry run: skipping GitHub PR creation")
+		log.Infof("Dry run: skipping %s PR creation", vcsCfg.Provider)
 		return
 	}
 
-	token := d.cfg.GitHub.Token
-	if token == "" {
-		log.Warn("GitHub token not configured, skipping PR creation")
+	if vcsCfg.Token == "" {
+		log.Warnf("%s token not configured, skipping PR creation", vcsCfg.Provider)
 		return
 	}
 
-	client := vcs.NewGitHubClient(
-		d.cfg.GitHub.Owner,
-		d.cfg.GitHub.Repo,
-		d.cfg.GitHub.Branch,
-		token,
-	)
+	provider, err := vcs.NewProvider(vcs.Options{
+		Kind:       vcsCfg.Provider,
+		URL:        vcsCfg.URL,
+		Owner:      vcsCfg.Owner,
+		Repo:       vcsCfg.Repo,
+		BaseBranch: vcsCfg.Branch,
+		Token:      vcsCfg.Token,
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to set up VCS provider for remediation PR")
+		return
+	}
 
 	branchName := fmt.Sprintf("remediation/drift-%s-%s", proposal.ResourceType, proposal.ID[:8])
 	body := terraform.FormatProposalMarkdown(proposal)
 
 	files := map[string]string{}
-	if proposal.TerraformCode != "" {
+	if proposal.PatchMode != "" {
+		// The owning block was found: commit only the in-place edit, which
+		// is empty when the source already matches
+		for path, content := range proposal.PatchedFiles {
+			files[path] = content
+		}
+	} else if proposal.TerraformCode != "" {
 		fileName := fmt.Sprintf("remediation/%s_%s.tf", proposal.ResourceType, proposal.ResourceName)
 		files[fileName] = proposal.TerraformCode
 	}
@@ -127,7 +156,7 @@ func (d *Detector) createRemediationPR(ctx context.Context, proposal *types.Reme
 		return
 	}
 
-	result, err := client.CreatePR(ctx, &vcs.PRRequest{
+	result, err := vcs.OpenPullRequest(ctx, provider, &vcs.PRRequest{
 		Title:      fmt.Sprintf("fix: remediate drift in %s.%s", proposal.ResourceType, proposal.ResourceName),
 		Body:       body,
 		BranchName: branchName,
@@ -144,7 +173,9 @@ func (d *Detector) createRemediationPR(ctx context.Context, proposal *types.Reme
 	proposal.Status = types.RemediationApproved
 
 	log.WithFields(log.Fields{
+		"provider":  provider.Name(),
 		"pr_url":    result.URL,
 		"pr_number": result.Number,
+		"updated":   result.Existing,
 	}).Info("Remediation PR created")
 }
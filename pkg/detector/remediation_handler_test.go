@@ -0,0 +1,135 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/policy"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitLabStub serves the GitLab endpoints remediation PRs use and records
+// each call and committed file
+type gitLabStub struct {
+	*httptest.Server
+	calls []string
+	files map[string]string
+}
+
+func newGitLabStub(t *testing.T) *gitLabStub {
+	t.Helper()
+	stub := &gitLabStub{files: map[string]string{}}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "glpat-test", r.Header.Get("PRIVATE-TOKEN"))
+		path := strings.TrimPrefix(r.URL.EscapedPath(), "/api/v4/projects/infra%2Fnetwork")
+		stub.calls = append(stub.calls, r.Method+" "+strings.SplitN(path, "?", 2)[0])
+
+		switch {
+		case r.Method == http.MethodGet && path == "/merge_requests":
+			_, _ = w.Write([]byte("[]"))
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && path == "/repository/commits":
+			var commit struct {
+				Actions []struct {
+					FilePath string `json:"file_path"`
+					Content  string `json:"content"`
+				} `json:"actions"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&commit)
+			for _, a := range commit.Actions {
+				stub.files[a.FilePath] = a.Content
+			}
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPost && path == "/merge_requests":
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"iid":     12,
+				"web_url": "https://gitlab.example.com/infra/network/-/merge_requests/12",
+			})
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func gitLabRemediationConfig(url string) *config.Config {
+	return &config.Config{
+		Remediation: config.RemediationConfig{Enabled: true, CreatePRs: true},
+		VCS: config.VCSConfig{
+			Enabled:  true,
+			Provider: "gitlab",
+			URL:      url,
+			Owner:    "infra",
+			Repo:     "network",
+			Branch:   "main",
+			Token:    "glpat-test",
+		},
+	}
+}
+
+func TestCreateRemediationPR_GitLab(t *testing.T) {
+	stub := newGitLabStub(t)
+	d := &Detector{cfg: gitLabRemediationConfig(stub.URL)}
+
+	proposal := &types.RemediationProposal{
+		ID:            "prop-12345678",
+		ResourceID:    "sg-123",
+		ResourceType:  "aws_security_group",
+		ResourceName:  "web",
+		TerraformCode: `resource "aws_security_group" "web" {}`,
+	}
+	d.createRemediationPR(context.Background(), proposal)
+
+	assert.Equal(t, "https://gitlab.example.com/infra/network/-/merge_requests/12", proposal.PRUrl)
+	assert.Equal(t, 12, proposal.PRNumber)
+	assert.Equal(t, types.RemediationApproved, proposal.Status)
+	assert.Equal(t, []string{
+		"GET /merge_requests",
+		"POST /repository/branches",
+		"HEAD /repository/files/remediation%2Faws_security_group_web.tf",
+		"POST /repository/commits",
+		"POST /merge_requests",
+	}, stub.calls)
+}
+
+func TestHandleRemediation_CommitsSourcePatch(t *testing.T) {
+	dir := t.TempDir()
+	src := "resource \"aws_s3_bucket\" \"logs\" {\n  bucket = \"logs\"\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "s3.tf"), []byte(src), 0o644))
+
+	stub := newGitLabStub(t)
+	cfg := gitLabRemediationConfig(stub.URL)
+	cfg.Remediation.SourceDir = dir
+	cfg.Remediation.SourceRepoPath = "envs/prod"
+	d := &Detector{cfg: cfg}
+
+	alert := &types.DriftAlert{
+		ResourceType: "aws_s3_bucket",
+		ResourceName: "logs",
+		ResourceID:   "logs",
+		Attribute:    "tags",
+		NewValue:     map[string]interface{}{"Owner": "finance"},
+		Severity:     "low",
+	}
+	d.handleRemediation(context.Background(), alert, &policy.EvalResult{
+		Decision:    policy.DecisionRemediate,
+		Remediation: policy.RemediationIgnoreChanges,
+	})
+
+	require.Len(t, stub.files, 1, "only the owning file is committed")
+	assert.Contains(t, stub.files["envs/prod/s3.tf"], "ignore_changes = [tags]")
+}
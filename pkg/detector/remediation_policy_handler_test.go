@@ -27,7 +27,7 @@ func TestHandleRemediation_DisabledRemediationConfig(t *testing.T) {
 	}
 
 	// Should return immediately without error
-	detector.handleRemediation(context.Background(), alert)
+	detector.handleRemediation(context.Background(), alert, nil)
 }
 
 func TestHandleRemediation_WithNilBroadcaster(t *testing.T) {
@@ -49,7 +49,7 @@ func TestHandleRemediation_WithNilBroadcaster(t *testing.T) {
 	}
 
 	// Should not panic even without broadcaster
-	detector.handleRemediation(context.Background(), alert)
+	detector.handleRemediation(context.Background(), alert, nil)
 }
 
 func TestHandleUnmanagedRemediation_DisabledRemediationConfig(t *testing.T) {
@@ -295,7 +295,7 @@ func TestHandleRemediation_ProposalCreation(t *testing.T) {
 	}
 
 	// Should not panic and should process remediation
-	detector.handleRemediation(context.Background(), alert)
+	detector.handleRemediation(context.Background(), alert, nil)
 }
 
 func TestHandleUnmanagedRemediation_ProposalCreation(t *testing.T) {
@@ -0,0 +1,69 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+)
+
+// ReplayAlert is an alert raised while replaying recorded events
+type ReplayAlert struct {
+	// Event is the index of the replayed event that raised the alert
+	Event int
+	Alert *types.DriftAlert
+}
+
+// alertRecorder collects alerts instead of delivering them
+type alertRecorder struct {
+	current int
+	alerts  []ReplayAlert
+}
+
+func (r *alertRecorder) Send(alert *types.DriftAlert) error {
+	recorded := *alert
+	r.alerts = append(r.alerts, ReplayAlert{Event: r.current, Alert: &recorded})
+	return nil
+}
+
+// Replay runs recorded events through the detection pipeline offline, one at
+// a time and in order, against the Terraform state configured in cfg, and
+// returns the alerts they raise. Notifications, auto-import, remediation,
+// live verification and the CloudTrail collector are disabled so a replay
+// cannot reach external systems. Human-readable alert output is written to
+// console.
+func Replay(ctx context.Context, cfg *config.Config, events []types.Event, console io.Writer) ([]ReplayAlert, error) {
+	replayCfg := *cfg
+	replayCfg.DryRun = false
+	replayCfg.Notifications = config.NotificationsConfig{}
+	replayCfg.AutoImport.Enabled = false
+	replayCfg.Remediation.Enabled = false
+	replayCfg.CloudTrail.Enabled = false
+	replayCfg.Verify.Enabled = false
+	// A recording is deduplicated on its own, not against the live store
+	replayCfg.Dedup.Backend = ""
+	replayCfg.Dedup.Path = ""
+
+	d, err := New(&replayCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create detector: %w", err)
+	}
+	recorder := &alertRecorder{}
+	d.notifier = recorder
+	d.console = console
+
+	if err := d.loadAllState(ctx); err != nil {
+		return nil, err
+	}
+
+	for i, event := range events {
+		if err := ctx.Err(); err != nil {
+			return recorder.alerts, err
+		}
+		recorder.current = i
+		d.handleEvent(event)
+	}
+	return recorder.alerts, nil
+}
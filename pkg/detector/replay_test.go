@@ -0,0 +1,67 @@
+package detector
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/falcosecurity/client-go/pkg/api/outputs"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestReplay_RecordsAlertsWithEventTime(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.AWS.Enabled = true
+	cfg.Providers.AWS.State = writeProviderState(t, "aws_instance", map[string]interface{}{
+		"id": "i-123", "instance_type": "t3.micro",
+	})
+	// Must never be contacted during a replay
+	cfg.Notifications.Webhook.Enabled = true
+	cfg.Notifications.Webhook.URL = "http://127.0.0.1:1/hook"
+
+	when := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	events := []types.Event{
+		{
+			Provider: "aws", EventName: "ModifyInstanceAttribute", ResourceType: "aws_instance",
+			ResourceID: "i-other", Changes: map[string]interface{}{"instance_type": "t3.large"},
+		},
+		{
+			Provider: "aws", EventName: "ModifyInstanceAttribute", ResourceType: "aws_instance",
+			ResourceID: "i-123", Changes: map[string]interface{}{"instance_type": "t3.large"},
+			RawEvent: &outputs.Response{Time: timestamppb.New(when)},
+		},
+	}
+
+	var console bytes.Buffer
+	alerts, err := Replay(context.Background(), cfg, events, &console)
+	require.NoError(t, err)
+
+	var drift []ReplayAlert
+	for _, a := range alerts {
+		if a.Alert.AlertType == "drift" {
+			drift = append(drift, a)
+		}
+	}
+	require.Len(t, drift, 1)
+	assert.Equal(t, 1, drift[0].Event)
+	assert.Equal(t, "i-123", drift[0].Alert.ResourceID)
+	assert.Equal(t, "t3.micro", drift[0].Alert.OldValue)
+	assert.Equal(t, "t3.large", drift[0].Alert.NewValue)
+	assert.Equal(t, "2026-10-01T12:00:00Z", drift[0].Alert.Timestamp)
+	assert.Contains(t, console.String(), "i-123")
+	assert.True(t, cfg.Notifications.Webhook.Enabled, "caller's config must not be modified")
+}
+
+func TestReplay_StateLoadError(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.AWS.Enabled = true
+	cfg.Providers.AWS.State = config.TerraformStateConfig{Backend: "local", LocalPath: "/nonexistent/terraform.tfstate"}
+
+	_, err := Replay(context.Background(), cfg, nil, &bytes.Buffer{})
+	assert.Error(t, err)
+}
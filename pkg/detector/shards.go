@@ -0,0 +1,207 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/telemetry"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultEventWorkers is the number of event shards when unset
+	DefaultEventWorkers = 4
+	// DefaultEventQueueSize is the queue depth per shard when unset
+	DefaultEventQueueSize = 100
+)
+
+// Overflow policies for a full shard queue
+const (
+	overflowBlock = "block"
+	overflowDrop  = "drop"
+	overflowSpill = "spill"
+)
+
+// queuedEvent is an event waiting for its worker
+type queuedEvent struct {
+	event    types.Event
+	queuedAt time.Time
+}
+
+// shard is one worker with its own queue. All events for a resource land on
+// the same shard and are handled in arrival order.
+type shard struct {
+	queue chan queuedEvent
+	spill *spillQueue // overflow=spill only
+}
+
+// shardPool fans events out to workers by resource ID
+type shardPool struct {
+	shards   []*shard
+	overflow string
+	handle   func(types.Event)
+}
+
+// processingSettings returns cfg with defaults applied
+func processingSettings(cfg config.ProcessingConfig) config.ProcessingConfig {
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultEventWorkers
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultEventQueueSize
+	}
+	if cfg.Overflow == "" {
+		cfg.Overflow = overflowBlock
+	}
+	return cfg
+}
+
+// newShardPool creates the shards, and their spill files when overflow is
+// "spill"
+func newShardPool(cfg config.ProcessingConfig, handle func(types.Event)) (*shardPool, error) {
+	cfg = processingSettings(cfg)
+	p := &shardPool{overflow: cfg.Overflow, handle: handle}
+	for i := 0; i < cfg.Workers; i++ {
+		s := &shard{queue: make(chan queuedEvent, cfg.QueueSize)}
+		if cfg.Overflow == overflowSpill {
+			spill, err := newSpillQueue(cfg.SpillDir, fmt.Sprintf("tfdrift-events-%d", i))
+			if err != nil {
+				p.close()
+				return nil, err
+			}
+			s.spill = spill
+		}
+		p.shards = append(p.shards, s)
+	}
+	return p, nil
+}
+
+// shardFor picks the shard of an event by hashing its resource ID
+func (p *shardPool) shardFor(event *types.Event) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(event.ResourceID))
+	return p.shards[h.Sum32()%uint32(len(p.shards))]
+}
+
+// run dispatches events from in until ctx is cancelled, then waits for the
+// workers to stop. Events still queued at shutdown are not processed.
+func (p *shardPool) run(ctx context.Context, in <-chan types.Event) {
+	var wg sync.WaitGroup
+	for _, s := range p.shards {
+		wg.Add(1)
+		go func(s *shard) {
+			defer wg.Done()
+			p.work(ctx, s)
+		}(s)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case event := <-in:
+			p.dispatch(ctx, event)
+		}
+	}
+}
+
+// dispatch hands an event to its shard, applying the overflow policy when
+// the shard's queue is full
+func (p *shardPool) dispatch(ctx context.Context, event types.Event) {
+	s := p.shardFor(&event)
+	item := queuedEvent{event: event, queuedAt: time.Now()}
+
+	// Once a shard has spilled, later events follow the spilled ones to
+	// disk until the worker has drained them, so their order is kept
+	if s.spill == nil || s.spill.len() == 0 {
+		select {
+		case s.queue <- item:
+			telemetry.RecordEventsQueued(ctx, 1)
+			return
+		default:
+		}
+	}
+
+	switch p.overflow {
+	case overflowDrop:
+		telemetry.RecordEventOverflow(ctx, "dropped")
+		log.Warnf("Event queue full, dropping %s on %s", event.EventName, event.ResourceID)
+	case overflowSpill:
+		if err := s.spill.push(item); err != nil {
+			telemetry.RecordEventOverflow(ctx, "dropped")
+			log.Errorf("Event queue full and spill failed, dropping %s on %s: %v", event.EventName, event.ResourceID, err)
+			return
+		}
+		telemetry.RecordEventOverflow(ctx, "spilled")
+		telemetry.RecordEventsQueued(ctx, 1)
+	default:
+		select {
+		case s.queue <- item:
+			telemetry.RecordEventsQueued(ctx, 1)
+		case <-ctx.Done():
+		}
+	}
+}
+
+// work handles the events of one shard: queued events first, then spilled
+// ones, which are always newer
+func (p *shardPool) work(ctx context.Context, s *shard) {
+	for {
+		select {
+		case item := <-s.queue:
+			p.process(ctx, item)
+			continue
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		if s.spill != nil {
+			item, ok, err := s.spill.pop()
+			if err != nil {
+				telemetry.RecordEventsQueued(ctx, -1)
+				log.Errorf("Failed to read spilled event: %v", err)
+				continue
+			}
+			if ok {
+				p.process(ctx, item)
+				continue
+			}
+		}
+
+		select {
+		case item := <-s.queue:
+			p.process(ctx, item)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// process handles one queued event and records its latency
+func (p *shardPool) process(ctx context.Context, item queuedEvent) {
+	telemetry.RecordEventsQueued(ctx, -1)
+	p.handle(item.event)
+	telemetry.RecordEventProcessed(ctx, time.Since(item.queuedAt))
+}
+
+// close removes the spill files
+func (p *shardPool) close() {
+	for _, s := range p.shards {
+		if s.spill == nil {
+			continue
+		}
+		if n := s.spill.len(); n > 0 {
+			log.Warnf("Discarding %d spilled event(s) at shutdown", n)
+		}
+		if err := s.spill.close(); err != nil {
+			log.Warnf("Failed to remove spill file: %v", err)
+		}
+	}
+}
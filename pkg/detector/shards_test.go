@@ -0,0 +1,223 @@
+package detector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/falcosecurity/client-go/pkg/api/outputs"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder collects the events a pool handled, per resource
+type recorder struct {
+	mu      sync.Mutex
+	handled map[string][]string
+	count   int
+}
+
+func (r *recorder) handle(event types.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.handled == nil {
+		r.handled = map[string][]string{}
+	}
+	r.handled[event.ResourceID] = append(r.handled[event.ResourceID], event.EventName)
+	r.count++
+}
+
+func (r *recorder) total() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+func seqEvent(resourceID string, n int) types.Event {
+	return types.Event{ResourceID: resourceID, EventName: fmt.Sprintf("e%d", n)}
+}
+
+// resourcesOnDifferentShards returns two resource IDs that hash to
+// different shards of p
+func resourcesOnDifferentShards(t *testing.T, p *shardPool) (string, string) {
+	t.Helper()
+	first := "i-0"
+	for i := 1; i < 100; i++ {
+		id := fmt.Sprintf("i-%d", i)
+		if p.shardFor(&types.Event{ResourceID: id}) != p.shardFor(&types.Event{ResourceID: first}) {
+			return first, id
+		}
+	}
+	t.Fatal("no resource IDs on different shards")
+	return "", ""
+}
+
+func TestShardPool_KeepsPerResourceOrder(t *testing.T) {
+	rec := &recorder{}
+	pool, err := newShardPool(config.ProcessingConfig{Workers: 4, QueueSize: 2}, rec.handle)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan types.Event)
+	done := make(chan struct{})
+	go func() {
+		pool.run(ctx, in)
+		close(done)
+	}()
+
+	resources := []string{"i-a", "i-b", "i-c", "i-d", "i-e"}
+	for n := 0; n < 50; n++ {
+		for _, id := range resources {
+			in <- seqEvent(id, n)
+		}
+	}
+	require.Eventually(t, func() bool { return rec.total() == 250 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	for _, id := range resources {
+		want := make([]string, 50)
+		for n := range want {
+			want[n] = fmt.Sprintf("e%d", n)
+		}
+		assert.Equal(t, want, rec.handled[id], id)
+	}
+}
+
+func TestShardPool_SlowResourceDoesNotStallOthers(t *testing.T) {
+	release := make(chan struct{})
+	rec := &recorder{}
+	var slowID string
+	handle := func(event types.Event) {
+		if event.ResourceID == slowID {
+			<-release
+		}
+		rec.handle(event)
+	}
+	pool, err := newShardPool(config.ProcessingConfig{Workers: 2}, handle)
+	require.NoError(t, err)
+	var fastID string
+	slowID, fastID = resourcesOnDifferentShards(t, pool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := make(chan types.Event)
+	go pool.run(ctx, in)
+
+	in <- seqEvent(slowID, 0)
+	in <- seqEvent(fastID, 0)
+	in <- seqEvent(fastID, 1)
+	require.Eventually(t, func() bool { return rec.total() == 2 }, 2*time.Second, 10*time.Millisecond,
+		"events for another resource must not wait for the slow one")
+
+	close(release)
+	require.Eventually(t, func() bool { return rec.total() == 3 }, 2*time.Second, 10*time.Millisecond)
+}
+
+// blockedPool returns a one-worker pool whose worker is busy with a first
+// event until release is closed, with one more event queued behind it
+func blockedPool(t *testing.T, cfg config.ProcessingConfig) (*shardPool, *recorder, chan struct{}, context.CancelFunc) {
+	t.Helper()
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	rec := &recorder{}
+	cfg.Workers, cfg.QueueSize = 1, 1
+	pool, err := newShardPool(cfg, func(event types.Event) {
+		select {
+		case started <- struct{}{}:
+			<-release
+		default:
+		}
+		rec.handle(event)
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go pool.work(ctx, pool.shards[0])
+	pool.dispatch(ctx, seqEvent("i-1", 0))
+	<-started
+	pool.dispatch(ctx, seqEvent("i-1", 1)) // fills the queue
+	return pool, rec, release, cancel
+}
+
+func TestShardPool_DropWhenFull(t *testing.T) {
+	pool, rec, release, cancel := blockedPool(t, config.ProcessingConfig{Overflow: "drop"})
+	defer cancel()
+	defer pool.close()
+
+	pool.dispatch(context.Background(), seqEvent("i-1", 2))
+	close(release)
+
+	require.Eventually(t, func() bool { return rec.total() == 2 }, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, []string{"e0", "e1"}, rec.handled["i-1"], "the event that found the queue full is dropped")
+}
+
+func TestShardPool_SpillWhenFull(t *testing.T) {
+	dir := t.TempDir()
+	pool, rec, release, cancel := blockedPool(t, config.ProcessingConfig{Overflow: "spill", SpillDir: dir})
+	defer cancel()
+
+	spilled := seqEvent("i-1", 2)
+	spilled.RawEvent = &outputs.Response{Source: "aws_cloudtrail", OutputFields: map[string]string{"ct.id": "abc"}}
+	pool.dispatch(context.Background(), spilled)
+	pool.dispatch(context.Background(), seqEvent("i-1", 3))
+	assert.Equal(t, 2, pool.shards[0].spill.len(), "once spilled, later events follow to disk")
+
+	close(release)
+	require.Eventually(t, func() bool { return rec.total() == 4 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"e0", "e1", "e2", "e3"}, rec.handled["i-1"])
+
+	cancel()
+	pool.close()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "spill files are removed on close")
+}
+
+func TestSpillQueue_RoundTrip(t *testing.T) {
+	q, err := newSpillQueue(t.TempDir(), "test")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, q.close()) }()
+
+	queuedAt := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	event := types.Event{
+		Provider:   "aws",
+		EventName:  "ModifyInstanceAttribute",
+		ResourceID: "i-1",
+		Changes:    map[string]interface{}{"instance_type": "t3.large"},
+		RawEvent:   &outputs.Response{Source: "aws_cloudtrail", OutputFields: map[string]string{"ct.id": "abc"}},
+	}
+	require.NoError(t, q.push(queuedEvent{event: event, queuedAt: queuedAt}))
+	require.NoError(t, q.push(queuedEvent{event: types.Event{ResourceID: "i-2", RawEvent: func() {}}}))
+
+	got, ok, err := q.pop()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "ModifyInstanceAttribute", got.event.EventName)
+	assert.Equal(t, "t3.large", got.event.Changes["instance_type"])
+	assert.True(t, queuedAt.Equal(got.queuedAt))
+	raw, isFalco := got.event.RawEvent.(*outputs.Response)
+	require.True(t, isFalco, "falco responses decode back to their type")
+	assert.Equal(t, "aws:event:abc", eventKey(&got.event))
+	assert.Equal(t, "aws_cloudtrail", raw.Source)
+
+	got, ok, err = q.pop()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "i-2", got.event.ResourceID)
+	assert.Nil(t, got.event.RawEvent, "unserializable raw events are dropped, the event is kept")
+
+	_, ok, err = q.pop()
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	info, err := q.file.Stat()
+	require.NoError(t, err)
+	assert.Zero(t, info.Size(), "drained spill file is truncated")
+}
@@ -0,0 +1,120 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/falcosecurity/client-go/pkg/api/outputs"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+)
+
+// spillRecord is an event as written to a spill file. Falco responses are
+// kept apart so they decode back into *outputs.Response, which event time
+// and dedup lookups depend on.
+type spillRecord struct {
+	Event    types.Event       `json:"event"`
+	Falco    *outputs.Response `json:"falco,omitempty"`
+	QueuedAt time.Time         `json:"queued_at"`
+}
+
+// spillQueue is a FIFO of events on disk for a worker whose queue is full.
+// Records are appended to one file and read back in order; the file is
+// truncated whenever the queue drains.
+type spillQueue struct {
+	file    *os.File
+	sizes   []int64 // sizes of the unread records, oldest first
+	readAt  int64
+	writeAt int64
+	mu      sync.Mutex
+}
+
+// newSpillQueue creates an empty spill file in dir ("" = temp directory)
+func newSpillQueue(dir, name string) (*spillQueue, error) {
+	file, err := os.CreateTemp(dir, name+"-*.spill")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill file: %w", err)
+	}
+	return &spillQueue{file: file}, nil
+}
+
+// push appends an event
+func (q *spillQueue) push(item queuedEvent) error {
+	rec := spillRecord{Event: item.event, QueuedAt: item.queuedAt}
+	if raw, ok := item.event.RawEvent.(*outputs.Response); ok {
+		rec.Falco = raw
+		rec.Event.RawEvent = nil
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		// Keep the parsed event even if the raw payload isn't serializable
+		rec.Event.RawEvent = nil
+		if data, err = json.Marshal(rec); err != nil {
+			return fmt.Errorf("failed to encode spilled event: %w", err)
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, err := q.file.WriteAt(data, q.writeAt); err != nil {
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	q.writeAt += int64(len(data))
+	q.sizes = append(q.sizes, int64(len(data)))
+	return nil
+}
+
+// pop removes and returns the oldest event. ok is false when the queue is
+// empty; a record that can't be read back is returned as an error.
+func (q *spillQueue) pop() (item queuedEvent, ok bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.sizes) == 0 {
+		return queuedEvent{}, false, nil
+	}
+
+	size := q.sizes[0]
+	data := make([]byte, size)
+	_, readErr := q.file.ReadAt(data, q.readAt)
+	q.sizes = q.sizes[1:]
+	q.readAt += size
+	if len(q.sizes) == 0 {
+		// Drained: reuse the file from the start. Truncating only reclaims
+		// disk space; later writes overwrite from offset 0 either way.
+		q.sizes = nil
+		q.readAt, q.writeAt = 0, 0
+		_ = q.file.Truncate(0)
+	}
+	if readErr != nil {
+		return queuedEvent{}, false, fmt.Errorf("failed to read spill file: %w", readErr)
+	}
+
+	var rec spillRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return queuedEvent{}, false, fmt.Errorf("failed to decode spilled event: %w", err)
+	}
+	if rec.Falco != nil {
+		rec.Event.RawEvent = rec.Falco
+	}
+	return queuedEvent{event: rec.Event, queuedAt: rec.QueuedAt}, true, nil
+}
+
+// len returns the number of spilled events not yet read
+func (q *spillQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.sizes)
+}
+
+// close removes the spill file. Events still in it are lost.
+func (q *spillQueue) close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	name := q.file.Name()
+	if err := q.file.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/keitahigaki/tfdrift-falco/pkg/api/broadcaster"
 	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -66,3 +70,141 @@ func TestRefreshAllState_PicksUpAppliedChanges(t *testing.T) {
 	_, exists := det.GetStateManager().GetResource("i-bbb")
 	assert.True(t, exists, "the newly applied resource must be known after refresh")
 }
+
+func TestRefreshAllState_BroadcastsStateChange(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "terraform.tfstate")
+	require.NoError(t, os.WriteFile(statePath, []byte(stateOneResource), 0o600))
+
+	cfg := &config.Config{}
+	cfg.Providers.AWS.Enabled = true
+	cfg.Providers.AWS.Regions = []string{"ap-northeast-1"}
+	cfg.Providers.AWS.State.Backend = "local"
+	cfg.Providers.AWS.State.LocalPath = statePath
+	cfg.Falco.Enabled = false
+
+	det, err := New(cfg)
+	require.NoError(t, err)
+	bc := broadcaster.NewBroadcaster()
+	events := make(chan broadcaster.Event, 4)
+	bc.Subscribe(events)
+	det.SetBroadcaster(bc)
+
+	ctx := context.Background()
+	require.NoError(t, det.GetStateManager().Load(ctx))
+
+	// Nothing changed: no event
+	det.refreshAllState(ctx)
+	assert.Empty(t, events)
+
+	require.NoError(t, os.WriteFile(statePath, []byte(stateTwoResources), 0o600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(statePath, later, later))
+	det.refreshAllState(ctx)
+
+	select {
+	case ev := <-events:
+		assert.Equal(t, "state_change", ev.Type)
+		assert.Equal(t, "aws", ev.Payload["provider"])
+		assert.Equal(t, 1, ev.Payload["previous_serial"])
+		assert.Equal(t, 2, ev.Payload["serial"])
+		assert.Equal(t, []string{"aws_instance.b"}, ev.Payload["added"])
+		assert.Equal(t, []string{}, ev.Payload["removed"])
+		assert.Equal(t, []string{}, ev.Payload["changed"])
+	case <-time.After(time.Second):
+		t.Fatal("no state_change event broadcast")
+	}
+}
+
+// TestHandleEvent_ComparesWithStateAtEventTime: an event delivered after an
+// apply is compared with the state that was current when it happened
+func TestHandleEvent_ComparesWithStateAtEventTime(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "terraform.tfstate")
+	applied := time.Now().Add(-10 * time.Minute).UTC().Truncate(time.Second)
+	write := func(content string, modified time.Time) {
+		require.NoError(t, os.WriteFile(statePath, []byte(content), 0o600))
+		require.NoError(t, os.Chtimes(statePath, modified, modified))
+	}
+	write(stateOneResource, applied.Add(-time.Hour))
+
+	cfg := &config.Config{}
+	cfg.Providers.AWS.Enabled = true
+	cfg.Providers.AWS.Regions = []string{"ap-northeast-1"}
+	cfg.Providers.AWS.State.Backend = "local"
+	cfg.Providers.AWS.State.LocalPath = statePath
+	cfg.Dedup.Disabled = true
+
+	det, err := New(cfg)
+	require.NoError(t, err)
+	spy := &spyNotifier{}
+	det.notifier = spy
+	ctx := context.Background()
+	require.NoError(t, det.loadAllState(ctx))
+
+	// The apply changes the instance type to t3.small
+	resized := strings.NewReplacer(`"serial": 1`, `"serial": 2`, "t3.micro", "t3.small").Replace(stateOneResource)
+	write(resized, applied)
+	det.refreshAllState(ctx)
+
+	// A manual resize before the apply, delivered late, is still drift
+	before := modifyEvent("i-aaa", map[string]interface{}{"instance_type": "t3.small"})
+	before.RawEvent = map[string]interface{}{"eventTime": applied.Add(-5 * time.Minute).Format(time.RFC3339)}
+	det.handleEvent(before)
+	require.Len(t, spy.sent, 1)
+	assert.Equal(t, "t3.micro", spy.sent[0].OldValue)
+
+	// The same change after the apply matches the new state
+	after := modifyEvent("i-aaa", map[string]interface{}{"instance_type": "t3.small"})
+	after.RawEvent = map[string]interface{}{"eventTime": applied.Add(5 * time.Minute).Format(time.RFC3339)}
+	det.handleEvent(after)
+	assert.Len(t, spy.sent, 1)
+}
+
+// TestHandleEvent_DelayedEventUsesStateOfItsTime: an event dated before the
+// last state load is compared with the state that was current at its time,
+// not with the state written since
+func TestHandleEvent_DelayedEventUsesStateOfItsTime(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "terraform.tfstate")
+	base := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	write := func(data string, at time.Time) {
+		require.NoError(t, os.WriteFile(statePath, []byte(data), 0o600))
+		require.NoError(t, os.Chtimes(statePath, at, at))
+	}
+
+	cfg := &config.Config{}
+	cfg.Providers.AWS.Enabled = true
+	cfg.Providers.AWS.Regions = []string{"ap-northeast-1"}
+	cfg.Providers.AWS.State.Backend = "local"
+	cfg.Providers.AWS.State.LocalPath = statePath
+	cfg.Dedup.Disabled = true
+
+	det, err := New(cfg)
+	require.NoError(t, err)
+	spy := &spyNotifier{}
+	det.notifier = spy
+	ctx := context.Background()
+
+	write(stateOneResource, base)
+	require.NoError(t, det.loadAllState(ctx))
+	// An apply at 11:00 resizes i-aaa
+	resized := strings.Replace(strings.Replace(stateOneResource, `"t3.micro"`, `"t3.large"`, 1), `"serial": 1`, `"serial": 2`, 1)
+	write(resized, base.Add(time.Hour))
+	det.refreshAllState(ctx)
+
+	eventAt := func(at time.Time) types.Event {
+		event := modifyEvent("i-aaa", map[string]interface{}{"instance_type": "t3.large"})
+		event.RawEvent = map[string]interface{}{"eventTime": at.Format(time.RFC3339)}
+		return event
+	}
+
+	// Made at 10:30, delivered after the apply: drift against the 10:00 state
+	det.handleEvent(eventAt(base.Add(30 * time.Minute)))
+	require.Len(t, spy.sent, 1)
+	assert.Equal(t, "t3.micro", spy.sent[0].OldValue)
+
+	// Made after the apply: matches the current state
+	det.handleEvent(eventAt(base.Add(90 * time.Minute)))
+	assert.Len(t, spy.sent, 1)
+}
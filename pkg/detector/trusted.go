@@ -0,0 +1,79 @@
+package detector
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// stateRefresher coalesces state refresh requests per provider. Requests
+// made while a refresh is queued or running cause one more refresh after
+// it, so an apply's burst of API calls re-reads the state a couple of times
+// rather than once per event, and the last read sees the state it wrote.
+type stateRefresher struct {
+	mu      sync.Mutex
+	pending map[string]bool
+	wake    chan struct{}
+}
+
+func newStateRefresher() *stateRefresher {
+	return &stateRefresher{
+		pending: make(map[string]bool),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// request queues a refresh of the provider's state without blocking
+func (r *stateRefresher) request(providerName string) {
+	r.mu.Lock()
+	r.pending[providerName] = true
+	r.mu.Unlock()
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run refreshes queued providers until the context is cancelled
+func (r *stateRefresher) run(ctx context.Context, refresh func(ctx context.Context, providerName string)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake:
+		}
+
+		r.mu.Lock()
+		providers := make([]string, 0, len(r.pending))
+		for name := range r.pending {
+			providers = append(providers, name)
+		}
+		r.pending = make(map[string]bool)
+		r.mu.Unlock()
+
+		sort.Strings(providers)
+		for _, name := range providers {
+			refresh(ctx, name)
+		}
+	}
+}
+
+// refreshProviderState re-reads one provider's Terraform state, so the
+// resources a trusted agent just changed are compared against what it wrote
+func (d *Detector) refreshProviderState(ctx context.Context, providerName string) {
+	sm := d.stateManagerFor(providerName)
+	if sm == nil {
+		return
+	}
+	if err := sm.Refresh(ctx); err != nil {
+		log.Warnf("State refresh after trusted change failed for provider %s: %v", providerName, err)
+		return
+	}
+	if d.graphStore != nil {
+		d.graphStore.RebuildGraphDB()
+	}
+	log.Debugf("Terraform state for %s refreshed after trusted change", providerName)
+}
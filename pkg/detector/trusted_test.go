@@ -0,0 +1,101 @@
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleEvent_TrustedAgentRefreshesState: an apply by the CI role
+// creates i-bbb. Its event raises no unmanaged alert; the state is re-read
+// and the resource is managed afterwards.
+func TestHandleEvent_TrustedAgentRefreshesState(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "terraform.tfstate")
+	require.NoError(t, os.WriteFile(statePath, []byte(stateOneResource), 0o600))
+
+	cfg := &config.Config{}
+	cfg.Providers.AWS.Enabled = true
+	cfg.Providers.AWS.Regions = []string{"ap-northeast-1"}
+	cfg.Providers.AWS.State.Backend = "local"
+	cfg.Providers.AWS.State.LocalPath = statePath
+	cfg.TrustedAgents.AWSRoleARNs = []string{"arn:aws:iam::123456789012:role/terraform-ci"}
+	cfg.Dedup.Disabled = true
+
+	det, err := New(cfg)
+	require.NoError(t, err)
+	spy := &spyNotifier{}
+	det.notifier = spy
+	ctx := context.Background()
+	require.NoError(t, det.loadAllState(ctx))
+
+	require.NoError(t, os.WriteFile(statePath, []byte(stateTwoResources), 0o600))
+	event := modifyEvent("i-bbb", map[string]interface{}{"instance_type": "t3.small"})
+	event.UserIdentity = types.UserIdentity{
+		Type: "AssumedRole",
+		ARN:  "arn:aws:sts::123456789012:assumed-role/terraform-ci/GitHubActions",
+	}
+	det.handleEvent(event)
+	assert.Empty(t, spy.sent, "a trusted agent's change is not drift")
+	assert.True(t, det.refresher.pending["aws"], "the change queues a refresh of the AWS state")
+
+	det.refreshProviderState(ctx, "aws")
+	_, managed := det.GetStateManager().GetResource("i-bbb")
+	assert.True(t, managed, "the refresh picks up what the apply wrote")
+
+	// Anyone else changing the same resource is still drift
+	other := modifyEvent("i-bbb", map[string]interface{}{"instance_type": "t3.large"})
+	// ...after the apply: events are compared with the state of their time
+	other.RawEvent = map[string]interface{}{"eventTime": time.Now().UTC().Add(time.Minute).Format(time.RFC3339)}
+	det.handleEvent(other)
+	require.Len(t, spy.sent, 1)
+	assert.Equal(t, "instance_type", spy.sent[0].Attribute)
+}
+
+func TestStateRefresher_CoalescesRequests(t *testing.T) {
+	r := newStateRefresher()
+	var mu sync.Mutex
+	var refreshed []string
+	done := make(chan struct{}, 8)
+
+	r.request("aws")
+	r.request("gcp")
+	r.request("aws")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.run(ctx, func(_ context.Context, name string) {
+		mu.Lock()
+		refreshed = append(refreshed, name)
+		mu.Unlock()
+		done <- struct{}{}
+	})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("refresh never ran")
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, []string{"aws", "gcp"}, refreshed, "queued requests run once per provider")
+	mu.Unlock()
+
+	// A request after the batch runs again
+	r.request("aws")
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("second refresh never ran")
+	}
+}
@@ -0,0 +1,277 @@
+package detector
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/comparator"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/provider"
+	"github.com/keitahigaki/tfdrift-falco/pkg/telemetry"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultVerifyDebounce is how long a resource must be quiet before it is read back
+	DefaultVerifyDebounce = 5 * time.Second
+	// DefaultVerifyTimeout bounds one cloud API read
+	DefaultVerifyTimeout = 30 * time.Second
+)
+
+// pendingVerify is a resource waiting for its debounce timer. Changes of
+// every event in the burst are merged; the latest event is reported.
+type pendingVerify struct {
+	timer    *time.Timer
+	resource *terraform.Resource
+	event    types.Event
+}
+
+// verifier debounces live verification per resource, so a burst of events
+// for one resource (a rule added per API call, several tags) costs one read
+type verifier struct {
+	debounce time.Duration
+	timeout  time.Duration
+	verify   func(resource *terraform.Resource, event types.Event)
+
+	mu      sync.Mutex
+	pending map[string]*pendingVerify
+	stopped bool
+}
+
+func newVerifier(cfg config.VerifyConfig, verify func(*terraform.Resource, types.Event)) *verifier {
+	v := &verifier{
+		debounce: DefaultVerifyDebounce,
+		timeout:  DefaultVerifyTimeout,
+		verify:   verify,
+		pending:  make(map[string]*pendingVerify),
+	}
+	if cfg.DebounceSeconds > 0 {
+		v.debounce = time.Duration(cfg.DebounceSeconds) * time.Second
+	}
+	if cfg.TimeoutSeconds > 0 {
+		v.timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	return v
+}
+
+// schedule (re)arms the resource's timer, merging the event into the
+// pending burst
+func (v *verifier) schedule(resource *terraform.Resource, event types.Event) {
+	// Events naming the resource by different keys are one burst
+	key := event.Provider + ":" + stateResourceID(resource, &event)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.stopped {
+		return
+	}
+
+	if p, ok := v.pending[key]; ok {
+		p.timer.Stop()
+		merged := make(map[string]interface{}, len(p.event.Changes)+len(event.Changes))
+		for k, val := range p.event.Changes {
+			merged[k] = val
+		}
+		for k, val := range event.Changes {
+			merged[k] = val
+		}
+		event.Changes = merged
+	}
+	p := &pendingVerify{resource: resource, event: event}
+	p.timer = time.AfterFunc(v.debounce, func() { v.fire(key, p) })
+	v.pending[key] = p
+}
+
+// fire verifies a resource whose burst has ended
+func (v *verifier) fire(key string, p *pendingVerify) {
+	v.mu.Lock()
+	if v.pending[key] != p {
+		// Re-armed by a newer event while this timer was firing
+		v.mu.Unlock()
+		return
+	}
+	delete(v.pending, key)
+	v.mu.Unlock()
+
+	v.verify(p.resource, p.event)
+}
+
+// stop cancels pending verifications. Reads already in flight complete.
+func (v *verifier) stop() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.stopped = true
+	for key, p := range v.pending {
+		p.timer.Stop()
+		delete(v.pending, key)
+	}
+}
+
+// shouldVerify reports whether an event on a managed resource is verified
+// live: a mutating event, on a type its provider can fetch
+func (d *Detector) shouldVerify(event *types.Event, resource *terraform.Resource) bool {
+	if d.verifier == nil || d.providerRegistry == nil || !isMutatingEvent(event.EventName) {
+		return false
+	}
+	if _, ok := d.providerRegistry.GetFetcher(event.Provider); !ok {
+		return false
+	}
+	if _, ok := d.providerRegistry.GetComparator(event.Provider); !ok {
+		return false
+	}
+	discoverer, ok := d.providerRegistry.GetDiscoverer(event.Provider)
+	if !ok {
+		return false
+	}
+	for _, t := range discoverer.SupportedDiscoveryTypes() {
+		if t == resource.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyResource reads the resource from the cloud API and alerts on every
+// attribute that differs from Terraform state. The resource is read by its
+// state ID, since the event may name it by another key (an ARN, a name). If
+// the read fails or the resource is gone, the event's own changes are
+// reported as without verification.
+func (d *Detector) verifyResource(resource *terraform.Resource, event types.Event) {
+	resourceID := stateResourceID(resource, &event)
+
+	ctx, span := telemetry.StartSpan(context.Background(), "detector.verify_resource",
+		trace.WithAttributes(
+			telemetry.AttrProvider.String(event.Provider),
+			telemetry.AttrEventName.String(event.EventName),
+			telemetry.AttrResourceType.String(resource.Type),
+			telemetry.AttrResourceID.String(resourceID),
+		),
+	)
+	defer span.End()
+
+	fetcher, _ := d.providerRegistry.GetFetcher(event.Provider)
+	fetchCtx, cancel := context.WithTimeout(ctx, d.verifier.timeout)
+	live, err := fetcher.FetchResource(fetchCtx, resource.Type, resourceID, provider.FetchOptions{
+		Region:  eventRegion(&event),
+		Project: eventProject(&event),
+	})
+	cancel()
+	if err != nil {
+		log.Warnf("Live verification of %s failed, reporting the event's changes: %v", resourceID, err)
+		span.AddEvent("verify_failed", trace.WithAttributes(attribute.String("error", err.Error())))
+		d.reportEventDrift(ctx, span, resource, &event)
+		return
+	}
+	if live == nil {
+		log.Debugf("Live verification: %s no longer exists, reporting the event's changes", resourceID)
+		span.AddEvent("verify_not_found")
+		d.reportEventDrift(ctx, span, resource, &event)
+		return
+	}
+
+	drifts := d.liveDrifts(resource, live, &event)
+	span.SetAttributes(attribute.Int("drift_count", len(drifts)))
+	if len(drifts) == 0 {
+		if len(event.Changes) == 0 {
+			// The live read covers only some attributes; with nothing
+			// extracted from the event the change may be in one it doesn't
+			log.Debugf("Live verification found no drift on %s; the change is outside the compared attributes", resourceID)
+			d.sendCoarseDriftAlert(ctx, resource, &event)
+		} else {
+			log.Infof("Live verification: %s matches Terraform state, no drift", resourceID)
+		}
+		telemetry.SetOK(span)
+		return
+	}
+
+	span.AddEvent("drift_detected", trace.WithAttributes(
+		telemetry.AttrDriftCount.Int(len(drifts)),
+	))
+	for _, drift := range drifts {
+		d.raiseDriftAlert(ctx, span, resource, &event, drift)
+	}
+	telemetry.SetOK(span)
+}
+
+// stateResourceID returns the resource's ID in Terraform state, or the
+// event's resource ID when the state has none
+func stateResourceID(resource *terraform.Resource, event *types.Event) string {
+	if id, ok := resource.Attributes["id"].(string); ok && id != "" {
+		return id
+	}
+	return event.ResourceID
+}
+
+// liveDrifts compares the live resource with Terraform state using the
+// provider's comparator. Event changes to attributes the comparator doesn't
+// cover are checked against the live value when the read includes it, and
+// reported as extracted from the event otherwise.
+func (d *Detector) liveDrifts(resource *terraform.Resource, live *types.DiscoveredResource, event *types.Event) []AttributeDrift {
+	cmp, _ := d.providerRegistry.GetComparator(event.Provider)
+
+	// The fetcher already resolved the state resource to this cloud
+	// resource; align the IDs so the comparator pairs them
+	attrs := make(map[string]interface{}, len(resource.Attributes)+1)
+	for k, v := range resource.Attributes {
+		attrs[k] = v
+	}
+	attrs["id"] = live.ID
+	result := cmp.CompareState([]*types.TerraformResource{{
+		Type:       resource.Type,
+		Name:       resource.Name,
+		ID:         live.ID,
+		Provider:   event.Provider,
+		Attributes: attrs,
+	}}, []*types.DiscoveredResource{live}, provider.CompareOptions{})
+
+	var drifts []AttributeDrift
+	compared := make(map[string]bool)
+	for _, diff := range result.ModifiedResources {
+		for _, fd := range diff.Differences {
+			compared[fd.Field] = true
+			drifts = append(drifts, AttributeDrift{Attribute: fd.Field, OldValue: fd.TerraformValue, NewValue: fd.ActualValue})
+		}
+	}
+
+	keys := make([]string, 0, len(event.Changes))
+	for key := range event.Changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if compared[key] {
+			continue
+		}
+		oldValue := resource.Attributes[key]
+		if liveValue, ok := live.Attributes[key]; ok {
+			if !comparator.ValuesEqual(oldValue, liveValue) {
+				drifts = append(drifts, AttributeDrift{Attribute: key, OldValue: oldValue, NewValue: liveValue})
+			}
+			continue
+		}
+		drifts = append(drifts, d.detectDrifts(resource, map[string]interface{}{key: event.Changes[key]})...)
+	}
+	return drifts
+}
+
+// eventRegion returns the region an event happened in, if known
+func eventRegion(event *types.Event) string {
+	if region := event.GetMetadata("region"); region != "" {
+		return region
+	}
+	return event.Region
+}
+
+// eventProject returns the GCP project of an event, if known
+func eventProject(event *types.Event) string {
+	if project := event.GetMetadata("project_id"); project != "" {
+		return project
+	}
+	return event.ProjectID
+}
@@ -0,0 +1,209 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/provider"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fetchingAWSProvider is the AWS provider with FetchResource answered from
+// a fixed live resource
+type fetchingAWSProvider struct {
+	*provider.AWSProvider
+	mu      sync.Mutex
+	live    *types.DiscoveredResource
+	err     error
+	fetches []provider.FetchOptions
+	ids     []string
+}
+
+func (p *fetchingAWSProvider) FetchResource(ctx context.Context, resourceType, resourceID string, opts provider.FetchOptions) (*types.DiscoveredResource, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fetches = append(p.fetches, opts)
+	p.ids = append(p.ids, resourceID)
+	return p.live, p.err
+}
+
+func (p *fetchingAWSProvider) fetchCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.fetches)
+}
+
+// newVerifyingDetector is newTestDetector with live verification through fp
+func newVerifyingDetector(t *testing.T, attrs map[string]interface{}, fp *fetchingAWSProvider) (*Detector, *syncSpy) {
+	t.Helper()
+	d, _ := newTestDetector(t, nil, attrs)
+	spy := &syncSpy{}
+	d.notifier = spy
+
+	fp.AWSProvider = provider.NewAWSProvider()
+	d.providerRegistry = provider.NewRegistry()
+	require.NoError(t, d.providerRegistry.Register(fp))
+
+	d.verifier = newVerifier(config.VerifyConfig{}, d.verifyResource)
+	d.verifier.debounce = 20 * time.Millisecond
+	t.Cleanup(d.verifier.stop)
+	return d, spy
+}
+
+// syncSpy is spyNotifier for alerts sent from verifier timers
+type syncSpy struct {
+	mu   sync.Mutex
+	sent []*types.DriftAlert
+}
+
+func (s *syncSpy) Send(a *types.DriftAlert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, a)
+	return nil
+}
+
+func (s *syncSpy) alerts() []*types.DriftAlert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*types.DriftAlert(nil), s.sent...)
+}
+
+func liveInstance(attrs map[string]interface{}) *types.DiscoveredResource {
+	return &types.DiscoveredResource{ID: "i-123", Type: "aws_instance", Provider: "aws", Attributes: attrs, Tags: map[string]string{}}
+}
+
+func TestVerify_ReplacesCoarseAlertWithFieldDiffs(t *testing.T) {
+	fp := &fetchingAWSProvider{live: liveInstance(map[string]interface{}{
+		"instance_type": "t3.large", "subnet_id": "subnet-1", "vpc_id": "vpc-1", "availability_zone": "us-east-1a",
+	})}
+	d, spy := newVerifyingDetector(t, map[string]interface{}{
+		"id": "i-123", "instance_type": "t2.micro", "subnet_id": "subnet-1", "vpc_id": "vpc-1", "availability_zone": "us-east-1a",
+	}, fp)
+
+	// A burst of events with nothing extractable is read back once
+	for i := 0; i < 3; i++ {
+		event := modifyEvent("i-123", nil)
+		event.SetMetadata("region", "eu-west-1")
+		d.handleEvent(event)
+	}
+	assert.Empty(t, spy.alerts(), "nothing is reported before the burst ends")
+
+	require.Eventually(t, func() bool { return len(spy.alerts()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, fp.fetchCount(), "a burst of events is verified once")
+	assert.Equal(t, "eu-west-1", fp.fetches[0].Region)
+
+	alert := spy.alerts()[0]
+	assert.Equal(t, "instance_type", alert.Attribute)
+	assert.Equal(t, "t2.micro", alert.OldValue)
+	assert.Equal(t, "t3.large", alert.NewValue)
+	assert.Equal(t, "alice", alert.UserIdentity.UserName)
+}
+
+func TestVerify_EventChangesOutsideLiveRead(t *testing.T) {
+	fp := &fetchingAWSProvider{live: liveInstance(map[string]interface{}{
+		"instance_type": "t2.micro", "state": "running",
+	})}
+	d, spy := newVerifyingDetector(t, map[string]interface{}{"id": "i-123", "instance_type": "t2.micro"}, fp)
+
+	// instance_type was changed and changed back: live matches state. The
+	// termination flag isn't in the live read, so the event's value stands.
+	d.handleEvent(modifyEvent("i-123", map[string]interface{}{"instance_type": "t3.large"}))
+	d.handleEvent(modifyEvent("i-123", map[string]interface{}{"disable_api_termination": true}))
+
+	require.Eventually(t, func() bool { return len(spy.alerts()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, spy.alerts(), 1)
+	assert.Equal(t, "disable_api_termination", spy.alerts()[0].Attribute)
+	assert.Equal(t, true, spy.alerts()[0].NewValue)
+}
+
+func TestVerify_NoDriftWhenLiveMatchesState(t *testing.T) {
+	fp := &fetchingAWSProvider{live: liveInstance(map[string]interface{}{"instance_type": "t2.micro"})}
+	d, spy := newVerifyingDetector(t, map[string]interface{}{"id": "i-123", "instance_type": "t2.micro"}, fp)
+
+	d.handleEvent(modifyEvent("i-123", map[string]interface{}{"instance_type": "t3.large"}))
+
+	require.Eventually(t, func() bool { return fp.fetchCount() == 1 }, 2*time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, spy.alerts(), "a change that was reverted before the read is not drift")
+}
+
+func TestVerify_FetchesByStateID(t *testing.T) {
+	fp := &fetchingAWSProvider{live: liveInstance(map[string]interface{}{"instance_type": "t2.micro"})}
+	const arn = "arn:aws:ec2:us-east-1:123456789012:instance/i-123"
+	d, _ := newVerifyingDetector(t, map[string]interface{}{"id": "i-123", "arn": arn, "instance_type": "t2.micro"}, fp)
+
+	// The event names the instance by its ARN, a secondary key
+	d.handleEvent(modifyEvent(arn, map[string]interface{}{"instance_type": "t3.large"}))
+
+	require.Eventually(t, func() bool { return fp.fetchCount() == 1 }, 2*time.Second, 5*time.Millisecond)
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	assert.Equal(t, []string{"i-123"}, fp.ids, "the live read uses the state's canonical ID")
+}
+
+func TestVerify_FallsBackToEventOnFetchFailure(t *testing.T) {
+	for name, fp := range map[string]*fetchingAWSProvider{
+		"api error": {err: errors.New("UnauthorizedOperation")},
+		"not found": {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			d, spy := newVerifyingDetector(t, map[string]interface{}{"id": "i-123", "instance_type": "t2.micro"}, fp)
+
+			d.handleEvent(modifyEvent("i-123", nil))
+
+			require.Eventually(t, func() bool { return len(spy.alerts()) == 1 }, 2*time.Second, 5*time.Millisecond)
+			assert.Equal(t, "(resource modified out-of-band)", spy.alerts()[0].Attribute)
+		})
+	}
+}
+
+func TestVerify_OnlyDiscoverableTypes(t *testing.T) {
+	fp := &fetchingAWSProvider{}
+	d, _ := newVerifyingDetector(t, nil, fp)
+
+	assert.True(t, d.shouldVerify(&types.Event{Provider: "aws", EventName: "ModifyInstanceAttribute"}, &terraform.Resource{Type: "aws_instance"}))
+	assert.False(t, d.shouldVerify(&types.Event{Provider: "aws", EventName: "PutRolePolicy"}, &terraform.Resource{Type: "aws_iam_role"}))
+	assert.False(t, d.shouldVerify(&types.Event{Provider: "aws", EventName: "DescribeInstances"}, &terraform.Resource{Type: "aws_instance"}))
+	assert.False(t, d.shouldVerify(&types.Event{Provider: "gcp", EventName: "compute.instances.setMachineType"}, &terraform.Resource{Type: "google_compute_instance"}))
+
+	d.verifier = nil
+	assert.False(t, d.shouldVerify(&types.Event{Provider: "aws", EventName: "ModifyInstanceAttribute"}, &terraform.Resource{Type: "aws_instance"}))
+}
+
+func TestVerifier_MergesBurstAndStops(t *testing.T) {
+	verified := make(chan types.Event, 4)
+	v := newVerifier(config.VerifyConfig{DebounceSeconds: 7, TimeoutSeconds: 9}, func(_ *terraform.Resource, event types.Event) {
+		verified <- event
+	})
+	assert.Equal(t, 7*time.Second, v.debounce)
+	assert.Equal(t, 9*time.Second, v.timeout)
+	v.debounce = 20 * time.Millisecond
+
+	resource := &terraform.Resource{Type: "aws_security_group"}
+	v.schedule(resource, types.Event{Provider: "aws", ResourceID: "sg-1", EventName: "A", Changes: map[string]interface{}{"a": 1, "b": 1}})
+	v.schedule(resource, types.Event{Provider: "aws", ResourceID: "sg-1", EventName: "B", Changes: map[string]interface{}{"b": 2}})
+
+	select {
+	case event := <-verified:
+		assert.Equal(t, "B", event.EventName, "the latest event is reported")
+		assert.Equal(t, map[string]interface{}{"a": 1, "b": 2}, event.Changes)
+	case <-time.After(2 * time.Second):
+		t.Fatal("verification never ran")
+	}
+
+	v.schedule(resource, types.Event{Provider: "aws", ResourceID: "sg-2"})
+	v.stop()
+	v.schedule(resource, types.Event{Provider: "aws", ResourceID: "sg-3"})
+	time.Sleep(60 * time.Millisecond)
+	assert.Empty(t, verified, "stopped verifier runs nothing")
+}
@@ -10,12 +10,13 @@ import (
 
 // FormatConsole formats the drift for console output with colors.
 func (f *Formatter) FormatConsole(alert *types.DriftAlert) string {
+	alert = alert.Redacted()
 	var b strings.Builder
 
 	// Header
 	severityColor := f.getSeverityColor(alert.Severity)
 	b.WriteString(f.color(severityColor, "\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"))
-	b.WriteString(f.color(ColorBold, fmt.Sprintf("🚨 DRIFT DETECTED: %s.%s\n", alert.ResourceType, alert.ResourceName)))
+	b.WriteString(f.color(ColorBold, fmt.Sprintf("🚨 DRIFT DETECTED: %s\n", alert.Address())))
 	b.WriteString(f.color(severityColor, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n"))
 
 	// Severity
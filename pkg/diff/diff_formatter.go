@@ -9,12 +9,13 @@ import (
 
 // FormatUnifiedDiff formats the drift as a unified diff (Git-style).
 func (f *Formatter) FormatUnifiedDiff(alert *types.DriftAlert) string {
+	alert = alert.Redacted()
 	var b strings.Builder
 
-	b.WriteString(fmt.Sprintf("--- terraform/%s.%s\t(Terraform State)\n",
-		alert.ResourceType, alert.ResourceName))
-	b.WriteString(fmt.Sprintf("+++ runtime/%s.%s\t(Actual Configuration)\n",
-		alert.ResourceType, alert.ResourceName))
+	b.WriteString(fmt.Sprintf("--- terraform/%s\t(Terraform State)\n",
+		alert.Address()))
+	b.WriteString(fmt.Sprintf("+++ runtime/%s\t(Actual Configuration)\n",
+		alert.Address()))
 	b.WriteString("@@ -1,1 +1,1 @@\n")
 
 	oldStr := f.formatValue(alert.OldValue)
@@ -33,6 +34,7 @@ func (f *Formatter) FormatUnifiedDiff(alert *types.DriftAlert) string {
 
 // FormatSideBySide formats the drift as side-by-side comparison.
 func (f *Formatter) FormatSideBySide(alert *types.DriftAlert) string {
+	alert = alert.Redacted()
 	var b strings.Builder
 
 	oldStr := f.formatValue(alert.OldValue)
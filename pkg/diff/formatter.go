@@ -1,6 +1,7 @@
 package diff
 
-// Formatter formats drift differences in various output formats.
+// Formatter formats drift differences in various output formats. Values of
+// sensitive alerts are always shown redacted.
 type Formatter struct {
 	colorEnabled bool
 }
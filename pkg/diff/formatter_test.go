@@ -390,6 +390,34 @@ func TestFormatSideBySide(t *testing.T) {
 	assert.Contains(t, result, "│") // Column separator
 }
 
+func TestFormatters_RedactSensitiveValues(t *testing.T) {
+	formatter := NewFormatter(false)
+
+	alert := &types.DriftAlert{
+		Severity:     "high",
+		ResourceType: "aws_db_instance",
+		ResourceName: "main",
+		Attribute:    "password",
+		OldValue:     "old-secret",
+		NewValue:     "new-secret",
+		Sensitive:    true,
+	}
+
+	jsonOut, err := formatter.FormatJSON(alert)
+	require.NoError(t, err)
+
+	for name, out := range map[string]string{
+		"console":      formatter.FormatConsole(alert),
+		"unified":      formatter.FormatUnifiedDiff(alert),
+		"side-by-side": formatter.FormatSideBySide(alert),
+		"markdown":     formatter.FormatMarkdown(alert),
+		"json":         jsonOut,
+	} {
+		assert.NotContains(t, out, "secret", name)
+		assert.Contains(t, out, types.RedactedValue, name)
+	}
+}
+
 func TestFormatSideBySide_ComplexValues(t *testing.T) {
 	formatter := NewFormatter(false)
 
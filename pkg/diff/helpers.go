@@ -135,8 +135,8 @@ func (f *Formatter) formatRecommendations(alert *types.DriftAlert) string {
 	b.WriteString("  3. Update Terraform code if the change is intentional:\n")
 	b.WriteString(f.color(ColorCyan, "     terraform plan && terraform apply\n"))
 	b.WriteString("  4. Or revert the manual change to match IaC:\n")
-	b.WriteString(f.color(ColorCyan, fmt.Sprintf("     terraform apply -target=%s.%s\n",
-		alert.ResourceType, alert.ResourceName)))
+	b.WriteString(f.color(ColorCyan, fmt.Sprintf("     terraform apply -target=%s\n",
+		alert.ShellAddress())))
 
 	return b.String()
 }
@@ -8,6 +8,7 @@ import (
 
 // FormatJSON formats the drift as JSON.
 func (f *Formatter) FormatJSON(alert *types.DriftAlert) (string, error) {
+	alert = alert.Redacted()
 	// Create a structured diff object
 	diff := map[string]interface{}{
 		"severity":      alert.Severity,
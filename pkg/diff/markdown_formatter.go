@@ -9,10 +9,11 @@ import (
 
 // FormatMarkdown formats the drift for Markdown (GitHub, Slack, etc.).
 func (f *Formatter) FormatMarkdown(alert *types.DriftAlert) string {
+	alert = alert.Redacted()
 	var b strings.Builder
 
 	// Title
-	b.WriteString(fmt.Sprintf("## 🚨 Drift Detected: `%s.%s`\n\n", alert.ResourceType, alert.ResourceName))
+	b.WriteString(fmt.Sprintf("## 🚨 Drift Detected: `%s`\n\n", alert.Address()))
 
 	// Severity Badge
 	severityEmoji := map[string]string{
@@ -54,8 +55,8 @@ func (f *Formatter) FormatMarkdown(alert *types.DriftAlert) string {
 	b.WriteString("### Recommended Actions\n\n")
 	b.WriteString("- [ ] Review change with user\n")
 	b.WriteString("- [ ] Update Terraform code if intentional\n")
-	b.WriteString(fmt.Sprintf("- [ ] Run `terraform apply -target=%s.%s` to revert\n\n",
-		alert.ResourceType, alert.ResourceName))
+	b.WriteString(fmt.Sprintf("- [ ] Run `terraform apply -target=%s` to revert\n\n",
+		alert.ShellAddress()))
 
 	return b.String()
 }
@@ -0,0 +1,578 @@
+package falco
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeRuleFile is a YAML document of change-extraction rules, like the
+// built-in configs/change_rules.yaml
+type ChangeRuleFile struct {
+	Rules []ChangeRule `yaml:"rules"`
+}
+
+// ChangeRule maps the fields of one or more CloudTrail events to the
+// Terraform attributes they change
+type ChangeRule struct {
+	// Events are CloudTrail event names (ct.name)
+	Events []string `yaml:"events"`
+	// Source limits the rule to an event source (ct.src); empty = any
+	Source string `yaml:"source"`
+	// Changes extract one attribute each
+	Changes []AttributeChange `yaml:"changes"`
+}
+
+// AttributeChange extracts one Terraform attribute from an event
+type AttributeChange struct {
+	// Attribute is the Terraform attribute name
+	Attribute string `yaml:"attribute"`
+	// Field is the Falco output field holding the value. ct.request.<key>
+	// and ct.response.<key> fall back to <key> in the ct.request /
+	// ct.response JSON when the plugin doesn't output the field itself.
+	Field string `yaml:"field"`
+	// Path is a JSONPath into the field's JSON value
+	Path string `yaml:"path"`
+	// Transform converts the value, in order
+	Transform transformList `yaml:"transform"`
+	// Value is a constant used instead of a field; null removes the attribute
+	Value interface{} `yaml:"value"`
+
+	path     jsonPath
+	steps    []valueTransform
+	appendTo bool
+	fixed    bool // Value is set, possibly to null
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, noting whether value is given:
+// an explicit null decodes the same as a missing key
+func (c *AttributeChange) UnmarshalYAML(node *yaml.Node) error {
+	type plain AttributeChange
+	if err := node.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "value" {
+			c.fixed = true
+		}
+	}
+	return nil
+}
+
+// transformList accepts a single transform name or a list of them
+type transformList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (t *transformList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = transformList{node.Value}
+		return nil
+	}
+	var names []string
+	if err := node.Decode(&names); err != nil {
+		return err
+	}
+	*t = names
+	return nil
+}
+
+// valueTransform converts an extracted value
+type valueTransform func(interface{}) (interface{}, error)
+
+// listAppend collects the values of an attribute into a list instead of
+// overwriting it. It must be the last transform.
+const listAppend = "list-append"
+
+// valueTransforms are the transforms rules may name. Numbers come out as
+// float64, the way Terraform state attributes are decoded, so they compare
+// equal to the state.
+var valueTransforms = map[string]valueTransform{
+	"json-decode": func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return v, nil
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+			return nil, err
+		}
+		return decoded, nil
+	},
+	"to-int": func(v interface{}) (interface{}, error) {
+		switch val := v.(type) {
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			return float64(n), nil
+		case float64:
+			return math.Trunc(val), nil
+		}
+		return nil, fmt.Errorf("cannot convert %T to int", v)
+	},
+	"to-float": func(v interface{}) (interface{}, error) {
+		switch val := v.(type) {
+		case string:
+			return strconv.ParseFloat(strings.TrimSpace(val), 64)
+		case float64:
+			return val, nil
+		}
+		return nil, fmt.Errorf("cannot convert %T to float", v)
+	},
+	"to-bool": func(v interface{}) (interface{}, error) {
+		switch val := v.(type) {
+		case string:
+			return strconv.ParseBool(strings.TrimSpace(val))
+		case bool:
+			return val, nil
+		}
+		return nil, fmt.Errorf("cannot convert %T to bool", v)
+	},
+	"to-string": func(v interface{}) (interface{}, error) {
+		return stringValue(v), nil
+	},
+	"lowercase": func(v interface{}) (interface{}, error) {
+		if s, ok := v.(string); ok {
+			return strings.ToLower(s), nil
+		}
+		return nil, fmt.Errorf("cannot lowercase %T", v)
+	},
+	"uppercase": func(v interface{}) (interface{}, error) {
+		if s, ok := v.(string); ok {
+			return strings.ToUpper(s), nil
+		}
+		return nil, fmt.Errorf("cannot uppercase %T", v)
+	},
+}
+
+// ChangeRules is a compiled set of change-extraction rules
+type ChangeRules struct {
+	// byEvent holds the rule for each event name and source ("" = any)
+	byEvent map[string]map[string]*ChangeRule
+}
+
+var (
+	builtinRules     *ChangeRules
+	builtinRulesErr  error
+	builtinRulesOnce sync.Once
+)
+
+// BuiltinChangeRules returns the rules embedded from configs/change_rules.yaml
+func BuiltinChangeRules() (*ChangeRules, error) {
+	builtinRulesOnce.Do(func() {
+		data, err := embeddedConfigs.ReadFile("configs/change_rules.yaml")
+		if err != nil {
+			builtinRulesErr = fmt.Errorf("failed to read embedded change rules: %w", err)
+			return
+		}
+		rules := &ChangeRules{byEvent: map[string]map[string]*ChangeRule{}}
+		if err := rules.add(data, "built-in change rules"); err != nil {
+			builtinRulesErr = err
+			return
+		}
+		builtinRules = rules
+	})
+	return builtinRules, builtinRulesErr
+}
+
+// LoadChangeRules returns the built-in rules extended by the rule files at
+// paths, each a YAML file or a directory of them. A loaded rule replaces the
+// rule for the same event and source loaded before it.
+func LoadChangeRules(paths ...string) (*ChangeRules, error) {
+	builtin, err := BuiltinChangeRules()
+	if err != nil {
+		return nil, err
+	}
+	rules := builtin.clone()
+
+	for _, path := range paths {
+		files, err := ruleFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read change rules %s: %w", file, err)
+			}
+			if err := rules.add(data, file); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return rules, nil
+}
+
+// ruleFiles lists the YAML files of path in name order
+func ruleFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change rules: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change rules directory: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if !e.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// clone copies the rule index so added rules don't leak into r
+func (r *ChangeRules) clone() *ChangeRules {
+	c := &ChangeRules{byEvent: make(map[string]map[string]*ChangeRule, len(r.byEvent))}
+	for event, bySource := range r.byEvent {
+		c.byEvent[event] = make(map[string]*ChangeRule, len(bySource))
+		for source, rule := range bySource {
+			c.byEvent[event][source] = rule
+		}
+	}
+	return c
+}
+
+// add parses, validates and indexes a rule file
+func (r *ChangeRules) add(data []byte, name string) error {
+	var file ChangeRuleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse change rules %s: %w", name, err)
+	}
+	for i := range file.Rules {
+		rule := &file.Rules[i]
+		if err := rule.compile(); err != nil {
+			return fmt.Errorf("%s: rule %d: %w", name, i+1, err)
+		}
+		for _, event := range rule.Events {
+			if r.byEvent[event] == nil {
+				r.byEvent[event] = map[string]*ChangeRule{}
+			}
+			r.byEvent[event][rule.Source] = rule
+		}
+	}
+	return nil
+}
+
+// compile validates a rule and prepares its paths and transforms
+func (rule *ChangeRule) compile() error {
+	if len(rule.Events) == 0 {
+		return fmt.Errorf("events is required")
+	}
+	for i := range rule.Changes {
+		c := &rule.Changes[i]
+		if c.Attribute == "" {
+			return fmt.Errorf("change %d: attribute is required", i+1)
+		}
+		if c.fixed {
+			if c.Field != "" || c.Path != "" {
+				return fmt.Errorf("change %s: value cannot be combined with field or path", c.Attribute)
+			}
+		} else if c.Field == "" && c.Path == "" {
+			return fmt.Errorf("change %s: field, path or value is required", c.Attribute)
+		}
+		if c.Path != "" {
+			if c.Field == "" {
+				c.Field = "ct.request"
+			}
+			path, err := parseJSONPath(c.Path)
+			if err != nil {
+				return fmt.Errorf("change %s: %w", c.Attribute, err)
+			}
+			c.path = path
+		}
+		for j, name := range c.Transform {
+			if name == listAppend {
+				if j != len(c.Transform)-1 {
+					return fmt.Errorf("change %s: %s must be the last transform", c.Attribute, listAppend)
+				}
+				c.appendTo = true
+				continue
+			}
+			fn, ok := valueTransforms[name]
+			if !ok {
+				return fmt.Errorf("change %s: unknown transform %q", c.Attribute, name)
+			}
+			c.steps = append(c.steps, fn)
+		}
+	}
+	return nil
+}
+
+// rule returns the rule for an event, preferring one for its source
+func (r *ChangeRules) rule(eventName, eventSource string) *ChangeRule {
+	bySource := r.byEvent[eventName]
+	if rule, ok := bySource[eventSource]; ok && eventSource != "" {
+		return rule
+	}
+	return bySource[""]
+}
+
+// Covers reports whether any rule extracts changes for the event
+func (r *ChangeRules) Covers(eventName string) bool {
+	return len(r.byEvent[eventName]) > 0
+}
+
+// Extract returns the attributes an event changed according to its rule.
+// Attributes whose field is missing or empty, or whose transform fails, are
+// left out.
+func (r *ChangeRules) Extract(eventName, eventSource string, fields map[string]string) map[string]interface{} {
+	changes := make(map[string]interface{})
+	rule := r.rule(eventName, eventSource)
+	if rule == nil {
+		return changes
+	}
+
+	for i := range rule.Changes {
+		c := &rule.Changes[i]
+		value, ok := c.extract(fields)
+		if !ok {
+			continue
+		}
+		if !c.appendTo {
+			changes[c.Attribute] = value
+			continue
+		}
+		list, _ := changes[c.Attribute].([]interface{})
+		if items, isList := value.([]interface{}); isList {
+			list = append(list, items...)
+		} else {
+			list = append(list, value)
+		}
+		changes[c.Attribute] = list
+	}
+	return changes
+}
+
+// extract reads and converts the value of one attribute
+func (c *AttributeChange) extract(fields map[string]string) (interface{}, bool) {
+	if c.fixed {
+		return c.Value, true
+	}
+
+	raw := outputField(fields, c.Field)
+	if raw == "" {
+		return nil, false
+	}
+	var value interface{} = raw
+	if c.path != nil {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+			log.Debugf("Change rule %s: %s is not JSON: %v", c.Attribute, c.Field, err)
+			return nil, false
+		}
+		found, ok := c.path.eval(doc)
+		if !ok || found == nil {
+			return nil, false
+		}
+		value = found
+	}
+
+	for _, step := range c.steps {
+		converted, err := step(value)
+		if err != nil {
+			log.Debugf("Change rule %s: transform failed: %v", c.Attribute, err)
+			return nil, false
+		}
+		value = converted
+	}
+	return value, true
+}
+
+// outputField returns a Falco output field. The cloudtrail plugin only
+// outputs requestParameters and responseElements whole (ct.request,
+// ct.response), so ct.request.<key>[.<key>...] is looked up in that JSON
+// when the field itself is absent.
+func outputField(fields map[string]string, name string) string {
+	if v := getStringField(fields, name); v != "" {
+		return v
+	}
+	for _, root := range []string{"ct.request", "ct.response"} {
+		if !strings.HasPrefix(name, root+".") {
+			continue
+		}
+		raw := getStringField(fields, root)
+		if raw == "" {
+			return ""
+		}
+		var doc interface{}
+		if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+			return ""
+		}
+		for _, key := range strings.Split(strings.TrimPrefix(name, root+"."), ".") {
+			var ok bool
+			if doc, ok = objectKey(doc, key); !ok {
+				return ""
+			}
+		}
+		if doc == nil {
+			return ""
+		}
+		return stringValue(doc)
+	}
+	return ""
+}
+
+// stringValue renders a JSON value the way flattened output fields carry
+// it: strings as-is, everything else as JSON
+func stringValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+// objectKey looks a key up in a JSON object, case-insensitively if there is
+// no exact match (CloudTrail keys are camelCase, field names lowercase)
+func objectKey(doc interface{}, key string) (interface{}, bool) {
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	if v, ok := obj[key]; ok {
+		return v, true
+	}
+	for k, v := range obj {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// jsonPath is a compiled JSONPath subset: $, .key, ['key'], [n] and [*]
+type jsonPath []pathSegment
+
+// pathSegment is one step of a jsonPath
+type pathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath compiles expressions like $.attribute.value,
+// $['instanceType'].value, $.items[0] and $.ipPermissions.items[*].fromPort
+func parseJSONPath(expr string) (jsonPath, error) {
+	rest := strings.TrimSpace(expr)
+	if !strings.HasPrefix(rest, "$") {
+		return nil, fmt.Errorf("path %q must start with $", expr)
+	}
+	rest = rest[1:]
+
+	path := jsonPath{}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("path %q has an empty key", expr)
+			}
+			if rest[:end] == "*" {
+				path = append(path, pathSegment{wildcard: true})
+			} else {
+				path = append(path, pathSegment{key: rest[:end]})
+			}
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("path %q has an unclosed [", expr)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				path = append(path, pathSegment{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path = append(path, pathSegment{key: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("path %q has an invalid index %q", expr, inner)
+				}
+				path = append(path, pathSegment{index: n, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("path %q: unexpected %q", expr, rest[0])
+		}
+	}
+	return path, nil
+}
+
+// eval applies the path to a decoded JSON document. A wildcard yields the
+// list of matches below it.
+func (p jsonPath) eval(doc interface{}) (interface{}, bool) {
+	for i, seg := range p {
+		switch {
+		case seg.wildcard:
+			var items []interface{}
+			switch val := doc.(type) {
+			case []interface{}:
+				items = val
+			case map[string]interface{}:
+				keys := make([]string, 0, len(val))
+				for k := range val {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				for _, k := range keys {
+					items = append(items, val[k])
+				}
+			default:
+				return nil, false
+			}
+			matches := []interface{}{}
+			for _, item := range items {
+				if v, ok := p[i+1:].eval(item); ok {
+					matches = append(matches, v)
+				}
+			}
+			return matches, true
+		case seg.isIndex:
+			list, ok := doc.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			idx := seg.index
+			if idx < 0 {
+				idx += len(list)
+			}
+			if idx < 0 || idx >= len(list) {
+				return nil, false
+			}
+			doc = list[idx]
+		default:
+			v, ok := objectKey(doc, seg.key)
+			if !ok {
+				return nil, false
+			}
+			doc = v
+		}
+	}
+	return doc, true
+}
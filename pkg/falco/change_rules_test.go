@@ -0,0 +1,213 @@
+package falco
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRules(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestBuiltinChangeRules_CoverRelevantEventsOnly(t *testing.T) {
+	rules, err := BuiltinChangeRules()
+	require.NoError(t, err)
+	cfg, err := LoadEventConfig()
+	require.NoError(t, err)
+
+	for event := range rules.byEvent {
+		assert.True(t, cfg.IsRelevantEvent(event), "built-in change rule for %s, which is never forwarded", event)
+	}
+	assert.True(t, rules.Covers("ModifyInstanceAttribute"))
+	assert.False(t, rules.Covers("ModifyVpcAttribute"))
+}
+
+func TestChangeRules_FallBackToRequestJSON(t *testing.T) {
+	rules, err := BuiltinChangeRules()
+	require.NoError(t, err)
+
+	// The cloudtrail plugin outputs requestParameters whole, not per key
+	changes := rules.Extract("AttachRolePolicy", "iam.amazonaws.com", map[string]string{
+		"ct.request": `{"roleName":"app","policyArn":"arn:aws:iam::aws:policy/ReadOnlyAccess"}`,
+	})
+	assert.Equal(t, map[string]interface{}{"attached_policy_arn": "arn:aws:iam::aws:policy/ReadOnlyAccess"}, changes)
+
+	changes = rules.Extract("CreateAccessKey", "", map[string]string{
+		"ct.response": `{"accessKey":{"accessKeyId":"AKIAEXAMPLE","userName":"bob"}}`,
+	})
+	assert.Equal(t, "AKIAEXAMPLE", changes["access_key_id"])
+
+	changes = rules.Extract("UpdateFunctionConfiguration", "", map[string]string{
+		"ct.request": `{"functionName":"f","timeout":30}`,
+	})
+	assert.Equal(t, map[string]interface{}{"timeout": "30"}, changes, "non-string values read as their JSON text, like flattened fields")
+}
+
+func TestChangeRules_PathsTransformsAndSources(t *testing.T) {
+	dir := t.TempDir()
+	writeRules(t, dir, "ec2.yaml", `
+rules:
+  - events: [ModifyInstanceAttribute]
+    source: ec2.amazonaws.com
+    changes:
+      - attribute: instance_type
+        path: $.instanceType.value
+      - attribute: disable_api_termination
+        path: $.disableApiTermination.value
+        transform: [to-string, to-bool]
+  - events: [AuthorizeSecurityGroupIngress]
+    changes:
+      - attribute: ingress_ports
+        path: $.ipPermissions.items[*].fromPort
+        transform: list-append
+      - attribute: ingress_ports
+        path: $.extraPort
+        transform: [to-int, list-append]
+      - attribute: first_cidr
+        path: "$['ipPermissions'].items[0].ipRanges.items[0].cidrIp"
+        transform: uppercase
+`)
+	rules, err := LoadChangeRules(dir)
+	require.NoError(t, err)
+
+	changes := rules.Extract("ModifyInstanceAttribute", "ec2.amazonaws.com", map[string]string{
+		"ct.request": `{"instanceId":"i-1","instanceType":{"value":"t3.large"},"disableApiTermination":{"value":true}}`,
+	})
+	assert.Equal(t, map[string]interface{}{"instance_type": "t3.large", "disable_api_termination": true}, changes)
+
+	// Another source falls back to the source-less built-in rule
+	changes = rules.Extract("ModifyInstanceAttribute", "other.amazonaws.com", map[string]string{
+		"ct.request.instancetype": "t3.small",
+	})
+	assert.Equal(t, map[string]interface{}{"instance_type": "t3.small"}, changes)
+
+	changes = rules.Extract("AuthorizeSecurityGroupIngress", "ec2.amazonaws.com", map[string]string{
+		"ct.request": `{"groupId":"sg-1","extraPort":"8443","ipPermissions":{"items":[
+			{"fromPort":443,"ipRanges":{"items":[{"cidrIp":"0.0.0.0/0"}]}},
+			{"fromPort":22}]}}`,
+	})
+	assert.Equal(t, []interface{}{float64(443), float64(22), float64(8443)}, changes["ingress_ports"])
+	assert.Equal(t, "0.0.0.0/0", changes["first_cidr"])
+
+	// A failed transform leaves the attribute out
+	changes = rules.Extract("AuthorizeSecurityGroupIngress", "", map[string]string{
+		"ct.request": `{"extraPort":"https"}`,
+	})
+	assert.NotContains(t, changes, "ingress_ports")
+}
+
+func TestChangeRules_ConstantValues(t *testing.T) {
+	dir := t.TempDir()
+	writeRules(t, dir, "s3.yaml", `
+rules:
+  - events: [DeleteBucketPolicy]
+    changes:
+      - attribute: policy
+        value: null
+  - events: [PutBucketVersioning]
+    changes:
+      - attribute: versioning_enabled
+        value: true
+`)
+	rules, err := LoadChangeRules(dir)
+	require.NoError(t, err)
+
+	changes := rules.Extract("DeleteBucketPolicy", "", nil)
+	require.Contains(t, changes, "policy")
+	assert.Nil(t, changes["policy"])
+	assert.Equal(t, map[string]interface{}{"versioning_enabled": true}, rules.Extract("PutBucketVersioning", "", nil))
+
+	builtin, err := BuiltinChangeRules()
+	require.NoError(t, err)
+	assert.False(t, builtin.Covers("DeleteBucketPolicy"), "loaded rules don't leak into the built-in set")
+}
+
+func TestLoadChangeRules_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no events":            "rules:\n  - changes:\n      - attribute: a\n        field: f\n",
+		"no attribute":         "rules:\n  - events: [E]\n    changes:\n      - field: f\n",
+		"no field":             "rules:\n  - events: [E]\n    changes:\n      - attribute: a\n",
+		"value and field":      "rules:\n  - events: [E]\n    changes:\n      - attribute: a\n        field: f\n        value: 1\n",
+		"unknown transform":    "rules:\n  - events: [E]\n    changes:\n      - attribute: a\n        field: f\n        transform: base64\n",
+		"list-append not last": "rules:\n  - events: [E]\n    changes:\n      - attribute: a\n        field: f\n        transform: [list-append, to-int]\n",
+		"bad path":             "rules:\n  - events: [E]\n    changes:\n      - attribute: a\n        path: items[0]\n",
+		"not yaml":             "rules: [",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := writeRules(t, t.TempDir(), "rules.yaml", content)
+			_, err := LoadChangeRules(path)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := LoadChangeRules(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestNewSubscriber_LoadsChangeRules(t *testing.T) {
+	path := writeRules(t, t.TempDir(), "rules.yaml", `
+rules:
+  - events: [ModifyVpcAttribute]
+    changes:
+      - attribute: enable_dns_support
+        path: $.enableDnsSupport.value
+        transform: to-bool
+`)
+	sub, err := NewSubscriber(config.FalcoConfig{ChangeRules: []string{path}})
+	require.NoError(t, err)
+
+	changes := sub.extractChanges("ModifyVpcAttribute", map[string]string{
+		"ct.src":     "ec2.amazonaws.com",
+		"ct.request": `{"vpcId":"vpc-1","enableDnsSupport":{"value":"false"}}`,
+	})
+	assert.Equal(t, map[string]interface{}{"enable_dns_support": false}, changes)
+
+	_, err = NewSubscriber(config.FalcoConfig{ChangeRules: []string{filepath.Join(t.TempDir(), "missing")}})
+	assert.Error(t, err)
+}
+
+func TestParseJSONPath(t *testing.T) {
+	doc := map[string]interface{}{
+		"a": map[string]interface{}{"b c": []interface{}{"x", "y"}},
+		"n": []interface{}{map[string]interface{}{"v": 1.0}, map[string]interface{}{"v": 2.0}},
+	}
+	tests := []struct {
+		expr string
+		want interface{}
+		ok   bool
+	}{
+		{"$", doc, true},
+		{"$.a['b c'][1]", "y", true},
+		{"$.a[\"b c\"][-1]", "y", true},
+		{"$.A['B C'][0]", "x", true},
+		{"$.n[*].v", []interface{}{1.0, 2.0}, true},
+		{"$.n.*.v", []interface{}{1.0, 2.0}, true},
+		{"$.a.missing", nil, false},
+		{"$.n[5]", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			path, err := parseJSONPath(tt.expr)
+			require.NoError(t, err)
+			got, ok := path.eval(doc)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+
+	for _, bad := range []string{"a.b", "$..a", "$.a[", "$.a[x]", "$a"} {
+		_, err := parseJSONPath(bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -8,7 +8,7 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-//go:embed configs/event_mappings.yaml
+//go:embed configs/event_mappings.yaml configs/change_rules.yaml
 var embeddedConfigs embed.FS
 
 // EventConfig contains all event mapping configurations loaded from YAML
@@ -77,7 +77,7 @@ func (s *Subscriber) parseAWSEvent(res *outputs.Response) *types.Event {
 	// Extract changes based on event type
 	changes := s.extractChanges(eventName, fields)
 
-	return &types.Event{
+	event := &types.Event{
 		Provider:     "aws",
 		EventName:    eventName,
 		ResourceType: resourceType,
@@ -86,6 +86,11 @@ func (s *Subscriber) parseAWSEvent(res *outputs.Response) *types.Event {
 		Changes:      changes,
 		RawEvent:     res,
 	}
+	// Terraform and OpenTofu identify themselves in the user agent
+	if userAgent := getStringField(fields, "ct.useragent"); userAgent != "" {
+		event.SetMetadata("user_agent", userAgent)
+	}
+	return event
 }
 
 // isRelevantEvent checks if an event is relevant for drift detection
@@ -3,6 +3,7 @@ package falco
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,15 +13,16 @@ import (
 
 	"github.com/falcosecurity/client-go/pkg/api/outputs"
 	"github.com/falcosecurity/client-go/pkg/api/schema"
+	"github.com/keitahigaki/tfdrift-falco/pkg/telemetry"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-// maxFalcoBodyBytes caps the request body Falco's http_output may POST so a
-// misconfigured or hostile sender cannot exhaust memory. A single Falco alert
-// is a few KB; 1 MiB is generous.
-const maxFalcoBodyBytes = 1 << 20
+// maxFalcoBodyBytes caps the request body Falco's http_output or
+// Falcosidekick may POST so a misconfigured or hostile sender cannot exhaust
+// memory. A single Falco alert is a few KB; 8 MiB leaves room for batches.
+const maxFalcoBodyBytes = 8 << 20
 
 // falcoAlert mirrors the JSON Falco emits via http_output when json_output is
 // enabled (ADR-006). output_fields is deliberately map[string]interface{}: real
@@ -108,10 +110,73 @@ func (s *Subscriber) ParseHTTPAlert(body []byte) (*types.Event, error) {
 	return s.parseFalcoOutput(a.toResponse()), nil
 }
 
+// Per-alert outcomes reported by the HTTP receiver
+const (
+	alertQueued  = "queued"  // drift-relevant, fed to the detector
+	alertIgnored = "ignored" // valid but not drift-relevant
+	alertInvalid = "invalid" // could not be parsed
+)
+
+// alertResult is the receiver's verdict on one alert of a request
+type alertResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Event  string `json:"event,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// receiverResponse is the body returned for every accepted request
+type receiverResponse struct {
+	Received int           `json:"received"`
+	Queued   int           `json:"queued"`
+	Ignored  int           `json:"ignored"`
+	Invalid  int           `json:"invalid"`
+	Results  []alertResult `json:"results"`
+}
+
+// splitAlerts returns the alerts in body: one JSON object, a JSON array of
+// them (Falcosidekick batches) or newline-delimited objects. A value that
+// can't be decoded ends the stream; it is returned as a nil element so it
+// gets a result. Fails only when not even the first value decodes.
+func splitAlerts(body []byte) ([]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	var alerts []json.RawMessage
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if len(alerts) == 0 {
+				return nil, fmt.Errorf("decode Falco alert: %w", err)
+			}
+			return append(alerts, nil), nil
+		}
+
+		var batch []json.RawMessage
+		if json.Unmarshal(raw, &batch) == nil {
+			alerts = append(alerts, batch...)
+			continue
+		}
+		alerts = append(alerts, raw)
+	}
+	if len(alerts) == 0 {
+		return nil, fmt.Errorf("empty request body")
+	}
+	return alerts, nil
+}
+
 // HTTPHandler returns the HTTP counterpart of the gRPC Sub stream (ADR-006):
-// Falco's http_output POSTs one JSON alert per request, and each parsed drift
-// event is fed onto eventCh — the same channel the gRPC path uses, so the
-// downstream detector/broadcaster pipeline is unchanged.
+// Falco's http_output POSTs one JSON alert per request, Falcosidekick may
+// POST batches (a JSON array or NDJSON). Each parsed drift event is fed onto
+// eventCh — the same channel the gRPC path uses, so the downstream
+// detector/broadcaster pipeline is unchanged.
+//
+// Senders are checked against falco.receiver before anything is parsed.
+// The response lists a result per alert; the request fails with 400 only
+// when no alert in it was usable, so a sender retrying on errors doesn't
+// resubmit the good alerts of a partly bad batch.
 func (s *Subscriber) HTTPHandler(eventCh chan<- types.Event) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -125,28 +190,67 @@ func (s *Subscriber) HTTPHandler(eventCh chan<- types.Event) http.HandlerFunc {
 			return
 		}
 
-		event, err := s.ParseHTTPAlert(body)
+		if err := authenticateAlertRequest(s.cfg.Receiver, r, body); err != nil {
+			log.Warnf("Falco http_output: rejected request from %s: %v", r.RemoteAddr, err)
+			reason := "unauthenticated"
+			if errors.Is(err, errClientCert) {
+				reason = "client_cert"
+			}
+			telemetry.RecordFalcoRejected(r.Context(), reason, 1)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		alerts, err := splitAlerts(body)
 		if err != nil {
 			log.Warnf("Falco http_output: %v", err)
+			telemetry.RecordFalcoRejected(r.Context(), "malformed", 1)
 			http.Error(w, "bad alert", http.StatusBadRequest)
 			return
 		}
 
-		// Receiving a well-formed alert means Falco is alive and reaching us:
-		// surface it the same way the gRPC stream does so /health does not
-		// report "ok" while silently receiving nothing (pus #9).
-		s.connected.Store(true)
-
-		if event != nil {
-			select {
-			case eventCh <- *event:
-				log.Debugf("Falco http_output event queued: %s", event.EventName)
-			case <-r.Context().Done():
-				http.Error(w, "shutting down", http.StatusServiceUnavailable)
-				return
+		resp := receiverResponse{Received: len(alerts), Results: make([]alertResult, 0, len(alerts))}
+		for i, raw := range alerts {
+			result := alertResult{Index: i}
+			event, err := s.ParseHTTPAlert(raw)
+			switch {
+			case err != nil:
+				log.Warnf("Falco http_output: alert %d: %v", i, err)
+				result.Status, result.Error = alertInvalid, err.Error()
+				resp.Invalid++
+			case event == nil:
+				result.Status = alertIgnored
+				resp.Ignored++
+			default:
+				select {
+				case eventCh <- *event:
+					log.Debugf("Falco http_output event queued: %s", event.EventName)
+				case <-r.Context().Done():
+					http.Error(w, "shutting down", http.StatusServiceUnavailable)
+					return
+				}
+				result.Status, result.Event = alertQueued, event.EventName
+				resp.Queued++
 			}
+			resp.Results = append(resp.Results, result)
 		}
+		telemetry.RecordFalcoRejected(r.Context(), "invalid", resp.Invalid)
+		telemetry.RecordFalcoAlerts(r.Context(), resp.Queued+resp.Ignored)
 
-		w.WriteHeader(http.StatusAccepted)
+		status := http.StatusAccepted
+		if resp.Queued+resp.Ignored == 0 {
+			status = http.StatusBadRequest
+		} else {
+			// Receiving a well-formed alert means Falco is alive and reaching
+			// us: surface it the same way the gRPC stream does so /health does
+			// not report "ok" while silently receiving nothing (pus #9).
+			s.connected.Store(true)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Debugf("Falco http_output: failed to write response: %v", err)
+		}
 	}
 }
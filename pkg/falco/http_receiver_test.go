@@ -134,3 +134,65 @@ func TestHTTPHandler_IrrelevantEventAcceptedButNotQueued(t *testing.T) {
 	assert.Equal(t, http.StatusAccepted, rr.Code)
 	assert.Len(t, eventCh, 0, "read-only events must not be queued as drift")
 }
+
+// readOnlyAlert is valid but not drift-relevant
+const readOnlyAlert = `{"source":"aws_cloudtrail","output_fields":{"ct.name":"DescribeInstances"}}`
+
+func decodeReceiverResponse(t *testing.T, rr *httptest.ResponseRecorder) receiverResponse {
+	t.Helper()
+	var resp receiverResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp), rr.Body.String())
+	return resp
+}
+
+func TestHTTPHandler_BatchedArrayAndNDJSON(t *testing.T) {
+	compact := strings.Join(strings.Fields(sampleFalcoHTTPAlert), "")
+	bodies := map[string]string{
+		"array":  "[" + compact + "," + readOnlyAlert + `,{"rule":"no source"}]`,
+		"ndjson": compact + "\n" + readOnlyAlert + "\n" + `{"rule":"no source"}` + "\n",
+	}
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			sub := NewSubscriberWithDefaults()
+			eventCh := make(chan types.Event, 3)
+			rr := httptest.NewRecorder()
+			sub.HTTPHandler(eventCh)(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+			assert.Equal(t, http.StatusAccepted, rr.Code, "a partly bad batch is still accepted")
+			resp := decodeReceiverResponse(t, rr)
+			assert.Equal(t, 3, resp.Received)
+			assert.Equal(t, 1, resp.Queued)
+			assert.Equal(t, 1, resp.Ignored)
+			assert.Equal(t, 1, resp.Invalid)
+			require.Len(t, resp.Results, 3)
+			assert.Equal(t, alertResult{Index: 0, Status: alertQueued, Event: "ModifyInstanceAttribute"}, resp.Results[0])
+			assert.Equal(t, alertIgnored, resp.Results[1].Status)
+			assert.Equal(t, alertInvalid, resp.Results[2].Status)
+			assert.Contains(t, resp.Results[2].Error, "missing source")
+			assert.Len(t, eventCh, 1)
+		})
+	}
+}
+
+func TestHTTPHandler_NDJSONTruncatedTail(t *testing.T) {
+	sub := NewSubscriberWithDefaults()
+	eventCh := make(chan types.Event, 1)
+	rr := httptest.NewRecorder()
+	body := readOnlyAlert + "\n{\"source\":"
+	sub.HTTPHandler(eventCh)(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	resp := decodeReceiverResponse(t, rr)
+	assert.Equal(t, 2, resp.Received)
+	assert.Equal(t, alertInvalid, resp.Results[1].Status)
+}
+
+func TestHTTPHandler_AllInvalidIsBadRequest(t *testing.T) {
+	sub := NewSubscriberWithDefaults()
+	rr := httptest.NewRecorder()
+	sub.HTTPHandler(make(chan types.Event, 1))(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"rule":"x"},{"rule":"y"}]`)))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, 2, decodeReceiverResponse(t, rr).Invalid)
+	assert.False(t, sub.Connected())
+}
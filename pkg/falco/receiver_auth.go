@@ -0,0 +1,83 @@
+package falco
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+)
+
+// defaultHMACHeader carries the body signature when hmac_header is unset
+const defaultHMACHeader = "X-TFDrift-Signature"
+
+// errUnauthenticated is returned when a request carries no valid credential
+var errUnauthenticated = errors.New("missing or invalid credentials")
+
+// errClientCert is returned when a required client certificate is missing or
+// not allowed
+var errClientCert = errors.New("client certificate required")
+
+// authenticateAlertRequest checks the sender of an alert request against the
+// receiver config. The body is needed for the HMAC signature. An unsecured
+// receiver accepts everything.
+func authenticateAlertRequest(cfg config.FalcoReceiverConfig, r *http.Request, body []byte) error {
+	if cfg.RequireClientCert && !clientCertAllowed(r, cfg.ClientSubjects) {
+		return errClientCert
+	}
+
+	if cfg.HMACSecret == "" && cfg.BearerToken == "" {
+		return nil
+	}
+	if cfg.BearerToken != "" && validBearer(r, cfg.BearerToken) {
+		return nil
+	}
+	if cfg.HMACSecret != "" && validSignature(r, cfg, body) {
+		return nil
+	}
+	return errUnauthenticated
+}
+
+// validBearer compares the Authorization bearer token in constant time
+func validBearer(r *http.Request, token string) bool {
+	header := r.Header.Get("Authorization")
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return false
+	}
+	got := strings.TrimSpace(header[len("Bearer "):])
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// validSignature verifies the hex HMAC-SHA256 of body
+func validSignature(r *http.Request, cfg config.FalcoReceiverConfig, body []byte) bool {
+	name := cfg.HMACHeader
+	if name == "" {
+		name = defaultHMACHeader
+	}
+	sig := strings.TrimPrefix(strings.TrimSpace(r.Header.Get(name)), "sha256=")
+	got, err := hex.DecodeString(sig)
+	if err != nil || len(got) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(cfg.HMACSecret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// clientCertAllowed reports whether the TLS handshake verified a client
+// certificate whose common name is allowed. Presented but unverified
+// certificates don't count.
+func clientCertAllowed(r *http.Request, subjects []string) bool {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return false
+	}
+	if len(subjects) == 0 {
+		return true
+	}
+	return slices.Contains(subjects, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+}
@@ -0,0 +1,106 @@
+package falco
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newSecuredSubscriber(receiver config.FalcoReceiverConfig) *Subscriber {
+	sub, _ := NewSubscriber(config.FalcoConfig{Enabled: true, Transport: "http", Receiver: receiver})
+	return sub
+}
+
+func TestHTTPHandler_BearerToken(t *testing.T) {
+	sub := newSecuredSubscriber(config.FalcoReceiverConfig{BearerToken: "s3cret"})
+	eventCh := make(chan types.Event, 2)
+	handler := sub.HTTPHandler(eventCh)
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"wrong scheme", "Basic s3cret", http.StatusUnauthorized},
+		{"valid", "Bearer s3cret", http.StatusAccepted},
+		{"scheme case-insensitive", "bearer s3cret", http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(sampleFalcoHTTPAlert))
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+	assert.Len(t, eventCh, 2, "only authenticated alerts are queued")
+}
+
+func TestHTTPHandler_HMACSignature(t *testing.T) {
+	sub := newSecuredSubscriber(config.FalcoReceiverConfig{HMACSecret: "k", HMACHeader: "X-Hub-Signature-256"})
+	handler := sub.HTTPHandler(make(chan types.Event, 2))
+
+	post := func(body, sig string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if sig != "" {
+			req.Header.Set("X-Hub-Signature-256", sig)
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusAccepted, post(sampleFalcoHTTPAlert, sign("k", sampleFalcoHTTPAlert)))
+	assert.Equal(t, http.StatusAccepted, post(sampleFalcoHTTPAlert, "sha256="+sign("k", sampleFalcoHTTPAlert)))
+	assert.Equal(t, http.StatusUnauthorized, post(sampleFalcoHTTPAlert, sign("other", sampleFalcoHTTPAlert)))
+	assert.Equal(t, http.StatusUnauthorized, post(sampleFalcoHTTPAlert+" ", sign("k", sampleFalcoHTTPAlert)), "tampered body")
+	assert.Equal(t, http.StatusUnauthorized, post(sampleFalcoHTTPAlert, "not-hex"))
+	assert.Equal(t, http.StatusUnauthorized, post(sampleFalcoHTTPAlert, ""))
+}
+
+func TestAuthenticateAlertRequest_ClientCert(t *testing.T) {
+	withCert := func(cn string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}}}
+		return req
+	}
+	cfg := config.FalcoReceiverConfig{RequireClientCert: true, ClientSubjects: []string{"falcosidekick"}}
+
+	assert.NoError(t, authenticateAlertRequest(cfg, withCert("falcosidekick"), nil))
+	assert.ErrorIs(t, authenticateAlertRequest(cfg, withCert("someone-else"), nil), errClientCert)
+	assert.ErrorIs(t, authenticateAlertRequest(cfg, httptest.NewRequest(http.MethodPost, "/", nil), nil), errClientCert)
+
+	// A presented but unverified certificate doesn't count
+	unverified := httptest.NewRequest(http.MethodPost, "/", nil)
+	unverified.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "falcosidekick"}}}}
+	assert.ErrorIs(t, authenticateAlertRequest(cfg, unverified, nil), errClientCert)
+
+	// Certificate and token are both required when both are configured
+	cfg.BearerToken = "t"
+	assert.ErrorIs(t, authenticateAlertRequest(cfg, withCert("falcosidekick"), nil), errUnauthenticated)
+	req := withCert("falcosidekick")
+	req.Header.Set("Authorization", "Bearer t")
+	assert.NoError(t, authenticateAlertRequest(cfg, req, nil))
+}
@@ -33,6 +33,7 @@ type Subscriber struct {
 	isInsecure  bool
 	gcpParser   *gcp.AuditParser      // GCP Audit Log parser
 	azureParser *azure.ActivityParser // Azure Activity Log parser
+	changeRules *ChangeRules          // nil = built-in rules only
 
 	// connected reflects whether the outputs stream is currently established
 	// and being read. Exposed via Connected() so /health can surface a
@@ -53,10 +54,15 @@ func (s *Subscriber) Connected() bool {
 
 // NewSubscriber creates a new Falco subscriber
 func NewSubscriber(cfg config.FalcoConfig) (*Subscriber, error) {
+	changeRules, err := LoadChangeRules(cfg.ChangeRules...)
+	if err != nil {
+		return nil, err
+	}
 	return &Subscriber{
 		cfg:         cfg,
 		gcpParser:   gcp.NewAuditParser(),
 		azureParser: azure.NewActivityParser(),
+		changeRules: changeRules,
 	}, nil
 }
 
@@ -0,0 +1,134 @@
+package falco
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+)
+
+// TrustedAgents recognises the identities allowed to change infrastructure —
+// Terraform running in CI, usually — so their API calls are treated as
+// expected changes rather than drift. An event matches on its caller
+// identity (per provider). User agent patterns only narrow an identity
+// match: the client sets its user agent, so it never trusts an event alone.
+type TrustedAgents struct {
+	awsARNs    map[string]bool // exact principal ARNs
+	awsRoles   map[string]bool // "<account>/<role name>" of trusted roles
+	gcpEmails  map[string]bool
+	azureIDs   map[string]bool
+	userAgents []*regexp.Regexp
+}
+
+// NewTrustedAgents compiles the trusted agent configuration. It returns nil
+// when nothing is configured; a nil *TrustedAgents matches no event. User
+// agent patterns without any identity are rejected.
+func NewTrustedAgents(cfg config.TrustedAgentsConfig) (*TrustedAgents, error) {
+	identities := len(cfg.AWSRoleARNs) + len(cfg.GCPServiceAccounts) + len(cfg.AzurePrincipals)
+	if identities+len(cfg.UserAgents) == 0 {
+		return nil, nil
+	}
+	if identities == 0 {
+		return nil, fmt.Errorf("user agent patterns require a trusted identity")
+	}
+
+	t := &TrustedAgents{
+		awsARNs:   make(map[string]bool),
+		awsRoles:  make(map[string]bool),
+		gcpEmails: make(map[string]bool),
+		azureIDs:  make(map[string]bool),
+	}
+	for _, arn := range cfg.AWSRoleARNs {
+		t.awsARNs[arn] = true
+		if account, role, ok := parseRoleARN(arn); ok {
+			t.awsRoles[account+"/"+role] = true
+		}
+	}
+	for _, email := range cfg.GCPServiceAccounts {
+		t.gcpEmails[strings.ToLower(email)] = true
+	}
+	for _, id := range cfg.AzurePrincipals {
+		t.azureIDs[strings.ToLower(id)] = true
+	}
+	for _, pattern := range cfg.UserAgents {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user agent pattern %q: %w", pattern, err)
+		}
+		t.userAgents = append(t.userAgents, re)
+	}
+	return t, nil
+}
+
+// Match reports whether an event was made by a trusted agent, and which one.
+// When user agent patterns are configured, the event's user agent must match
+// one of them as well as its identity.
+func (t *TrustedAgents) Match(event *types.Event) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+
+	agent, ok := t.matchIdentity(event)
+	if !ok || len(t.userAgents) == 0 {
+		return agent, ok
+	}
+
+	if userAgent := event.GetMetadata("user_agent"); userAgent != "" {
+		for _, re := range t.userAgents {
+			if re.MatchString(userAgent) {
+				return agent + " with user agent " + re.String(), true
+			}
+		}
+	}
+	return "", false
+}
+
+// matchIdentity matches the event's caller identity for its provider
+func (t *TrustedAgents) matchIdentity(event *types.Event) (string, bool) {
+	ui := event.UserIdentity
+	switch event.Provider {
+	case "aws":
+		if t.awsARNs[ui.ARN] {
+			return ui.ARN, true
+		}
+		// Role sessions carry the STS ARN, which drops the role's path:
+		// arn:aws:sts::<account>:assumed-role/<role>/<session>
+		if role, _, ok := parseAssumedRoleARN(ui.ARN); ok && t.awsRoles[arnAccount(ui.ARN)+"/"+role] {
+			return "role " + role, true
+		}
+	case "gcp":
+		if ui.UserName != "" && t.gcpEmails[strings.ToLower(ui.UserName)] {
+			return ui.UserName, true
+		}
+	case "azure":
+		if ui.UserName != "" && t.azureIDs[strings.ToLower(ui.UserName)] {
+			return ui.UserName, true
+		}
+	}
+	return "", false
+}
+
+// parseRoleARN extracts the account and role name from an IAM role ARN:
+// arn:aws:iam::<account>:role/<path>/<RoleName>
+func parseRoleARN(arn string) (account, role string, ok bool) {
+	const marker = ":role/"
+	idx := strings.Index(arn, marker)
+	if idx < 0 {
+		return "", "", false
+	}
+	rest := arn[idx+len(marker):]
+	role = rest[strings.LastIndex(rest, "/")+1:]
+	account = arnAccount(arn)
+	return account, role, account != "" && role != ""
+}
+
+// arnAccount returns the account field of an ARN (arn:partition:service:region:account:resource)
+func arnAccount(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 {
+		return ""
+	}
+	return parts[4]
+}
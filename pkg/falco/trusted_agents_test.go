@@ -0,0 +1,119 @@
+package falco
+
+import (
+	"testing"
+
+	"github.com/falcosecurity/client-go/pkg/api/outputs"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustedAgents_Match(t *testing.T) {
+	agents, err := NewTrustedAgents(config.TrustedAgentsConfig{
+		AWSRoleARNs: []string{
+			"arn:aws:iam::123456789012:role/ci/terraform-apply",
+			"arn:aws:iam::123456789012:user/deploy-bot",
+		},
+		GCPServiceAccounts: []string{"terraform@infra-prod.iam.gserviceaccount.com"},
+		AzurePrincipals:    []string{"5F2C1E0A-1111-2222-3333-444455556666"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		event *types.Event
+		want  bool
+	}{
+		{"assumed role session, path dropped from the STS ARN", &types.Event{Provider: "aws", UserIdentity: types.UserIdentity{
+			ARN: "arn:aws:sts::123456789012:assumed-role/terraform-apply/GitHubActions-42"}}, true},
+		{"same role name in another account", &types.Event{Provider: "aws", UserIdentity: types.UserIdentity{
+			ARN: "arn:aws:sts::999999999999:assumed-role/terraform-apply/x"}}, false},
+		{"other role", &types.Event{Provider: "aws", UserIdentity: types.UserIdentity{
+			ARN: "arn:aws:sts::123456789012:assumed-role/Admin/alice"}}, false},
+		{"IAM user by exact ARN", &types.Event{Provider: "aws", UserIdentity: types.UserIdentity{
+			ARN: "arn:aws:iam::123456789012:user/deploy-bot"}}, true},
+		{"GCP service account, case-insensitive", &types.Event{Provider: "gcp", UserIdentity: types.UserIdentity{
+			UserName: "Terraform@infra-prod.iam.gserviceaccount.com"}}, true},
+		{"GCP human", &types.Event{Provider: "gcp", UserIdentity: types.UserIdentity{UserName: "alice@example.com"}}, false},
+		{"Azure service principal", &types.Event{Provider: "azure", UserIdentity: types.UserIdentity{
+			UserName: "5f2c1e0a-1111-2222-3333-444455556666"}}, true},
+		{"identity only counts for its provider", &types.Event{Provider: "azure", UserIdentity: types.UserIdentity{
+			UserName: "terraform@infra-prod.iam.gserviceaccount.com"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent, ok := agents.Match(tt.event)
+			assert.Equal(t, tt.want, ok)
+			if tt.want {
+				assert.NotEmpty(t, agent)
+			}
+		})
+	}
+}
+
+func TestTrustedAgents_MatchUserAgent(t *testing.T) {
+	agents, err := NewTrustedAgents(config.TrustedAgentsConfig{
+		AWSRoleARNs: []string{"arn:aws:iam::123456789012:role/terraform-apply"},
+		UserAgents:  []string{`OpenTofu/\d`},
+	})
+	require.NoError(t, err)
+
+	event := func(arn, userAgent string) *types.Event {
+		e := &types.Event{Provider: "aws", UserIdentity: types.UserIdentity{ARN: arn}}
+		if userAgent != "" {
+			e.SetMetadata("user_agent", userAgent)
+		}
+		return e
+	}
+	const (
+		ciRole = "arn:aws:sts::123456789012:assumed-role/terraform-apply/GitHubActions-42"
+		tofu   = "APN/1.0 HashiCorp/1.0 OpenTofu/1.8.2 terraform-provider-aws/5.60.0"
+	)
+
+	agent, ok := agents.Match(event(ciRole, tofu))
+	assert.True(t, ok)
+	assert.Contains(t, agent, "terraform-apply")
+
+	// The CI role used from the CLI or console is not Terraform
+	_, ok = agents.Match(event(ciRole, "aws-cli/2.17.0 Python/3.11"))
+	assert.False(t, ok)
+	_, ok = agents.Match(event(ciRole, ""))
+	assert.False(t, ok)
+
+	// A user agent is set by the client: anyone can send Terraform's
+	_, ok = agents.Match(event("arn:aws:sts::123456789012:assumed-role/Admin/alice", tofu))
+	assert.False(t, ok)
+}
+
+func TestNewTrustedAgents_EmptyAndInvalid(t *testing.T) {
+	agents, err := NewTrustedAgents(config.TrustedAgentsConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, agents)
+	_, ok := agents.Match(&types.Event{Provider: "aws"})
+	assert.False(t, ok, "nil trusted agents match nothing")
+
+	_, err = NewTrustedAgents(config.TrustedAgentsConfig{
+		AWSRoleARNs: []string{"arn:aws:iam::123456789012:role/terraform-apply"},
+		UserAgents:  []string{"Terraform/("},
+	})
+	assert.Error(t, err)
+
+	_, err = NewTrustedAgents(config.TrustedAgentsConfig{UserAgents: []string{"Terraform/"}})
+	assert.Error(t, err, "a user agent alone must not trust events")
+}
+
+func TestParseAWSEvent_UserAgent(t *testing.T) {
+	sub := &Subscriber{}
+	event := sub.parseAWSEvent(&outputs.Response{
+		Source: "aws_cloudtrail",
+		OutputFields: map[string]string{
+			"ct.name":               "ModifyInstanceAttribute",
+			"ct.request.instanceid": "i-123",
+			"ct.useragent":          "APN/1.0 HashiCorp/1.0 Terraform/1.9.0 (+https://www.terraform.io)",
+		},
+	})
+	require.NotNil(t, event)
+	assert.Equal(t, "APN/1.0 HashiCorp/1.0 Terraform/1.9.0 (+https://www.terraform.io)", event.GetMetadata("user_agent"))
+}
@@ -157,6 +157,9 @@ func (p *AuditParser) createConfig() parser.EventParserConfig {
 			if serviceName := parser.GetStringField(fields, "gcp.serviceName"); serviceName != "" {
 				metadata["service_name"] = serviceName
 			}
+			if userAgent := parser.GetStringField(fields, "gcp.requestMetadata.callerSuppliedUserAgent"); userAgent != "" {
+				metadata["user_agent"] = userAgent
+			}
 			return metadata
 		},
 	}
@@ -21,11 +21,12 @@ func TestAuditParser_Parse_ValidEvent(t *testing.T) {
 	res := &outputs.Response{
 		Source: "gcpaudit",
 		OutputFields: map[string]string{
-			"gcp.methodName":                        "compute.instances.setMetadata",
-			"gcp.resource.name":                     "projects/my-project-123/zones/us-central1-a/instances/vm-1",
-			"gcp.serviceName":                       "compute.googleapis.com",
-			"gcp.authenticationInfo.principalEmail": "user@example.com",
-			"gcp.request":                           `{"metadata": {"items": [{"key": "ssh-keys", "value": "..."}]}}`,
+			"gcp.methodName":                              "compute.instances.setMetadata",
+			"gcp.resource.name":                           "projects/my-project-123/zones/us-central1-a/instances/vm-1",
+			"gcp.serviceName":                             "compute.googleapis.com",
+			"gcp.authenticationInfo.principalEmail":       "user@example.com",
+			"gcp.requestMetadata.callerSuppliedUserAgent": "Terraform/1.9.0 (+https://www.terraform.io) terraform-provider-google/5.40.0",
+			"gcp.request":                                 `{"metadata": {"items": [{"key": "ssh-keys", "value": "..."}]}}`,
 		},
 	}
 
@@ -40,6 +41,7 @@ func TestAuditParser_Parse_ValidEvent(t *testing.T) {
 	assert.Equal(t, "us-central1", event.Region) // Region is extracted from zone (us-central1-a -> us-central1)
 	assert.Equal(t, "compute.googleapis.com", event.ServiceName)
 	assert.Equal(t, "user@example.com", event.UserIdentity.UserName)
+	assert.Contains(t, event.GetMetadata("user_agent"), "Terraform/1.9.0")
 	assert.NotEmpty(t, event.Changes)
 }
 
@@ -107,36 +107,43 @@ func NewDiscoveryClient(ctx context.Context, projectID string, regions []string,
 	}, nil
 }
 
+// discoveryTask discovers the resources of one Terraform type
+type discoveryTask struct {
+	name         string
+	resourceType string
+	override     func(context.Context) ([]*DiscoveredResource, error)
+	fallback     func(context.Context) ([]*DiscoveredResource, error)
+}
+
+// discover returns the function override if set, otherwise the default implementation
+func (t discoveryTask) discover() func(context.Context) ([]*DiscoveredResource, error) {
+	if t.override != nil {
+		return t.override
+	}
+	return t.fallback
+}
+
+func (d *DiscoveryClient) discoveryTasks() []discoveryTask {
+	return []discoveryTask{
+		{"VPC Networks", "google_compute_network", d.discoverNetworksFunc, d.discoverNetworks},
+		{"Subnetworks", "google_compute_subnetwork", d.discoverSubnetworksFunc, d.discoverSubnetworks},
+		{"Firewalls", "google_compute_firewall", d.discoverFirewallsFunc, d.discoverFirewalls},
+		{"Compute Instances", "google_compute_instance", d.discoverInstancesFunc, d.discoverInstances},
+		{"GCS Buckets", "google_storage_bucket", d.discoverBucketsFunc, d.discoverBuckets},
+		{"Cloud SQL Instances", "google_sql_database_instance", d.discoverSQLFunc, d.discoverSQLInstances},
+		{"GKE Clusters", "google_container_cluster", d.discoverGKEFunc, d.discoverGKEClusters},
+		{"Cloud Run Services", "google_cloud_run_v2_service", d.discoverCloudRunFunc, d.discoverCloudRunServices},
+	}
+}
+
 // DiscoverAll discovers all supported GCP resources in the project.
 func (d *DiscoveryClient) DiscoverAll(ctx context.Context) ([]*DiscoveredResource, error) {
 	log.Infof("Starting GCP resource discovery in project %s", d.projectID)
 
 	var allResources []*DiscoveredResource
 
-	// Each discover call uses function override if set, otherwise default implementation
-	type discoveryTask struct {
-		name     string
-		override func(context.Context) ([]*DiscoveredResource, error)
-		fallback func(context.Context) ([]*DiscoveredResource, error)
-	}
-
-	tasks := []discoveryTask{
-		{"VPC Networks", d.discoverNetworksFunc, d.discoverNetworks},
-		{"Subnetworks", d.discoverSubnetworksFunc, d.discoverSubnetworks},
-		{"Firewalls", d.discoverFirewallsFunc, d.discoverFirewalls},
-		{"Compute Instances", d.discoverInstancesFunc, d.discoverInstances},
-		{"GCS Buckets", d.discoverBucketsFunc, d.discoverBuckets},
-		{"Cloud SQL Instances", d.discoverSQLFunc, d.discoverSQLInstances},
-		{"GKE Clusters", d.discoverGKEFunc, d.discoverGKEClusters},
-		{"Cloud Run Services", d.discoverCloudRunFunc, d.discoverCloudRunServices},
-	}
-
-	for _, task := range tasks {
-		fn := task.fallback
-		if task.override != nil {
-			fn = task.override
-		}
-		resources, err := fn(ctx)
+	for _, task := range d.discoveryTasks() {
+		resources, err := task.discover()(ctx)
 		if err != nil {
 			log.Warnf("Failed to discover %s: %v", task.name, err)
 		} else {
@@ -149,6 +156,30 @@ func (d *DiscoveryClient) DiscoverAll(ctx context.Context) ([]*DiscoveredResourc
 	return allResources, nil
 }
 
+// DiscoverResource reads the current state of a single resource. Only the
+// resource's own type is listed; the resource is matched by ID or self link,
+// or by name when id is a bare name. It returns nil when the resource no
+// longer exists.
+func (d *DiscoveryClient) DiscoverResource(ctx context.Context, resourceType, id string) (*DiscoveredResource, error) {
+	for _, task := range d.discoveryTasks() {
+		if task.resourceType != resourceType {
+			continue
+		}
+		resources, err := task.discover()(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover %s: %w", task.name, err)
+		}
+		for _, r := range resources {
+			if r.ID == id || (r.SelfLink != "" && strings.HasSuffix(r.SelfLink, "/"+id)) ||
+				(!strings.Contains(id, "/") && r.Name == id) {
+				return r, nil
+			}
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("resource type %s is not discoverable", resourceType)
+}
+
 // discoverNetworks discovers all VPC Networks in the project.
 func (d *DiscoveryClient) discoverNetworks(ctx context.Context) ([]*DiscoveredResource, error) {
 	networkList, err := d.computeService.Networks.List(d.projectID).Context(ctx).Do()
@@ -512,3 +512,54 @@ func TestHelperFunctions_Extended(t *testing.T) {
 		})
 	}
 }
+
+// TestDiscoverResource_WithMocks tests single-resource lookup using function overrides
+func TestDiscoverResource_WithMocks(t *testing.T) {
+	client := NewDiscoveryClientForTesting("test-project", nil)
+	client.discoverInstancesFunc = func(ctx context.Context) ([]*DiscoveredResource, error) {
+		return []*DiscoveredResource{
+			{ID: "projects/test-project/zones/us-central1-a/instances/vm-1", Type: "google_compute_instance", Name: "vm-1",
+				SelfLink: "https://www.googleapis.com/compute/v1/projects/test-project/zones/us-central1-a/instances/vm-1"},
+			{ID: "projects/test-project/zones/us-central1-b/instances/vm-2", Type: "google_compute_instance", Name: "vm-2"},
+		}, nil
+	}
+	client.discoverNetworksFunc = func(ctx context.Context) ([]*DiscoveredResource, error) {
+		t.Error("only the requested type should be listed")
+		return nil, nil
+	}
+
+	tests := []struct {
+		id     string
+		wantID string
+	}{
+		{"projects/test-project/zones/us-central1-b/instances/vm-2", "projects/test-project/zones/us-central1-b/instances/vm-2"},
+		{"zones/us-central1-a/instances/vm-1", "projects/test-project/zones/us-central1-a/instances/vm-1"},
+		{"vm-2", "projects/test-project/zones/us-central1-b/instances/vm-2"},
+		{"projects/test-project/zones/us-central1-a/instances/vm-9", ""},
+	}
+	for _, tt := range tests {
+		r, err := client.DiscoverResource(context.Background(), "google_compute_instance", tt.id)
+		if err != nil {
+			t.Fatalf("DiscoverResource(%s) failed: %v", tt.id, err)
+		}
+		if tt.wantID == "" {
+			if r != nil {
+				t.Errorf("DiscoverResource(%s) = %s, want nil", tt.id, r.ID)
+			}
+			continue
+		}
+		if r == nil || r.ID != tt.wantID {
+			t.Errorf("DiscoverResource(%s) = %+v, want %s", tt.id, r, tt.wantID)
+		}
+	}
+
+	client.discoverBucketsFunc = func(ctx context.Context) ([]*DiscoveredResource, error) {
+		return nil, fmt.Errorf("permission denied")
+	}
+	if _, err := client.DiscoverResource(context.Background(), "google_storage_bucket", "b"); err == nil {
+		t.Error("expected error from failed listing")
+	}
+	if _, err := client.DiscoverResource(context.Background(), "google_pubsub_topic", "t"); err == nil {
+		t.Error("expected error for undiscoverable type")
+	}
+}
@@ -1,90 +1,134 @@
-// Package graph provides in-memory graph storage for drift detection results.
+// Package graph provides graph storage for drift detection results.
 package graph
 
 import (
 	"sync"
+	"time"
 
 	"github.com/keitahigaki/tfdrift-falco/pkg/api/models"
+	"github.com/keitahigaki/tfdrift-falco/pkg/history"
 	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 	log "github.com/sirupsen/logrus"
 )
 
-// Store maintains the graph data in memory
+// Store maintains the graph data. Drifts, events and unmanaged alerts are
+// kept in a history backend (in memory by default, or persisted on disk).
 type Store struct {
-	drifts       []types.DriftAlert
-	events       []types.Event
-	unmanaged    []types.UnmanagedResourceAlert
+	history      history.Backend
 	stateManager *terraform.StateManager
+	sensitive    terraform.SensitivePatterns
 	graphDB      *Database // Neo4j-style graph database
 	mu           sync.RWMutex
 }
 
-// NewStore creates a new graph store
+// NewStore creates a new graph store backed by unbounded in-memory history
 func NewStore() *Store {
+	return NewStoreWithHistory(history.NewMemoryBackend(history.Retention{}))
+}
+
+// NewStoreWithHistory creates a graph store on top of the given history backend
+func NewStoreWithHistory(h history.Backend) *Store {
 	return &Store{
-		drifts:    make([]types.DriftAlert, 0),
-		events:    make([]types.Event, 0),
-		unmanaged: make([]types.UnmanagedResourceAlert, 0),
-		graphDB:   NewDatabase(),
+		history: h,
+		graphDB: NewDatabase(),
 	}
 }
 
-// AddDrift adds a drift alert to the store
-func (s *Store) AddDrift(drift types.DriftAlert) {
+// SetSensitivePatterns sets the configured sensitive attribute patterns.
+// Records are redacted with them, and with the state's sensitive
+// attributes, before they reach the history backend.
+func (s *Store) SetSensitivePatterns(patterns terraform.SensitivePatterns) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.drifts = append(s.drifts, drift)
+	s.sensitive = patterns
+}
+
+// AddDrift adds a drift alert to the store
+func (s *Store) AddDrift(drift types.DriftAlert) {
+	if err := s.history.AddDrift(*drift.Redacted()); err != nil {
+		log.Errorf("Failed to record drift in history: %v", err)
+	}
 }
 
 // AddEvent adds a Falco event to the store
 func (s *Store) AddEvent(event types.Event) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.events = append(s.events, event)
+	event.Changes = s.redactChanges(event.ResourceType, event.ResourceID, event.Changes)
+	if err := s.history.AddEvent(event); err != nil {
+		log.Errorf("Failed to record event in history: %v", err)
+	}
 }
 
 // AddUnmanaged adds an unmanaged resource to the store
 func (s *Store) AddUnmanaged(unmanaged types.UnmanagedResourceAlert) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.unmanaged = append(s.unmanaged, unmanaged)
+	s.mu.RLock()
+	unmanaged.Changes = s.sensitive.Redact(unmanaged.ResourceType, unmanaged.Changes)
+	s.mu.RUnlock()
+	if err := s.history.AddUnmanaged(unmanaged); err != nil {
+		log.Errorf("Failed to record unmanaged resource in history: %v", err)
+	}
 }
 
-// GetDrifts returns all drift alerts
-func (s *Store) GetDrifts() []types.DriftAlert {
+// redactChanges redacts an event's changes: as attributes of the managed
+// resource when the state has it, by the configured patterns otherwise
+func (s *Store) redactChanges(resourceType, resourceID string, changes map[string]interface{}) map[string]interface{} {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	result := make([]types.DriftAlert, len(s.drifts))
-	copy(result, s.drifts)
-	return result
+	if s.stateManager != nil {
+		if resource, ok := s.stateManager.GetResourceOfType(resourceType, resourceID); ok {
+			return resource.RedactValues(changes)
+		}
+	}
+	return s.sensitive.Redact(resourceType, changes)
+}
+
+// GetDrifts returns all drift alerts
+func (s *Store) GetDrifts() []types.DriftAlert {
+	drifts, err := s.history.Drifts()
+	if err != nil {
+		log.Errorf("Failed to read drift history: %v", err)
+		return []types.DriftAlert{}
+	}
+	return drifts
 }
 
 // GetEvents returns all events
 func (s *Store) GetEvents() []types.Event {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	result := make([]types.Event, len(s.events))
-	copy(result, s.events)
-	return result
+	events, err := s.history.Events()
+	if err != nil {
+		log.Errorf("Failed to read event history: %v", err)
+		return []types.Event{}
+	}
+	return events
 }
 
 // GetUnmanaged returns all unmanaged resources
 func (s *Store) GetUnmanaged() []types.UnmanagedResourceAlert {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	result := make([]types.UnmanagedResourceAlert, len(s.unmanaged))
-	copy(result, s.unmanaged)
-	return result
+	unmanaged, err := s.history.Unmanaged()
+	if err != nil {
+		log.Errorf("Failed to read unmanaged resource history: %v", err)
+		return []types.UnmanagedResourceAlert{}
+	}
+	return unmanaged
 }
 
 // Clear clears all data from the store
 func (s *Store) Clear() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.drifts = make([]types.DriftAlert, 0)
-	s.events = make([]types.Event, 0)
-	s.unmanaged = make([]types.UnmanagedResourceAlert, 0)
+	if err := s.history.Clear(); err != nil {
+		log.Errorf("Failed to clear history: %v", err)
+	}
+}
+
+// Prune applies the history retention policy
+func (s *Store) Prune() {
+	if err := s.history.Prune(time.Now()); err != nil {
+		log.Errorf("Failed to prune history: %v", err)
+	}
+}
+
+// Close releases the history backend
+func (s *Store) Close() error {
+	return s.history.Close()
 }
 
 // SetStateManager sets the Terraform state manager for graph building
@@ -124,7 +168,7 @@ func (s *Store) rebuildGraphDB() {
 
 	// Track which resources have drifts
 	driftedIDs := make(map[string]bool)
-	for _, drift := range s.drifts {
+	for _, drift := range s.GetDrifts() {
 		driftedIDs[drift.ResourceID] = true
 	}
 
@@ -144,13 +188,17 @@ func (s *Store) BuildGraph() models.CytoscapeElements {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	drifts := s.GetDrifts()
+	events := s.GetEvents()
+	unmanagedAlerts := s.GetUnmanaged()
+
 	nodes := make([]models.CytoscapeNode, 0)
 	edges := make([]models.CytoscapeEdge, 0)
 	nodeIDs := make(map[string]bool)
 	driftedIDs := make(map[string]bool)
 
 	// Track which resources have drifts
-	for _, drift := range s.drifts {
+	for _, drift := range drifts {
 		driftedIDs[drift.ResourceID] = true
 	}
 
@@ -176,7 +224,7 @@ func (s *Store) BuildGraph() models.CytoscapeElements {
 	}
 
 	// SECOND: Add drift nodes (for resources not in Terraform State)
-	for _, drift := range s.drifts {
+	for _, drift := range drifts {
 		if !nodeIDs[drift.ResourceID] {
 			nodes = append(nodes, ConvertDriftToCytoscape(drift))
 			nodeIDs[drift.ResourceID] = true
@@ -184,7 +232,7 @@ func (s *Store) BuildGraph() models.CytoscapeElements {
 	}
 
 	// Add event nodes
-	for _, event := range s.events {
+	for _, event := range events {
 		if !nodeIDs[event.ResourceID] {
 			nodes = append(nodes, ConvertEventToCytoscape(event))
 			nodeIDs[event.ResourceID] = true
@@ -192,7 +240,7 @@ func (s *Store) BuildGraph() models.CytoscapeElements {
 	}
 
 	// Add unmanaged nodes
-	for _, unmanaged := range s.unmanaged {
+	for _, unmanaged := range unmanagedAlerts {
 		if !nodeIDs[unmanaged.ResourceID] {
 			nodes = append(nodes, ConvertUnmanagedToCytoscape(unmanaged))
 			nodeIDs[unmanaged.ResourceID] = true
@@ -202,10 +250,10 @@ func (s *Store) BuildGraph() models.CytoscapeElements {
 	// Create edges (causal relationships)
 	// For now, create simple sequential edges
 	// This can be enhanced with more sophisticated causal analysis
-	for i := 0; i < len(s.events)-1; i++ {
+	for i := 0; i < len(events)-1; i++ {
 		edge := CreateEdge(
-			s.events[i].ResourceID,
-			s.events[i+1].ResourceID,
+			events[i].ResourceID,
+			events[i+1].ResourceID,
 			"triggered",
 			"caused_by",
 			"event_sequence",
@@ -214,8 +262,8 @@ func (s *Store) BuildGraph() models.CytoscapeElements {
 	}
 
 	// Connect events to drifts
-	for _, drift := range s.drifts {
-		for _, event := range s.events {
+	for _, drift := range drifts {
+		for _, event := range events {
 			if event.ResourceID == drift.ResourceID || event.ResourceType == drift.ResourceType {
 				edge := CreateEdge(
 					event.ResourceID,
@@ -238,23 +286,22 @@ func (s *Store) BuildGraph() models.CytoscapeElements {
 
 // GetStats returns graph statistics
 func (s *Store) GetStats() map[string]interface{} {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	drifts := s.GetDrifts()
 
 	severityCounts := make(map[string]int)
-	for _, drift := range s.drifts {
+	for _, drift := range drifts {
 		severityCounts[drift.Severity]++
 	}
 
 	resourceTypeCounts := make(map[string]int)
-	for _, drift := range s.drifts {
+	for _, drift := range drifts {
 		resourceTypeCounts[drift.ResourceType]++
 	}
 
 	return map[string]interface{}{
-		"total_drifts":         len(s.drifts),
-		"total_events":         len(s.events),
-		"total_unmanaged":      len(s.unmanaged),
+		"total_drifts":         len(drifts),
+		"total_events":         len(s.GetEvents()),
+		"total_unmanaged":      len(s.GetUnmanaged()),
 		"severity_counts":      severityCounts,
 		"resource_type_counts": resourceTypeCounts,
 	}
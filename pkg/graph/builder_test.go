@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_RedactsBeforeRecording(t *testing.T) {
+	s := NewStore()
+	s.SetSensitivePatterns(terraform.CompileSensitivePatterns(config.SensitiveAttributesConfig{
+		Patterns: []string{"aws_db_instance:master_*"},
+	}))
+	changes := map[string]interface{}{"master_user_password": "pw-secret", "engine": "postgres"}
+
+	s.AddEvent(types.Event{ResourceType: "aws_db_instance", ResourceID: "db-1", Changes: changes})
+	s.AddUnmanaged(types.UnmanagedResourceAlert{ResourceType: "aws_db_instance", ResourceID: "db-1", Changes: changes})
+	s.AddDrift(types.DriftAlert{ResourceType: "aws_db_instance", Attribute: "password",
+		OldValue: "pw-old", NewValue: "pw-new", Sensitive: true})
+
+	events := s.GetEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, types.RedactedValue, events[0].Changes["master_user_password"])
+	assert.Equal(t, "postgres", events[0].Changes["engine"])
+
+	unmanaged := s.GetUnmanaged()
+	require.Len(t, unmanaged, 1)
+	assert.Equal(t, types.RedactedValue, unmanaged[0].Changes["master_user_password"])
+
+	drifts := s.GetDrifts()
+	require.Len(t, drifts, 1)
+	assert.Equal(t, types.RedactedValue, drifts[0].OldValue)
+	assert.Equal(t, types.RedactedValue, drifts[0].NewValue)
+
+	assert.Equal(t, "pw-secret", changes["master_user_password"], "the caller's changes are not modified")
+}
@@ -88,6 +88,7 @@ func resourceToNode(resource *terraform.Resource, driftedIDs map[string]bool) *N
 	properties["has_drift"] = driftedIDs[resourceID]
 	properties["mode"] = resource.Mode
 	properties["provider"] = resource.Provider
+	properties["address"] = resource.Address
 
 	// Add resource-specific properties
 	addResourceSpecificProperties(resource, properties)
@@ -10,6 +10,7 @@ import (
 
 // ConvertDriftToCytoscape converts a drift alert to Cytoscape node
 func ConvertDriftToCytoscape(drift types.DriftAlert) models.CytoscapeNode {
+	redacted := drift.Redacted()
 	return models.CytoscapeNode{
 		Data: models.NodeData{
 			ID:           drift.ResourceID,
@@ -20,13 +21,14 @@ func ConvertDriftToCytoscape(drift types.DriftAlert) models.CytoscapeNode {
 			Severity:     drift.Severity,
 			Metadata: map[string]interface{}{
 				"attribute":     drift.Attribute,
-				"old_value":     drift.OldValue,
-				"new_value":     drift.NewValue,
+				"old_value":     redacted.OldValue,
+				"new_value":     redacted.NewValue,
 				"user":          drift.UserIdentity.UserName,
 				"user_arn":      drift.UserIdentity.ARN,
 				"matched_rules": drift.MatchedRules,
 				"timestamp":     drift.Timestamp,
 				"alert_type":    drift.AlertType,
+				"address":       drift.Address(),
 			},
 		},
 	}
@@ -103,8 +105,9 @@ func ConvertTerraformResourceToCytoscape(resource *terraform.Resource, hasDrift
 				"mode":       resource.Mode,
 				"provider":   resource.Provider,
 				"tf_name":    resource.Name,
+				"address":    resource.Address,
 				"has_drift":  hasDrift,
-				"attributes": resource.Attributes,
+				"attributes": resource.RedactedAttributes(),
 			},
 		},
 	}
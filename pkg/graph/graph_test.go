@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/keitahigaki/tfdrift-falco/pkg/history"
 	"github.com/keitahigaki/tfdrift-falco/pkg/terraform"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 )
@@ -3338,3 +3339,24 @@ func TestBuildGraphWithAllResourceTypes(t *testing.T) {
 		t.Errorf("Expected at least 3 nodes, got %d", len(elements.Nodes))
 	}
 }
+
+// TestStoreWithHistoryRetention tests that the store's history stays bounded
+func TestStoreWithHistoryRetention(t *testing.T) {
+	store := NewStoreWithHistory(history.NewMemoryBackend(history.Retention{MaxCount: 2}))
+	defer func() { _ = store.Close() }()
+
+	for i := 0; i < 5; i++ {
+		store.AddDrift(types.DriftAlert{ResourceID: fmt.Sprintf("i-%d", i), Severity: "high"})
+	}
+
+	drifts := store.GetDrifts()
+	if len(drifts) != 2 {
+		t.Fatalf("expected 2 retained drifts, got %d", len(drifts))
+	}
+	if drifts[0].ResourceID != "i-3" || drifts[1].ResourceID != "i-4" {
+		t.Errorf("expected the newest drifts to be kept, got %s and %s", drifts[0].ResourceID, drifts[1].ResourceID)
+	}
+	if total := store.GetStats()["total_drifts"]; total != 2 {
+		t.Errorf("expected stats to reflect retained drifts, got %v", total)
+	}
+}
@@ -0,0 +1,290 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketDrifts    = []byte("drifts")
+	bucketEvents    = []byte("events")
+	bucketUnmanaged = []byte("unmanaged")
+
+	allBuckets = [][]byte{bucketDrifts, bucketEvents, bucketUnmanaged}
+)
+
+// agePruneInterval limits how often appends trigger an age-based prune
+const agePruneInterval = time.Minute
+
+// boltRecord is the on-disk envelope of a history record
+type boltRecord struct {
+	At     time.Time       `json:"at"`
+	Record json.RawMessage `json:"record"`
+}
+
+// BoltBackend persists history in an embedded BoltDB file. Keys are
+// monotonically increasing sequence numbers, so iteration order is insertion
+// order and retention trims from the front of each bucket.
+type BoltBackend struct {
+	db        *bolt.DB
+	retention Retention
+	counts    map[string]int
+	lastPrune time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// Compile-time interface check
+var _ Backend = (*BoltBackend)(nil)
+
+// NewBoltBackend opens (or creates) the history database at path
+func NewBoltBackend(path string, retention Retention) (*BoltBackend, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create history directory: %w", err)
+		}
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database %s: %w", path, err)
+	}
+
+	b := &BoltBackend{
+		db:        db,
+		retention: retention,
+		counts:    make(map[string]int),
+		now:       time.Now,
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range allBuckets {
+			bucket, err := tx.CreateBucketIfNotExists(name)
+			if err != nil {
+				return err
+			}
+			b.counts[string(name)] = bucket.Stats().KeyN
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize history database: %w", err)
+	}
+
+	if err := b.Prune(b.now()); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	log.Infof("History store opened at %s (%d drifts, %d events, %d unmanaged)", path,
+		b.counts[string(bucketDrifts)], b.counts[string(bucketEvents)], b.counts[string(bucketUnmanaged)])
+	return b, nil
+}
+
+// AddDrift records a drift alert
+func (b *BoltBackend) AddDrift(drift types.DriftAlert) error {
+	return b.add(bucketDrifts, drift)
+}
+
+// AddEvent records an event
+func (b *BoltBackend) AddEvent(event types.Event) error {
+	if _, err := json.Marshal(event.RawEvent); err != nil {
+		// Keep the parsed event even if the raw payload isn't serializable
+		event.RawEvent = nil
+	}
+	return b.add(bucketEvents, event)
+}
+
+// AddUnmanaged records an unmanaged resource alert
+func (b *BoltBackend) AddUnmanaged(unmanaged types.UnmanagedResourceAlert) error {
+	return b.add(bucketUnmanaged, unmanaged)
+}
+
+func (b *BoltBackend) add(bucketName []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history record: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	rec, err := json.Marshal(boltRecord{At: now, Record: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal history record: %w", err)
+	}
+
+	counts := b.copyCounts()
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(sequenceKey(seq), rec); err != nil {
+			return err
+		}
+		counts[string(bucketName)]++
+
+		if b.retention.MaxCount > 0 {
+			return b.trim(tx, counts, bucketName, now, false)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write history record: %w", err)
+	}
+	b.counts = counts
+
+	if b.retention.MaxAge > 0 && now.Sub(b.lastPrune) >= agePruneInterval {
+		return b.pruneLocked(now)
+	}
+	return nil
+}
+
+// copyCounts returns a copy of the record counts for a transaction to
+// update. It replaces b.counts only once the transaction commits, so a
+// failed one leaves the counts as they were. Callers must hold b.mu.
+func (b *BoltBackend) copyCounts() map[string]int {
+	counts := make(map[string]int, len(b.counts))
+	for name, n := range b.counts {
+		counts[name] = n
+	}
+	return counts
+}
+
+// trim deletes records from the front of the bucket that fall outside the
+// retention policy, keeping counts in step. Age is only checked when
+// checkAge is set. Callers must hold b.mu.
+func (b *BoltBackend) trim(tx *bolt.Tx, counts map[string]int, bucketName []byte, now time.Time, checkAge bool) error {
+	bucket := tx.Bucket(bucketName)
+	name := string(bucketName)
+
+	c := bucket.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.First() {
+		overCount := b.retention.MaxCount > 0 && counts[name] > b.retention.MaxCount
+		if !overCount {
+			if !checkAge || b.retention.MaxAge <= 0 {
+				return nil
+			}
+			var rec boltRecord
+			if err := json.Unmarshal(v, &rec); err == nil && !b.retention.expired(rec.At, now) {
+				return nil
+			}
+		}
+		if err := bucket.Delete(k); err != nil {
+			return err
+		}
+		counts[name]--
+	}
+	return nil
+}
+
+// Drifts returns the retained drift alerts
+func (b *BoltBackend) Drifts() ([]types.DriftAlert, error) {
+	return readAll[types.DriftAlert](b.db, bucketDrifts)
+}
+
+// Events returns the retained events
+func (b *BoltBackend) Events() ([]types.Event, error) {
+	return readAll[types.Event](b.db, bucketEvents)
+}
+
+// Unmanaged returns the retained unmanaged resource alerts
+func (b *BoltBackend) Unmanaged() ([]types.UnmanagedResourceAlert, error) {
+	return readAll[types.UnmanagedResourceAlert](b.db, bucketUnmanaged)
+}
+
+// Prune drops records outside the retention policy
+func (b *BoltBackend) Prune(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pruneLocked(now)
+}
+
+func (b *BoltBackend) pruneLocked(now time.Time) error {
+	b.lastPrune = now
+	counts := b.copyCounts()
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range allBuckets {
+			if err := b.trim(tx, counts, name, now, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to prune history: %w", err)
+	}
+	b.counts = counts
+	return nil
+}
+
+// Clear removes all history
+func (b *BoltBackend) Clear() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range allBuckets {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range allBuckets {
+		b.counts[string(name)] = 0
+	}
+	return nil
+}
+
+// Close closes the database file
+func (b *BoltBackend) Close() error {
+	return b.db.Close()
+}
+
+func readAll[T any](db *bolt.DB, bucketName []byte) ([]T, error) {
+	out := make([]T, 0)
+	err := db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketName).ForEach(func(_, v []byte) error {
+			var rec boltRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			var item T
+			if err := json.Unmarshal(rec.Record, &item); err != nil {
+				return err
+			}
+			out = append(out, item)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s history: %w", bucketName, err)
+	}
+	return out, nil
+}
+
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
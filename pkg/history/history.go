@@ -0,0 +1,66 @@
+// Package history persists detected drifts, events and unmanaged-resource
+// alerts so the API and dashboard survive restarts, with bounded retention.
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+)
+
+// Backend stores drift/event history. Records are returned oldest first.
+type Backend interface {
+	AddDrift(drift types.DriftAlert) error
+	AddEvent(event types.Event) error
+	AddUnmanaged(unmanaged types.UnmanagedResourceAlert) error
+
+	Drifts() ([]types.DriftAlert, error)
+	Events() ([]types.Event, error)
+	Unmanaged() ([]types.UnmanagedResourceAlert, error)
+
+	// Prune applies the retention policy as of now
+	Prune(now time.Time) error
+	// Clear removes all history
+	Clear() error
+	// Close releases the backend's resources
+	Close() error
+}
+
+// Retention bounds how much history is kept, per record kind. Zero values
+// mean unlimited.
+type Retention struct {
+	MaxAge   time.Duration
+	MaxCount int
+}
+
+// expired reports whether a record stored at `at` is past MaxAge
+func (r Retention) expired(at, now time.Time) bool {
+	return r.MaxAge > 0 && now.Sub(at) > r.MaxAge
+}
+
+// RetentionFromConfig converts the history config's retention settings
+func RetentionFromConfig(cfg config.HistoryConfig) Retention {
+	return Retention{
+		MaxAge:   time.Duration(cfg.MaxAgeHours) * time.Hour,
+		MaxCount: cfg.MaxRecords,
+	}
+}
+
+// New creates the history backend selected by cfg
+func New(cfg config.HistoryConfig) (Backend, error) {
+	retention := RetentionFromConfig(cfg)
+
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryBackend(retention), nil
+	case "bolt":
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("history.path is required for the bolt backend")
+		}
+		return NewBoltBackend(cfg.Path, retention)
+	default:
+		return nil, fmt.Errorf("unsupported history backend: %s", cfg.Backend)
+	}
+}
@@ -0,0 +1,182 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+// fakeClock returns a controllable time source
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func drift(id string) types.DriftAlert {
+	return types.DriftAlert{ResourceID: id, ResourceType: "aws_instance", Severity: "high"}
+}
+
+func driftIDs(t *testing.T, b Backend) []string {
+	t.Helper()
+	drifts, err := b.Drifts()
+	require.NoError(t, err)
+	ids := make([]string, 0, len(drifts))
+	for _, d := range drifts {
+		ids = append(ids, d.ResourceID)
+	}
+	return ids
+}
+
+func openBolt(t *testing.T, path string, retention Retention, clock *fakeClock) *BoltBackend {
+	t.Helper()
+	b, err := NewBoltBackend(path, retention)
+	require.NoError(t, err)
+	if clock != nil {
+		b.now = clock.now
+	}
+	return b
+}
+
+// backends returns a fresh instance of every backend with the given policy
+func backends(t *testing.T, retention Retention, clock *fakeClock) map[string]Backend {
+	t.Helper()
+	mem := NewMemoryBackend(retention)
+	mem.now = clock.now
+	bolt := openBolt(t, filepath.Join(t.TempDir(), "history.db"), retention, clock)
+	t.Cleanup(func() { _ = bolt.Close() })
+	return map[string]Backend{"memory": mem, "bolt": bolt}
+}
+
+func TestBackends_RecordAndRead(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	for name, b := range backends(t, Retention{}, clock) {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, b.AddDrift(drift("i-1")))
+			require.NoError(t, b.AddDrift(drift("i-2")))
+			require.NoError(t, b.AddEvent(types.Event{EventName: "RunInstances", ResourceID: "i-1",
+				RawEvent: map[string]interface{}{"eventTime": "2026-01-01T00:00:00Z"}}))
+			require.NoError(t, b.AddUnmanaged(types.UnmanagedResourceAlert{ResourceID: "sg-1"}))
+
+			assert.Equal(t, []string{"i-1", "i-2"}, driftIDs(t, b), "records are returned oldest first")
+			events, err := b.Events()
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			assert.Equal(t, "RunInstances", events[0].EventName)
+			unmanaged, err := b.Unmanaged()
+			require.NoError(t, err)
+			assert.Len(t, unmanaged, 1)
+
+			require.NoError(t, b.Clear())
+			assert.Empty(t, driftIDs(t, b))
+		})
+	}
+}
+
+func TestBackends_MaxCount(t *testing.T) {
+	clock := &fakeClock{t: time.Now()}
+	for name, b := range backends(t, Retention{MaxCount: 2}, clock) {
+		t.Run(name, func(t *testing.T) {
+			for _, id := range []string{"a", "b", "c", "d"} {
+				require.NoError(t, b.AddDrift(drift(id)))
+			}
+			assert.Equal(t, []string{"c", "d"}, driftIDs(t, b))
+		})
+	}
+}
+
+func TestMemoryBackend_MaxCountTrimsInBatches(t *testing.T) {
+	m := NewMemoryBackend(Retention{MaxCount: 3})
+	for i := 0; i < 100; i++ {
+		require.NoError(t, m.AddDrift(drift(fmt.Sprintf("i-%d", i))))
+		assert.LessOrEqual(t, len(m.drifts.items), 2*3+1, "dropped records are compacted away")
+	}
+	assert.Equal(t, []string{"i-97", "i-98", "i-99"}, driftIDs(t, m))
+}
+
+func TestBoltBackend_FailedWriteKeepsCounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	b := openBolt(t, path, Retention{MaxCount: 2}, nil)
+	defer func() { _ = b.Close() }()
+	require.NoError(t, b.AddDrift(drift("a")))
+
+	// Reopen capped at its current size so growing the file fails the commit
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, b.db.Close())
+	b.db, err = bolt.Open(path, 0o600, &bolt.Options{MaxSize: int(info.Size())})
+	require.NoError(t, err)
+
+	big := drift("b")
+	big.ResourceName = strings.Repeat("x", 4<<20)
+	assert.Error(t, b.AddDrift(big))
+	assert.Equal(t, 1, b.counts[string(bucketDrifts)], "a failed write must not count")
+	assert.Equal(t, []string{"a"}, driftIDs(t, b))
+}
+
+func TestBackends_MaxAge(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for name, b := range backends(t, Retention{MaxAge: time.Hour}, clock) {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, b.AddDrift(drift("old")))
+			clock.t = clock.t.Add(30 * time.Minute)
+			require.NoError(t, b.AddDrift(drift("new")))
+
+			require.NoError(t, b.Prune(clock.t.Add(45*time.Minute)))
+			assert.Equal(t, []string{"new"}, driftIDs(t, b))
+		})
+	}
+}
+
+func TestBoltBackend_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history.db")
+
+	b := openBolt(t, path, Retention{MaxCount: 3}, nil)
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, b.AddDrift(drift(id)))
+	}
+	require.NoError(t, b.Close())
+
+	b = openBolt(t, path, Retention{MaxCount: 3}, nil)
+	defer func() { _ = b.Close() }()
+	assert.Equal(t, []string{"a", "b", "c"}, driftIDs(t, b))
+
+	// The count survives the restart, so the cap still applies
+	require.NoError(t, b.AddDrift(drift("d")))
+	assert.Equal(t, []string{"b", "c", "d"}, driftIDs(t, b))
+}
+
+func TestBoltBackend_UnserializableRawEvent(t *testing.T) {
+	b := openBolt(t, filepath.Join(t.TempDir(), "history.db"), Retention{}, nil)
+	defer func() { _ = b.Close() }()
+
+	require.NoError(t, b.AddEvent(types.Event{EventName: "X", RawEvent: make(chan int)}))
+	events, err := b.Events()
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Nil(t, events[0].RawEvent)
+}
+
+func TestNew(t *testing.T) {
+	b, err := New(config.HistoryConfig{})
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryBackend{}, b)
+
+	_, err = New(config.HistoryConfig{Backend: "bolt"})
+	assert.Error(t, err, "bolt requires a path")
+
+	_, err = New(config.HistoryConfig{Backend: "redis"})
+	assert.Error(t, err)
+
+	b, err = New(config.HistoryConfig{Backend: "bolt", Path: filepath.Join(t.TempDir(), "h.db"), MaxRecords: 10, MaxAgeHours: 24})
+	require.NoError(t, err)
+	defer func() { _ = b.Close() }()
+	assert.Equal(t, Retention{MaxAge: 24 * time.Hour, MaxCount: 10}, b.(*BoltBackend).retention)
+}
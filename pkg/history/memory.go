@@ -0,0 +1,144 @@
+package history
+
+import (
+	"sync"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+)
+
+// record is a stored value with the time it was recorded
+type record[T any] struct {
+	at    time.Time
+	value T
+}
+
+// ring is an append-only list bounded by a Retention policy. Dropped
+// records are skipped by advancing head; the slice is compacted once more
+// than half of it is dropped, so adding at MaxCount costs amortised O(1)
+// instead of a copy of the whole list.
+type ring[T any] struct {
+	items []record[T]
+	head  int // index of the oldest retained record
+}
+
+func (r *ring[T]) add(v T, now time.Time, retention Retention) {
+	r.items = append(r.items, record[T]{at: now, value: v})
+	r.prune(now, retention)
+}
+
+func (r *ring[T]) prune(now time.Time, retention Retention) {
+	drop := r.head
+	for drop < len(r.items) && retention.expired(r.items[drop].at, now) {
+		drop++
+	}
+	if retention.MaxCount > 0 && len(r.items)-drop > retention.MaxCount {
+		drop = len(r.items) - retention.MaxCount
+	}
+	// Release the dropped values so they can be garbage collected
+	clear(r.items[r.head:drop])
+	r.head = drop
+
+	if r.head > len(r.items)/2 {
+		r.items = append([]record[T](nil), r.items[r.head:]...)
+		r.head = 0
+	}
+}
+
+func (r *ring[T]) values() []T {
+	live := r.items[r.head:]
+	out := make([]T, len(live))
+	for i, item := range live {
+		out[i] = item.value
+	}
+	return out
+}
+
+// MemoryBackend keeps history in process memory. It is lost on restart but,
+// unlike the previous unbounded slices, honours the retention policy.
+type MemoryBackend struct {
+	retention Retention
+	drifts    ring[types.DriftAlert]
+	events    ring[types.Event]
+	unmanaged ring[types.UnmanagedResourceAlert]
+	now       func() time.Time
+	mu        sync.RWMutex
+}
+
+// Compile-time interface check
+var _ Backend = (*MemoryBackend)(nil)
+
+// NewMemoryBackend creates an in-memory history backend
+func NewMemoryBackend(retention Retention) *MemoryBackend {
+	return &MemoryBackend{retention: retention, now: time.Now}
+}
+
+// AddDrift records a drift alert
+func (m *MemoryBackend) AddDrift(drift types.DriftAlert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drifts.add(drift, m.now(), m.retention)
+	return nil
+}
+
+// AddEvent records an event
+func (m *MemoryBackend) AddEvent(event types.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events.add(event, m.now(), m.retention)
+	return nil
+}
+
+// AddUnmanaged records an unmanaged resource alert
+func (m *MemoryBackend) AddUnmanaged(unmanaged types.UnmanagedResourceAlert) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unmanaged.add(unmanaged, m.now(), m.retention)
+	return nil
+}
+
+// Drifts returns the retained drift alerts
+func (m *MemoryBackend) Drifts() ([]types.DriftAlert, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.drifts.values(), nil
+}
+
+// Events returns the retained events
+func (m *MemoryBackend) Events() ([]types.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.events.values(), nil
+}
+
+// Unmanaged returns the retained unmanaged resource alerts
+func (m *MemoryBackend) Unmanaged() ([]types.UnmanagedResourceAlert, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.unmanaged.values(), nil
+}
+
+// Prune drops records outside the retention policy
+func (m *MemoryBackend) Prune(now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drifts.prune(now, m.retention)
+	m.events.prune(now, m.retention)
+	m.unmanaged.prune(now, m.retention)
+	return nil
+}
+
+// Clear removes all history
+func (m *MemoryBackend) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drifts = ring[types.DriftAlert]{}
+	m.events = ring[types.Event]{}
+	m.unmanaged = ring[types.UnmanagedResourceAlert]{}
+	return nil
+}
+
+// Close is a no-op for the memory backend
+func (m *MemoryBackend) Close() error {
+	return nil
+}
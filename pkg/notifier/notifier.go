@@ -32,8 +32,11 @@ func NewManager(cfg config.NotificationsConfig) (*Manager, error) {
 	}, nil
 }
 
-// Send sends a drift alert to configured channels
+// Send sends a drift alert to configured channels, with sensitive values
+// redacted
 func (m *Manager) Send(alert *types.DriftAlert) error {
+	alert = alert.Redacted()
+
 	_, span := telemetry.StartSpan(context.Background(), "notifier.send",
 		trace.WithAttributes(
 			telemetry.AttrSeverity.String(alert.Severity),
@@ -109,7 +112,7 @@ func (m *Manager) formatSlackMessage(alert *types.DriftAlert) []map[string]inter
 			"type": "header",
 			"text": map[string]string{
 				"type": "plain_text",
-				"text": fmt.Sprintf("%s Drift Detected: %s.%s", emoji, alert.ResourceType, alert.ResourceName),
+				"text": fmt.Sprintf("%s Drift Detected: %s", emoji, alert.Address()),
 			},
 		},
 		{
@@ -138,13 +141,26 @@ func (m *Manager) formatSlackMessage(alert *types.DriftAlert) []map[string]inter
 			"elements": []map[string]string{
 				{
 					"type": "mrkdwn",
-					"text": fmt.Sprintf("Resource ID: `%s` | Time: %s", alert.ResourceID, alert.Timestamp),
+					"text": slackContext(alert),
 				},
 			},
 		},
 	}
 }
 
+// slackContext renders the context line, including the Terraform state the
+// resource belongs to when it is known.
+func slackContext(alert *types.DriftAlert) string {
+	text := fmt.Sprintf("Resource ID: `%s` | Time: %s", alert.ResourceID, alert.Timestamp)
+	if alert.StateName != "" {
+		text += fmt.Sprintf(" | State: `%s`", alert.StateName)
+	}
+	if alert.TerraformWorkspace != "" {
+		text += fmt.Sprintf(" | Workspace: `%s`", alert.TerraformWorkspace)
+	}
+	return text
+}
+
 // sendDiscord sends alert to Discord
 func (m *Manager) sendDiscord(alert *types.DriftAlert) error {
 	severityColor := map[string]int{
@@ -155,7 +171,7 @@ func (m *Manager) sendDiscord(alert *types.DriftAlert) error {
 	}
 
 	embed := map[string]interface{}{
-		"title":       fmt.Sprintf("Drift Detected: %s.%s", alert.ResourceType, alert.ResourceName),
+		"title":       fmt.Sprintf("Drift Detected: %s", alert.Address()),
 		"description": fmt.Sprintf("Attribute `%s` was modified", alert.Attribute),
 		"color":       severityColor[alert.Severity],
 		"fields": []map[string]interface{}{
@@ -192,22 +208,26 @@ func (m *Manager) sendDiscord(alert *types.DriftAlert) error {
 func (m *Manager) sendFalcoOutput(alert *types.DriftAlert) error {
 	// Format as Falco JSON output
 	falcoEvent := map[string]interface{}{
-		"output": fmt.Sprintf("Terraform drift detected: %s.%s attribute %s changed from %v to %v (user=%s resource=%s)",
-			alert.ResourceType, alert.ResourceName, alert.Attribute,
+		"output": fmt.Sprintf("Terraform drift detected: %s attribute %s changed from %v to %v (user=%s resource=%s)",
+			alert.Address(), alert.Attribute,
 			alert.OldValue, alert.NewValue,
 			alert.UserIdentity.UserName, alert.ResourceID),
 		"priority": m.mapSeverityToPriority(alert.Severity),
 		"rule":     "Terraform Drift Detection",
 		"time":     alert.Timestamp,
 		"output_fields": map[string]interface{}{
-			"resource.type":   alert.ResourceType,
-			"resource.name":   alert.ResourceName,
-			"resource.id":     alert.ResourceID,
-			"drift.attribute": alert.Attribute,
-			"drift.old_value": alert.OldValue,
-			"drift.new_value": alert.NewValue,
-			"user.name":       alert.UserIdentity.UserName,
-			"severity":        alert.Severity,
+			"resource.type":    alert.ResourceType,
+			"resource.name":    alert.ResourceName,
+			"resource.address": alert.Address(),
+			"resource.id":      alert.ResourceID,
+			"drift.attribute":  alert.Attribute,
+			"drift.old_value":  alert.OldValue,
+			"drift.new_value":  alert.NewValue,
+			"user.name":        alert.UserIdentity.UserName,
+			"severity":         alert.Severity,
+			"tf.state":         alert.StateName,
+			"tf.workspace":     alert.TerraformWorkspace,
+			"tf.backend":       alert.StateBackend,
 		},
 	}
 
@@ -91,6 +91,35 @@ func TestSend_Discord(t *testing.T) {
 	assert.Len(t, embeds, 1)
 }
 
+func TestSend_RedactsSensitiveValues(t *testing.T) {
+	slack := testutil.NewMockHTTPServer()
+	defer slack.Close()
+	discord := testutil.NewMockHTTPServer()
+	defer discord.Close()
+
+	manager, err := NewManager(config.NotificationsConfig{
+		Slack:   config.SlackConfig{Enabled: true, WebhookURL: slack.URL()},
+		Discord: config.DiscordConfig{Enabled: true, WebhookURL: discord.URL()},
+	})
+	require.NoError(t, err)
+
+	alert := testutil.CreateTestDriftAlert()
+	alert.Attribute = "password"
+	alert.OldValue = "old-pw-123"
+	alert.NewValue = "new-pw-456"
+	alert.Sensitive = true
+
+	require.NoError(t, manager.Send(alert))
+
+	for name, body := range map[string]string{
+		"slack":   slack.GetLastRequestBody(),
+		"discord": discord.GetLastRequestBody(),
+	} {
+		assert.NotContains(t, body, "pw-", name)
+		assert.Contains(t, body, types.RedactedValue, name)
+	}
+}
+
 func TestSend_FalcoOutput(t *testing.T) {
 	cfg := config.NotificationsConfig{
 		FalcoOutput: config.FalcoOutputConfig{
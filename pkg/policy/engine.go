@@ -154,6 +154,13 @@ func parseResult(raw map[string]interface{}) *EvalResult {
 		}
 	}
 
+	if rem, ok := raw["remediation"].(string); ok {
+		switch rem {
+		case RemediationUpdateCode, RemediationIgnoreChanges:
+			result.Remediation = rem
+		}
+	}
+
 	if suppressors, ok := raw["suppressors"].([]interface{}); ok {
 		for _, s := range suppressors {
 			if sv, ok := s.(string); ok {
@@ -266,4 +266,17 @@ func TestParseResultEdgeCases(t *testing.T) {
 	if len(r.Suppressors) != 2 {
 		t.Errorf("expected 2 suppressors, got %d", len(r.Suppressors))
 	}
+
+	// Remediation strategy; unknown values are dropped
+	r = parseResult(map[string]interface{}{
+		"decision":    "remediate",
+		"remediation": "ignore_changes",
+	})
+	if r.Remediation != RemediationIgnoreChanges {
+		t.Errorf("expected ignore_changes, got %q", r.Remediation)
+	}
+	r = parseResult(map[string]interface{}{"remediation": "delete_everything"})
+	if r.Remediation != "" {
+		t.Errorf("expected unknown remediation to be dropped, got %q", r.Remediation)
+	}
 }
@@ -15,6 +15,14 @@ const (
 	DecisionDeny Decision = "deny"
 )
 
+// Remediation strategies a policy can pick for a drift it remediates.
+const (
+	// RemediationUpdateCode writes the cloud value into the Terraform source
+	RemediationUpdateCode = "update_code"
+	// RemediationIgnoreChanges adds the attribute to lifecycle.ignore_changes
+	RemediationIgnoreChanges = "ignore_changes"
+)
+
 // EvalResult holds the output of a policy evaluation.
 type EvalResult struct {
 	Decision    Decision          `json:"decision"`
@@ -22,6 +30,7 @@ type EvalResult struct {
 	Severity    string            `json:"severity,omitempty"`    // override severity from policy
 	Labels      map[string]string `json:"labels,omitempty"`      // additional labels for routing
 	Suppressors []string          `json:"suppressors,omitempty"` // reasons why the drift is suppressed
+	Remediation string            `json:"remediation,omitempty"` // RemediationUpdateCode (default) or RemediationIgnoreChanges
 }
 
 // DriftInput is the input document passed to Rego policies for drift evaluation.
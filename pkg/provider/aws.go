@@ -3,6 +3,8 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/keitahigaki/tfdrift-falco/pkg/aws"
 	"github.com/keitahigaki/tfdrift-falco/pkg/falco"
@@ -16,6 +18,8 @@ var (
 	_ Provider           = (*AWSProvider)(nil)
 	_ ResourceDiscoverer = (*AWSProvider)(nil)
 	_ StateComparator    = (*AWSProvider)(nil)
+	_ StateKeyer         = (*AWSProvider)(nil)
+	_ ResourceFetcher    = (*AWSProvider)(nil)
 )
 
 // AWSProvider implements Provider, ResourceDiscoverer, and StateComparator
@@ -24,6 +28,11 @@ var (
 type AWSProvider struct {
 	relevantEvents map[string]bool
 	regions        []string // configured AWS regions
+
+	// Discovery clients for FetchResource, per region, created on first use
+	fetchMu        sync.Mutex
+	fetchClients   map[string]*aws.DiscoveryClient
+	newFetchClient func(ctx context.Context, region string) (*aws.DiscoveryClient, error)
 }
 
 // AWSProviderOption configures the AWS provider.
@@ -41,6 +50,8 @@ func NewAWSProvider(opts ...AWSProviderOption) *AWSProvider {
 	p := &AWSProvider{
 		relevantEvents: falco.GetAWSRelevantEvents(),
 		regions:        []string{"us-east-1"}, // default region
+		fetchClients:   make(map[string]*aws.DiscoveryClient),
+		newFetchClient: aws.NewDiscoveryClient,
 	}
 	for _, opt := range opts {
 		opt(p)
@@ -106,6 +117,9 @@ func (p *AWSProvider) ParseEvent(source string, fields map[string]string, rawEve
 	if eventSource := fields["ct.src"]; eventSource != "" {
 		event.Metadata["event_source"] = eventSource
 	}
+	if userAgent := fields["ct.useragent"]; userAgent != "" {
+		event.Metadata["user_agent"] = userAgent
+	}
 
 	return event
 }
@@ -224,6 +238,42 @@ func (p *AWSProvider) SupportedDiscoveryTypes() []string {
 	}
 }
 
+// --- ResourceFetcher implementation ---
+
+// FetchResource reads one AWS resource with a Describe call scoped to its ID.
+func (p *AWSProvider) FetchResource(ctx context.Context, resourceType, resourceID string, opts FetchOptions) (*types.DiscoveredResource, error) {
+	region := opts.Region
+	if region == "" && len(p.regions) > 0 {
+		region = p.regions[0]
+	}
+
+	client, err := p.fetchClient(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	r, err := client.DiscoverResource(ctx, resourceType, resourceID)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	r.Provider = "aws"
+	return r, nil
+}
+
+func (p *AWSProvider) fetchClient(ctx context.Context, region string) (*aws.DiscoveryClient, error) {
+	p.fetchMu.Lock()
+	defer p.fetchMu.Unlock()
+
+	if client, ok := p.fetchClients[region]; ok {
+		return client, nil
+	}
+	client, err := p.newFetchClient(ctx, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client for region %s: %w", region, err)
+	}
+	p.fetchClients[region] = client
+	return client, nil
+}
+
 // --- StateComparator implementation ---
 
 // CompareState compares Terraform resources with discovered AWS resources.
@@ -279,6 +329,7 @@ func (p *AWSProvider) CompareState(tfResources []*types.TerraformResource, actua
 		result.MissingResources = append(result.MissingResources, &types.TerraformResource{
 			Type:       r.Type,
 			Name:       r.Name,
+			ID:         r.ID,
 			Provider:   "aws",
 			Attributes: r.Attributes,
 		})
@@ -305,3 +356,42 @@ func (p *AWSProvider) CompareState(tfResources []*types.TerraformResource, actua
 
 	return result
 }
+
+// awsKeyAttributes are state attributes that CloudTrail events commonly use
+// instead of the resource's Terraform ID.
+var awsKeyAttributes = []string{
+	"unique_id",              // IAM roles/users (AROA.../AIDA...)
+	"function_name",          // Lambda
+	"qualified_arn",          // Lambda versions
+	"identifier",             // RDS instances/clusters (id is the dbi-resource-id)
+	"cluster_identifier",     // RDS/Redshift clusters
+	"bucket",                 // S3
+	"url",                    // SQS
+	"repository_url",         // ECR
+	"db_instance_identifier", // legacy RDS
+}
+
+// StateKeys implements StateKeyer: CloudTrail identifies resources by name,
+// ARN or service-specific identifiers that don't match the Terraform ID.
+func (p *AWSProvider) StateKeys(resourceType string, attributes map[string]interface{}) []string {
+	var keys []string
+	for _, attr := range awsKeyAttributes {
+		if v, ok := attributes[attr].(string); ok && v != "" {
+			keys = append(keys, v)
+		}
+	}
+
+	if arn, ok := attributes["arn"].(string); ok && strings.HasPrefix(arn, "arn:") {
+		// The trailing resource name of an ARN (topic, role, function, ...)
+		if i := strings.LastIndexAny(arn, ":/"); i >= 0 && i+1 < len(arn) {
+			keys = append(keys, arn[i+1:])
+		}
+	}
+
+	if resourceType == "aws_s3_bucket" {
+		if bucket, ok := attributes["bucket"].(string); ok && bucket != "" {
+			keys = append(keys, "arn:aws:s3:::"+bucket)
+		}
+	}
+	return keys
+}
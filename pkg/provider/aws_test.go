@@ -1,8 +1,13 @@
 package provider
 
 import (
+	"context"
 	"testing"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/keitahigaki/tfdrift-falco/pkg/aws"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -384,3 +389,71 @@ func TestAWSParseEvent_MinimalFields(t *testing.T) {
 		assert.Equal(t, "RunInstances", event.EventName)
 	}
 }
+
+// --- AWS State Key Tests ---
+
+func TestAWSStateKeys(t *testing.T) {
+	p := NewAWSProvider()
+
+	keys := p.StateKeys("aws_sns_topic", map[string]interface{}{
+		"id":  "arn:aws:sns:us-east-1:123456789012:alerts",
+		"arn": "arn:aws:sns:us-east-1:123456789012:alerts",
+	})
+	assert.Contains(t, keys, "alerts")
+
+	keys = p.StateKeys("aws_iam_role", map[string]interface{}{
+		"id":        "deploy",
+		"arn":       "arn:aws:iam::123456789012:role/ci/deploy",
+		"unique_id": "AROAEXAMPLE",
+	})
+	assert.Contains(t, keys, "AROAEXAMPLE")
+	assert.Contains(t, keys, "deploy")
+
+	keys = p.StateKeys("aws_s3_bucket", map[string]interface{}{"id": "logs", "bucket": "logs"})
+	assert.Contains(t, keys, "arn:aws:s3:::logs")
+
+	keys = p.StateKeys("aws_db_instance", map[string]interface{}{"id": "db-ABC", "identifier": "orders"})
+	assert.Contains(t, keys, "orders")
+}
+
+// fakeFetchEC2 serves DescribeInstances for FetchResource tests
+type fakeFetchEC2 struct {
+	aws.EC2API
+	instanceType string
+}
+
+func (f *fakeFetchEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	var instances []ec2Types.Instance
+	for _, id := range params.InstanceIds {
+		instances = append(instances, ec2Types.Instance{
+			InstanceId:   awssdk.String(id),
+			InstanceType: ec2Types.InstanceType(f.instanceType),
+			Placement:    &ec2Types.Placement{},
+			State:        &ec2Types.InstanceState{},
+		})
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2Types.Reservation{{Instances: instances}}}, nil
+}
+
+func TestAWSFetchResource(t *testing.T) {
+	p := NewAWSProvider(WithAWSRegions([]string{"us-east-1", "eu-west-1"}))
+	var created []string
+	p.newFetchClient = func(ctx context.Context, region string) (*aws.DiscoveryClient, error) {
+		created = append(created, region)
+		return aws.NewDiscoveryClientWithServices(region, &fakeFetchEC2{instanceType: "t3.large"}, nil, nil, nil, nil), nil
+	}
+	assert.True(t, GetCapabilities(p).Fetch)
+
+	r, err := p.FetchResource(context.Background(), "aws_instance", "i-123", FetchOptions{Region: "eu-west-1"})
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, "aws", r.Provider)
+	assert.Equal(t, "eu-west-1", r.Region)
+	assert.Equal(t, "t3.large", r.Attributes["instance_type"])
+
+	_, err = p.FetchResource(context.Background(), "aws_instance", "i-456", FetchOptions{})
+	require.NoError(t, err)
+	_, err = p.FetchResource(context.Background(), "aws_instance", "i-789", FetchOptions{Region: "eu-west-1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu-west-1", "us-east-1"}, created, "one client per region, default region when unset")
+}
@@ -14,6 +14,8 @@ var (
 	_ Provider           = (*AzureProvider)(nil)
 	_ ResourceDiscoverer = (*AzureProvider)(nil)
 	_ StateComparator    = (*AzureProvider)(nil)
+	_ StateKeyer         = (*AzureProvider)(nil)
+	_ ResourceFetcher    = (*AzureProvider)(nil)
 )
 
 // AzureProvider implements Provider, ResourceDiscoverer, and StateComparator
@@ -209,33 +211,60 @@ func (p *AzureProvider) DiscoverResources(ctx context.Context, opts DiscoveryOpt
 	// Convert Azure-specific DiscoveredResource to common type
 	var allResources []*types.DiscoveredResource
 	for _, r := range azureResources {
-		metadata := map[string]string{
-			"subscription_id": subscriptionID,
-		}
-		if rg := extractResourceGroupFromAzureID(r.ID); rg != "" {
-			metadata["resource_group"] = rg
-		}
-
-		allResources = append(allResources, &types.DiscoveredResource{
-			ID:         r.ID,
-			Type:       r.Type,
-			Provider:   "azure",
-			Name:       r.Name,
-			Region:     r.Region,
-			Attributes: r.Attributes,
-			Tags:       r.Tags,
-			Metadata:   metadata,
-		})
+		allResources = append(allResources, azureDiscovered(subscriptionID, r))
 	}
 
 	return allResources, nil
 }
 
+// azureDiscovered converts an Azure discovered resource to the common type,
+// with the subscription and resource group as metadata.
+func azureDiscovered(subscriptionID string, r *azurepkg.DiscoveredResource) *types.DiscoveredResource {
+	metadata := map[string]string{
+		"subscription_id": subscriptionID,
+	}
+	if rg := extractResourceGroupFromAzureID(r.ID); rg != "" {
+		metadata["resource_group"] = rg
+	}
+
+	return &types.DiscoveredResource{
+		ID:         r.ID,
+		Type:       r.Type,
+		Provider:   "azure",
+		Name:       r.Name,
+		Region:     r.Region,
+		Attributes: r.Attributes,
+		Tags:       r.Tags,
+		Metadata:   metadata,
+	}
+}
+
 // SupportedDiscoveryTypes returns the Terraform resource types that Azure can discover.
 func (p *AzureProvider) SupportedDiscoveryTypes() []string {
 	return azurepkg.SupportedDiscoveryTypes()
 }
 
+// --- ResourceFetcher implementation ---
+
+// FetchResource reads one Azure resource, listing only its resource group.
+// The subscription is taken from the resource ID when none is configured.
+func (p *AzureProvider) FetchResource(ctx context.Context, resourceType, resourceID string, opts FetchOptions) (*types.DiscoveredResource, error) {
+	subscriptionID := p.subscriptionID
+	if subscriptionID == "" {
+		subscriptionID = extractSubscriptionFromAzureID(resourceID)
+	}
+
+	client, err := azurepkg.NewDiscoveryClient(subscriptionID, nil, p.resourceLister)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure discovery client: %w", err)
+	}
+	r, err := client.DiscoverResource(ctx, resourceType, resourceID)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	return azureDiscovered(subscriptionID, r), nil
+}
+
 // --- StateComparator implementation ---
 
 // CompareState compares Terraform resources with discovered Azure resources.
@@ -289,6 +318,7 @@ func (p *AzureProvider) CompareState(tfResources []*types.TerraformResource, act
 		result.MissingResources = append(result.MissingResources, &types.TerraformResource{
 			Type:       r.Type,
 			Name:       r.Name,
+			ID:         r.ID,
 			Provider:   "azure",
 			Attributes: r.Attributes,
 		})
@@ -326,3 +356,28 @@ func extractResourceGroupFromAzureID(resourceID string) string {
 	}
 	return ""
 }
+
+// extractSubscriptionFromAzureID extracts the subscription ID from an Azure resource ID.
+func extractSubscriptionFromAzureID(resourceID string) string {
+	parts := strings.Split(resourceID, "/")
+	for i, part := range parts {
+		if strings.EqualFold(part, "subscriptions") && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// StateKeys implements StateKeyer: some azurerm resources (Key Vault secrets,
+// keys, certificates) use a data-plane URL as their Terraform ID and keep the
+// ARM resource ID, which Activity Log events reference, in other attributes.
+// ARM IDs are matched case-insensitively by the state manager.
+func (p *AzureProvider) StateKeys(resourceType string, attributes map[string]interface{}) []string {
+	var keys []string
+	for _, attr := range []string{"resource_id", "resource_versionless_id", "versionless_id"} {
+		if v, ok := attributes[attr].(string); ok && v != "" {
+			keys = append(keys, v)
+		}
+	}
+	return keys
+}
@@ -387,3 +387,45 @@ func TestAzureProviderImplementsInterfaces(t *testing.T) {
 	// StateComparator interface
 	var _ StateComparator = p
 }
+
+func TestAzureStateKeys(t *testing.T) {
+	p := NewAzureProvider()
+
+	keys := p.StateKeys("azurerm_key_vault_secret", map[string]interface{}{
+		"id":                      "https://kv.vault.azure.net/secrets/db/abc",
+		"resource_id":             "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv/secrets/db/versions/abc",
+		"resource_versionless_id": "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv/secrets/db",
+	})
+	assert.Len(t, keys, 2)
+	assert.True(t, GetCapabilities(p).StateKeys)
+}
+
+type staticAzureResourceLister struct {
+	resources []*azurepkg.Resource
+}
+
+func (l *staticAzureResourceLister) ListResources(ctx context.Context, subscriptionID string, resourceGroup string) ([]*azurepkg.Resource, error) {
+	return l.resources, nil
+}
+
+func TestAzureFetchResource(t *testing.T) {
+	id := "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg-web"
+	p := NewAzureProvider(WithAzureResourceLister(&staticAzureResourceLister{resources: []*azurepkg.Resource{
+		{ID: id, Name: "nsg-web", Type: "Microsoft.Network/networkSecurityGroups", Location: "eastus"},
+	}}))
+	assert.True(t, GetCapabilities(p).Fetch)
+
+	r, err := p.FetchResource(context.Background(), "azurerm_network_security_group", id, FetchOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, r)
+	assert.Equal(t, "azure", r.Provider)
+	assert.Equal(t, "sub-1", r.Metadata["subscription_id"], "subscription comes from the ID when not configured")
+	assert.Equal(t, "rg", r.Metadata["resource_group"])
+
+	r, err = p.FetchResource(context.Background(), "azurerm_network_security_group", id+"-deleted", FetchOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, r)
+
+	_, err = NewAzureProvider().FetchResource(context.Background(), "azurerm_network_security_group", id, FetchOptions{})
+	assert.Error(t, err, "no resource lister configured")
+}
@@ -3,6 +3,8 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	gcppkg "github.com/keitahigaki/tfdrift-falco/pkg/gcp"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
@@ -13,6 +15,8 @@ var (
 	_ Provider           = (*GCPProvider)(nil)
 	_ ResourceDiscoverer = (*GCPProvider)(nil)
 	_ StateComparator    = (*GCPProvider)(nil)
+	_ StateKeyer         = (*GCPProvider)(nil)
+	_ ResourceFetcher    = (*GCPProvider)(nil)
 )
 
 // GCPProvider implements Provider, ResourceDiscoverer, and StateComparator
@@ -23,6 +27,10 @@ type GCPProvider struct {
 	mapper    *gcppkg.ResourceMapper
 	projectID string   // GCP project ID for resource discovery
 	regions   []string // configured GCP regions
+
+	// Discovery clients for FetchResource, per project, created on first use
+	fetchMu      sync.Mutex
+	fetchClients map[string]*gcppkg.DiscoveryClient
 }
 
 // GCPProviderOption configures the GCP provider.
@@ -45,9 +53,10 @@ func WithGCPRegions(regions []string) GCPProviderOption {
 // NewGCPProvider creates a new GCP provider instance.
 func NewGCPProvider(opts ...GCPProviderOption) *GCPProvider {
 	p := &GCPProvider{
-		parser:  gcppkg.NewAuditParser(),
-		mapper:  gcppkg.NewResourceMapper(),
-		regions: []string{}, // empty = all regions
+		parser:       gcppkg.NewAuditParser(),
+		mapper:       gcppkg.NewResourceMapper(),
+		regions:      []string{}, // empty = all regions
+		fetchClients: make(map[string]*gcppkg.DiscoveryClient),
 	}
 	for _, opt := range opts {
 		opt(p)
@@ -172,9 +181,14 @@ func (p *GCPProvider) SupportedResourceTypes() []string {
 // --- ResourceDiscoverer implementation ---
 
 // DiscoverResources enumerates actual GCP resources across configured regions.
+// opts.Projects overrides the configured project so a single provider can
+// cover several projects (e.g. `tfdrift scan` with providers.gcp.projects).
 func (p *GCPProvider) DiscoverResources(ctx context.Context, opts DiscoveryOptions) ([]*types.DiscoveredResource, error) {
-	projectID := p.projectID
-	if projectID == "" {
+	projects := opts.Projects
+	if len(projects) == 0 && p.projectID != "" {
+		projects = []string{p.projectID}
+	}
+	if len(projects) == 0 {
 		return nil, fmt.Errorf("GCP project ID is required for resource discovery; use WithGCPProjectID option")
 	}
 
@@ -183,41 +197,49 @@ func (p *GCPProvider) DiscoverResources(ctx context.Context, opts DiscoveryOptio
 		regions = p.regions
 	}
 
-	client, err := gcppkg.NewDiscoveryClient(ctx, projectID, regions)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create GCP discovery client: %w", err)
-	}
-
-	gcpResources, err := client.DiscoverAll(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to discover GCP resources: %w", err)
-	}
-
-	// Convert GCP-specific DiscoveredResource to common type
 	var allResources []*types.DiscoveredResource
-	for _, r := range gcpResources {
-		metadata := map[string]string{
-			"project_id": projectID,
+	for _, projectID := range projects {
+		client, err := gcppkg.NewDiscoveryClient(ctx, projectID, regions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCP discovery client for project %s: %w", projectID, err)
 		}
-		if r.SelfLink != "" {
-			metadata["self_link"] = r.SelfLink
+
+		gcpResources, err := client.DiscoverAll(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover GCP resources in project %s: %w", projectID, err)
 		}
 
-		allResources = append(allResources, &types.DiscoveredResource{
-			ID:         r.ID,
-			Type:       r.Type,
-			Provider:   "gcp",
-			Name:       r.Name,
-			Region:     r.Region,
-			Attributes: r.Attributes,
-			Tags:       r.Labels,
-			Metadata:   metadata,
-		})
+		// Convert GCP-specific DiscoveredResource to common type
+		for _, r := range gcpResources {
+			allResources = append(allResources, gcpDiscovered(projectID, r))
+		}
 	}
 
 	return allResources, nil
 }
 
+// gcpDiscovered converts a GCP discovered resource to the common type:
+// labels become tags, the project and self link become metadata.
+func gcpDiscovered(projectID string, r *gcppkg.DiscoveredResource) *types.DiscoveredResource {
+	metadata := map[string]string{
+		"project_id": projectID,
+	}
+	if r.SelfLink != "" {
+		metadata["self_link"] = r.SelfLink
+	}
+
+	return &types.DiscoveredResource{
+		ID:         r.ID,
+		Type:       r.Type,
+		Provider:   "gcp",
+		Name:       r.Name,
+		Region:     r.Region,
+		Attributes: r.Attributes,
+		Tags:       r.Labels,
+		Metadata:   metadata,
+	}
+}
+
 // SupportedDiscoveryTypes returns the Terraform resource types that GCP can discover.
 func (p *GCPProvider) SupportedDiscoveryTypes() []string {
 	return []string{
@@ -232,6 +254,44 @@ func (p *GCPProvider) SupportedDiscoveryTypes() []string {
 	}
 }
 
+// --- ResourceFetcher implementation ---
+
+// FetchResource reads one GCP resource, listing only resources of its type.
+func (p *GCPProvider) FetchResource(ctx context.Context, resourceType, resourceID string, opts FetchOptions) (*types.DiscoveredResource, error) {
+	projectID := opts.Project
+	if projectID == "" {
+		projectID = p.projectID
+	}
+	if projectID == "" {
+		return nil, fmt.Errorf("GCP project ID is required to fetch %s", resourceID)
+	}
+
+	client, err := p.fetchClient(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	r, err := client.DiscoverResource(ctx, resourceType, resourceID)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	return gcpDiscovered(projectID, r), nil
+}
+
+func (p *GCPProvider) fetchClient(ctx context.Context, projectID string) (*gcppkg.DiscoveryClient, error) {
+	p.fetchMu.Lock()
+	defer p.fetchMu.Unlock()
+
+	if client, ok := p.fetchClients[projectID]; ok {
+		return client, nil
+	}
+	client, err := gcppkg.NewDiscoveryClient(ctx, projectID, p.regions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCP discovery client for project %s: %w", projectID, err)
+	}
+	p.fetchClients[projectID] = client
+	return client, nil
+}
+
 // --- StateComparator implementation ---
 
 // CompareState compares Terraform resources with discovered GCP resources.
@@ -285,6 +345,7 @@ func (p *GCPProvider) CompareState(tfResources []*types.TerraformResource, actua
 		result.MissingResources = append(result.MissingResources, &types.TerraformResource{
 			Type:       r.Type,
 			Name:       r.Name,
+			ID:         r.ID,
 			Provider:   "gcp",
 			Attributes: r.Attributes,
 		})
@@ -311,3 +372,32 @@ func (p *GCPProvider) CompareState(tfResources []*types.TerraformResource, actua
 
 	return result
 }
+
+// StateKeys implements StateKeyer: Cloud Audit Logs name resources by their
+// API path ("projects/p/zones/z/instances/vm"), which Terraform state keeps
+// as a self link URL or under a type-specific ID format.
+func (p *GCPProvider) StateKeys(resourceType string, attributes map[string]interface{}) []string {
+	var keys []string
+
+	if selfLink, ok := attributes["self_link"].(string); ok {
+		if i := strings.Index(selfLink, "/projects/"); i >= 0 {
+			keys = append(keys, selfLink[i+1:])
+		}
+	}
+	if id, ok := attributes["instance_id"].(string); ok && id != "" {
+		keys = append(keys, id)
+	}
+
+	name, _ := attributes["name"].(string)
+	switch resourceType {
+	case "google_storage_bucket":
+		if name != "" {
+			keys = append(keys, "projects/_/buckets/"+name)
+		}
+	case "google_project":
+		if projectID, ok := attributes["project_id"].(string); ok && projectID != "" {
+			keys = append(keys, "projects/"+projectID)
+		}
+	}
+	return keys
+}
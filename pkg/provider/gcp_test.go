@@ -371,3 +371,20 @@ func TestGCPProviderWithRegionsOption(t *testing.T) {
 	p := NewGCPProvider(WithGCPRegions(regions))
 	assert.Equal(t, regions, p.regions)
 }
+
+// --- GCP State Key Tests ---
+
+func TestGCPStateKeys(t *testing.T) {
+	p := NewGCPProvider()
+
+	keys := p.StateKeys("google_compute_instance", map[string]interface{}{
+		"id":          "my-project/us-central1-a/vm-1",
+		"self_link":   "https://www.googleapis.com/compute/v1/projects/my-project/zones/us-central1-a/instances/vm-1",
+		"instance_id": "1234567890",
+	})
+	assert.Contains(t, keys, "projects/my-project/zones/us-central1-a/instances/vm-1")
+	assert.Contains(t, keys, "1234567890")
+
+	keys = p.StateKeys("google_storage_bucket", map[string]interface{}{"name": "assets"})
+	assert.Equal(t, []string{"projects/_/buckets/assets"}, keys)
+}
@@ -4,6 +4,7 @@
 //   - Provider: core interface for event parsing and resource mapping (required)
 //   - ResourceDiscoverer: optional interface for discovering actual cloud resources
 //   - StateComparator: optional interface for comparing Terraform state with actual state
+//   - ResourceFetcher: optional interface for reading a single resource's current state
 //   - FullProvider: composite interface combining all capabilities
 //
 // Providers implement the interfaces they support. Use type assertions to check
@@ -51,6 +52,9 @@ type DiscoveryOptions struct {
 	// Regions to discover resources in (empty = all configured regions)
 	Regions []string
 
+	// Projects to discover resources in (GCP only; empty = the provider's configured project)
+	Projects []string
+
 	// ResourceTypes to discover (empty = all supported types)
 	ResourceTypes []string
 
@@ -88,6 +92,34 @@ type StateComparator interface {
 	CompareState(tfResources []*types.TerraformResource, actualResources []*types.DiscoveredResource, opts CompareOptions) *types.DriftResult
 }
 
+// FetchOptions locates a single resource for ResourceFetcher.
+type FetchOptions struct {
+	// Region of the resource (AWS; empty = the provider's first configured region)
+	Region string
+
+	// Project of the resource (GCP; empty = the provider's configured project)
+	Project string
+}
+
+// ResourceFetcher is an optional interface for providers that can read the
+// current state of one resource, so a change reported by an event can be
+// verified against Terraform state without a full discovery scan.
+type ResourceFetcher interface {
+	// FetchResource returns the live resource of a discoverable Terraform
+	// type (see SupportedDiscoveryTypes), or nil if it no longer exists.
+	FetchResource(ctx context.Context, resourceType, resourceID string, opts FetchOptions) (*types.DiscoveredResource, error)
+}
+
+// StateKeyer is an optional interface for providers whose events may reference
+// a Terraform-managed resource by something other than its state ID (an ARN,
+// name, URL or API path). The state manager indexes these keys so such events
+// still resolve to the managed resource.
+type StateKeyer interface {
+	// StateKeys returns the additional lookup keys of a resource of the given
+	// Terraform type, derived from its state attributes.
+	StateKeys(resourceType string, attributes map[string]interface{}) []string
+}
+
 // FullProvider combines all provider interfaces.
 // Providers that implement all capabilities can satisfy this interface.
 type FullProvider interface {
@@ -100,15 +132,21 @@ type FullProvider interface {
 type Capabilities struct {
 	Discovery  bool
 	Comparison bool
+	StateKeys  bool
+	Fetch      bool
 }
 
 // GetCapabilities checks which optional interfaces a provider implements.
 func GetCapabilities(p Provider) Capabilities {
 	_, hasDiscovery := p.(ResourceDiscoverer)
 	_, hasComparison := p.(StateComparator)
+	_, hasStateKeys := p.(StateKeyer)
+	_, hasFetch := p.(ResourceFetcher)
 	return Capabilities{
 		Discovery:  hasDiscovery,
 		Comparison: hasComparison,
+		StateKeys:  hasStateKeys,
+		Fetch:      hasFetch,
 	}
 }
 
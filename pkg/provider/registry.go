@@ -121,6 +121,32 @@ func (r *Registry) GetComparator(name string) (StateComparator, bool) {
 	return c, ok
 }
 
+// GetStateKeyer returns the StateKeyer for the named provider, if supported.
+func (r *Registry) GetStateKeyer(name string) (StateKeyer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, false
+	}
+	k, ok := p.(StateKeyer)
+	return k, ok
+}
+
+// GetFetcher returns the ResourceFetcher for the named provider, if supported.
+func (r *Registry) GetFetcher(name string) (ResourceFetcher, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, false
+	}
+	f, ok := p.(ResourceFetcher)
+	return f, ok
+}
+
 // DiscoverAll runs resource discovery across all providers that support it.
 func (r *Registry) DiscoverAll(ctx context.Context, opts DiscoveryOptions) (map[string][]*types.DiscoveredResource, error) {
 	r.mu.RLock()
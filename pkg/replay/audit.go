@@ -0,0 +1,180 @@
+package replay
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/falcosecurity/client-go/pkg/api/outputs"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// gcpLogEntry is a Cloud Audit Logs entry as exported by Cloud Logging
+type gcpLogEntry struct {
+	InsertID  string    `json:"insertId"`
+	Timestamp time.Time `json:"timestamp"`
+	Resource  struct {
+		Type   string            `json:"type"`
+		Labels map[string]string `json:"labels"`
+	} `json:"resource"`
+	ProtoPayload struct {
+		ServiceName        string `json:"serviceName"`
+		MethodName         string `json:"methodName"`
+		ResourceName       string `json:"resourceName"`
+		AuthenticationInfo struct {
+			PrincipalEmail string `json:"principalEmail"`
+		} `json:"authenticationInfo"`
+		Request  json.RawMessage `json:"request"`
+		Response json.RawMessage `json:"response"`
+		Status   struct {
+			Code int `json:"code"`
+		} `json:"status"`
+	} `json:"protoPayload"`
+}
+
+// failed reports whether the audited call returned an error
+func (e *gcpLogEntry) failed() bool {
+	return e.ProtoPayload.Status.Code != 0
+}
+
+// response reshapes the entry into the fields the Falco gcpaudit plugin
+// emits, for the GCP audit parser
+func (e *gcpLogEntry) response() *outputs.Response {
+	p := e.ProtoPayload
+	fields := map[string]string{
+		"gcp.insertId":                          e.InsertID,
+		"gcp.serviceName":                       p.ServiceName,
+		"gcp.methodName":                        p.MethodName,
+		"gcp.resource.name":                     p.ResourceName,
+		"gcp.resource.type":                     e.Resource.Type,
+		"gcp.authenticationInfo.principalEmail": p.AuthenticationInfo.PrincipalEmail,
+		"gcp.request":                           rawObject(p.Request),
+		"gcp.response":                          rawObject(p.Response),
+	}
+	for k, v := range e.Resource.Labels {
+		fields["gcp.resource.labels."+k] = v
+	}
+	return newResponse("gcpaudit", p.MethodName, e.Timestamp, fields)
+}
+
+// azureActivity is an Azure Activity Log record, either as exported by
+// diagnostic settings (operationName and resultType strings, "time") or as
+// returned by the REST API (localized {"value": ...} objects,
+// "eventTimestamp")
+type azureActivity struct {
+	Time             time.Time       `json:"time"`
+	EventTimestamp   time.Time       `json:"eventTimestamp"`
+	ResourceID       string          `json:"resourceId"`
+	OperationName    localized       `json:"operationName"`
+	Status           localized       `json:"status"`
+	ResultType       string          `json:"resultType"`
+	Caller           string          `json:"caller"`
+	CorrelationID    string          `json:"correlationId"`
+	EventDataID      string          `json:"eventDataId"`
+	Location         string          `json:"location"`
+	ResourceLocation string          `json:"resourceLocation"`
+	Properties       json.RawMessage `json:"properties"`
+}
+
+// localized decodes a plain string or an Azure {"value", "localizedValue"}
+// object
+type localized string
+
+func (l *localized) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*l = localized(s)
+		return nil
+	}
+	var v struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*l = localized(v.Value)
+	return nil
+}
+
+// failed reports whether the operation did not succeed. Start/Accepted
+// records are kept: the change is under way.
+func (a *azureActivity) failed() bool {
+	status := string(a.Status)
+	if status == "" {
+		status = a.ResultType
+	}
+	return strings.EqualFold(status, "Failed") || strings.EqualFold(status, "Failure")
+}
+
+// response reshapes the record into the fields the Falco Azure plugin
+// emits, for the Azure activity parser
+func (a *azureActivity) response() *outputs.Response {
+	status := string(a.Status)
+	if status == "" {
+		status = a.ResultType
+	}
+	location := a.ResourceLocation
+	if location == "" {
+		location = a.Location
+	}
+	when := a.EventTimestamp
+	if when.IsZero() {
+		when = a.Time
+	}
+
+	fields := map[string]string{
+		"azure.operationName":    string(a.OperationName),
+		"azure.resourceId":       a.ResourceID,
+		"azure.caller":           a.Caller,
+		"azure.correlationId":    a.CorrelationID,
+		"azure.eventDataId":      a.EventDataID,
+		"azure.resourceLocation": location,
+		"azure.status":           status,
+	}
+
+	// requestbody/responseBody are JSON-encoded strings inside properties
+	var props map[string]interface{}
+	if json.Unmarshal(a.Properties, &props) == nil {
+		for k, v := range props {
+			s, ok := v.(string)
+			if !ok {
+				continue
+			}
+			switch strings.ToLower(k) {
+			case "requestbody":
+				fields["azure.requestProperties"] = s
+			case "responsebody":
+				fields["azure.responseProperties"] = s
+			}
+		}
+	}
+	return newResponse("azure_activity", string(a.OperationName), when, fields)
+}
+
+// rawObject returns a JSON object as a string, or "" for anything else
+func rawObject(raw json.RawMessage) string {
+	trimmed := strings.TrimSpace(string(raw))
+	if !strings.HasPrefix(trimmed, "{") {
+		return ""
+	}
+	return trimmed
+}
+
+// newResponse builds a Falco-shaped response, dropping empty fields the way
+// Falco omits null ones
+func newResponse(source, name string, when time.Time, fields map[string]string) *outputs.Response {
+	for k, v := range fields {
+		if v == "" {
+			delete(fields, k)
+		}
+	}
+	res := &outputs.Response{
+		Rule:         "replay " + name,
+		Source:       source,
+		OutputFields: fields,
+	}
+	if !when.IsZero() {
+		res.Time = timestamppb.New(when)
+	}
+	return res
+}
@@ -0,0 +1,259 @@
+// Package replay reads recorded Falco alerts and raw cloud audit records
+// (CloudTrail, GCP Cloud Audit Logs, Azure Activity Log) from files and
+// parses them into drift events for offline runs of the detector.
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/falcosecurity/client-go/pkg/api/outputs"
+	"github.com/keitahigaki/tfdrift-falco/pkg/cloudtrail"
+	"github.com/keitahigaki/tfdrift-falco/pkg/falco"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// Record is a drift-relevant event read from a recording
+type Record struct {
+	// Source locates the record, as file:line
+	Source string
+	// Time is when the event happened; zero if the record has none
+	Time  time.Time
+	Event types.Event
+}
+
+// Stats counts what a load read
+type Stats struct {
+	// Records is the number of alerts and audit records read
+	Records int
+	// Skipped counts records that failed, are not drift-relevant or have
+	// an unrecognized shape
+	Skipped int
+}
+
+// fileExtensions are the recordings Load picks up from a directory
+var fileExtensions = []string{".json", ".jsonl", ".ndjson", ".json.gz", ".jsonl.gz", ".ndjson.gz"}
+
+// Load reads the recording at path, a file or a directory of files, and
+// returns its drift-relevant events ordered by event time. Records without a
+// time keep their position in the file order, and records with equal times
+// keep their relative order; directories are read in lexical order. Files may hold one JSON document, a JSON array,
+// newline-delimited JSON or a CloudTrail log document ({"Records": [...]}),
+// optionally gzipped.
+func Load(path string, sub *falco.Subscriber) ([]Record, Stats, error) {
+	files, err := recordingFiles(path)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+
+	var records []Record
+	var stats Stats
+	for _, file := range files {
+		data, err := readFile(file)
+		if err != nil {
+			return nil, stats, err
+		}
+		if err := decode(file, data, sub, &records, &stats); err != nil {
+			return nil, stats, err
+		}
+	}
+
+	sortByTime(records)
+	return records, stats, nil
+}
+
+// sortByTime orders the timed records by time in the positions timed
+// records hold, so records without a time stay where they are in the file
+func sortByTime(records []Record) {
+	var slots []int
+	var timed []Record
+	for i, r := range records {
+		if !r.Time.IsZero() {
+			slots = append(slots, i)
+			timed = append(timed, r)
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool {
+		return timed[i].Time.Before(timed[j].Time)
+	})
+	for k, i := range slots {
+		records[i] = timed[k]
+	}
+}
+
+// recordingFiles lists the files to read, sorted
+func recordingFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		for _, ext := range fileExtensions {
+			if strings.HasSuffix(p, ext) {
+				files = append(files, p)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", path, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// readFile returns a file's contents, decompressed if gzipped
+func readFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if !bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		return data, nil
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = gz.Close() }()
+	data, err = io.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// decode reads each JSON value in data. Arrays and CloudTrail log documents
+// are expanded into their elements, which share the document's line.
+func decode(file string, data []byte, sub *falco.Subscriber, records *[]Record, stats *Stats) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		offset := int(dec.InputOffset())
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("%s:%d: %w", file, lineAt(data, offset), err)
+		}
+		source := fmt.Sprintf("%s:%d", file, lineAt(data, offset))
+
+		for _, item := range expand(raw) {
+			stats.Records++
+			record, ok := parseRecord(item, sub)
+			if !ok {
+				stats.Skipped++
+				continue
+			}
+			record.Source = source
+			*records = append(*records, record)
+		}
+	}
+}
+
+// lineAt returns the line of the first non-space byte at or after offset
+func lineAt(data []byte, offset int) int {
+	for offset < len(data) && strings.ContainsRune(" \t\r\n", rune(data[offset])) {
+		offset++
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// expand unwraps JSON arrays and CloudTrail log documents
+func expand(raw json.RawMessage) []json.RawMessage {
+	var items []json.RawMessage
+	if json.Unmarshal(raw, &items) == nil {
+		return items
+	}
+	var doc struct {
+		Records []json.RawMessage `json:"Records"`
+	}
+	if json.Unmarshal(raw, &doc) == nil && doc.Records != nil {
+		return doc.Records
+	}
+	return []json.RawMessage{raw}
+}
+
+// parseRecord identifies a record by its shape and parses it through the
+// same path its live counterpart takes
+func parseRecord(raw json.RawMessage, sub *falco.Subscriber) (Record, bool) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		log.Debugf("Skipping non-object record: %v", err)
+		return Record{}, false
+	}
+
+	var res *outputs.Response
+	switch {
+	case keys["output_fields"] != nil:
+		// Falco alert, as posted by http_output or written by json_output
+		event, err := sub.ParseHTTPAlert(raw)
+		if err != nil || event == nil {
+			return Record{}, false
+		}
+		return Record{Time: eventTime(event), Event: *event}, true
+
+	case keys["eventSource"] != nil && keys["eventName"] != nil:
+		var r cloudtrail.Record
+		if json.Unmarshal(raw, &r) != nil || r.Failed() {
+			return Record{}, false
+		}
+		res = r.Response()
+
+	case keys["protoPayload"] != nil:
+		var e gcpLogEntry
+		if json.Unmarshal(raw, &e) != nil || e.failed() {
+			return Record{}, false
+		}
+		res = e.response()
+
+	case keys["operationName"] != nil && keys["resourceId"] != nil:
+		var a azureActivity
+		if json.Unmarshal(raw, &a) != nil || a.failed() {
+			return Record{}, false
+		}
+		res = a.response()
+
+	default:
+		log.Debug("Skipping record of unrecognized shape")
+		return Record{}, false
+	}
+
+	event := sub.ParseFalcoOutput(res)
+	if event == nil {
+		return Record{}, false
+	}
+	return Record{Time: eventTime(event), Event: *event}, true
+}
+
+// eventTime returns the time recorded on a parsed event
+func eventTime(event *types.Event) time.Time {
+	if res, ok := event.RawEvent.(*outputs.Response); ok && res.Time != nil {
+		return res.Time.AsTime()
+	}
+	return time.Time{}
+}
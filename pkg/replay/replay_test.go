@@ -0,0 +1,152 @@
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/falco"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const falcoAlert = `{"time":"2026-10-01T12:05:00Z","rule":"Terraform Managed Resource Modified","source":"aws_cloudtrail","output_fields":{"ct.name":"ModifyInstanceAttribute","ct.request.instanceid":"i-falco","ct.request.instancetype":"t3.medium","ct.user":"admin"}}`
+
+const cloudTrailRecord = `{
+	"eventTime": "2026-10-01T12:00:00Z",
+	"eventSource": "ec2.amazonaws.com",
+	"eventName": "ModifyInstanceAttribute",
+	"awsRegion": "us-east-1",
+	"userIdentity": {"type": "IAMUser", "userName": "alice", "accountId": "123456789012"},
+	"requestParameters": {"instanceId": "i-trail", "instanceType": {"value": "t3.large"}}
+}`
+
+const failedCloudTrailRecord = `{
+	"eventTime": "2026-10-01T12:01:00Z",
+	"eventSource": "ec2.amazonaws.com",
+	"eventName": "ModifyInstanceAttribute",
+	"errorCode": "UnauthorizedOperation",
+	"requestParameters": {"instanceId": "i-denied"}
+}`
+
+const gcpEntry = `{
+	"timestamp": "2026-10-01T12:10:00Z",
+	"resource": {"type": "gce_instance", "labels": {"project_id": "my-project", "zone": "us-central1-a"}},
+	"protoPayload": {
+		"serviceName": "compute.googleapis.com",
+		"methodName": "compute.instances.setLabels",
+		"resourceName": "projects/my-project/zones/us-central1-a/instances/vm-1",
+		"authenticationInfo": {"principalEmail": "bob@example.com"},
+		"request": {"labels": {"env": "prod"}}
+	}
+}`
+
+const azureRecord = `{
+	"time": "2026-10-01T12:15:00Z",
+	"resourceId": "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm-az",
+	"operationName": "Microsoft.Compute/virtualMachines/write",
+	"resultType": "Success",
+	"caller": "carol@example.com"
+}`
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func TestLoad_MixedNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "incident.ndjson")
+	data := falcoAlert + "\n" + compact(cloudTrailRecord) + "\n" + compact(failedCloudTrailRecord) + "\n" +
+		compact(gcpEntry) + "\n" + compact(azureRecord) + "\n" + `{"unrelated": true}` + "\n"
+	writeFile(t, path, []byte(data))
+
+	records, stats, err := Load(path, falco.NewSubscriberWithDefaults())
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Records: 6, Skipped: 2}, stats)
+	require.Len(t, records, 4)
+
+	// Ordered by event time, not file order
+	assert.Equal(t, "i-trail", records[0].Event.ResourceID)
+	assert.Equal(t, path+":2", records[0].Source)
+	assert.Equal(t, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), records[0].Time.UTC())
+	assert.Equal(t, "i-falco", records[1].Event.ResourceID)
+	assert.Equal(t, path+":1", records[1].Source)
+	assert.Equal(t, "gcp", records[2].Event.Provider)
+	assert.Equal(t, "azure", records[3].Event.Provider)
+}
+
+func TestLoad_UntimedRecordsKeepTheirPosition(t *testing.T) {
+	untimed := `{"rule":"Terraform Managed Resource Modified","source":"aws_cloudtrail","output_fields":{"ct.name":"ModifyInstanceAttribute","ct.request.instanceid":"i-untimed","ct.request.instancetype":"t3.small","ct.user":"admin"}}`
+	path := filepath.Join(t.TempDir(), "incident.ndjson")
+	writeFile(t, path, []byte(falcoAlert+"\n"+untimed+"\n"+compact(cloudTrailRecord)+"\n"))
+
+	records, _, err := Load(path, falco.NewSubscriberWithDefaults())
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	// The timed records swap around the untimed one, which stays second
+	assert.Equal(t, "i-trail", records[0].Event.ResourceID)
+	assert.Equal(t, "i-untimed", records[1].Event.ResourceID)
+	assert.True(t, records[1].Time.IsZero())
+	assert.Equal(t, "i-falco", records[2].Event.ResourceID)
+}
+
+func TestLoad_DirectoryOfLogFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, err := w.Write([]byte(`{"Records":[` + cloudTrailRecord + `]}`))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	writeFile(t, filepath.Join(dir, "AWSLogs", "trail.json.gz"), gz.Bytes())
+	writeFile(t, filepath.Join(dir, "falco.json"), []byte("["+falcoAlert+"]"))
+	writeFile(t, filepath.Join(dir, "notes.txt"), []byte("ignored"))
+
+	records, stats, err := Load(dir, falco.NewSubscriberWithDefaults())
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Records: 2}, stats)
+	require.Len(t, records, 2)
+	assert.Equal(t, "i-trail", records[0].Event.ResourceID)
+	assert.Equal(t, "i-falco", records[1].Event.ResourceID)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	_, _, err := Load(filepath.Join(t.TempDir(), "missing.json"), falco.NewSubscriberWithDefaults())
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "bad.jsonl")
+	writeFile(t, path, []byte(falcoAlert+"\n{not json\n"))
+	_, _, err = Load(path, falco.NewSubscriberWithDefaults())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), path+":2")
+}
+
+func TestAzureActivity_RESTShape(t *testing.T) {
+	rest := `{
+		"eventTimestamp": "2026-10-01T12:20:00Z",
+		"resourceId": "/subscriptions/sub-1/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm-az",
+		"operationName": {"value": "Microsoft.Compute/virtualMachines/write", "localizedValue": "Create or Update Virtual Machine"},
+		"status": {"value": "Failed"}
+	}`
+	path := filepath.Join(t.TempDir(), "activity.json")
+	writeFile(t, path, []byte(rest))
+
+	records, stats, err := Load(path, falco.NewSubscriberWithDefaults())
+	require.NoError(t, err)
+	assert.Empty(t, records, "failed operations changed nothing")
+	assert.Equal(t, Stats{Records: 1, Skipped: 1}, stats)
+}
+
+// compact puts a fixture on one line for NDJSON
+func compact(s string) string {
+	var buf bytes.Buffer
+	for _, line := range bytes.Split([]byte(s), []byte("\n")) {
+		buf.Write(bytes.TrimSpace(line))
+	}
+	return buf.String()
+}
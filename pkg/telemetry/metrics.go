@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// AttrReason is the metric attribute explaining a rejection.
+var AttrReason = attribute.Key("tfdrift.reason")
+
+// AttrOverflow is the metric attribute naming what happened to an event that
+// did not fit in its worker queue ("dropped", "spilled").
+var AttrOverflow = attribute.Key("tfdrift.overflow")
+
+// Instruments record through the global meter provider, so they are no-ops
+// until NewProvider installs one and start exporting once it does.
+var (
+	instrumentsOnce       sync.Once
+	falcoReceiverRejected metric.Int64Counter
+	falcoReceiverAlerts   metric.Int64Counter
+	eventsQueued          metric.Int64UpDownCounter
+	eventsOverflow        metric.Int64Counter
+	eventDuration         metric.Float64Histogram
+)
+
+func initInstruments() {
+	instrumentsOnce.Do(func() {
+		meter := otel.Meter(instrumentationName)
+		var err error
+		falcoReceiverRejected, err = meter.Int64Counter("tfdrift.falco.receiver.rejected",
+			metric.WithDescription("Falco HTTP receiver requests and alerts that were refused"))
+		if err != nil {
+			falcoReceiverRejected = noop.Int64Counter{}
+		}
+		falcoReceiverAlerts, err = meter.Int64Counter("tfdrift.falco.receiver.alerts",
+			metric.WithDescription("Alerts accepted by the Falco HTTP receiver"))
+		if err != nil {
+			falcoReceiverAlerts = noop.Int64Counter{}
+		}
+		eventsQueued, err = meter.Int64UpDownCounter("tfdrift.events.queued",
+			metric.WithDescription("Events waiting in worker queues, spilled events included"))
+		if err != nil {
+			eventsQueued = noop.Int64UpDownCounter{}
+		}
+		eventsOverflow, err = meter.Int64Counter("tfdrift.events.overflow",
+			metric.WithDescription("Events that did not fit in their worker queue"))
+		if err != nil {
+			eventsOverflow = noop.Int64Counter{}
+		}
+		eventDuration, err = meter.Float64Histogram("tfdrift.events.processing.duration",
+			metric.WithDescription("Time from an event being queued until it was processed"),
+			metric.WithUnit("s"))
+		if err != nil {
+			eventDuration = noop.Float64Histogram{}
+		}
+	})
+}
+
+// RecordFalcoRejected counts a Falco HTTP receiver rejection: a whole request
+// (e.g. reason "unauthenticated") or a single alert of a batch ("invalid").
+func RecordFalcoRejected(ctx context.Context, reason string, n int) {
+	if n <= 0 {
+		return
+	}
+	initInstruments()
+	falcoReceiverRejected.Add(ctx, int64(n), metric.WithAttributes(AttrReason.String(reason)))
+}
+
+// RecordFalcoAlerts counts alerts the Falco HTTP receiver accepted.
+func RecordFalcoAlerts(ctx context.Context, n int) {
+	if n <= 0 {
+		return
+	}
+	initInstruments()
+	falcoReceiverAlerts.Add(ctx, int64(n))
+}
+
+// RecordEventsQueued adjusts the number of events waiting for a worker by
+// delta (negative when events are taken off the queue).
+func RecordEventsQueued(ctx context.Context, delta int) {
+	if delta == 0 {
+		return
+	}
+	initInstruments()
+	eventsQueued.Add(ctx, int64(delta))
+}
+
+// RecordEventOverflow counts an event that found its worker queue full.
+func RecordEventOverflow(ctx context.Context, outcome string) {
+	initInstruments()
+	eventsOverflow.Add(ctx, 1, metric.WithAttributes(AttrOverflow.String(outcome)))
+}
+
+// RecordEventProcessed records how long an event took from being queued to
+// being processed.
+func RecordEventProcessed(ctx context.Context, latency time.Duration) {
+	initInstruments()
+	eventDuration.Record(ctx, latency.Seconds())
+}
@@ -0,0 +1,106 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// useTestMeter installs a meter provider backed by a manual reader and
+// recreates the instruments on it, since they bind to the provider that was
+// global when they were first created
+func useTestMeter(t *testing.T) *sdkmetric.ManualReader {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	instrumentsOnce = sync.Once{}
+	t.Cleanup(func() {
+		otel.SetMeterProvider(prev)
+		instrumentsOnce = sync.Once{}
+	})
+	return reader
+}
+
+func TestRecordFalcoRejected(t *testing.T) {
+	reader := useTestMeter(t)
+
+	ctx := context.Background()
+	RecordFalcoRejected(ctx, "unauthenticated", 1)
+	RecordFalcoRejected(ctx, "invalid", 2)
+	RecordFalcoRejected(ctx, "invalid", 0) // no-op
+	RecordFalcoAlerts(ctx, 3)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	got := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			require.True(t, ok, m.Name)
+			for _, dp := range sum.DataPoints {
+				key := m.Name
+				if reason, ok := dp.Attributes.Value(AttrReason); ok {
+					key += "/" + reason.AsString()
+				}
+				got[key] += dp.Value
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{
+		"tfdrift.falco.receiver.rejected/unauthenticated": 1,
+		"tfdrift.falco.receiver.rejected/invalid":         2,
+		"tfdrift.falco.receiver.alerts":                   3,
+	}, got)
+}
+
+func TestRecordEventQueueMetrics(t *testing.T) {
+	reader := useTestMeter(t)
+
+	ctx := context.Background()
+	RecordEventsQueued(ctx, 3)
+	RecordEventsQueued(ctx, -1)
+	RecordEventOverflow(ctx, "dropped")
+	RecordEventOverflow(ctx, "spilled")
+	RecordEventOverflow(ctx, "spilled")
+	RecordEventProcessed(ctx, 250*time.Millisecond)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+
+	sums := map[string]int64{}
+	var histogramCount uint64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					key := m.Name
+					if outcome, ok := dp.Attributes.Value(AttrOverflow); ok {
+						key += "/" + outcome.AsString()
+					}
+					sums[key] += dp.Value
+				}
+			case metricdata.Histogram[float64]:
+				assert.Equal(t, "tfdrift.events.processing.duration", m.Name)
+				for _, dp := range data.DataPoints {
+					histogramCount += dp.Count
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{
+		"tfdrift.events.queued":           2,
+		"tfdrift.events.overflow/dropped": 1,
+		"tfdrift.events.overflow/spilled": 2,
+	}, sums)
+	assert.Equal(t, uint64(1), histogramCount)
+}
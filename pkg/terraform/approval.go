@@ -16,17 +16,19 @@ import (
 
 // ApprovalRequest represents a request for import approval
 type ApprovalRequest struct {
-	ID            string
-	ResourceType  string
-	ResourceID    string
-	ResourceName  string
-	DetectedAt    time.Time
-	UserIdentity  string
-	Changes       map[string]interface{}
-	ImportCommand *ImportCommand
-	Status        ApprovalStatus
-	ApprovedBy    string
-	ApprovedAt    time.Time
+	ID            string                 `json:"id"`
+	ResourceType  string                 `json:"resource_type"`
+	ResourceID    string                 `json:"resource_id"`
+	ResourceName  string                 `json:"resource_name"`
+	DetectedAt    time.Time              `json:"detected_at"`
+	UserIdentity  string                 `json:"user_identity"`
+	Changes       map[string]interface{} `json:"changes,omitempty"`
+	ImportCommand *ImportCommand         `json:"import_command"`
+	Status        ApprovalStatus         `json:"status"`
+	ApprovedBy    string                 `json:"approved_by,omitempty"`
+	ApprovedAt    time.Time              `json:"approved_at,omitempty"`
+	RejectedBy    string                 `json:"rejected_by,omitempty"`
+	RejectReason  string                 `json:"reject_reason,omitempty"`
 }
 
 // ApprovalStatus represents the status of an approval request
@@ -40,21 +42,110 @@ const (
 	ApprovalExpired  ApprovalStatus = "expired"  // Request has expired
 )
 
-// ApprovalManager manages import approval workflow
+// ApprovalManager manages import approval workflow. Pending requests, and
+// approved ones whose import has not run yet, are held in memory for the
+// workflow; every state change is also written to the ApprovalStore
+// together with an audit entry.
 type ApprovalManager struct {
 	pendingRequests map[string]*ApprovalRequest
+	store           ApprovalStore
 	importer        *Importer
 	interactiveMode bool
 	mu              sync.RWMutex
 	stdin           io.Reader // For testing: if nil, uses os.Stdin
+
+	// ctx is cancelled by Close; background imports run under it
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
-// NewApprovalManager creates a new approval manager
+// NewApprovalManager creates a new approval manager backed by an in-memory store
 func NewApprovalManager(importer *Importer, interactiveMode bool) *ApprovalManager {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &ApprovalManager{
 		pendingRequests: make(map[string]*ApprovalRequest),
+		store:           NewMemoryApprovalStore(),
 		importer:        importer,
 		interactiveMode: interactiveMode,
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+}
+
+// NewApprovalManagerWithStore creates an approval manager on the given store
+// and reloads the requests that were still pending when it was last used,
+// and those approved whose import never ran. See ResumeApproved.
+func NewApprovalManagerWithStore(importer *Importer, interactiveMode bool, store ApprovalStore) (*ApprovalManager, error) {
+	am := NewApprovalManager(importer, interactiveMode)
+	am.store = store
+
+	requests, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	audit, err := store.Audit("")
+	if err != nil {
+		return nil, err
+	}
+	ran := make(map[string]bool)
+	for _, entry := range audit {
+		if entry.Action == ApprovalActionExecuted || entry.Action == ApprovalActionFailed {
+			ran[entry.RequestID] = true
+		}
+	}
+
+	var pending, approved int
+	for _, req := range requests {
+		switch {
+		case req.Status == ApprovalPending:
+			pending++
+		case req.Status == ApprovalApproved && !ran[req.ID]:
+			approved++
+		default:
+			continue
+		}
+		am.pendingRequests[req.ID] = req
+	}
+	if pending > 0 {
+		log.Infof("Loaded %d pending approval request(s)", pending)
+	}
+	if approved > 0 {
+		log.Infof("Loaded %d approved import(s) that have not run yet", approved)
+	}
+	return am, nil
+}
+
+// IsInteractive reports whether approvals are prompted for on the console.
+// Otherwise requests wait in the queue for the approval CLI or API.
+func (am *ApprovalManager) IsInteractive() bool {
+	return am.interactiveMode
+}
+
+// Close cancels imports running in the background, waits for them and
+// closes the underlying approval store
+func (am *ApprovalManager) Close() error {
+	am.cancel()
+	am.wg.Wait()
+	return am.store.Close()
+}
+
+// record persists the request's current state and appends an audit entry.
+// Store failures are logged rather than returned so a broken store never
+// blocks the in-memory workflow. Callers must hold am.mu.
+func (am *ApprovalManager) record(request *ApprovalRequest, action ApprovalAction, actor, reason string) {
+	if err := am.store.Put(request); err != nil {
+		log.Errorf("Failed to persist approval request %s: %v", request.ID, err)
+	}
+	entry := ApprovalAuditEntry{
+		RequestID: request.ID,
+		Action:    action,
+		Actor:     actor,
+		Reason:    reason,
+		At:        time.Now(),
+	}
+	if err := am.store.AppendAudit(entry); err != nil {
+		log.Errorf("Failed to record approval audit entry for %s: %v", request.ID, err)
 	}
 }
 
@@ -76,6 +167,7 @@ func (am *ApprovalManager) RequestApproval(resourceType, resourceID string, chan
 
 	am.mu.Lock()
 	am.pendingRequests[request.ID] = request
+	am.record(request, ApprovalActionRequested, userIdentity, "")
 	am.mu.Unlock()
 	return request
 }
@@ -122,30 +214,46 @@ func (am *ApprovalManager) PromptForApproval(_ context.Context, request *Approva
 	input = strings.TrimSpace(strings.ToLower(input))
 	approved := input == "y" || input == "yes"
 
+	am.mu.Lock()
 	if approved {
 		request.Status = ApprovalApproved
 		request.ApprovedBy = request.UserIdentity
 		request.ApprovedAt = time.Now()
+		am.record(request, ApprovalActionApproved, request.ApprovedBy, "approved at console prompt")
 		fmt.Println("✅ Import approved!")
 	} else {
 		request.Status = ApprovalRejected
+		request.RejectedBy = "console-user"
+		delete(am.pendingRequests, request.ID)
+		am.record(request, ApprovalActionRejected, request.RejectedBy, "rejected at console prompt")
 		fmt.Println("❌ Import rejected")
 	}
+	am.mu.Unlock()
 
 	return approved, nil
 }
 
 // ApproveAndExecute approves and executes an import
 func (am *ApprovalManager) ApproveAndExecute(ctx context.Context, requestID string, approvedBy string) (*ImportResult, error) {
+	request, err := am.Approve(requestID, approvedBy)
+	if err != nil {
+		return nil, err
+	}
+	return am.execute(ctx, request), nil
+}
+
+// Approve marks a pending request as approved without running the import.
+// Follow up with ExecuteApproved.
+func (am *ApprovalManager) Approve(requestID string, approvedBy string) (*ApprovalRequest, error) {
 	am.mu.Lock()
+	defer am.mu.Unlock()
+
 	request, exists := am.pendingRequests[requestID]
 	if !exists {
-		am.mu.Unlock()
 		return nil, fmt.Errorf("approval request not found: %s", requestID)
 	}
 
 	if request.Status != ApprovalPending {
-		am.mu.Unlock()
 		return nil, fmt.Errorf("request is not pending (status: %s)", request.Status)
 	}
 
@@ -153,23 +261,103 @@ func (am *ApprovalManager) ApproveAndExecute(ctx context.Context, requestID stri
 	request.Status = ApprovalApproved
 	request.ApprovedBy = approvedBy
 	request.ApprovedAt = time.Now()
-	am.mu.Unlock()
+	am.record(request, ApprovalActionApproved, approvedBy, "")
 
 	log.Infof("Import approved by %s: %s", approvedBy, request.ImportCommand.String())
+	return request, nil
+}
 
-	// Execute import
+// ExecuteApproved runs the import for a request that was already approved,
+// e.g. at the console prompt.
+func (am *ApprovalManager) ExecuteApproved(ctx context.Context, requestID string) (*ImportResult, error) {
+	am.mu.RLock()
+	request, exists := am.pendingRequests[requestID]
+	am.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("approval request not found: %s", requestID)
+	}
+	if request.Status != ApprovalApproved {
+		return nil, fmt.Errorf("request is not approved (status: %s)", request.Status)
+	}
+	return am.execute(ctx, request), nil
+}
+
+// ExecuteApprovedAsync runs the import for an approved request in the
+// background, under a context Close cancels. The outcome is recorded in the
+// audit trail.
+func (am *ApprovalManager) ExecuteApprovedAsync(requestID string) error {
+	am.mu.RLock()
+	request, exists := am.pendingRequests[requestID]
+	am.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("approval request not found: %s", requestID)
+	}
+	if request.Status != ApprovalApproved {
+		return fmt.Errorf("request is not approved (status: %s)", request.Status)
+	}
+
+	am.wg.Add(1)
+	go func() {
+		defer am.wg.Done()
+		am.execute(am.ctx, request)
+	}()
+	return nil
+}
+
+// ResumeApproved runs, in the background, the imports of requests that were
+// approved but had not run when the manager was last used
+func (am *ApprovalManager) ResumeApproved() {
+	am.mu.RLock()
+	var approved []string
+	for id, req := range am.pendingRequests {
+		if req.Status == ApprovalApproved {
+			approved = append(approved, id)
+		}
+	}
+	am.mu.RUnlock()
+
+	for _, id := range approved {
+		log.Infof("Resuming approved import %s", id)
+		if err := am.ExecuteApprovedAsync(id); err != nil {
+			log.Errorf("Failed to resume approved import %s: %v", id, err)
+		}
+	}
+}
+
+// execute runs an approved import, records its outcome and drops the
+// request from the pending set. An import cut short by Close stays approved
+// so it runs again on the next start.
+func (am *ApprovalManager) execute(ctx context.Context, request *ApprovalRequest) *ImportResult {
 	result := am.importer.AutoImport(ctx, request.ResourceType, request.ResourceID, request.Changes)
 
-	// Clean up
 	am.mu.Lock()
-	delete(am.pendingRequests, requestID)
+	if am.ctx.Err() != nil && (result == nil || !result.Success) {
+		am.mu.Unlock()
+		log.Warnf("Import %s interrupted by shutdown; it runs again on the next start", request.ID)
+		return result
+	}
+	delete(am.pendingRequests, request.ID)
+	if result != nil && result.Success {
+		am.record(request, ApprovalActionExecuted, request.ApprovedBy, "")
+	} else {
+		reason := "import failed"
+		if result != nil && result.Error != nil {
+			reason = result.Error.Error()
+		}
+		am.record(request, ApprovalActionFailed, request.ApprovedBy, reason)
+	}
 	am.mu.Unlock()
 
-	return result, nil
+	return result
 }
 
 // Reject rejects an import request
 func (am *ApprovalManager) Reject(requestID string, reason string) error {
+	return am.RejectBy(requestID, "", reason)
+}
+
+// RejectBy rejects an import request on behalf of rejectedBy
+func (am *ApprovalManager) RejectBy(requestID, rejectedBy, reason string) error {
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
@@ -178,13 +366,56 @@ func (am *ApprovalManager) Reject(requestID string, reason string) error {
 		return fmt.Errorf("approval request not found: %s", requestID)
 	}
 
+	if request.Status != ApprovalPending {
+		return fmt.Errorf("request is not pending (status: %s)", request.Status)
+	}
+
 	request.Status = ApprovalRejected
+	request.RejectedBy = rejectedBy
+	request.RejectReason = reason
 	log.Infof("Import rejected: %s (reason: %s)", request.ImportCommand.String(), reason)
 
 	delete(am.pendingRequests, requestID)
+	am.record(request, ApprovalActionRejected, rejectedBy, reason)
 	return nil
 }
 
+// Get returns a request by ID, including already decided ones
+func (am *ApprovalManager) Get(requestID string) (*ApprovalRequest, bool, error) {
+	am.mu.RLock()
+	request, exists := am.pendingRequests[requestID]
+	am.mu.RUnlock()
+	if exists {
+		return request, true, nil
+	}
+	return am.store.Get(requestID)
+}
+
+// List returns stored requests with the given status, oldest first. An
+// empty status returns every request.
+func (am *ApprovalManager) List(status ApprovalStatus) ([]*ApprovalRequest, error) {
+	requests, err := am.store.List()
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		return requests, nil
+	}
+	filtered := make([]*ApprovalRequest, 0, len(requests))
+	for _, req := range requests {
+		if req.Status == status {
+			filtered = append(filtered, req)
+		}
+	}
+	return filtered, nil
+}
+
+// AuditTrail returns the audit entries for a request, or for every request
+// when requestID is empty
+func (am *ApprovalManager) AuditTrail(requestID string) ([]ApprovalAuditEntry, error) {
+	return am.store.Audit(requestID)
+}
+
 // ListPending returns all pending approval requests
 func (am *ApprovalManager) ListPending() []*ApprovalRequest {
 	am.mu.RLock()
@@ -211,6 +442,7 @@ func (am *ApprovalManager) CleanupExpired(expiryDuration time.Duration) int {
 		if req.Status == ApprovalPending && now.Sub(req.DetectedAt) > expiryDuration {
 			req.Status = ApprovalExpired
 			delete(am.pendingRequests, id)
+			am.record(req, ApprovalActionExpired, "system", fmt.Sprintf("pending longer than %s", expiryDuration))
 			count++
 			log.Infof("Expired import request: %s", req.ImportCommand.String())
 		}
@@ -257,6 +489,14 @@ func (request *ApprovalRequest) FormatApprovalSummary() string {
 		b.WriteString(fmt.Sprintf("Approved By:     %s\n", request.ApprovedBy))
 		b.WriteString(fmt.Sprintf("Approved At:     %s\n", request.ApprovedAt.Format(time.RFC3339)))
 	}
+	if request.Status == ApprovalRejected {
+		if request.RejectedBy != "" {
+			b.WriteString(fmt.Sprintf("Rejected By:     %s\n", request.RejectedBy))
+		}
+		if request.RejectReason != "" {
+			b.WriteString(fmt.Sprintf("Reason:          %s\n", request.RejectReason))
+		}
+	}
 
 	return b.String()
 }
@@ -0,0 +1,277 @@
+package terraform
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ApprovalAction names an entry in the approval audit trail
+type ApprovalAction string
+
+// Approval audit actions
+const (
+	ApprovalActionRequested ApprovalAction = "requested" // Request was queued
+	ApprovalActionApproved  ApprovalAction = "approved"  // Request was approved
+	ApprovalActionRejected  ApprovalAction = "rejected"  // Request was rejected
+	ApprovalActionExpired   ApprovalAction = "expired"   // Request expired while pending
+	ApprovalActionExecuted  ApprovalAction = "executed"  // Import ran successfully
+	ApprovalActionFailed    ApprovalAction = "failed"    // Import ran and failed
+)
+
+// ApprovalAuditEntry records who did what to an approval request and when
+type ApprovalAuditEntry struct {
+	RequestID string         `json:"request_id"`
+	Action    ApprovalAction `json:"action"`
+	Actor     string         `json:"actor"`
+	Reason    string         `json:"reason,omitempty"`
+	At        time.Time      `json:"at"`
+}
+
+// ApprovalStore persists approval requests and their audit trail so the
+// queue survives restarts and can be inspected outside the daemon.
+type ApprovalStore interface {
+	// Put inserts or replaces a request
+	Put(request *ApprovalRequest) error
+	// Get returns the request with the given ID
+	Get(id string) (*ApprovalRequest, bool, error)
+	// List returns every stored request, oldest first
+	List() ([]*ApprovalRequest, error)
+	// AppendAudit adds an entry to the audit trail
+	AppendAudit(entry ApprovalAuditEntry) error
+	// Audit returns the audit trail for one request, or all entries when
+	// requestID is empty, in the order they were recorded
+	Audit(requestID string) ([]ApprovalAuditEntry, error)
+	// Close releases the store
+	Close() error
+}
+
+// Compile-time interface checks
+var (
+	_ ApprovalStore = (*MemoryApprovalStore)(nil)
+	_ ApprovalStore = (*BoltApprovalStore)(nil)
+)
+
+// MemoryApprovalStore keeps approval requests in memory. It is the default
+// when no store path is configured; requests are lost on restart.
+type MemoryApprovalStore struct {
+	requests map[string]ApprovalRequest
+	audit    []ApprovalAuditEntry
+	mu       sync.RWMutex
+}
+
+// NewMemoryApprovalStore creates an empty in-memory approval store
+func NewMemoryApprovalStore() *MemoryApprovalStore {
+	return &MemoryApprovalStore{requests: make(map[string]ApprovalRequest)}
+}
+
+// Put inserts or replaces a request
+func (s *MemoryApprovalStore) Put(request *ApprovalRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[request.ID] = *request
+	return nil
+}
+
+// Get returns the request with the given ID
+func (s *MemoryApprovalStore) Get(id string) (*ApprovalRequest, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	req, ok := s.requests[id]
+	if !ok {
+		return nil, false, nil
+	}
+	return &req, true, nil
+}
+
+// List returns every stored request, oldest first
+func (s *MemoryApprovalStore) List() ([]*ApprovalRequest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*ApprovalRequest, 0, len(s.requests))
+	for _, req := range s.requests {
+		r := req
+		out = append(out, &r)
+	}
+	sortApprovalRequests(out)
+	return out, nil
+}
+
+// AppendAudit adds an entry to the audit trail
+func (s *MemoryApprovalStore) AppendAudit(entry ApprovalAuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.audit = append(s.audit, entry)
+	return nil
+}
+
+// Audit returns the audit trail for one request, or all of it
+func (s *MemoryApprovalStore) Audit(requestID string) ([]ApprovalAuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]ApprovalAuditEntry, 0)
+	for _, e := range s.audit {
+		if requestID == "" || e.RequestID == requestID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// Close is a no-op for the in-memory store
+func (s *MemoryApprovalStore) Close() error { return nil }
+
+var (
+	bucketApprovalRequests = []byte("approval_requests")
+	bucketApprovalAudit    = []byte("approval_audit")
+)
+
+// boltApprovalLockTimeout bounds how long opening the store waits for the
+// file lock. BoltDB allows a single writer process, so a CLI opening the
+// store of a running daemon fails fast instead of hanging.
+const boltApprovalLockTimeout = 2 * time.Second
+
+// BoltApprovalStore persists approval requests in an embedded BoltDB file.
+// Requests are keyed by ID; audit entries by a monotonically increasing
+// sequence so iteration returns them in the order they were recorded.
+type BoltApprovalStore struct {
+	db *bolt.DB
+}
+
+// NewBoltApprovalStore opens (or creates) the approval database at path
+func NewBoltApprovalStore(path string) (*BoltApprovalStore, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create approval store directory: %w", err)
+		}
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: boltApprovalLockTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open approval store %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketApprovalRequests, bucketApprovalAudit} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize approval store: %w", err)
+	}
+
+	return &BoltApprovalStore{db: db}, nil
+}
+
+// Put inserts or replaces a request
+func (s *BoltApprovalStore) Put(request *ApprovalRequest) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode approval request: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketApprovalRequests).Put([]byte(request.ID), data)
+	})
+}
+
+// Get returns the request with the given ID
+func (s *BoltApprovalStore) Get(id string) (*ApprovalRequest, bool, error) {
+	var req *ApprovalRequest
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketApprovalRequests).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		req = &ApprovalRequest{}
+		return json.Unmarshal(data, req)
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read approval request %s: %w", id, err)
+	}
+	return req, req != nil, nil
+}
+
+// List returns every stored request, oldest first
+func (s *BoltApprovalStore) List() ([]*ApprovalRequest, error) {
+	var out []*ApprovalRequest
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketApprovalRequests).ForEach(func(_, v []byte) error {
+			req := &ApprovalRequest{}
+			if err := json.Unmarshal(v, req); err != nil {
+				return err
+			}
+			out = append(out, req)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approval requests: %w", err)
+	}
+	sortApprovalRequests(out)
+	return out, nil
+}
+
+// AppendAudit adds an entry to the audit trail
+func (s *BoltApprovalStore) AppendAudit(entry ApprovalAuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketApprovalAudit)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		return bucket.Put(key, data)
+	})
+}
+
+// Audit returns the audit trail for one request, or all of it
+func (s *BoltApprovalStore) Audit(requestID string) ([]ApprovalAuditEntry, error) {
+	out := make([]ApprovalAuditEntry, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketApprovalAudit).ForEach(func(_, v []byte) error {
+			var e ApprovalAuditEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			if requestID == "" || e.RequestID == requestID {
+				out = append(out, e)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval audit trail: %w", err)
+	}
+	return out, nil
+}
+
+// Close closes the underlying database
+func (s *BoltApprovalStore) Close() error {
+	return s.db.Close()
+}
+
+// sortApprovalRequests orders requests by detection time, then ID
+func sortApprovalRequests(reqs []*ApprovalRequest) {
+	sort.Slice(reqs, func(i, j int) bool {
+		if !reqs[i].DetectedAt.Equal(reqs[j].DetectedAt) {
+			return reqs[i].DetectedAt.Before(reqs[j].DetectedAt)
+		}
+		return reqs[i].ID < reqs[j].ID
+	})
+}
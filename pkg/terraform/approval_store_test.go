@@ -0,0 +1,170 @@
+package terraform
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoltApprovalStore_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "approvals.db")
+
+	store, err := NewBoltApprovalStore(path)
+	require.NoError(t, err)
+	manager, err := NewApprovalManagerWithStore(NewImporter(".", true), false, store)
+	require.NoError(t, err)
+
+	keep := manager.RequestApproval("aws_instance", "i-keep", map[string]interface{}{"ami": "ami-1"}, "alice")
+	drop := manager.RequestApproval("aws_s3_bucket", "bucket-drop", nil, "alice")
+	require.NoError(t, manager.RejectBy(drop.ID, "bob", "not ours"))
+	require.NoError(t, manager.Close())
+
+	// Reopen: the pending request is back in the workflow, the decided one
+	// is only in the store
+	store, err = NewBoltApprovalStore(path)
+	require.NoError(t, err)
+	manager, err = NewApprovalManagerWithStore(NewImporter(".", true), false, store)
+	require.NoError(t, err)
+	defer manager.Close()
+
+	pending := manager.ListPending()
+	require.Len(t, pending, 1)
+	assert.Equal(t, keep.ID, pending[0].ID)
+	assert.Equal(t, "ami-1", pending[0].Changes["ami"])
+	assert.Equal(t, "i-keep", pending[0].ImportCommand.ResourceID)
+
+	rejected, err := manager.List(ApprovalRejected)
+	require.NoError(t, err)
+	require.Len(t, rejected, 1)
+	assert.Equal(t, "bob", rejected[0].RejectedBy)
+	assert.Equal(t, "not ours", rejected[0].RejectReason)
+
+	all, err := manager.List("")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+func TestBoltApprovalStore_ResumesApprovedImports(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "approvals.db")
+
+	store, err := NewBoltApprovalStore(path)
+	require.NoError(t, err)
+	manager, err := NewApprovalManagerWithStore(NewImporter(".", true), false, store)
+	require.NoError(t, err)
+
+	// Approved, then stopped before the import ran
+	waiting := manager.RequestApproval("aws_instance", "i-waiting", nil, "alice")
+	_, err = manager.Approve(waiting.ID, "carol")
+	require.NoError(t, err)
+	done := manager.RequestApproval("aws_instance", "i-done", nil, "alice")
+	_, err = manager.ApproveAndExecute(context.Background(), done.ID, "carol")
+	require.NoError(t, err)
+	require.NoError(t, manager.Close())
+
+	store, err = NewBoltApprovalStore(path)
+	require.NoError(t, err)
+	manager, err = NewApprovalManagerWithStore(NewImporter(".", true), false, store)
+	require.NoError(t, err)
+
+	require.Len(t, manager.pendingRequests, 1, "only the import that never ran is reloaded")
+	require.Contains(t, manager.pendingRequests, waiting.ID)
+	assert.Empty(t, manager.ListPending())
+
+	manager.ResumeApproved()
+	manager.wg.Wait()
+	require.NoError(t, manager.Close())
+
+	store, err = NewBoltApprovalStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	trail, err := store.Audit(waiting.ID)
+	require.NoError(t, err)
+	require.Len(t, trail, 3)
+	assert.Contains(t, []ApprovalAction{ApprovalActionExecuted, ApprovalActionFailed}, trail[2].Action)
+}
+
+func TestApprovalManager_ExecuteApprovedAsyncStopsOnClose(t *testing.T) {
+	manager := NewApprovalManager(NewImporter(".", true), false)
+
+	req := manager.RequestApproval("aws_instance", "i-async", nil, "alice")
+	assert.ErrorContains(t, manager.ExecuteApprovedAsync(req.ID), "request is not approved")
+	_, err := manager.Approve(req.ID, "carol")
+	require.NoError(t, err)
+
+	// Cancel before the import starts: it is cut short and stays approved
+	manager.cancel()
+	require.NoError(t, manager.ExecuteApprovedAsync(req.ID))
+	require.NoError(t, manager.Close())
+
+	trail, err := manager.AuditTrail(req.ID)
+	require.NoError(t, err)
+	assert.Len(t, trail, 2, "an interrupted import is not recorded as run")
+	assert.Contains(t, manager.pendingRequests, req.ID)
+}
+
+func TestApprovalManager_AuditTrail(t *testing.T) {
+	manager := NewApprovalManager(NewImporter(".", true), false)
+
+	req := manager.RequestApproval("aws_instance", "i-audit", nil, "alice")
+	_, err := manager.ApproveAndExecute(context.Background(), req.ID, "carol")
+	require.NoError(t, err)
+	other := manager.RequestApproval("aws_instance", "i-old", nil, "alice")
+	other.DetectedAt = time.Now().Add(-2 * time.Hour)
+	assert.Equal(t, 1, manager.CleanupExpired(time.Hour))
+
+	trail, err := manager.AuditTrail(req.ID)
+	require.NoError(t, err)
+	require.Len(t, trail, 3)
+	assert.Equal(t, ApprovalActionRequested, trail[0].Action)
+	assert.Equal(t, "alice", trail[0].Actor)
+	assert.Equal(t, ApprovalActionApproved, trail[1].Action)
+	assert.Equal(t, "carol", trail[1].Actor)
+	// The import outcome depends on the terraform binary being installed
+	assert.Contains(t, []ApprovalAction{ApprovalActionExecuted, ApprovalActionFailed}, trail[2].Action)
+	assert.Equal(t, "carol", trail[2].Actor)
+	assert.False(t, trail[2].At.IsZero())
+
+	all, err := manager.AuditTrail("")
+	require.NoError(t, err)
+	assert.Len(t, all, 5)
+	assert.Equal(t, ApprovalActionExpired, all[4].Action)
+	assert.Equal(t, other.ID, all[4].RequestID)
+
+	stored, ok, err := manager.Get(req.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, ApprovalApproved, stored.Status)
+	assert.Equal(t, "carol", stored.ApprovedBy)
+}
+
+func TestApprovalManager_ExecuteApproved(t *testing.T) {
+	manager := NewApprovalManager(NewImporter(".", true), true)
+	manager.stdin = mockStdin("y")
+
+	req := manager.RequestApproval("aws_instance", "i-prompt", nil, "alice")
+	_, err := manager.ExecuteApproved(context.Background(), req.ID)
+	assert.ErrorContains(t, err, "request is not approved")
+
+	approved, err := manager.PromptForApproval(context.Background(), req)
+	require.NoError(t, err)
+	require.True(t, approved)
+
+	result, err := manager.ExecuteApproved(context.Background(), req.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Empty(t, manager.ListPending())
+}
+
+func TestBoltApprovalStore_LockedByAnotherHandle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "approvals.db")
+	store, err := NewBoltApprovalStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, err = NewBoltApprovalStore(path)
+	assert.ErrorContains(t, err, "failed to open approval store")
+}
@@ -116,6 +116,24 @@ func TestReject_NotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "approval request not found")
 }
 
+func TestReject_NotPending(t *testing.T) {
+	importer := NewImporter(".", true)
+	manager := NewApprovalManager(importer, false)
+
+	request := manager.RequestApproval("aws_instance", "i-123", nil, "user")
+	_, err := manager.Approve(request.ID, "admin")
+	assert.NoError(t, err)
+
+	// An approved (possibly running) import can no longer be rejected
+	err = manager.RejectBy(request.ID, "bob", "too late")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "request is not pending")
+	assert.Equal(t, ApprovalApproved, request.Status)
+	assert.Empty(t, request.RejectedBy)
+	_, exists := manager.pendingRequests[request.ID]
+	assert.True(t, exists)
+}
+
 func TestApproveAndExecute_DryRun(t *testing.T) {
 	importer := NewImporter(".", true) // dry-run mode
 	manager := NewApprovalManager(importer, false)
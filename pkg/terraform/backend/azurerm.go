@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -107,6 +108,16 @@ func NewAzureRMBackend(cfg AzureRMBackendConfig) (*AzureRMBackend, error) {
 //   - []byte: Complete Terraform state file contents as JSON
 //   - error: If the blob doesn't exist, authentication fails, or network errors
 func (b *AzureRMBackend) Load(ctx context.Context) ([]byte, error) {
+	state, err := b.LoadIfChanged(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return state.Data, nil
+}
+
+// LoadIfChanged downloads the blob unless its ETag still equals version:
+// Blob Storage answers the conditional request with 304 Not Modified.
+func (b *AzureRMBackend) LoadIfChanged(ctx context.Context, version string) (*StateData, error) {
 	blobURL := b.buildBlobURL()
 	log.Infof("Loading Terraform state from Azure Blob Storage: %s/%s/%s",
 		b.storageAccountName, b.containerName, b.blobName)
@@ -118,6 +129,9 @@ func (b *AzureRMBackend) Load(ctx context.Context) ([]byte, error) {
 
 	// Set headers for Azure Blob Storage REST API
 	req.Header.Set("x-ms-version", "2020-10-02")
+	if version != "" {
+		req.Header.Set("If-None-Match", version)
+	}
 
 	resp, err := b.httpClient.Do(req)
 	if err != nil {
@@ -125,6 +139,11 @@ func (b *AzureRMBackend) Load(ctx context.Context) ([]byte, error) {
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	if resp.StatusCode == http.StatusNotModified && version != "" {
+		log.Debugf("Azure state %s/%s/%s unchanged (ETag %s)",
+			b.storageAccountName, b.containerName, b.blobName, version)
+		return nil, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("azure Blob Storage returned status %d: %s", resp.StatusCode, string(body))
@@ -137,7 +156,11 @@ func (b *AzureRMBackend) Load(ctx context.Context) ([]byte, error) {
 
 	log.Infof("Successfully loaded %d bytes from Azure Blob Storage (%s/%s/%s)",
 		len(data), b.storageAccountName, b.containerName, b.blobName)
-	return data, nil
+	state := &StateData{Data: data, Version: resp.Header.Get("ETag")}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		state.LastModified = modified.UTC().Format(time.RFC3339)
+	}
+	return state, nil
 }
 
 // buildBlobURL constructs the Azure Blob Storage URL for the state file.
@@ -1,7 +1,12 @@
 package backend
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"fmt"
+	"io"
+	"time"
 )
 
 // Backend is the interface for Terraform state backends
@@ -17,5 +22,47 @@ type Backend interface {
 type StateData struct {
 	Data         []byte
 	LastModified string
-	Version      string
+	// Version identifies this revision of the state in its backend: an ETag,
+	// object generation, state version ID or modification time
+	Version string
+}
+
+// ConditionalBackend is implemented by backends that can tell whether the
+// state changed since a previous load without transferring it again.
+type ConditionalBackend interface {
+	Backend
+
+	// LoadIfChanged loads the state unless its current version equals
+	// version, in which case it returns nil and no error. An empty version
+	// always loads.
+	LoadIfChanged(ctx context.Context, version string) (*StateData, error)
+}
+
+// VersionedBackend is implemented by backends that keep earlier versions of
+// the state (S3 object versions, GCS object generations).
+type VersionedBackend interface {
+	Backend
+
+	// LoadAt loads the version of the state that was current at the given
+	// time. StateData.LastModified is when that version was written.
+	LoadAt(ctx context.Context, at time.Time) (*StateData, error)
+}
+
+// gunzipIfCompressed decompresses state written gzipped (Kubernetes secrets,
+// Consul with gzip = true) and returns anything else unchanged
+func gunzipIfCompressed(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gzipped state: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress state: %w", err)
+	}
+	return out, nil
 }
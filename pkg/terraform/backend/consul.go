@@ -0,0 +1,166 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultConsulAddress is the Consul agent address used when none is configured
+	DefaultConsulAddress = "127.0.0.1:8500"
+	// consulWorkspaceSeparator joins the state path and a non-default workspace
+	consulWorkspaceSeparator = "-env:"
+)
+
+// ConsulBackend implements the Backend interface for Terraform's "consul"
+// backend, reading state from the Consul KV store over the HTTP API.
+//
+// The default workspace is stored at path, other workspaces at
+// "<path>-env:<workspace>". Gzipped state and state Terraform split into
+// chunks (large states) are both read.
+type ConsulBackend struct {
+	address    string
+	path       string
+	token      string
+	httpClient *http.Client
+}
+
+// ConsulBackendConfig contains configuration for the Consul backend.
+type ConsulBackendConfig struct {
+	// Address is the Consul agent ("host:port" or a URL). Falls back to
+	// CONSUL_HTTP_ADDR, then 127.0.0.1:8500.
+	Address string
+
+	// Path is the KV path of the default workspace's state
+	Path string
+
+	// Workspace selects the state of a non-default workspace
+	Workspace string
+
+	// Token is an ACL token. Falls back to CONSUL_HTTP_TOKEN.
+	Token string
+}
+
+// NewConsulBackend creates a backend reading state from Consul KV.
+func NewConsulBackend(cfg ConsulBackendConfig) (*ConsulBackend, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("consul path is required")
+	}
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+
+	address := strings.TrimSuffix(consulAddress(cfg.Address), "/")
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	return &ConsulBackend{
+		address:    address,
+		path:       consulStatePath(cfg.Path, cfg.Workspace),
+		token:      cfg.Token,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// consulAddress applies the CONSUL_HTTP_ADDR and default fallbacks
+func consulAddress(address string) string {
+	if address == "" {
+		address = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+	if address == "" {
+		address = DefaultConsulAddress
+	}
+	return address
+}
+
+// consulStatePath returns the KV path of a workspace's state
+func consulStatePath(path, workspace string) string {
+	path = strings.Trim(path, "/")
+	if workspace == "" || workspace == DefaultWorkspace {
+		return path
+	}
+	return path + consulWorkspaceSeparator + workspace
+}
+
+// consulChunkedPayload is what Terraform stores at the state path when the
+// state was too large for one KV entry
+type consulChunkedPayload struct {
+	CurrentHash string   `json:"current-hash"`
+	Chunks      []string `json:"chunks"`
+}
+
+// Load reads the state from Consul KV.
+func (b *ConsulBackend) Load(ctx context.Context) ([]byte, error) {
+	log.Infof("Loading Terraform state from Consul: %s", b.path)
+
+	data, err := b.get(ctx, b.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunked consulChunkedPayload
+	if json.Unmarshal(data, &chunked) == nil && chunked.CurrentHash != "" && len(chunked.Chunks) > 0 {
+		var joined []byte
+		for _, key := range chunked.Chunks {
+			chunk, err := b.get(ctx, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read state chunk: %w", err)
+			}
+			joined = append(joined, chunk...)
+		}
+		data = joined
+	}
+
+	data, err = gunzipIfCompressed(data)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Successfully loaded %d bytes from Consul (%s)", len(data), b.path)
+	return data, nil
+}
+
+// get reads the raw value of one key
+func (b *ConsulBackend) get(ctx context.Context, key string) ([]byte, error) {
+	kvURL := b.address + "/v1/kv/" + (&url.URL{Path: key}).EscapedPath() + "?raw"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, kvURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if b.token != "" {
+		req.Header.Set("X-Consul-Token", b.token)
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from Consul: %w", key, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Consul response: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("no state stored at Consul key %s", key)
+	default:
+		return nil, fmt.Errorf("consul returned status %d: %s", resp.StatusCode, string(body))
+	}
+}
+
+// Name returns the backend identifier for logging and debugging.
+func (b *ConsulBackend) Name() string {
+	return "consul"
+}
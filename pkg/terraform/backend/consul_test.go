@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestConsulBackend_Load(t *testing.T) {
+	gz := gzipBytes(t, `{"version":4,"serial":9}`)
+	kv := map[string][]byte{
+		"/v1/kv/infra/network":          []byte(`{"version":4,"serial":1}`),
+		"/v1/kv/infra/network-env:prod": gz,
+		// Large state split by Terraform into chunks
+		"/v1/kv/infra/big":               []byte(`{"current-hash":"abc","chunks":["infra/big/tfstate.abc/0","infra/big/tfstate.abc/1"]}`),
+		"/v1/kv/infra/big/tfstate.abc/0": gz[:10],
+		"/v1/kv/infra/big/tfstate.abc/1": gz[10:],
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "acl-token", r.Header.Get("X-Consul-Token"))
+		assert.True(t, r.URL.Query().Has("raw"))
+		value, ok := kv[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(value)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		path, workspace, want string
+	}{
+		{"infra/network", "", `{"version":4,"serial":1}`},
+		{"infra/network", "default", `{"version":4,"serial":1}`},
+		{"infra/network", "prod", `{"version":4,"serial":9}`},
+		{"infra/big", "", `{"version":4,"serial":9}`},
+	}
+	for _, tt := range tests {
+		b, err := NewConsulBackend(ConsulBackendConfig{Address: srv.URL, Path: tt.path, Workspace: tt.workspace, Token: "acl-token"})
+		require.NoError(t, err)
+		data, err := b.Load(ctx)
+		require.NoError(t, err, tt.path+" "+tt.workspace)
+		assert.Equal(t, tt.want, string(data), tt.path+" "+tt.workspace)
+	}
+
+	b, err := NewConsulBackend(ConsulBackendConfig{Address: srv.URL, Path: "infra/network", Workspace: "dev", Token: "acl-token"})
+	require.NoError(t, err)
+	_, err = b.Load(ctx)
+	assert.ErrorContains(t, err, "no state stored at Consul key infra/network-env:dev")
+}
+
+func TestNewConsulBackend_Defaults(t *testing.T) {
+	_, err := NewConsulBackend(ConsulBackendConfig{})
+	assert.ErrorContains(t, err, "path is required")
+
+	t.Setenv("CONSUL_HTTP_ADDR", "consul.service:8500")
+	t.Setenv("CONSUL_HTTP_TOKEN", "from-env")
+	b, err := NewConsulBackend(ConsulBackendConfig{Path: "/infra/network/"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://consul.service:8500", b.address)
+	assert.Equal(t, "from-env", b.token)
+	assert.Equal(t, "infra/network", b.path)
+	assert.Equal(t, "consul", b.Name())
+}
@@ -0,0 +1,461 @@
+package backend
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/api/iterator"
+)
+
+// DefaultWorkspace is the workspace of a state that is not stored under a
+// workspace-specific key.
+const DefaultWorkspace = "default"
+
+// keyLister enumerates the state file paths / object keys that start with prefix.
+type keyLister func(ctx context.Context, prefix string) ([]string, error)
+
+// ExpandStateConfig resolves one configured state entry into concrete states.
+// An entry without key_pattern is returned as-is; an entry with one becomes one
+// entry per matching file/object. Every returned entry has Workspace set,
+// derived from the backend's workspace key layout unless configured.
+func ExpandStateConfig(ctx context.Context, cfg config.TerraformStateConfig) ([]config.TerraformStateConfig, error) {
+	if cfg.KeyPattern == "" {
+		if cfg.Workspace == "" {
+			cfg.Workspace = deriveWorkspace(cfg.Backend, stateKey(cfg))
+		}
+		return []config.TerraformStateConfig{cfg}, nil
+	}
+
+	list, err := newKeyLister(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return expandWithLister(ctx, cfg, list)
+}
+
+// expandWithLister is the backend-independent half of ExpandStateConfig.
+func expandWithLister(ctx context.Context, cfg config.TerraformStateConfig, list keyLister) ([]config.TerraformStateConfig, error) {
+	pattern := cfg.KeyPattern
+	if backendName(cfg.Backend) == "local" {
+		// The walk yields cleaned paths, so "./stacks/*" must match "stacks/a"
+		pattern = path.Clean(filepath.ToSlash(pattern))
+	}
+	re, err := compileKeyPattern(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key_pattern %q: %w", cfg.KeyPattern, err)
+	}
+
+	keys, err := list(ctx, keyPatternPrefix(pattern))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s states matching %q: %w", backendName(cfg.Backend), cfg.KeyPattern, err)
+	}
+	sort.Strings(keys)
+
+	var expanded []config.TerraformStateConfig
+	for _, key := range keys {
+		if !re.MatchString(key) {
+			continue
+		}
+		c := cfg
+		c.KeyPattern = ""
+		setStateKey(&c, key)
+		if cfg.Name != "" {
+			c.Name = cfg.Name + ":" + key
+		}
+		if c.Workspace == "" {
+			c.Workspace = deriveWorkspace(c.Backend, key)
+		}
+		expanded = append(expanded, c)
+	}
+
+	log.Infof("State pattern %q on %s backend matched %d state file(s)", cfg.KeyPattern, backendName(cfg.Backend), len(expanded))
+	return expanded, nil
+}
+
+// Location returns a human-readable identifier for a concrete state, used as
+// its name when none is configured.
+func Location(cfg config.TerraformStateConfig) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	switch cfg.Backend {
+	case "s3":
+		return fmt.Sprintf("s3://%s/%s", cfg.S3Bucket, cfg.S3Key)
+	case "gcs":
+		return fmt.Sprintf("gs://%s/%s", cfg.GCSBucket, cfg.GCSPrefix)
+	case "azurerm":
+		return fmt.Sprintf("azurerm://%s/%s/%s", cfg.AzureStorageAccount, cfg.AzureContainerName, cfg.AzureBlobName)
+	case "remote", "cloud":
+		host := cfg.RemoteHostname
+		if host == "" {
+			host = DefaultRemoteHostname
+		}
+		if u, err := url.Parse(host); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		return fmt.Sprintf("remote://%s/%s/%s", host, cfg.RemoteOrganization, cfg.RemoteWorkspace)
+	case "http":
+		return redactAddress(cfg.HTTPAddress)
+	case "consul":
+		address := consulAddress(cfg.ConsulAddress)
+		if u, err := url.Parse(address); err == nil && u.Host != "" {
+			address = u.Host
+		}
+		return fmt.Sprintf("consul://%s/%s", address, consulStatePath(cfg.ConsulPath, cfg.Workspace))
+	case "pg":
+		schema := cfg.PGSchemaName
+		if schema == "" {
+			schema = DefaultPGSchemaName
+		}
+		return fmt.Sprintf("pg://%s/%s", schema, workspaceOrDefault(cfg.Workspace))
+	case "kubernetes":
+		namespace := cfg.KubernetesNamespace
+		if namespace == "" {
+			namespace = DefaultKubernetesNamespace
+		}
+		return fmt.Sprintf("kubernetes://%s/%s", namespace, kubernetesSecretName(workspaceOrDefault(cfg.Workspace), cfg.KubernetesSecretSuffix))
+	default:
+		if cfg.LocalPath == "" {
+			return "./terraform.tfstate"
+		}
+		return cfg.LocalPath
+	}
+}
+
+// redactAddress drops credentials and query parameters (often a token) from
+// a state URL before it is logged or shown.
+func redactAddress(address string) string {
+	u, err := url.Parse(address)
+	if err != nil {
+		return address
+	}
+	u.User = nil
+	u.RawQuery = ""
+	return u.String()
+}
+
+func workspaceOrDefault(workspace string) string {
+	if workspace == "" {
+		return DefaultWorkspace
+	}
+	return workspace
+}
+
+func backendName(b string) string {
+	if b == "" {
+		return "local"
+	}
+	return b
+}
+
+// stateKey returns the path/key field that identifies the state in its backend.
+func stateKey(cfg config.TerraformStateConfig) string {
+	switch cfg.Backend {
+	case "s3":
+		return cfg.S3Key
+	case "gcs":
+		return cfg.GCSPrefix
+	case "azurerm":
+		return cfg.AzureBlobName
+	case "remote", "cloud":
+		return cfg.RemoteWorkspace
+	case "http":
+		return cfg.HTTPAddress
+	case "consul":
+		return cfg.ConsulPath
+	case "pg":
+		return cfg.PGSchemaName
+	case "kubernetes":
+		return cfg.KubernetesSecretSuffix
+	default:
+		return cfg.LocalPath
+	}
+}
+
+func setStateKey(cfg *config.TerraformStateConfig, key string) {
+	switch cfg.Backend {
+	case "s3":
+		cfg.S3Key = key
+	case "gcs":
+		cfg.GCSPrefix = key
+	case "azurerm":
+		cfg.AzureBlobName = key
+	case "remote", "cloud":
+		cfg.RemoteWorkspace = key
+	default:
+		cfg.LocalPath = key
+	}
+}
+
+// deriveWorkspace maps a state key to its Terraform workspace using each
+// backend's non-default workspace layout:
+//
+//	s3:      env:/<workspace>/<key>        (workspace_key_prefix "env:")
+//	gcs:     <prefix>/<workspace>.tfstate
+//	azurerm: <key>env:<workspace>
+//	remote:  one HCP Terraform workspace per state, named as the workspace
+//	consul, pg, kubernetes: selected by the configured workspace
+//	local:   terraform.tfstate.d/<workspace>/terraform.tfstate
+func deriveWorkspace(backend, key string) string {
+	switch backend {
+	case "s3":
+		if rest, ok := strings.CutPrefix(key, "env:/"); ok {
+			if ws, _, found := strings.Cut(rest, "/"); found && ws != "" {
+				return ws
+			}
+		}
+	case "gcs":
+		if ws, ok := strings.CutSuffix(path.Base(key), ".tfstate"); ok && ws != "" {
+			return ws
+		}
+	case "azurerm":
+		if i := strings.LastIndex(key, "env:"); i >= 0 && i+4 < len(key) {
+			return key[i+4:]
+		}
+	case "remote", "cloud":
+		if key != "" {
+			return key
+		}
+	case "http", "consul", "pg", "kubernetes":
+		// One state per address; pg, consul and kubernetes select the
+		// state by the configured workspace
+	default:
+		dir := filepath.Dir(filepath.Clean(key))
+		if filepath.Base(filepath.Dir(dir)) == "terraform.tfstate.d" {
+			return filepath.Base(dir)
+		}
+	}
+	return DefaultWorkspace
+}
+
+// keyPatternPrefix returns the literal part of a pattern before its first
+// wildcard, used as the listing prefix.
+func keyPatternPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, "*?"); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// compileKeyPattern turns a key_pattern glob into an anchored regexp: "*" and
+// "?" stay within one "/"-separated segment, "**" spans segments and "**/"
+// also matches zero segments.
+func compileKeyPattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// newKeyLister builds the lister for the entry's backend.
+func newKeyLister(ctx context.Context, cfg config.TerraformStateConfig) (keyLister, error) {
+	switch cfg.Backend {
+	case "local", "":
+		return listLocalFiles, nil
+
+	case "s3":
+		if cfg.S3Bucket == "" {
+			return nil, fmt.Errorf("S3 bucket is required")
+		}
+		region := strings.TrimSpace(cfg.S3Region)
+		if region == "" {
+			region = "us-east-1"
+		}
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		return s3KeyLister(s3.NewFromConfig(awsCfg), cfg.S3Bucket), nil
+
+	case "gcs":
+		if cfg.GCSBucket == "" {
+			return nil, fmt.Errorf("GCS bucket is required")
+		}
+		return func(ctx context.Context, prefix string) ([]string, error) {
+			client, err := storage.NewClient(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create GCS client: %w", err)
+			}
+			defer func() { _ = client.Close() }()
+
+			var keys []string
+			it := client.Bucket(cfg.GCSBucket).Objects(ctx, &storage.Query{Prefix: prefix})
+			for {
+				attrs, err := it.Next()
+				if err == iterator.Done {
+					return keys, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+				keys = append(keys, attrs.Name)
+			}
+		}, nil
+
+	case "azurerm":
+		if cfg.AzureStorageAccount == "" || cfg.AzureContainerName == "" {
+			return nil, fmt.Errorf("azure storage account and container name are required")
+		}
+		containerURL := fmt.Sprintf("https://%s.blob.core.windows.net/%s", cfg.AzureStorageAccount, cfg.AzureContainerName)
+		return azureBlobLister(&http.Client{}, containerURL, cfg.AzureSASToken), nil
+
+	case "remote", "cloud":
+		if cfg.RemoteOrganization == "" {
+			return nil, fmt.Errorf("remote organization is required")
+		}
+		client, err := newTFEClient(cfg.RemoteHostname, cfg.RemoteToken)
+		if err != nil {
+			return nil, err
+		}
+		return remoteWorkspaceLister(client, cfg.RemoteOrganization), nil
+
+	default:
+		return nil, fmt.Errorf("key_pattern is not supported for backend %q", cfg.Backend)
+	}
+}
+
+// listLocalFiles walks the directory containing prefix and returns every
+// regular file path (slash-separated) beneath it.
+func listLocalFiles(_ context.Context, prefix string) ([]string, error) {
+	root := prefix
+	if !strings.HasSuffix(root, "/") {
+		root = filepath.Dir(root)
+	}
+	if root == "" {
+		root = "."
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, filepath.ToSlash(p))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// s3ListClient is the subset of the S3 API used for state discovery.
+type s3ListClient interface {
+	ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+}
+
+func s3KeyLister(client s3ListClient, bucket string) keyLister {
+	return func(ctx context.Context, prefix string) ([]string, error) {
+		var keys []string
+		input := &s3.ListObjectsV2Input{Bucket: aws.String(bucket), Prefix: aws.String(prefix)}
+		for {
+			out, err := client.ListObjectsV2(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			for _, obj := range out.Contents {
+				keys = append(keys, aws.ToString(obj.Key))
+			}
+			if !aws.ToBool(out.IsTruncated) || out.NextContinuationToken == nil {
+				return keys, nil
+			}
+			input.ContinuationToken = out.NextContinuationToken
+		}
+	}
+}
+
+// azureBlobList is the subset of the List Blobs XML response we use.
+type azureBlobList struct {
+	Blobs struct {
+		Blob []struct {
+			Name string `xml:"Name"`
+		} `xml:"Blob"`
+	} `xml:"Blobs"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+func azureBlobLister(client *http.Client, containerURL, sasToken string) keyLister {
+	return func(ctx context.Context, prefix string) ([]string, error) {
+		var keys []string
+		marker := ""
+		for {
+			q := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+			if marker != "" {
+				q.Set("marker", marker)
+			}
+			listURL := containerURL + "?" + q.Encode()
+			if sas := strings.TrimPrefix(sasToken, "?"); sas != "" {
+				listURL += "&" + sas
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, listURL, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create request: %w", err)
+			}
+			req.Header.Set("x-ms-version", "2020-10-02")
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list blobs: %w", err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read blob list: %w", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("azure Blob Storage returned status %d: %s", resp.StatusCode, string(body))
+			}
+
+			var page azureBlobList
+			if err := xml.Unmarshal(body, &page); err != nil {
+				return nil, fmt.Errorf("failed to parse blob list: %w", err)
+			}
+			for _, b := range page.Blobs.Blob {
+				keys = append(keys, b.Name)
+			}
+			if page.NextMarker == "" {
+				return keys, nil
+			}
+			marker = page.NextMarker
+		}
+	}
+}
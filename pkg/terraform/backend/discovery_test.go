@@ -0,0 +1,206 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileKeyPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		match   bool
+	}{
+		{"stacks/*/terraform.tfstate", "stacks/network/terraform.tfstate", true},
+		{"stacks/*/terraform.tfstate", "stacks/a/b/terraform.tfstate", false},
+		{"stacks/**/terraform.tfstate", "stacks/a/b/terraform.tfstate", true},
+		{"stacks/**/terraform.tfstate", "stacks/terraform.tfstate", true},
+		{"env:/*/app.tfstate", "env:/prod/app.tfstate", true},
+		{"env:/*/app.tfstate", "app.tfstate", false},
+		{"**", "anything/at/all", true},
+		{"state?.tfstate", "state1.tfstate", true},
+		{"state?.tfstate", "state12.tfstate", false},
+		{"a.tfstate", "aXtfstate", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"~"+tt.key, func(t *testing.T) {
+			re, err := compileKeyPattern(tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, re.MatchString(tt.key))
+		})
+	}
+}
+
+func TestKeyPatternPrefix(t *testing.T) {
+	assert.Equal(t, "stacks/", keyPatternPrefix("stacks/*/terraform.tfstate"))
+	assert.Equal(t, "env:/", keyPatternPrefix("env:/?/x"))
+	assert.Equal(t, "exact.tfstate", keyPatternPrefix("exact.tfstate"))
+}
+
+func TestDeriveWorkspace(t *testing.T) {
+	tests := []struct {
+		backend string
+		key     string
+		want    string
+	}{
+		{"s3", "env:/staging/network/terraform.tfstate", "staging"},
+		{"s3", "network/terraform.tfstate", DefaultWorkspace},
+		{"gcs", "terraform/state/prod.tfstate", "prod"},
+		{"azurerm", "app.tfstateenv:dev", "dev"},
+		{"azurerm", "app.tfstate", DefaultWorkspace},
+		{"local", "infra/terraform.tfstate.d/qa/terraform.tfstate", "qa"},
+		{"", "infra/terraform.tfstate", DefaultWorkspace},
+	}
+	for _, tt := range tests {
+		t.Run(tt.backend+":"+tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, deriveWorkspace(tt.backend, tt.key))
+		})
+	}
+}
+
+func TestExpandStateConfig_NoPattern(t *testing.T) {
+	cfg := config.TerraformStateConfig{Backend: "s3", S3Bucket: "b", S3Key: "env:/prod/app.tfstate"}
+
+	got, err := ExpandStateConfig(context.Background(), cfg)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "prod", got[0].Workspace)
+	assert.Equal(t, "env:/prod/app.tfstate", got[0].S3Key)
+}
+
+func TestExpandStateConfig_LocalPattern(t *testing.T) {
+	root := t.TempDir()
+	for _, p := range []string{
+		"network/terraform.tfstate",
+		"app/terraform.tfstate",
+		"app/terraform.tfstate.d/staging/terraform.tfstate",
+		"app/README.md",
+	} {
+		full := filepath.Join(root, p)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, []byte("{}"), 0o600))
+	}
+
+	cfg := config.TerraformStateConfig{
+		Backend:    "local",
+		KeyPattern: filepath.ToSlash(root) + "/**/terraform.tfstate",
+	}
+	got, err := ExpandStateConfig(context.Background(), cfg)
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+
+	byPath := make(map[string]config.TerraformStateConfig)
+	for _, c := range got {
+		assert.Empty(t, c.KeyPattern)
+		byPath[c.LocalPath] = c
+	}
+	assert.Equal(t, DefaultWorkspace, byPath[filepath.ToSlash(filepath.Join(root, "network/terraform.tfstate"))].Workspace)
+	assert.Equal(t, "staging", byPath[filepath.ToSlash(filepath.Join(root, "app/terraform.tfstate.d/staging/terraform.tfstate"))].Workspace)
+}
+
+func TestExpandStateConfig_LocalRelativePattern(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, p := range []string{"stacks/network/terraform.tfstate", "stacks/app/terraform.tfstate"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte("{}"), 0o600))
+	}
+
+	got, err := ExpandStateConfig(context.Background(), config.TerraformStateConfig{
+		Backend:    "local",
+		KeyPattern: "./stacks/*/terraform.tfstate",
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "stacks/app/terraform.tfstate", got[0].LocalPath)
+	assert.Equal(t, "stacks/network/terraform.tfstate", got[1].LocalPath)
+}
+
+// mockS3ListClient implements s3ListClient, serving keys in pages of two
+type mockS3ListClient struct {
+	keys     []string
+	prefixes []string
+}
+
+func (m *mockS3ListClient) ListObjectsV2(_ context.Context, input *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	m.prefixes = append(m.prefixes, aws.ToString(input.Prefix))
+	start := 0
+	if input.ContinuationToken != nil {
+		start = len(aws.ToString(input.ContinuationToken))
+	}
+	end := min(start+2, len(m.keys))
+
+	out := &s3.ListObjectsV2Output{IsTruncated: aws.Bool(end < len(m.keys))}
+	for _, k := range m.keys[start:end] {
+		out.Contents = append(out.Contents, s3types.Object{Key: aws.String(k)})
+	}
+	if end < len(m.keys) {
+		// Token length encodes the next offset
+		out.NextContinuationToken = aws.String(string(make([]byte, end)))
+	}
+	return out, nil
+}
+
+func TestExpandWithLister_S3Workspaces(t *testing.T) {
+	client := &mockS3ListClient{keys: []string{
+		"env:/prod/app.tfstate",
+		"env:/staging/app.tfstate",
+		"env:/staging/other.tfstate",
+		"env:/dev/app.tfstate",
+	}}
+	cfg := config.TerraformStateConfig{
+		Name:       "app",
+		Backend:    "s3",
+		S3Bucket:   "state-bucket",
+		KeyPattern: "env:/*/app.tfstate",
+	}
+
+	got, err := expandWithLister(context.Background(), cfg, s3KeyLister(client, "state-bucket"))
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, []string{"env:/", "env:/"}, client.prefixes, "listing must page through results using the pattern's literal prefix")
+
+	// Sorted by key
+	assert.Equal(t, "env:/dev/app.tfstate", got[0].S3Key)
+	assert.Equal(t, "dev", got[0].Workspace)
+	assert.Equal(t, "app:env:/dev/app.tfstate", got[0].Name)
+	assert.Equal(t, "prod", got[1].Workspace)
+	assert.Equal(t, "staging", got[2].Workspace)
+}
+
+func TestAzureBlobLister_FollowsMarker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "list", r.URL.Query().Get("comp"))
+		assert.Equal(t, "stacks/", r.URL.Query().Get("prefix"))
+		assert.Equal(t, "sig", r.URL.Query().Get("sv"))
+		w.Header().Set("Content-Type", "application/xml")
+		if r.URL.Query().Get("marker") == "" {
+			_, _ = w.Write([]byte(`<EnumerationResults><Blobs><Blob><Name>stacks/a.tfstate</Name></Blob></Blobs><NextMarker>m1</NextMarker></EnumerationResults>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<EnumerationResults><Blobs><Blob><Name>stacks/b.tfstateenv:prod</Name></Blob></Blobs><NextMarker/></EnumerationResults>`))
+	}))
+	defer srv.Close()
+
+	keys, err := azureBlobLister(srv.Client(), srv.URL+"/tfstate", "?sv=sig")(context.Background(), "stacks/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stacks/a.tfstate", "stacks/b.tfstateenv:prod"}, keys)
+}
+
+func TestLocation(t *testing.T) {
+	assert.Equal(t, "s3://b/k.tfstate", Location(config.TerraformStateConfig{Backend: "s3", S3Bucket: "b", S3Key: "k.tfstate"}))
+	assert.Equal(t, "gs://b/prefix", Location(config.TerraformStateConfig{Backend: "gcs", GCSBucket: "b", GCSPrefix: "prefix"}))
+	assert.Equal(t, "azurerm://acct/c/blob", Location(config.TerraformStateConfig{Backend: "azurerm", AzureStorageAccount: "acct", AzureContainerName: "c", AzureBlobName: "blob"}))
+	assert.Equal(t, "./terraform.tfstate", Location(config.TerraformStateConfig{Backend: "local"}))
+	assert.Equal(t, "network", Location(config.TerraformStateConfig{Name: "network", Backend: "local"}))
+}
@@ -39,7 +39,48 @@ func NewBackend(ctx context.Context, cfg config.TerraformStateConfig) (Backend,
 			SASToken:           cfg.AzureSASToken,
 		})
 
+	case "remote", "cloud":
+		return NewRemoteBackend(RemoteBackendConfig{
+			Hostname:     cfg.RemoteHostname,
+			Organization: cfg.RemoteOrganization,
+			Workspace:    cfg.RemoteWorkspace,
+			Token:        cfg.RemoteToken,
+		})
+
+	case "http":
+		return NewHTTPBackend(HTTPBackendConfig{
+			Address:  cfg.HTTPAddress,
+			Username: cfg.HTTPUsername,
+			Password: cfg.HTTPPassword,
+			Token:    cfg.HTTPToken,
+		})
+
+	case "consul":
+		return NewConsulBackend(ConsulBackendConfig{
+			Address:   cfg.ConsulAddress,
+			Path:      cfg.ConsulPath,
+			Workspace: cfg.Workspace,
+			Token:     cfg.ConsulToken,
+		})
+
+	case "pg":
+		return NewPGBackend(PGBackendConfig{
+			ConnStr:    cfg.PGConnStr,
+			SchemaName: cfg.PGSchemaName,
+			Workspace:  cfg.Workspace,
+		})
+
+	case "kubernetes":
+		return NewKubernetesBackend(KubernetesBackendConfig{
+			SecretSuffix: cfg.KubernetesSecretSuffix,
+			Namespace:    cfg.KubernetesNamespace,
+			Workspace:    cfg.Workspace,
+			Host:         cfg.KubernetesHost,
+			Token:        cfg.KubernetesToken,
+			CACertFile:   cfg.KubernetesCACert,
+		})
+
 	default:
-		return nil, fmt.Errorf("unsupported backend: %s (supported: local, s3, gcs, azurerm)", cfg.Backend)
+		return nil, fmt.Errorf("unsupported backend: %s (supported: local, s3, gcs, azurerm, remote, http, consul, pg, kubernetes)", cfg.Backend)
 	}
 }
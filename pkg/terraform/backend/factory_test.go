@@ -407,3 +407,21 @@ func TestNewBackend_AllBackendTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestNewBackend_RemoteAndHTTP(t *testing.T) {
+	ctx := context.Background()
+	for _, name := range []string{"remote", "cloud"} {
+		be, err := NewBackend(ctx, config.TerraformStateConfig{
+			Backend:            name,
+			RemoteOrganization: "acme",
+			RemoteWorkspace:    "network-prod",
+			RemoteToken:        "t",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "remote", be.Name())
+	}
+
+	be, err := NewBackend(ctx, config.TerraformStateConfig{Backend: "http", HTTPAddress: "https://state.example.com/network"})
+	require.NoError(t, err)
+	assert.Equal(t, "http", be.Name())
+}
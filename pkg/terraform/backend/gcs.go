@@ -2,11 +2,15 @@ package backend
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"time"
 
 	"cloud.google.com/go/storage"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
@@ -191,6 +195,90 @@ func (b *GCSBackend) Load(ctx context.Context) ([]byte, error) {
 	return data, nil
 }
 
+// LoadIfChanged downloads the object unless its generation still equals
+// version. Only the object's metadata is read when it is unchanged.
+func (b *GCSBackend) LoadIfChanged(ctx context.Context, version string) (*StateData, error) {
+	if b.loadFunc != nil {
+		data, err := b.loadFunc(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &StateData{Data: data}, nil
+	}
+
+	obj := b.client.Bucket(b.bucket).Object(b.prefix)
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object attributes from GCS gs://%s/%s: %w", b.bucket, b.prefix, err)
+	}
+	generation := strconv.FormatInt(attrs.Generation, 10)
+	if version != "" && version == generation {
+		log.Debugf("GCS state gs://%s/%s unchanged (generation %s)", b.bucket, b.prefix, generation)
+		return nil, nil
+	}
+
+	log.Infof("Loading Terraform state from GCS: gs://%s/%s (generation %s)", b.bucket, b.prefix, generation)
+	reader, err := obj.Generation(attrs.Generation).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object from GCS gs://%s/%s: %w", b.bucket, b.prefix, err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GCS object body: %w", err)
+	}
+	return &StateData{
+		Data:         data,
+		LastModified: attrs.Updated.UTC().Format(time.RFC3339),
+		Version:      generation,
+	}, nil
+}
+
+// LoadAt reads the object generation that was current at the given time.
+// The bucket must have object versioning enabled.
+func (b *GCSBackend) LoadAt(ctx context.Context, at time.Time) (*StateData, error) {
+	var match *storage.ObjectAttrs
+	it := b.client.Bucket(b.bucket).Objects(ctx, &storage.Query{Prefix: b.prefix, Versions: true})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list generations of gs://%s/%s: %w", b.bucket, b.prefix, err)
+		}
+		if attrs.Name != b.prefix || attrs.Created.After(at) {
+			continue
+		}
+		if match == nil || attrs.Created.After(match.Created) {
+			match = attrs
+		}
+	}
+	// A generation deleted before at without a successor means no state then
+	if match == nil || (!match.Deleted.IsZero() && !match.Deleted.After(at)) {
+		return nil, fmt.Errorf("no generation of gs://%s/%s existed at %s", b.bucket, b.prefix, at.UTC().Format(time.RFC3339))
+	}
+
+	generation := strconv.FormatInt(match.Generation, 10)
+	log.Infof("Loading Terraform state from GCS: gs://%s/%s (generation %s of %s)", b.bucket, b.prefix, generation, match.Created.UTC().Format(time.RFC3339))
+	reader, err := b.client.Bucket(b.bucket).Object(b.prefix).Generation(match.Generation).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read generation %s of gs://%s/%s: %w", generation, b.bucket, b.prefix, err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GCS object body: %w", err)
+	}
+	return &StateData{
+		Data:         data,
+		LastModified: match.Created.UTC().Format(time.RFC3339),
+		Version:      generation,
+	}, nil
+}
+
 // Name returns the backend identifier for logging and debugging.
 //
 // This method is part of the Backend interface and returns a constant
@@ -0,0 +1,99 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPBackend_ConditionalLoad(t *testing.T) {
+	state := `{"version":4,"serial":1}`
+	etag := `"v1"`
+	var fullResponses int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "ci" || pass != "hunter2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullResponses++
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(state))
+	}))
+	defer srv.Close()
+
+	b, err := NewHTTPBackend(HTTPBackendConfig{Address: srv.URL + "/state/network", Username: "ci", Password: "hunter2"})
+	require.NoError(t, err)
+	assert.Equal(t, "http", b.Name())
+	ctx := context.Background()
+
+	first, err := b.LoadIfChanged(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, state, string(first.Data))
+	assert.Equal(t, etag, first.Version)
+
+	unchanged, err := b.LoadIfChanged(ctx, first.Version)
+	require.NoError(t, err)
+	assert.Nil(t, unchanged, "an unchanged ETag is answered with 304")
+	assert.Equal(t, 1, fullResponses)
+
+	state, etag = `{"version":4,"serial":2}`, `"v2"`
+	changed, err := b.LoadIfChanged(ctx, first.Version)
+	require.NoError(t, err)
+	assert.Equal(t, state, string(changed.Data))
+	assert.Equal(t, `"v2"`, changed.Version)
+	assert.Equal(t, 2, fullResponses)
+
+	data, err := b.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, state, string(data), "Load always transfers the state")
+	assert.Equal(t, 3, fullResponses)
+}
+
+func TestHTTPBackend_TokenAndErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/empty" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	_, err := NewHTTPBackend(HTTPBackendConfig{})
+	assert.ErrorContains(t, err, "address is required")
+
+	b, _ := NewHTTPBackend(HTTPBackendConfig{Address: srv.URL + "/state", Token: "tok"})
+	_, err = b.Load(ctx)
+	assert.NoError(t, err)
+
+	b, _ = NewHTTPBackend(HTTPBackendConfig{Address: srv.URL + "/empty?token=tok", Token: "tok"})
+	_, err = b.Load(ctx)
+	assert.ErrorContains(t, err, "no state stored at "+srv.URL+"/empty ")
+
+	b, _ = NewHTTPBackend(HTTPBackendConfig{Address: srv.URL + "/state", Token: "wrong"})
+	_, err = b.Load(ctx)
+	assert.ErrorContains(t, err, "status 403")
+}
+
+func TestLocation_RemoteAndHTTP(t *testing.T) {
+	assert.Equal(t, "remote://app.terraform.io/acme/network-prod",
+		Location(config.TerraformStateConfig{Backend: "remote", RemoteOrganization: "acme", RemoteWorkspace: "network-prod"}))
+	assert.Equal(t, "https://state.example.com/network",
+		Location(config.TerraformStateConfig{Backend: "http", HTTPAddress: "https://user:pw@state.example.com/network?token=x"}))
+	assert.Equal(t, DefaultWorkspace, deriveWorkspace("http", "https://state.example.com/terraform.tfstate.d/x/y"))
+}
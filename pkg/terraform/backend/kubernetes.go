@@ -0,0 +1,187 @@
+package backend
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultKubernetesNamespace is the namespace used when none is configured
+	DefaultKubernetesNamespace = "default"
+
+	// kubernetesSecretKey is the Secret data key holding the gzipped state
+	kubernetesSecretKey = "tfstate"
+
+	inClusterTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	inClusterCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// KubernetesBackend implements the Backend interface for Terraform's
+// "kubernetes" backend: each workspace's state is the gzipped "tfstate" key
+// of the Secret "tfstate-<workspace>-<secret_suffix>".
+//
+// The Secret is read through the Kubernetes API with a bearer token: the
+// configured one, or the pod's service account when running in-cluster
+// (the token file is re-read on every load, as projected tokens rotate).
+type KubernetesBackend struct {
+	host       string
+	namespace  string
+	secretName string
+	token      string
+	tokenFile  string
+	httpClient *http.Client
+}
+
+// KubernetesBackendConfig contains configuration for the Kubernetes backend.
+type KubernetesBackendConfig struct {
+	// SecretSuffix is the suffix Terraform's backend was configured with
+	SecretSuffix string
+
+	// Namespace holds the Secret (default "default")
+	Namespace string
+
+	// Workspace selects the Secret (default "default")
+	Workspace string
+
+	// Host is the API server URL. When empty the in-cluster configuration
+	// (KUBERNETES_SERVICE_HOST and the service account) is used.
+	Host string
+
+	// Token is a bearer token for the API server
+	Token string
+
+	// CACertFile is a PEM file with the API server's CA
+	CACertFile string
+}
+
+// NewKubernetesBackend creates a backend reading state from a Kubernetes Secret.
+func NewKubernetesBackend(cfg KubernetesBackendConfig) (*KubernetesBackend, error) {
+	if cfg.SecretSuffix == "" {
+		return nil, fmt.Errorf("kubernetes secret suffix is required")
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = DefaultKubernetesNamespace
+	}
+	if cfg.Workspace == "" {
+		cfg.Workspace = DefaultWorkspace
+	}
+
+	b := &KubernetesBackend{
+		host:       strings.TrimSuffix(cfg.Host, "/"),
+		namespace:  cfg.Namespace,
+		secretName: kubernetesSecretName(cfg.Workspace, cfg.SecretSuffix),
+		token:      cfg.Token,
+	}
+	if b.host == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("kubernetes host is required when not running in a cluster")
+		}
+		b.host = "https://" + net.JoinHostPort(host, port)
+		if b.token == "" {
+			b.tokenFile = inClusterTokenFile
+		}
+		if cfg.CACertFile == "" {
+			cfg.CACertFile = inClusterCAFile
+		}
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CACertFile != "" {
+		pem, err := os.ReadFile(cfg.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	b.httpClient = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment}}
+	return b, nil
+}
+
+// kubernetesSecretName returns the Secret Terraform stores a workspace's state in
+func kubernetesSecretName(workspace, suffix string) string {
+	return "tfstate-" + workspace + "-" + suffix
+}
+
+// kubernetesSecret is the subset of a Secret we use; data values are
+// base64 in JSON and decoded into bytes
+type kubernetesSecret struct {
+	Data map[string][]byte `json:"data"`
+}
+
+// Load reads and decompresses the state Secret.
+func (b *KubernetesBackend) Load(ctx context.Context) ([]byte, error) {
+	log.Infof("Loading Terraform state from Kubernetes secret %s/%s", b.namespace, b.secretName)
+
+	secretURL := fmt.Sprintf("%s/api/v1/namespaces/%s/secrets/%s", b.host, url.PathEscape(b.namespace), url.PathEscape(b.secretName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	token := b.token
+	if b.tokenFile != "" {
+		raw, err := os.ReadFile(b.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read service account token: %w", err)
+		}
+		token = strings.TrimSpace(string(raw))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubernetes response: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("no state secret %s/%s", b.namespace, b.secretName)
+	default:
+		return nil, fmt.Errorf("kubernetes API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var secret kubernetesSecret
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return nil, fmt.Errorf("failed to parse secret: %w", err)
+	}
+	raw, ok := secret.Data[kubernetesSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no %q key", b.namespace, b.secretName, kubernetesSecretKey)
+	}
+	data, err := gunzipIfCompressed(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Successfully loaded %d bytes from Kubernetes secret %s/%s", len(data), b.namespace, b.secretName)
+	return data, nil
+}
+
+// Name returns the backend identifier for logging and debugging.
+func (b *KubernetesBackend) Name() string {
+	return "kubernetes"
+}
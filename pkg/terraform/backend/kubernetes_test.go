@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubernetesBackend_Load(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString(gzipBytes(t, `{"version":4,"serial":3}`))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sa-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/namespaces/terraform/secrets/tfstate-prod-network":
+			_, _ = fmt.Fprintf(w, `{"kind":"Secret","metadata":{"name":"tfstate-prod-network"},"data":{"tfstate":%q}}`, secret)
+		case "/api/v1/namespaces/terraform/secrets/tfstate-default-network":
+			_, _ = fmt.Fprint(w, `{"kind":"Secret","data":{}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	newBackend := func(workspace, token string) *KubernetesBackend {
+		b, err := NewKubernetesBackend(KubernetesBackendConfig{
+			SecretSuffix: "network",
+			Namespace:    "terraform",
+			Workspace:    workspace,
+			Host:         srv.URL,
+			Token:        token,
+		})
+		require.NoError(t, err)
+		return b
+	}
+
+	b := newBackend("prod", "sa-token")
+	assert.Equal(t, "kubernetes", b.Name())
+	data, err := b.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"version":4,"serial":3}`, string(data))
+
+	_, err = newBackend("", "sa-token").Load(ctx)
+	assert.ErrorContains(t, err, `has no "tfstate" key`)
+	_, err = newBackend("dev", "sa-token").Load(ctx)
+	assert.ErrorContains(t, err, "no state secret terraform/tfstate-dev-network")
+	_, err = newBackend("prod", "wrong").Load(ctx)
+	assert.ErrorContains(t, err, "status 401")
+}
+
+func TestNewKubernetesBackend_Errors(t *testing.T) {
+	_, err := NewKubernetesBackend(KubernetesBackendConfig{})
+	assert.ErrorContains(t, err, "secret suffix is required")
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	_, err = NewKubernetesBackend(KubernetesBackendConfig{SecretSuffix: "s"})
+	assert.ErrorContains(t, err, "host is required when not running in a cluster")
+
+	_, err = NewKubernetesBackend(KubernetesBackendConfig{SecretSuffix: "s", Host: "https://k8s", CACertFile: "/nonexistent/ca.crt"})
+	assert.ErrorContains(t, err, "failed to read kubernetes CA certificate")
+}
+
+func TestLocation_ConsulPGKubernetes(t *testing.T) {
+	assert.Equal(t, "consul://consul:8500/infra/network-env:prod",
+		Location(config.TerraformStateConfig{Backend: "consul", ConsulAddress: "https://consul:8500", ConsulPath: "infra/network", Workspace: "prod"}))
+	assert.Equal(t, "pg://terraform_remote_state/default", Location(config.TerraformStateConfig{Backend: "pg", PGConnStr: "postgres://u:secret@db/tf"}))
+	assert.Equal(t, "kubernetes://default/tfstate-prod-network",
+		Location(config.TerraformStateConfig{Backend: "kubernetes", KubernetesSecretSuffix: "network", Workspace: "prod"}))
+}
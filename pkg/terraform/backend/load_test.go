@@ -5,14 +5,19 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 // mockS3Client implements the s3Client interface for testing
 type mockS3Client struct {
-	getObjectFunc func(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	getObjectFunc          func(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	listObjectVersionsFunc func(ctx context.Context, input *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error)
 }
 
 func (m *mockS3Client) GetObject(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
@@ -22,6 +27,13 @@ func (m *mockS3Client) GetObject(ctx context.Context, input *s3.GetObjectInput,
 	return nil, fmt.Errorf("not implemented")
 }
 
+func (m *mockS3Client) ListObjectVersions(ctx context.Context, input *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+	if m.listObjectVersionsFunc != nil {
+		return m.listObjectVersionsFunc(ctx, input, optFns...)
+	}
+	return nil, fmt.Errorf("not implemented")
+}
+
 // TestS3Backend_Load_MockSuccess tests successful S3 Load
 func TestS3Backend_Load_MockSuccess(t *testing.T) {
 	stateData := []byte(`{"version": 4, "terraform_version": "1.5.0"}`)
@@ -152,6 +164,133 @@ func TestS3Backend_Load_MockEmptyState(t *testing.T) {
 	}
 }
 
+// statusError carries an HTTP status like the SDK's response errors
+type statusError struct{ code int }
+
+func (e *statusError) Error() string       { return fmt.Sprintf("status %d", e.code) }
+func (e *statusError) HTTPStatusCode() int { return e.code }
+
+// TestS3Backend_LoadIfChanged_NotModified tests the conditional GetObject
+func TestS3Backend_LoadIfChanged_NotModified(t *testing.T) {
+	stateData := []byte(`{"version": 4, "serial": 3}`)
+	modified := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	backend := &S3Backend{
+		bucket: "test-bucket",
+		key:    "terraform.tfstate",
+		region: "us-east-1",
+		client: &mockS3Client{
+			getObjectFunc: func(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+				if aws.ToString(input.IfNoneMatch) == `"abc"` {
+					return nil, &statusError{code: http.StatusNotModified}
+				}
+				return &s3.GetObjectOutput{
+					Body:         io.NopCloser(bytes.NewReader(stateData)),
+					ETag:         aws.String(`"abc"`),
+					LastModified: &modified,
+				}, nil
+			},
+		},
+	}
+
+	state, err := backend.LoadIfChanged(context.Background(), "")
+	if err != nil {
+		t.Fatalf("LoadIfChanged failed: %v", err)
+	}
+	if state.Version != `"abc"` || state.LastModified != "2026-10-01T12:00:00Z" || !bytes.Equal(state.Data, stateData) {
+		t.Errorf("unexpected state data: %+v", state)
+	}
+
+	state, err = backend.LoadIfChanged(context.Background(), `"abc"`)
+	if err != nil {
+		t.Fatalf("LoadIfChanged failed: %v", err)
+	}
+	if state != nil {
+		t.Errorf("expected nil for unchanged state, got %+v", state)
+	}
+
+	// A 304 without a version is not swallowed
+	backend.client = &mockS3Client{
+		getObjectFunc: func(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+			return nil, &statusError{code: http.StatusNotModified}
+		},
+	}
+	if _, err := backend.Load(context.Background()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+// TestS3Backend_LoadAt tests reading the object version current at a time
+func TestS3Backend_LoadAt(t *testing.T) {
+	at := func(hour int) *time.Time {
+		ts := time.Date(2026, 10, 1, hour, 0, 0, 0, time.UTC)
+		return &ts
+	}
+	version := func(key, id string, hour int) s3types.ObjectVersion {
+		return s3types.ObjectVersion{Key: aws.String(key), VersionId: aws.String(id), LastModified: at(hour)}
+	}
+
+	backend := &S3Backend{
+		bucket: "test-bucket",
+		key:    "terraform.tfstate",
+		region: "us-east-1",
+		client: &mockS3Client{
+			listObjectVersionsFunc: func(ctx context.Context, input *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+				if aws.ToString(input.KeyMarker) == "" {
+					return &s3.ListObjectVersionsOutput{
+						Versions: []s3types.ObjectVersion{
+							version("terraform.tfstate", "v3", 12),
+							version("terraform.tfstate", "v2", 10),
+						},
+						IsTruncated:         aws.Bool(true),
+						NextKeyMarker:       aws.String("terraform.tfstate"),
+						NextVersionIdMarker: aws.String("v2"),
+					}, nil
+				}
+				return &s3.ListObjectVersionsOutput{
+					Versions: []s3types.ObjectVersion{
+						version("terraform.tfstate", "v1", 8),
+						version("terraform.tfstate.backup", "b1", 11),
+					},
+					DeleteMarkers: []s3types.DeleteMarkerEntry{
+						{Key: aws.String("terraform.tfstate"), VersionId: aws.String("d1"), LastModified: at(6)},
+					},
+				}, nil
+			},
+			getObjectFunc: func(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+				return &s3.GetObjectOutput{
+					Body: io.NopCloser(bytes.NewReader([]byte(aws.ToString(input.VersionId)))),
+					ETag: aws.String(`"` + aws.ToString(input.VersionId) + `"`),
+				}, nil
+			},
+		},
+	}
+
+	tests := []struct {
+		hour     int
+		want     string
+		modified string
+	}{
+		{hour: 9, want: "v1", modified: "2026-10-01T08:00:00Z"},
+		{hour: 11, want: "v2", modified: "2026-10-01T10:00:00Z"},
+		{hour: 13, want: "v3", modified: "2026-10-01T12:00:00Z"},
+	}
+	for _, tt := range tests {
+		state, err := backend.LoadAt(context.Background(), *at(tt.hour))
+		if err != nil {
+			t.Fatalf("LoadAt(%d:00) failed: %v", tt.hour, err)
+		}
+		if string(state.Data) != tt.want || state.LastModified != tt.modified {
+			t.Errorf("LoadAt(%d:00) = %s written %s, want %s written %s", tt.hour, state.Data, state.LastModified, tt.want, tt.modified)
+		}
+	}
+
+	// Deleted at 06:00, rewritten at 08:00
+	if _, err := backend.LoadAt(context.Background(), *at(7)); err == nil || !contains(err.Error(), "no version") {
+		t.Errorf("expected no version before the state was rewritten, got %v", err)
+	}
+}
+
 // TestGCSBackend_Load_Success tests successful GCS Load using loadFunc override
 func TestGCSBackend_Load_Success(t *testing.T) {
 	stateData := []byte(`{"version": 4, "terraform_version": "1.5.0"}`)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -42,6 +43,30 @@ func (b *LocalBackend) Load(_ context.Context) ([]byte, error) {
 	return data, nil
 }
 
+// LoadIfChanged reads the state file unless its modification time and size
+// are unchanged since version
+func (b *LocalBackend) LoadIfChanged(ctx context.Context, version string) (*StateData, error) {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat state file %s: %w", b.path, err)
+	}
+	current := fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+	if version != "" && version == current {
+		log.Debugf("Local state %s unchanged", b.path)
+		return nil, nil
+	}
+
+	data, err := b.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &StateData{
+		Data:         data,
+		LastModified: info.ModTime().UTC().Format(time.RFC3339),
+		Version:      current,
+	}, nil
+}
+
 // Name returns the backend name
 func (b *LocalBackend) Name() string {
 	return "local"
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -143,6 +144,33 @@ func TestLocalBackend_Load_FileDeleted(t *testing.T) {
 	assert.Nil(t, data)
 }
 
+func TestLocalBackend_LoadIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(`{"serial": 1}`), 0600))
+	b, err := NewLocalBackend(path)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	first, err := b.LoadIfChanged(ctx, "")
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Equal(t, `{"serial": 1}`, string(first.Data))
+	assert.NotEmpty(t, first.Version)
+
+	unchanged, err := b.LoadIfChanged(ctx, first.Version)
+	require.NoError(t, err)
+	assert.Nil(t, unchanged)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"serial": 2}`), 0600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	changed, err := b.LoadIfChanged(ctx, first.Version)
+	require.NoError(t, err)
+	require.NotNil(t, changed)
+	assert.Equal(t, `{"serial": 2}`, string(changed.Data))
+	assert.NotEqual(t, first.Version, changed.Version)
+}
+
 func TestLocalBackend_Name(t *testing.T) {
 	tmpDir := t.TempDir()
 	statePath := filepath.Join(tmpDir, "terraform.tfstate")
@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultPGSchemaName is the schema Terraform's pg backend uses by default
+const DefaultPGSchemaName = "terraform_remote_state"
+
+// PGBackend implements the Backend interface for Terraform's "pg" backend.
+// Each workspace is one row of the schema's "states" table, keyed by the
+// workspace name.
+type PGBackend struct {
+	db        *sql.DB
+	schema    string
+	workspace string
+}
+
+// PGBackendConfig contains configuration for the PostgreSQL backend.
+type PGBackendConfig struct {
+	// ConnStr is a PostgreSQL connection string or URL. Falls back to PG_CONN_STR.
+	ConnStr string
+
+	// SchemaName holds the "states" table. Falls back to PG_SCHEMA_NAME,
+	// then "terraform_remote_state".
+	SchemaName string
+
+	// Workspace selects the row (default "default")
+	Workspace string
+}
+
+// NewPGBackend creates a backend reading state from PostgreSQL. The
+// connection is opened lazily on the first Load.
+func NewPGBackend(cfg PGBackendConfig) (*PGBackend, error) {
+	if cfg.ConnStr == "" {
+		cfg.ConnStr = os.Getenv("PG_CONN_STR")
+	}
+	if cfg.ConnStr == "" {
+		return nil, fmt.Errorf("pg connection string is required")
+	}
+	db, err := sql.Open("postgres", cfg.ConnStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pg connection string: %w", err)
+	}
+	return newPGBackend(db, cfg), nil
+}
+
+func newPGBackend(db *sql.DB, cfg PGBackendConfig) *PGBackend {
+	if cfg.SchemaName == "" {
+		cfg.SchemaName = os.Getenv("PG_SCHEMA_NAME")
+	}
+	if cfg.SchemaName == "" {
+		cfg.SchemaName = DefaultPGSchemaName
+	}
+	if cfg.Workspace == "" {
+		cfg.Workspace = DefaultWorkspace
+	}
+	return &PGBackend{db: db, schema: cfg.SchemaName, workspace: cfg.Workspace}
+}
+
+// Load reads the workspace's row.
+func (b *PGBackend) Load(ctx context.Context) ([]byte, error) {
+	log.Infof("Loading Terraform state from PostgreSQL: %s.states (workspace %s)", b.schema, b.workspace)
+
+	query := fmt.Sprintf("SELECT data FROM %s.states WHERE name = $1", pq.QuoteIdentifier(b.schema))
+	var data []byte
+	err := b.db.QueryRowContext(ctx, query, b.workspace).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no state stored for workspace %q in %s.states", b.workspace, b.schema)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query state: %w", err)
+	}
+
+	log.Infof("Successfully loaded %d bytes from PostgreSQL (%s.states, workspace %s)", len(data), b.schema, b.workspace)
+	return data, nil
+}
+
+// Close closes the database connection pool.
+func (b *PGBackend) Close() error {
+	return b.db.Close()
+}
+
+// Name returns the backend identifier for logging and debugging.
+func (b *PGBackend) Name() string {
+	return "pg"
+}
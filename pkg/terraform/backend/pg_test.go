@@ -0,0 +1,50 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPGBackend_Load(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery(`SELECT data FROM "tf"\.states WHERE name = \$1`).
+		WithArgs("prod").
+		WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(`{"version":4,"serial":5}`))
+	mock.ExpectQuery(`SELECT data FROM "terraform_remote_state"\.states WHERE name = \$1`).
+		WithArgs("default").
+		WillReturnError(sql.ErrNoRows)
+
+	ctx := context.Background()
+	b := newPGBackend(db, PGBackendConfig{SchemaName: "tf", Workspace: "prod"})
+	assert.Equal(t, "pg", b.Name())
+	data, err := b.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, `{"version":4,"serial":5}`, string(data))
+
+	b = newPGBackend(db, PGBackendConfig{})
+	_, err = b.Load(ctx)
+	assert.ErrorContains(t, err, `no state stored for workspace "default" in terraform_remote_state.states`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewPGBackend_ConnStr(t *testing.T) {
+	t.Setenv("PG_CONN_STR", "")
+	_, err := NewPGBackend(PGBackendConfig{})
+	assert.ErrorContains(t, err, "connection string is required")
+
+	t.Setenv("PG_CONN_STR", "postgres://tf@db.example.com/terraform_backend")
+	t.Setenv("PG_SCHEMA_NAME", "network")
+	b, err := NewPGBackend(PGBackendConfig{})
+	require.NoError(t, err)
+	defer func() { _ = b.Close() }()
+	assert.Equal(t, "network", b.schema)
+	assert.Equal(t, DefaultWorkspace, b.workspace)
+}
@@ -0,0 +1,289 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultRemoteHostname is the HCP Terraform hostname used when none is configured
+const DefaultRemoteHostname = "app.terraform.io"
+
+// RemoteBackend implements the Backend interface for HCP Terraform and
+// Terraform Enterprise workspaces.
+//
+// State is read through the state-versions API: the workspace's current state
+// version is looked up and its hosted state downloaded. LoadIfChanged skips
+// the download while the current state version is the one loaded before, so
+// state is only transferred after an apply.
+//
+// Example usage:
+//
+//	backend, err := NewRemoteBackend(RemoteBackendConfig{
+//	    Organization: "acme",
+//	    Workspace:    "network-prod",
+//	    Token:        os.Getenv("TFE_TOKEN"),
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	data, err := backend.Load(ctx)
+type RemoteBackend struct {
+	client       *tfeClient
+	organization string
+	workspace    string
+
+	mu          sync.Mutex
+	workspaceID string
+}
+
+// RemoteBackendConfig contains configuration for the HCP Terraform backend.
+type RemoteBackendConfig struct {
+	// Hostname is the HCP Terraform or Terraform Enterprise host (default
+	// "app.terraform.io"). A URL with a scheme is used as the API base as-is.
+	Hostname string
+
+	// Organization owns the workspace
+	Organization string
+
+	// Workspace is the workspace name (not its ID)
+	Workspace string
+
+	// Token is a user or team API token. When empty the TF_TOKEN_<hostname>
+	// variable Terraform CLI uses is read.
+	Token string
+}
+
+// NewRemoteBackend creates a backend reading one HCP Terraform workspace's state.
+func NewRemoteBackend(cfg RemoteBackendConfig) (*RemoteBackend, error) {
+	if cfg.Organization == "" {
+		return nil, fmt.Errorf("remote organization is required")
+	}
+	if cfg.Workspace == "" {
+		return nil, fmt.Errorf("remote workspace is required")
+	}
+	client, err := newTFEClient(cfg.Hostname, cfg.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemoteBackend{
+		client:       client,
+		organization: cfg.Organization,
+		workspace:    cfg.Workspace,
+	}, nil
+}
+
+// tfeStateVersion is the subset of a state-version resource we use
+type tfeStateVersion struct {
+	Data struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Serial              int64  `json:"serial"`
+			CreatedAt           string `json:"created-at"`
+			HostedStateDownload string `json:"hosted-state-download-url"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// Load returns the workspace's current state.
+func (b *RemoteBackend) Load(ctx context.Context) ([]byte, error) {
+	state, err := b.LoadIfChanged(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return state.Data, nil
+}
+
+// LoadIfChanged downloads the workspace's current state unless the current
+// state version ID equals version.
+func (b *RemoteBackend) LoadIfChanged(ctx context.Context, version string) (*StateData, error) {
+	workspaceID, err := b.lookupWorkspaceID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var current tfeStateVersion
+	if err := b.client.getJSON(ctx, "/api/v2/workspaces/"+url.PathEscape(workspaceID)+"/current-state-version", &current); err != nil {
+		return nil, fmt.Errorf("failed to read current state version of %s/%s: %w", b.organization, b.workspace, err)
+	}
+	if version != "" && current.Data.ID == version {
+		log.Debugf("HCP Terraform workspace %s/%s unchanged at serial %d", b.organization, b.workspace, current.Data.Attributes.Serial)
+		return nil, nil
+	}
+	if current.Data.Attributes.HostedStateDownload == "" {
+		return nil, fmt.Errorf("state version %s of %s/%s has no download URL", current.Data.ID, b.organization, b.workspace)
+	}
+
+	log.Infof("Loading Terraform state from HCP Terraform: %s/%s (serial %d)", b.organization, b.workspace, current.Data.Attributes.Serial)
+	data, err := b.client.download(ctx, current.Data.Attributes.HostedStateDownload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download state version %s: %w", current.Data.ID, err)
+	}
+
+	log.Infof("Successfully loaded %d bytes from HCP Terraform (%s/%s)", len(data), b.organization, b.workspace)
+	return &StateData{
+		Data:         data,
+		LastModified: current.Data.Attributes.CreatedAt,
+		Version:      current.Data.ID,
+	}, nil
+}
+
+// lookupWorkspaceID resolves the workspace name to its ID once
+func (b *RemoteBackend) lookupWorkspaceID(ctx context.Context) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.workspaceID == "" {
+		var ws struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		path := fmt.Sprintf("/api/v2/organizations/%s/workspaces/%s", url.PathEscape(b.organization), url.PathEscape(b.workspace))
+		if err := b.client.getJSON(ctx, path, &ws); err != nil {
+			return "", fmt.Errorf("failed to look up workspace %s/%s: %w", b.organization, b.workspace, err)
+		}
+		b.workspaceID = ws.Data.ID
+	}
+	return b.workspaceID, nil
+}
+
+// Name returns the backend identifier for logging and debugging.
+func (b *RemoteBackend) Name() string {
+	return "remote"
+}
+
+// tfeClient is a minimal HCP Terraform API client
+type tfeClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func newTFEClient(hostname, token string) (*tfeClient, error) {
+	if hostname == "" {
+		hostname = DefaultRemoteHostname
+	}
+	baseURL := strings.TrimSuffix(hostname, "/")
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "https://" + baseURL
+	}
+	if token == "" {
+		token = os.Getenv(remoteTokenEnv(hostname))
+	}
+	if token == "" {
+		return nil, fmt.Errorf("remote token is required (set remote_token or %s)", remoteTokenEnv(hostname))
+	}
+	return &tfeClient{baseURL: baseURL, token: token, httpClient: &http.Client{}}, nil
+}
+
+// remoteTokenEnv returns the variable Terraform CLI reads a host's token
+// from: dots become underscores and dashes double underscores.
+func remoteTokenEnv(hostname string) string {
+	if u, err := url.Parse(hostname); err == nil && u.Host != "" {
+		hostname = u.Host
+	}
+	host := strings.NewReplacer(".", "_", "-", "__").Replace(hostname)
+	return "TF_TOKEN_" + host
+}
+
+func (c *tfeClient) getJSON(ctx context.Context, path string, out interface{}) error {
+	body, err := c.get(ctx, c.baseURL+path, "application/vnd.api+json")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse API response: %w", err)
+	}
+	return nil
+}
+
+// download fetches hosted state. A relative URL is resolved against the API host.
+func (c *tfeClient) download(ctx context.Context, rawURL string) ([]byte, error) {
+	if strings.HasPrefix(rawURL, "/") {
+		rawURL = c.baseURL + rawURL
+	}
+	return c.get(ctx, rawURL, "")
+}
+
+func (c *tfeClient) get(ctx context.Context, rawURL, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusNotFound:
+		// The API answers 404 for missing and for unauthorized resources alike
+		return nil, fmt.Errorf("not found or not authorized (status 404)")
+	default:
+		return nil, fmt.Errorf("HCP Terraform returned status %d: %s", resp.StatusCode, string(body))
+	}
+}
+
+// tfeWorkspaceList is the subset of the list-workspaces response we use
+type tfeWorkspaceList struct {
+	Data []struct {
+		Attributes struct {
+			Name string `json:"name"`
+		} `json:"attributes"`
+	} `json:"data"`
+	Meta struct {
+		Pagination struct {
+			NextPage int `json:"next-page"`
+		} `json:"pagination"`
+	} `json:"meta"`
+}
+
+// remoteWorkspaceLister lists an organization's workspace names, so a
+// key_pattern selects workspaces by name.
+func remoteWorkspaceLister(client *tfeClient, organization string) keyLister {
+	return func(ctx context.Context, prefix string) ([]string, error) {
+		var names []string
+		page := 1
+		for page > 0 {
+			q := url.Values{"page[number]": {fmt.Sprint(page)}, "page[size]": {"100"}}
+			if prefix != "" {
+				q.Set("search[name]", prefix)
+			}
+			var list tfeWorkspaceList
+			path := fmt.Sprintf("/api/v2/organizations/%s/workspaces?%s", url.PathEscape(organization), q.Encode())
+			if err := client.getJSON(ctx, path, &list); err != nil {
+				return nil, err
+			}
+			for _, ws := range list.Data {
+				if strings.HasPrefix(ws.Attributes.Name, prefix) {
+					names = append(names, ws.Attributes.Name)
+				}
+			}
+			page = list.Meta.Pagination.NextPage
+		}
+		return names, nil
+	}
+}
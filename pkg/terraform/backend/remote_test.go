@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHCPTerraform serves one workspace whose current state version is
+// switched by setting version
+type fakeHCPTerraform struct {
+	*httptest.Server
+	version   atomic.Int64
+	downloads atomic.Int64
+}
+
+func newFakeHCPTerraform(t *testing.T) *fakeHCPTerraform {
+	f := &fakeHCPTerraform{}
+	f.version.Store(1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/acme/workspaces/network-prod", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"data":{"id":"ws-123","type":"workspaces"}}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-123/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+		v := f.version.Load()
+		_, _ = fmt.Fprintf(w, `{"data":{"id":"sv-%d","attributes":{"serial":%d,"hosted-state-download-url":"/archivist/sv-%d"}}}`, v, v, v)
+	})
+	mux.HandleFunc("/archivist/", func(w http.ResponseWriter, r *http.Request) {
+		f.downloads.Add(1)
+		_, _ = fmt.Fprintf(w, `{"version":4,"serial":%d}`, f.version.Load())
+	})
+	mux.HandleFunc("/api/v2/organizations/acme/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "network-", r.URL.Query().Get("search[name]"))
+		if r.URL.Query().Get("page[number]") == "1" {
+			_, _ = fmt.Fprint(w, `{"data":[{"attributes":{"name":"network-prod"}},{"attributes":{"name":"legacy-network-dev"}}],"meta":{"pagination":{"next-page":2}}}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"data":[{"attributes":{"name":"network-staging"}}],"meta":{"pagination":{"next-page":null}}}`)
+	})
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			http.Error(w, `{"errors":[{"status":"404"}]}`, http.StatusNotFound)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestRemoteBackend_LoadsCurrentStateVersion(t *testing.T) {
+	srv := newFakeHCPTerraform(t)
+	b, err := NewRemoteBackend(RemoteBackendConfig{
+		Hostname:     srv.URL,
+		Organization: "acme",
+		Workspace:    "network-prod",
+		Token:        "secret-token",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "remote", b.Name())
+	ctx := context.Background()
+
+	data, err := b.Load(ctx)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":4,"serial":1}`, string(data))
+
+	first, err := b.LoadIfChanged(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, "sv-1", first.Version)
+	assert.Equal(t, int64(2), srv.downloads.Load())
+
+	// Unchanged state version: no download
+	unchanged, err := b.LoadIfChanged(ctx, first.Version)
+	require.NoError(t, err)
+	assert.Nil(t, unchanged)
+	assert.Equal(t, int64(2), srv.downloads.Load())
+
+	srv.version.Store(2)
+	changed, err := b.LoadIfChanged(ctx, first.Version)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":4,"serial":2}`, string(changed.Data))
+	assert.Equal(t, "sv-2", changed.Version)
+	assert.Equal(t, int64(3), srv.downloads.Load())
+}
+
+func TestRemoteBackend_Errors(t *testing.T) {
+	srv := newFakeHCPTerraform(t)
+
+	_, err := NewRemoteBackend(RemoteBackendConfig{Workspace: "w", Token: "t"})
+	assert.ErrorContains(t, err, "organization is required")
+	_, err = NewRemoteBackend(RemoteBackendConfig{Organization: "acme", Token: "t"})
+	assert.ErrorContains(t, err, "workspace is required")
+
+	b, err := NewRemoteBackend(RemoteBackendConfig{Hostname: srv.URL, Organization: "acme", Workspace: "network-prod", Token: "wrong"})
+	require.NoError(t, err)
+	_, err = b.Load(context.Background())
+	assert.ErrorContains(t, err, "not found or not authorized")
+}
+
+func TestRemoteBackend_TokenFromEnvironment(t *testing.T) {
+	_, err := NewRemoteBackend(RemoteBackendConfig{Hostname: "tfe.example-corp.com", Organization: "acme", Workspace: "w"})
+	assert.ErrorContains(t, err, "TF_TOKEN_tfe_example__corp_com")
+
+	t.Setenv("TF_TOKEN_app_terraform_io", "from-env")
+	b, err := NewRemoteBackend(RemoteBackendConfig{Organization: "acme", Workspace: "w"})
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", b.client.token)
+	assert.Equal(t, "https://app.terraform.io", b.client.baseURL)
+}
+
+func TestExpandStateConfig_RemoteWorkspaces(t *testing.T) {
+	srv := newFakeHCPTerraform(t)
+
+	got, err := ExpandStateConfig(context.Background(), config.TerraformStateConfig{
+		Backend:            "remote",
+		RemoteHostname:     srv.URL,
+		RemoteOrganization: "acme",
+		RemoteToken:        "secret-token",
+		KeyPattern:         "network-*",
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "network-prod", got[0].RemoteWorkspace)
+	assert.Equal(t, "network-prod", got[0].Workspace)
+	assert.Equal(t, "network-staging", got[1].RemoteWorkspace)
+	assert.Equal(t, "remote://"+srv.Listener.Addr().String()+"/acme/network-staging", Location(got[1]))
+}
@@ -2,9 +2,12 @@ package backend
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -15,6 +18,7 @@ import (
 // s3Client defines the interface for S3 operations used by the backend.
 type s3Client interface {
 	GetObject(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	ListObjectVersions(ctx context.Context, input *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error)
 }
 
 // S3Backend implements AWS S3 backend
@@ -65,15 +69,33 @@ func NewS3Backend(cfg S3BackendConfig) (*S3Backend, error) {
 
 // Load reads the state file from S3
 func (b *S3Backend) Load(ctx context.Context) ([]byte, error) {
+	state, err := b.LoadIfChanged(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return state.Data, nil
+}
+
+// LoadIfChanged downloads the object unless its ETag still equals version:
+// S3 answers the conditional GetObject with 304 Not Modified and no body.
+func (b *S3Backend) LoadIfChanged(ctx context.Context, version string) (*StateData, error) {
 	log.Infof("Loading Terraform state from S3: s3://%s/%s (region: %s)", b.bucket, b.key, b.region)
 
 	input := &s3.GetObjectInput{
 		Bucket: aws.String(b.bucket),
 		Key:    aws.String(b.key),
 	}
+	if version != "" {
+		input.IfNoneMatch = aws.String(version)
+	}
 
 	result, err := b.client.GetObject(ctx, input)
 	if err != nil {
+		var statusErr interface{ HTTPStatusCode() int }
+		if version != "" && errors.As(err, &statusErr) && statusErr.HTTPStatusCode() == http.StatusNotModified {
+			log.Debugf("S3 state s3://%s/%s unchanged (ETag %s)", b.bucket, b.key, version)
+			return nil, nil
+		}
 		return nil, fmt.Errorf("failed to get object from S3 s3://%s/%s: %w", b.bucket, b.key, err)
 	}
 	defer func() { _ = result.Body.Close() }()
@@ -84,7 +106,72 @@ func (b *S3Backend) Load(ctx context.Context) ([]byte, error) {
 	}
 
 	log.Infof("Successfully loaded %d bytes from S3 (s3://%s/%s)", len(data), b.bucket, b.key)
-	return data, nil
+	state := &StateData{Data: data, Version: aws.ToString(result.ETag)}
+	if result.LastModified != nil {
+		state.LastModified = result.LastModified.UTC().Format(time.RFC3339)
+	}
+	return state, nil
+}
+
+// LoadAt reads the object version that was current at the given time. The
+// bucket must have versioning enabled; the credentials need
+// s3:ListBucketVersions and s3:GetObjectVersion.
+func (b *S3Backend) LoadAt(ctx context.Context, at time.Time) (*StateData, error) {
+	var (
+		versionID string
+		written   time.Time
+		deleted   bool
+	)
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(b.key),
+	}
+	for {
+		page, err := b.client.ListObjectVersions(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list object versions of s3://%s/%s: %w", b.bucket, b.key, err)
+		}
+		for _, v := range page.Versions {
+			if aws.ToString(v.Key) != b.key || v.LastModified == nil || v.LastModified.After(at) || !v.LastModified.After(written) {
+				continue
+			}
+			versionID, written, deleted = aws.ToString(v.VersionId), *v.LastModified, false
+		}
+		for _, m := range page.DeleteMarkers {
+			if aws.ToString(m.Key) != b.key || m.LastModified == nil || m.LastModified.After(at) || !m.LastModified.After(written) {
+				continue
+			}
+			versionID, written, deleted = "", *m.LastModified, true
+		}
+		if !aws.ToBool(page.IsTruncated) {
+			break
+		}
+		input.KeyMarker, input.VersionIdMarker = page.NextKeyMarker, page.NextVersionIdMarker
+	}
+	if versionID == "" || deleted {
+		return nil, fmt.Errorf("no version of s3://%s/%s existed at %s", b.bucket, b.key, at.UTC().Format(time.RFC3339))
+	}
+
+	log.Infof("Loading Terraform state from S3: s3://%s/%s (version %s of %s)", b.bucket, b.key, versionID, written.UTC().Format(time.RFC3339))
+	result, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:    aws.String(b.bucket),
+		Key:       aws.String(b.key),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version %s of s3://%s/%s: %w", versionID, b.bucket, b.key, err)
+	}
+	defer func() { _ = result.Body.Close() }()
+
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read S3 object body: %w", err)
+	}
+	return &StateData{
+		Data:         data,
+		LastModified: written.UTC().Format(time.RFC3339),
+		Version:      aws.ToString(result.ETag),
+	}, nil
 }
 
 // Name returns the backend name
@@ -0,0 +1,191 @@
+// Package encryption decrypts OpenTofu-encrypted Terraform state between
+// Backend.Load and state parsing.
+//
+// OpenTofu wraps encrypted state in a JSON envelope:
+//
+//	{
+//	  "meta": {"key_provider.pbkdf2.main": "<base64 key provider metadata>"},
+//	  "encrypted_data": "<base64 nonce || AES-GCM ciphertext>",
+//	  "encryption_version": "v0"
+//	}
+//
+// The key provider named in "meta" derives the AES key (a PBKDF2 provider
+// from its passphrase and the salt, iterations and hash function stored in
+// its metadata), and the aes_gcm method decrypts the payload.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+)
+
+var (
+	// ErrEncryptedState is returned for encrypted state when no key
+	// providers are configured
+	ErrEncryptedState = errors.New("state is encrypted by OpenTofu; configure state_encryption.key_providers to read it")
+	// ErrDecryptionFailed is returned when no configured key decrypts the state
+	ErrDecryptionFailed = errors.New("failed to decrypt OpenTofu state")
+)
+
+// metaKeyPrefix prefixes the key provider address of every metadata entry
+const metaKeyPrefix = "key_provider."
+
+// KeyProvider derives the decryption key for a state from the metadata the
+// provider stored when it was encrypted. KMS-backed providers plug in here
+// next to pbkdf2 and static.
+type KeyProvider interface {
+	DecryptionKey(meta []byte) ([]byte, error)
+}
+
+// envelope is OpenTofu's encrypted state format. []byte fields are base64 in JSON.
+type envelope struct {
+	Meta    map[string][]byte `json:"meta"`
+	Data    []byte            `json:"encrypted_data"`
+	Version string            `json:"encryption_version"`
+}
+
+// Decrypter decrypts encrypted state with the configured key providers. A nil
+// Decrypter passes unencrypted state through and rejects encrypted state.
+type Decrypter struct {
+	// providers by address, "<type>.<name>"
+	providers map[string]KeyProvider
+	// order keeps the configured order for fallback attempts
+	order []string
+}
+
+// NewDecrypter builds the key providers of the configuration. It returns nil
+// when no key provider is configured.
+func NewDecrypter(cfg config.StateEncryptionConfig) (*Decrypter, error) {
+	if len(cfg.KeyProviders) == 0 {
+		return nil, nil
+	}
+
+	d := &Decrypter{providers: make(map[string]KeyProvider, len(cfg.KeyProviders))}
+	for _, kp := range cfg.KeyProviders {
+		var (
+			p   KeyProvider
+			err error
+		)
+		switch kp.Type {
+		case "pbkdf2":
+			p, err = newPBKDF2Provider(kp.Passphrase)
+		case "static":
+			p, err = newStaticProvider(kp.Key)
+		default:
+			err = fmt.Errorf("unsupported key provider type %q (supported: pbkdf2, static)", kp.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key provider %s.%s: %w", kp.Type, kp.Name, err)
+		}
+		address := kp.Type + "." + kp.Name
+		d.providers[address] = p
+		d.order = append(d.order, address)
+	}
+	return d, nil
+}
+
+// IsEncrypted reports whether data is an OpenTofu encrypted state envelope
+func IsEncrypted(data []byte) bool {
+	var probe struct {
+		Version string          `json:"encryption_version"`
+		Data    json.RawMessage `json:"encrypted_data"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Version != "" && len(probe.Data) > 0
+}
+
+// Decrypt returns the plaintext state. Unencrypted state is returned
+// unchanged. The key provider named in the metadata is tried first, then
+// every other configured provider, so a rotated key still reads state
+// written before the rotation.
+func (d *Decrypter) Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	if d == nil {
+		return nil, ErrEncryptedState
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid encrypted state envelope: %w", err)
+	}
+	if env.Version != "v0" {
+		return nil, fmt.Errorf("unsupported OpenTofu encryption version %q", env.Version)
+	}
+
+	var named, unknown []string
+	for key := range env.Meta {
+		address := strings.TrimPrefix(key, metaKeyPrefix)
+		named = append(named, address)
+		if _, ok := d.providers[address]; !ok {
+			unknown = append(unknown, address)
+		}
+	}
+	sort.Strings(named)
+	sort.Strings(unknown)
+
+	var attempts []string
+	tried := make(map[string]bool)
+	try := func(address string, meta []byte) ([]byte, bool) {
+		p, ok := d.providers[address]
+		if !ok || tried[address] {
+			return nil, false
+		}
+		tried[address] = true
+		key, err := p.DecryptionKey(meta)
+		if err == nil {
+			var plain []byte
+			if plain, err = decryptAESGCM(key, env.Data); err == nil {
+				return plain, true
+			}
+		}
+		attempts = append(attempts, fmt.Sprintf("%s: %v", address, err))
+		return nil, false
+	}
+
+	for _, address := range named {
+		if plain, ok := try(address, env.Meta[metaKeyPrefix+address]); ok {
+			return plain, nil
+		}
+	}
+	for _, address := range d.order {
+		// Without metadata of its own a pbkdf2 provider can't derive a key
+		if plain, ok := try(address, nil); ok {
+			return plain, nil
+		}
+	}
+
+	if len(unknown) > 0 {
+		attempts = append([]string{fmt.Sprintf("encrypted with key provider %s, which is not configured", strings.Join(unknown, ", "))}, attempts...)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrDecryptionFailed, strings.Join(attempts, "; "))
+}
+
+// decryptAESGCM reverses OpenTofu's aes_gcm method: the nonce is prepended
+// to the ciphertext
+func decryptAESGCM(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong key or corrupted state: %w", err)
+	}
+	return plain, nil
+}
@@ -0,0 +1,130 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const plainState = `{"version":4,"serial":7,"lineage":"l","resources":[]}`
+
+// sealState encrypts like OpenTofu's aes_gcm method and wraps the result in
+// the state envelope with the given key provider metadata
+func sealState(t *testing.T, key []byte, meta map[string][]byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+
+	out, err := json.Marshal(envelope{
+		Meta:    meta,
+		Data:    append(nonce, gcm.Seal(nil, nonce, []byte(plainState), nil)...),
+		Version: "v0",
+	})
+	require.NoError(t, err)
+	return out
+}
+
+// sealWithPassphrase encrypts like OpenTofu's pbkdf2 key provider
+func sealWithPassphrase(t *testing.T, name, passphrase, hashFunction string) []byte {
+	t.Helper()
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	require.NoError(t, err)
+	h := map[string]func() hash.Hash{"sha512": sha512.New, "sha256": sha256.New}[hashFunction]
+	key, err := pbkdf2.Key(h, passphrase, salt, 1000, 32)
+	require.NoError(t, err)
+
+	meta, err := json.Marshal(pbkdf2Meta{Salt: salt, Iterations: 1000, HashFunction: hashFunction, KeyLength: 32})
+	require.NoError(t, err)
+	return sealState(t, key, map[string][]byte{"key_provider.pbkdf2." + name: meta})
+}
+
+func TestDecrypter_PBKDF2(t *testing.T) {
+	d, err := NewDecrypter(config.StateEncryptionConfig{KeyProviders: []config.StateKeyProviderConfig{
+		{Type: "pbkdf2", Name: "main", Passphrase: "correct horse battery staple"},
+	}})
+	require.NoError(t, err)
+
+	for _, hf := range []string{"sha512", "sha256"} {
+		plain, err := d.Decrypt(sealWithPassphrase(t, "main", "correct horse battery staple", hf))
+		require.NoError(t, err, hf)
+		assert.JSONEq(t, plainState, string(plain))
+	}
+
+	_, err = d.Decrypt(sealWithPassphrase(t, "main", "not the passphrase at all", "sha512"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	assert.ErrorContains(t, err, "pbkdf2.main: wrong key or corrupted state")
+
+	_, err = d.Decrypt(sealWithPassphrase(t, "other", "correct horse battery staple", "sha512"))
+	assert.ErrorIs(t, err, ErrDecryptionFailed)
+	assert.ErrorContains(t, err, "encrypted with key provider pbkdf2.other, which is not configured")
+}
+
+func TestDecrypter_StaticAndRotation(t *testing.T) {
+	oldKey := make([]byte, 32)
+	newKey := make([]byte, 32)
+	_, _ = rand.Read(oldKey)
+	_, _ = rand.Read(newKey)
+
+	d, err := NewDecrypter(config.StateEncryptionConfig{KeyProviders: []config.StateKeyProviderConfig{
+		{Type: "static", Name: "current", Key: hex.EncodeToString(newKey)},
+		{Type: "static", Name: "previous", Key: hex.EncodeToString(oldKey)},
+	}})
+	require.NoError(t, err)
+
+	plain, err := d.Decrypt(sealState(t, newKey, map[string][]byte{"key_provider.static.current": []byte("{}")}))
+	require.NoError(t, err)
+	assert.JSONEq(t, plainState, string(plain))
+
+	// State written before the rotation still names the old provider
+	plain, err = d.Decrypt(sealState(t, oldKey, map[string][]byte{"key_provider.static.previous": []byte("{}")}))
+	require.NoError(t, err)
+	assert.JSONEq(t, plainState, string(plain))
+
+	// Without metadata every configured key is tried
+	plain, err = d.Decrypt(sealState(t, oldKey, nil))
+	require.NoError(t, err)
+	assert.JSONEq(t, plainState, string(plain))
+}
+
+func TestDecrypter_PlainAndUnconfigured(t *testing.T) {
+	var d *Decrypter
+	plain, err := d.Decrypt([]byte(plainState))
+	require.NoError(t, err)
+	assert.Equal(t, plainState, string(plain), "unencrypted state passes through")
+
+	_, err = d.Decrypt(sealState(t, make([]byte, 16), nil))
+	assert.ErrorIs(t, err, ErrEncryptedState)
+
+	d, err = NewDecrypter(config.StateEncryptionConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, d)
+}
+
+func TestNewDecrypter_InvalidProviders(t *testing.T) {
+	for _, kp := range []config.StateKeyProviderConfig{
+		{Type: "pbkdf2", Name: "short", Passphrase: "too short"},
+		{Type: "static", Name: "hex", Key: "not-hex"},
+		{Type: "static", Name: "len", Key: "abcd"},
+		{Type: "aws_kms", Name: "kms"},
+	} {
+		_, err := NewDecrypter(config.StateEncryptionConfig{KeyProviders: []config.StateKeyProviderConfig{kp}})
+		assert.Error(t, err, kp.Name)
+	}
+}
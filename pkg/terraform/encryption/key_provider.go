@@ -0,0 +1,80 @@
+package encryption
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+)
+
+// pbkdf2Provider derives keys from a passphrase like OpenTofu's pbkdf2 key
+// provider
+type pbkdf2Provider struct {
+	passphrase string
+}
+
+// pbkdf2Meta is the metadata OpenTofu's pbkdf2 key provider stores with the state
+type pbkdf2Meta struct {
+	Salt         []byte `json:"salt"`
+	Iterations   int    `json:"iterations"`
+	HashFunction string `json:"hash_function"`
+	KeyLength    int    `json:"key_length"`
+}
+
+func newPBKDF2Provider(passphrase string) (*pbkdf2Provider, error) {
+	if len(passphrase) < 16 {
+		return nil, fmt.Errorf("passphrase must be at least 16 characters")
+	}
+	return &pbkdf2Provider{passphrase: passphrase}, nil
+}
+
+func (p *pbkdf2Provider) DecryptionKey(meta []byte) ([]byte, error) {
+	if len(meta) == 0 {
+		return nil, fmt.Errorf("no pbkdf2 metadata stored with the state")
+	}
+	var m pbkdf2Meta
+	if err := json.Unmarshal(meta, &m); err != nil {
+		return nil, fmt.Errorf("invalid pbkdf2 metadata: %w", err)
+	}
+	if len(m.Salt) == 0 || m.Iterations <= 0 || m.KeyLength <= 0 {
+		return nil, fmt.Errorf("incomplete pbkdf2 metadata")
+	}
+
+	var h func() hash.Hash
+	switch m.HashFunction {
+	case "sha512", "":
+		h = sha512.New
+	case "sha384":
+		h = sha512.New384
+	case "sha256":
+		h = sha256.New
+	case "sha224":
+		h = sha256.New224
+	default:
+		return nil, fmt.Errorf("unsupported pbkdf2 hash function %q", m.HashFunction)
+	}
+	return pbkdf2.Key(h, p.passphrase, m.Salt, m.Iterations, m.KeyLength)
+}
+
+// staticProvider is OpenTofu's static key provider: a fixed hex-encoded key
+type staticProvider struct {
+	key []byte
+}
+
+func newStaticProvider(hexKey string) (*staticProvider, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("key must be hex-encoded: %w", err)
+	}
+	if n := len(key); n != 16 && n != 24 && n != 32 {
+		return nil, fmt.Errorf("key must be 16, 24 or 32 bytes, got %d", n)
+	}
+	return &staticProvider{key: key}, nil
+}
+
+func (p *staticProvider) DecryptionKey(_ []byte) ([]byte, error) {
+	return p.key, nil
+}
@@ -39,10 +39,10 @@ func NewImporterWithBinary(workingDir string, dryRun bool, binary string) *Impor
 
 // ImportCommand represents an IaC import command
 type ImportCommand struct {
-	ResourceType string
-	ResourceName string
-	ResourceID   string
-	Binary       string // "terraform" or "tofu"; empty renders as "terraform"
+	ResourceType string `json:"resource_type"`
+	ResourceName string `json:"resource_name"`
+	ResourceID   string `json:"resource_id"`
+	Binary       string `json:"binary,omitempty"` // "terraform" or "tofu"; empty renders as "terraform"
 }
 
 // GenerateImportCommand generates an import command for the importer's binary
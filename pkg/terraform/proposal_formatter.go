@@ -3,6 +3,7 @@ package terraform
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
@@ -56,6 +57,25 @@ func FormatProposalMarkdown(proposal *types.RemediationProposal) string {
 	md.WriteString(proposal.TerraformCode)
 	md.WriteString("```\n\n")
 
+	// In-place source edits
+	if len(proposal.PatchedFiles) > 0 {
+		md.WriteString("### Source Changes\n\n")
+		if proposal.PatchMode == string(PatchIgnoreChanges) {
+			md.WriteString(fmt.Sprintf("Accepts the cloud value by adding `%v` to `lifecycle.ignore_changes` in:\n\n", proposal.Attributes["attribute"]))
+		} else {
+			md.WriteString("Updates the drifted attribute in place in:\n\n")
+		}
+		files := make([]string, 0, len(proposal.PatchedFiles))
+		for path := range proposal.PatchedFiles {
+			files = append(files, path)
+		}
+		sort.Strings(files)
+		for _, path := range files {
+			md.WriteString(fmt.Sprintf("- `%s`\n", path))
+		}
+		md.WriteString("\n")
+	}
+
 	// Commands section
 	md.WriteString("### Remediation Commands\n\n")
 	md.WriteString("#### Import Command\n\n")
@@ -81,7 +101,11 @@ func FormatProposalMarkdown(proposal *types.RemediationProposal) string {
 	md.WriteString("### Next Steps\n\n")
 	md.WriteString("1. Review the proposed Terraform code above\n")
 	md.WriteString("2. Run the import command to add the resource to your state (if unmanaged)\n")
-	md.WriteString("3. Update your Terraform configuration with the proposed code\n")
+	if len(proposal.PatchedFiles) > 0 {
+		md.WriteString("3. Review the source changes committed in this pull request\n")
+	} else {
+		md.WriteString("3. Update your Terraform configuration with the proposed code\n")
+	}
 	md.WriteString("4. Run the plan command to verify the changes\n")
 	md.WriteString(fmt.Sprintf("5. Apply the changes using `%s apply`\n\n", binary))
 
@@ -214,3 +214,30 @@ func TestFormatProposalMarkdownWithoutAttributes(t *testing.T) {
 		t.Errorf("Markdown should have Next Steps section")
 	}
 }
+
+func TestFormatProposalMarkdownWithSourcePatch(t *testing.T) {
+	proposal := &types.RemediationProposal{
+		ID:            "test-id-patch",
+		AlertType:     "drift",
+		ResourceType:  "aws_instance",
+		TerraformCode: "resource \"aws_instance\" \"web\" {}\n",
+		ImportCommand: "terraform import aws_instance.web i-1",
+		Attributes:    map[string]interface{}{"attribute": "tags"},
+		PatchMode:     "ignore_changes",
+		PatchedFiles:  map[string]string{"live/main.tf": "...", "live/b.tf": "..."},
+	}
+
+	md := FormatProposalMarkdown(proposal)
+	if !strings.Contains(md, "### Source Changes") {
+		t.Fatalf("Markdown should have Source Changes section:\n%s", md)
+	}
+	if !strings.Contains(md, "adding `tags` to `lifecycle.ignore_changes`") {
+		t.Errorf("Markdown should explain the ignore_changes strategy:\n%s", md)
+	}
+	if strings.Index(md, "`live/b.tf`") > strings.Index(md, "`live/main.tf`") {
+		t.Errorf("patched files should be listed in order")
+	}
+	if !strings.Contains(md, "Review the source changes committed in this pull request") {
+		t.Errorf("Next steps should refer to the committed change")
+	}
+}
@@ -8,12 +8,17 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	log "github.com/sirupsen/logrus"
 )
 
 // RemediationGenerator generates remediation proposals for detected drifts and unmanaged resources
 type RemediationGenerator struct {
 	// binary is the IaC CLI emitted in generated commands ("terraform" or "tofu")
 	binary string
+	// patcher edits the resource's own source when set
+	patcher *SourcePatcher
+	// sensitive redacts the attributes of unmanaged resources
+	sensitive SensitivePatterns
 }
 
 // NewRemediationGenerator creates a RemediationGenerator that emits
@@ -31,11 +36,36 @@ func NewRemediationGeneratorWithTool(binary string) *RemediationGenerator {
 	return &RemediationGenerator{binary: binary}
 }
 
-// GenerateForDrift creates a RemediationProposal for a detected drift
+// NewRemediationGeneratorWithSource creates a generator that, for drift,
+// patches the block declaring the resource in the configuration under
+// patcher instead of only emitting a standalone snippet.
+func NewRemediationGeneratorWithSource(binary string, patcher *SourcePatcher) *RemediationGenerator {
+	g := NewRemediationGeneratorWithTool(binary)
+	g.patcher = patcher
+	return g
+}
+
+// SetSensitivePatterns sets the configured sensitive attribute patterns,
+// applied to the attributes of unmanaged resources. Drift alerts arrive
+// already marked sensitive.
+func (g *RemediationGenerator) SetSensitivePatterns(patterns SensitivePatterns) {
+	g.sensitive = patterns
+}
+
+// GenerateForDrift creates a RemediationProposal for a detected drift,
+// writing the cloud value into the source when a source patcher is set
 func (g *RemediationGenerator) GenerateForDrift(alert *types.DriftAlert) *types.RemediationProposal {
+	return g.GenerateForDriftWithMode(alert, PatchUpdateValue)
+}
+
+// GenerateForDriftWithMode creates a RemediationProposal for a detected
+// drift. mode picks how the source is patched when a source patcher is set.
+// Sensitive values are redacted and never written into code.
+func (g *RemediationGenerator) GenerateForDriftWithMode(alert *types.DriftAlert, mode PatchMode) *types.RemediationProposal {
 	if alert == nil {
 		return nil
 	}
+	redacted := alert.Redacted()
 
 	proposal := &types.RemediationProposal{
 		ID:           uuid.New().String(),
@@ -48,30 +78,65 @@ func (g *RemediationGenerator) GenerateForDrift(alert *types.DriftAlert) *types.
 		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
 		Attributes: map[string]interface{}{
 			"attribute": alert.Attribute,
-			"old_value": alert.OldValue,
-			"new_value": alert.NewValue,
+			"old_value": redacted.OldValue,
+			"new_value": redacted.NewValue,
 		},
 	}
 
 	proposal.Description = fmt.Sprintf(
-		"Drift detected in %s.%s: attribute '%s' changed from %v to %v",
-		alert.ResourceType, alert.ResourceName, alert.Attribute, alert.OldValue, alert.NewValue,
+		"Drift detected in %s: attribute '%s' changed from %v to %v",
+		alert.Address(), alert.Attribute, redacted.OldValue, redacted.NewValue,
 	)
 
-	proposal.TerraformCode = g.generateDriftFixHCL(
-		alert.ResourceType, alert.ResourceName, alert.Attribute, alert.OldValue, alert.NewValue,
-	)
-	proposal.ImportCommand = g.generateImportCommand(alert.ResourceType, alert.ResourceName, alert.ResourceID)
-	proposal.PlanCommand = g.generatePlanCommand(alert.ResourceType, alert.ResourceName)
+	if alert.Sensitive {
+		proposal.TerraformCode = g.generateSensitiveDriftFixHCL(alert.ResourceType, alert.ResourceName, alert.Attribute)
+	} else {
+		proposal.TerraformCode = g.generateDriftFixHCL(
+			alert.ResourceType, alert.ResourceName, alert.Attribute, alert.OldValue, alert.NewValue,
+		)
+	}
+	if alert.ResourceAddress != "" {
+		// Target the exact module/count/for_each instance from state
+		proposal.ImportCommand = fmt.Sprintf("%s import %s %s", g.binary, alert.ShellAddress(), alert.ResourceID)
+		proposal.PlanCommand = fmt.Sprintf("%s plan -target=%s", g.binary, alert.ShellAddress())
+	} else {
+		proposal.ImportCommand = g.generateImportCommand(alert.ResourceType, alert.ResourceName, alert.ResourceID)
+		proposal.PlanCommand = g.generatePlanCommand(alert.ResourceType, alert.ResourceName)
+	}
+
+	// Taking the cloud value of a sensitive attribute would write the
+	// secret into the source; ignoring its changes does not
+	if g.patcher != nil && alert.ResourceName != "" && (!alert.Sensitive || mode == PatchIgnoreChanges) {
+		g.patchSource(proposal, alert, mode)
+	}
 
 	return proposal
 }
 
-// GenerateForUnmanaged creates a RemediationProposal for an unmanaged resource
+// patchSource replaces the standalone snippet with an in-place edit of the
+// resource's block. If the block cannot be patched the snippet is kept.
+func (g *RemediationGenerator) patchSource(proposal *types.RemediationProposal, alert *types.DriftAlert, mode PatchMode) {
+	patch, err := g.patcher.PatchAttribute(alert.Address(), alert.Attribute, alert.NewValue, mode)
+	if err != nil {
+		log.WithError(err).WithField("address", alert.Address()).Debug("Could not patch Terraform source, proposing a snippet instead")
+		return
+	}
+
+	proposal.PatchMode = string(patch.Mode)
+	proposal.TerraformCode = patch.Block
+	if patch.Changed() {
+		proposal.PatchedFiles = map[string]string{patch.Path: string(patch.Patched)}
+	}
+}
+
+// GenerateForUnmanaged creates a RemediationProposal for an unmanaged
+// resource. Attributes matching the sensitive patterns are redacted and
+// never written into code.
 func (g *RemediationGenerator) GenerateForUnmanaged(event *types.Event) *types.RemediationProposal {
 	if event == nil {
 		return nil
 	}
+	changes := g.sensitive.Redact(event.ResourceType, event.Changes)
 
 	proposal := &types.RemediationProposal{
 		ID:           uuid.New().String(),
@@ -82,7 +147,7 @@ func (g *RemediationGenerator) GenerateForUnmanaged(event *types.Event) *types.R
 		Severity:     "medium", // Default severity for unmanaged resources
 		Status:       types.RemediationPending,
 		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
-		Attributes:   event.Changes,
+		Attributes:   changes,
 	}
 
 	proposal.Description = fmt.Sprintf(
@@ -90,7 +155,7 @@ func (g *RemediationGenerator) GenerateForUnmanaged(event *types.Event) *types.R
 		event.ResourceType, event.ResourceID, event.Provider,
 	)
 
-	proposal.TerraformCode = g.generateHCL(event.ResourceType, "", event.Changes)
+	proposal.TerraformCode = g.generateHCL(event.ResourceType, "", changes)
 	proposal.ImportCommand = g.generateImportCommand(event.ResourceType, "", event.ResourceID)
 	proposal.PlanCommand = g.generatePlanCommand(event.ResourceType, "")
 
@@ -129,6 +194,10 @@ func (g *RemediationGenerator) generateHCL(resourceType, resourceName string, at
 	if len(attributes) > 0 {
 		hcl.WriteString("  # Attributes from cloud provider:\n")
 		for key, value := range attributes {
+			if value == types.RedactedValue {
+				hcl.WriteString(fmt.Sprintf("  # %s is sensitive and its value is not shown; set it from a variable or secret store\n", key))
+				continue
+			}
 			hcl.WriteString(fmt.Sprintf("  # %s = %q\n", key, fmt.Sprintf("%v", value)))
 		}
 	}
@@ -152,6 +221,22 @@ func (g *RemediationGenerator) generateDriftFixHCL(resourceType, resourceName st
 	return hcl.String()
 }
 
+// generateSensitiveDriftFixHCL generates the remediation snippet for a
+// sensitive attribute, leaving its value out
+func (g *RemediationGenerator) generateSensitiveDriftFixHCL(resourceType, resourceName, attribute string) string {
+	if resourceName == "" {
+		resourceName = "resource"
+	}
+
+	var hcl strings.Builder
+	hcl.WriteString("# Drift remediation: update the following attribute in your Terraform configuration\n\n")
+	hcl.WriteString(fmt.Sprintf("resource \"%s\" \"%s\" {\n", resourceType, resourceName))
+	hcl.WriteString(fmt.Sprintf("  # %s is sensitive and its value is not shown; set it from a variable or secret store\n", attribute))
+	hcl.WriteString("}\n")
+
+	return hcl.String()
+}
+
 // formatValue formats a value for HCL output
 func formatValue(v interface{}) string {
 	switch val := v.(type) {
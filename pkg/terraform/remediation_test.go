@@ -1,9 +1,12 @@
 package terraform
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
 	"github.com/keitahigaki/tfdrift-falco/pkg/types"
 )
 
@@ -407,3 +410,139 @@ func TestFormatValue(t *testing.T) {
 		})
 	}
 }
+
+func TestRemediationGeneratorForDrift_FullAddress(t *testing.T) {
+	gen := NewRemediationGenerator()
+	proposal := gen.GenerateForDrift(&types.DriftAlert{
+		ResourceType:    "aws_instance",
+		ResourceName:    "web",
+		ResourceID:      "i-2",
+		ResourceAddress: `module.app["blue"].aws_instance.web[2]`,
+		Attribute:       "instance_type",
+	})
+
+	if want := `terraform import 'module.app["blue"].aws_instance.web[2]' i-2`; proposal.ImportCommand != want {
+		t.Errorf("ImportCommand = %q, want %q", proposal.ImportCommand, want)
+	}
+	if want := `terraform plan -target='module.app["blue"].aws_instance.web[2]'`; proposal.PlanCommand != want {
+		t.Errorf("PlanCommand = %q, want %q", proposal.PlanCommand, want)
+	}
+	if !strings.Contains(proposal.Description, `module.app["blue"].aws_instance.web[2]`) {
+		t.Errorf("Description should name the full address, got %q", proposal.Description)
+	}
+}
+
+func TestRemediationGeneratorForDrift_PatchesSource(t *testing.T) {
+	dir := t.TempDir()
+	src := "resource \"aws_instance\" \"web\" {\n  instance_type = \"t3.micro\"\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gen := NewRemediationGeneratorWithSource("terraform", NewSourcePatcher(dir, "live"))
+	alert := &types.DriftAlert{
+		ResourceType: "aws_instance",
+		ResourceName: "web",
+		ResourceID:   "i-1",
+		Attribute:    "instance_type",
+		OldValue:     "t3.micro",
+		NewValue:     "t3.large",
+	}
+
+	proposal := gen.GenerateForDrift(alert)
+	if proposal.PatchMode != string(PatchUpdateValue) {
+		t.Fatalf("PatchMode = %q, want %q", proposal.PatchMode, PatchUpdateValue)
+	}
+	if got := proposal.PatchedFiles["live/main.tf"]; !strings.Contains(got, `instance_type = "t3.large"`) {
+		t.Errorf("patched main.tf = %q", got)
+	}
+	if !strings.HasPrefix(proposal.TerraformCode, `resource "aws_instance" "web"`) {
+		t.Errorf("TerraformCode should be the patched block, got %q", proposal.TerraformCode)
+	}
+
+	proposal = gen.GenerateForDriftWithMode(alert, PatchIgnoreChanges)
+	if got := proposal.PatchedFiles["live/main.tf"]; !strings.Contains(got, "ignore_changes = [instance_type]") {
+		t.Errorf("patched main.tf = %q", got)
+	}
+
+	// Resources the source does not declare fall back to a snippet
+	alert.ResourceName = "api"
+	proposal = gen.GenerateForDrift(alert)
+	if proposal.PatchMode != "" || len(proposal.PatchedFiles) != 0 {
+		t.Errorf("expected no source patch, got mode %q files %v", proposal.PatchMode, proposal.PatchedFiles)
+	}
+	if !strings.Contains(proposal.TerraformCode, "# Drift remediation") {
+		t.Errorf("expected snippet fallback, got %q", proposal.TerraformCode)
+	}
+}
+
+func TestRemediationGeneratorForDrift_SensitiveAttribute(t *testing.T) {
+	dir := t.TempDir()
+	src := "resource \"aws_db_instance\" \"main\" {\n  password = var.db_password\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gen := NewRemediationGeneratorWithSource("terraform", NewSourcePatcher(dir, ""))
+	alert := &types.DriftAlert{
+		ResourceType: "aws_db_instance",
+		ResourceName: "main",
+		ResourceID:   "db-1",
+		Attribute:    "password",
+		OldValue:     "old-pw-123",
+		NewValue:     "new-pw-456",
+		Sensitive:    true,
+	}
+
+	proposal := gen.GenerateForDrift(alert)
+	for name, text := range map[string]string{
+		"description": proposal.Description,
+		"code":        proposal.TerraformCode,
+		"markdown":    FormatProposalMarkdown(proposal),
+	} {
+		if strings.Contains(text, "pw-") {
+			t.Errorf("%s leaks the sensitive value: %q", name, text)
+		}
+	}
+	if len(proposal.PatchedFiles) != 0 {
+		t.Errorf("the cloud value must not be written into the source, got %v", proposal.PatchedFiles)
+	}
+	if !strings.Contains(proposal.TerraformCode, "# password is sensitive") {
+		t.Errorf("TerraformCode = %q", proposal.TerraformCode)
+	}
+
+	// Ignoring changes writes no value and still patches the source
+	proposal = gen.GenerateForDriftWithMode(alert, PatchIgnoreChanges)
+	if got := proposal.PatchedFiles["main.tf"]; !strings.Contains(got, "ignore_changes = [password]") {
+		t.Errorf("patched main.tf = %q", got)
+	}
+}
+
+func TestRemediationGeneratorForUnmanaged_SensitivePatterns(t *testing.T) {
+	gen := NewRemediationGenerator()
+	gen.SetSensitivePatterns(CompileSensitivePatterns(config.SensitiveAttributesConfig{
+		Patterns: []string{"aws_db_instance:master_*"},
+	}))
+
+	proposal := gen.GenerateForUnmanaged(&types.Event{
+		Provider:     "aws",
+		EventName:    "CreateDBInstance",
+		ResourceType: "aws_db_instance",
+		ResourceID:   "db-1",
+		Changes: map[string]interface{}{
+			"master_user_password": "pw-secret",
+			"engine":               "postgres",
+		},
+	})
+
+	if got := proposal.Attributes["master_user_password"]; got != types.RedactedValue {
+		t.Errorf("Attributes[master_user_password] = %v", got)
+	}
+	if proposal.Attributes["engine"] != "postgres" {
+		t.Errorf("Attributes[engine] = %v", proposal.Attributes["engine"])
+	}
+	if strings.Contains(proposal.TerraformCode, "pw-") || strings.Contains(FormatProposalMarkdown(proposal), "pw-") {
+		t.Errorf("the sensitive value leaks into the code: %q", proposal.TerraformCode)
+	}
+	if !strings.Contains(proposal.TerraformCode, "# master_user_password is sensitive") {
+		t.Errorf("TerraformCode = %q", proposal.TerraformCode)
+	}
+}
@@ -0,0 +1,293 @@
+package terraform
+
+import (
+	"encoding/json"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// sensitivePattern is one compiled sensitive_attributes pattern
+type sensitivePattern struct {
+	resourceType string
+	segments     []string
+}
+
+// SensitivePatterns are compiled sensitive_attributes patterns. They redact
+// values that have no state resource to consult, such as the changes of an
+// event on an unmanaged resource.
+type SensitivePatterns []sensitivePattern
+
+// CompileSensitivePatterns compiles the configured sensitive attribute
+// patterns ("type:path" or "path")
+func CompileSensitivePatterns(cfg config.SensitiveAttributesConfig) SensitivePatterns {
+	patterns := make(SensitivePatterns, 0, len(cfg.Patterns))
+	for _, pattern := range cfg.Patterns {
+		resourceType, attr, found := strings.Cut(pattern, ":")
+		if !found {
+			resourceType, attr = "", pattern
+		}
+		patterns = append(patterns, sensitivePattern{
+			resourceType: resourceType,
+			segments:     strings.Split(attr, "."),
+		})
+	}
+	return patterns
+}
+
+// Redact returns values, attributes of a resource of the given type, with
+// those matching a pattern replaced by types.RedactedValue. The values
+// themselves are returned when there are no patterns.
+func (p SensitivePatterns) Redact(resourceType string, values map[string]interface{}) map[string]interface{} {
+	r := &Resource{Type: resourceType, Attributes: values, sensitive: p}
+	return r.RedactedAttributes()
+}
+
+// SetSensitiveAttributes sets patterns of attributes that are sensitive in
+// addition to those the state marks. They apply from the next Load/Refresh.
+func (sm *StateManager) SetSensitiveAttributes(cfg config.SensitiveAttributesConfig) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.sensitive = CompileSensitivePatterns(cfg)
+	sm.reindex = true
+}
+
+// matches reports whether the pattern matches the attribute path of a
+// resource of the given type, or one of the path's ancestors
+func (p sensitivePattern) matches(resourceType string, attrPath []string) bool {
+	if p.resourceType != "" {
+		if ok, _ := path.Match(p.resourceType, resourceType); !ok {
+			return false
+		}
+	}
+	return matchSegments(p.segments, attrPath)
+}
+
+// matchSegments matches a dotted glob against a path. The path may continue
+// below the match.
+func matchSegments(pattern, attrPath []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(attrPath); i++ {
+			if matchSegments(pattern[1:], attrPath[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(attrPath) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], attrPath[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], attrPath[1:])
+}
+
+// statePathStep is one step of a path in an instance's sensitive_attributes.
+// Index values are written either plain or as {"value": ..., "type": ...}.
+type statePathStep struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// sensitivePaths converts an instance's sensitive_attributes to dotted
+// paths. Unreadable entries are skipped.
+func sensitivePaths(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var steps [][]statePathStep
+	if err := json.Unmarshal(raw, &steps); err != nil {
+		log.Debugf("Ignoring unreadable sensitive_attributes: %v", err)
+		return nil
+	}
+
+	var paths []string
+	for _, pathSteps := range steps {
+		segments := make([]string, 0, len(pathSteps))
+		for _, step := range pathSteps {
+			segment, ok := stepSegment(step.Value)
+			if !ok {
+				segments = nil
+				break
+			}
+			segments = append(segments, segment)
+		}
+		if len(segments) > 0 {
+			paths = append(paths, strings.Join(segments, "."))
+		}
+	}
+	return paths
+}
+
+// stepSegment returns the attribute name or index of a path step
+func stepSegment(raw json.RawMessage) (string, bool) {
+	var typed struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if json.Unmarshal(raw, &typed) == nil && typed.Value != nil {
+		raw = typed.Value
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", false
+	}
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// IsSensitive reports whether the attribute at path (a top-level attribute
+// or a dotted path such as "tags.Secret") is sensitive or holds a sensitive
+// value, per the state's sensitive_attributes and the configured patterns
+func (r *Resource) IsSensitive(attrPath string) bool {
+	segments := strings.Split(attrPath, ".")
+	if r.sensitiveAt(segments) {
+		return true
+	}
+	for _, p := range r.SensitivePaths {
+		if strings.HasPrefix(p, attrPath+".") {
+			return true
+		}
+	}
+	if len(r.sensitive) == 0 {
+		return false
+	}
+	value, ok := attributeAt(r.Attributes, segments)
+	return ok && r.containsSensitive(segments, value)
+}
+
+// RedactedAttributes returns the attributes with sensitive values replaced
+// by types.RedactedValue. The attributes themselves are returned when
+// nothing is sensitive.
+func (r *Resource) RedactedAttributes() map[string]interface{} {
+	if len(r.SensitivePaths) == 0 && len(r.sensitive) == 0 {
+		return r.Attributes
+	}
+	redacted, _ := r.redact(nil, r.Attributes).(map[string]interface{})
+	return redacted
+}
+
+// RedactValues returns values keyed by attribute path of this resource,
+// such as an event's changes, with the sensitive ones replaced by
+// types.RedactedValue
+func (r *Resource) RedactValues(values map[string]interface{}) map[string]interface{} {
+	if len(r.SensitivePaths) == 0 && len(r.sensitive) == 0 {
+		return values
+	}
+	redacted := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if r.IsSensitive(key) {
+			redacted[key] = types.RedactedValue
+			continue
+		}
+		redacted[key] = r.redact(strings.Split(key, "."), value)
+	}
+	return redacted
+}
+
+// sensitiveAt reports whether the path, or one of its ancestors, is
+// sensitive
+func (r *Resource) sensitiveAt(segments []string) bool {
+	attrPath := strings.Join(segments, ".")
+	for _, p := range r.SensitivePaths {
+		if attrPath == p || strings.HasPrefix(attrPath, p+".") {
+			return true
+		}
+	}
+	for _, p := range r.sensitive {
+		if p.matches(r.Type, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact copies value, found at path, replacing sensitive values
+func (r *Resource) redact(segments []string, value interface{}) interface{} {
+	if len(segments) > 0 && r.sensitiveAt(segments) {
+		return types.RedactedValue
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			out[key] = r.redact(childPath(segments, key), elem)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = r.redact(childPath(segments, strconv.Itoa(i)), elem)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// containsSensitive reports whether a value below path is sensitive
+func (r *Resource) containsSensitive(segments []string, value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			child := childPath(segments, key)
+			if r.sensitiveAt(child) || r.containsSensitive(child, elem) {
+				return true
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			child := childPath(segments, strconv.Itoa(i))
+			if r.sensitiveAt(child) || r.containsSensitive(child, elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// attributeAt returns the value at a path of the attributes
+func attributeAt(attributes map[string]interface{}, segments []string) (interface{}, bool) {
+	var value interface{} = attributes
+	for _, segment := range segments {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			elem, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			value = elem
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// childPath returns a new path one segment below segments
+func childPath(segments []string, segment string) []string {
+	child := make([]string, len(segments), len(segments)+1)
+	copy(child, segments)
+	return append(child, segment)
+}
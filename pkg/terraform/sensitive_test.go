@@ -0,0 +1,108 @@
+package terraform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensitivePaths(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{"none", ``, nil},
+		{"empty", `[]`, nil},
+		{"attribute", `[[{"type":"get_attr","value":"password"}]]`, []string{"password"}},
+		{"nested", `[[{"type":"get_attr","value":"connection"},{"type":"index","value":0},{"type":"get_attr","value":"secret"}]]`, []string{"connection.0.secret"}},
+		{"typed index", `[[{"type":"get_attr","value":"tags"},{"type":"index","value":{"value":"Token","type":"string"}}]]`, []string{"tags.Token"}},
+		{"several", `[[{"type":"get_attr","value":"password"}],[{"type":"get_attr","value":"master_key"}]]`, []string{"password", "master_key"}},
+		{"unreadable", `{"password":true}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sensitivePaths(json.RawMessage(tt.raw)))
+		})
+	}
+}
+
+func TestResource_IsSensitive(t *testing.T) {
+	r := &Resource{
+		Type: "aws_db_instance",
+		Attributes: map[string]interface{}{
+			"password": "s3cret",
+			"tags":     map[string]interface{}{"Name": "db", "Token": "t0ken"},
+			"labels":   map[string]interface{}{"api_key": "k3y"},
+			"engine":   "postgres",
+		},
+		SensitivePaths: []string{"password", "tags.Token"},
+		sensitive: []sensitivePattern{
+			{segments: []string{"**", "api_key"}},
+			{resourceType: "aws_db_*", segments: []string{"master_*"}},
+		},
+	}
+
+	for path, want := range map[string]bool{
+		"password":          true,
+		"password.0":        true,
+		"tags":              true, // holds tags.Token
+		"tags.Token":        true,
+		"tags.Name":         false,
+		"labels":            true, // holds labels.api_key
+		"labels.api_key":    true,
+		"master_username":   true,
+		"engine":            false,
+		"nonexistent":       false,
+		"nonexistent.child": false,
+	} {
+		assert.Equal(t, want, r.IsSensitive(path), path)
+	}
+
+	r.Type = "aws_rds_cluster"
+	assert.False(t, r.IsSensitive("master_username"), "type-scoped pattern applies to matching types only")
+}
+
+func TestResource_RedactedAttributes(t *testing.T) {
+	r := &Resource{
+		Attributes: map[string]interface{}{
+			"password": "s3cret",
+			"tags":     map[string]interface{}{"Name": "db", "Token": "t0ken"},
+			"rules":    []interface{}{map[string]interface{}{"secret": "x", "port": float64(443)}},
+		},
+		SensitivePaths: []string{"password", "tags.Token"},
+		sensitive:      []sensitivePattern{{segments: []string{"rules", "*", "secret"}}},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"password": types.RedactedValue,
+		"tags":     map[string]interface{}{"Name": "db", "Token": types.RedactedValue},
+		"rules":    []interface{}{map[string]interface{}{"secret": types.RedactedValue, "port": float64(443)}},
+	}, r.RedactedAttributes())
+	assert.Equal(t, "s3cret", r.Attributes["password"], "the state is left intact")
+
+	plain := &Resource{Attributes: map[string]interface{}{"id": "i-1"}}
+	assert.Equal(t, plain.Attributes, plain.RedactedAttributes())
+}
+
+func TestStateManager_SensitiveAttributes(t *testing.T) {
+	sm := &StateManager{}
+	sm.SetSensitiveAttributes(config.SensitiveAttributesConfig{Patterns: []string{"aws_db_instance:master_*"}})
+	require.NoError(t, sm.indexState(State{Resources: []ResourceDefinition{
+		{Mode: "managed", Type: "aws_db_instance", Name: "main", Instances: []ResourceInstance{{
+			Attributes:          map[string]interface{}{"id": "db-1", "password": "s3cret", "master_username": "admin"},
+			SensitiveAttributes: json.RawMessage(`[[{"type":"get_attr","value":"password"}]]`),
+		}}},
+	}}))
+
+	r, ok := sm.GetResource("db-1")
+	require.True(t, ok)
+	assert.Equal(t, []string{"password"}, r.SensitivePaths)
+	assert.True(t, r.IsSensitive("password"))
+	assert.True(t, r.IsSensitive("master_username"))
+	assert.False(t, r.IsSensitive("id"))
+}
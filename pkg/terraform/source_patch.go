@@ -0,0 +1,503 @@
+package terraform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// PatchMode selects how a drifted attribute is reconciled in the Terraform
+// source
+type PatchMode string
+
+const (
+	// PatchUpdateValue writes the cloud value into the configuration so the
+	// next apply keeps it
+	PatchUpdateValue PatchMode = "update_value"
+	// PatchIgnoreChanges keeps the configuration and adds the attribute to
+	// lifecycle.ignore_changes, accepting whatever the cloud holds
+	PatchIgnoreChanges PatchMode = "ignore_changes"
+)
+
+// ErrResourceNotFound is returned when no configuration block owns the
+// resource address
+var ErrResourceNotFound = errors.New("resource block not found in source")
+
+// SourcePatch is an in-place edit to the file that declares a resource
+type SourcePatch struct {
+	// Path is the file's path inside the repository (slash-separated)
+	Path string
+	// Address is the resource address the patch was made for
+	Address  string
+	Mode     PatchMode
+	Original []byte
+	Patched  []byte
+	// Block is the patched resource block, for display
+	Block string
+}
+
+// Changed reports whether the patch modifies the file
+func (p *SourcePatch) Changed() bool {
+	return !bytes.Equal(p.Original, p.Patched)
+}
+
+// SourcePatcher edits resource blocks in a local checkout of the Terraform
+// configuration with hclwrite, preserving formatting and comments outside
+// the edited attribute.
+type SourcePatcher struct {
+	// root is the root module directory on disk
+	root string
+	// repoPrefix is root's path inside the repository
+	repoPrefix string
+}
+
+// NewSourcePatcher creates a patcher for the root module at dir. repoPrefix
+// is dir's path inside the repository the patches are committed to ("" for
+// the repository root).
+func NewSourcePatcher(dir, repoPrefix string) *SourcePatcher {
+	return &SourcePatcher{root: dir, repoPrefix: strings.Trim(filepath.ToSlash(repoPrefix), "/")}
+}
+
+// PatchAttribute locates the block declaring address and reconciles
+// attribute according to mode. value is the attribute's value in the cloud
+// (as decoded from JSON) and is only used by PatchUpdateValue.
+func (s *SourcePatcher) PatchAttribute(address, attribute string, value interface{}, mode PatchMode) (*SourcePatch, error) {
+	if !hclsyntax.ValidIdentifier(attribute) {
+		return nil, fmt.Errorf("attribute %q is not a top-level argument name", attribute)
+	}
+
+	addr, err := parseConfigAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := s.moduleDir(addr.modules)
+	if err != nil {
+		return nil, err
+	}
+
+	file, relPath, block, err := findBlock(dir, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	original := file.Bytes()
+	switch mode {
+	case PatchUpdateValue, "":
+		mode = PatchUpdateValue
+		if err := setAttribute(block.Body(), attribute, value); err != nil {
+			return nil, err
+		}
+	case PatchIgnoreChanges:
+		if addr.kind == "data" {
+			return nil, fmt.Errorf("data sources have no lifecycle.ignore_changes")
+		}
+		addIgnoreChanges(block.Body(), attribute)
+	default:
+		return nil, fmt.Errorf("unknown patch mode %q", mode)
+	}
+
+	rel, err := filepath.Rel(s.root, filepath.Join(dir, relPath))
+	if err != nil || strings.HasPrefix(filepath.ToSlash(rel), "../") {
+		return nil, fmt.Errorf("module for %s lives outside the configured source directory", address)
+	}
+	repoPath := path.Join(s.repoPrefix, filepath.ToSlash(rel))
+
+	// Reformat only files that were canonically formatted to begin with, so
+	// the diff stays limited to the edited block
+	patched := file.Bytes()
+	if bytes.Equal(hclwrite.Format(original), original) {
+		patched = hclwrite.Format(patched)
+	}
+
+	return &SourcePatch{
+		Path:     repoPath,
+		Address:  address,
+		Mode:     mode,
+		Original: original,
+		Patched:  patched,
+		Block:    string(hclwrite.Format(block.BuildTokens(nil).Bytes())),
+	}, nil
+}
+
+// configAddress is a resource address reduced to what identifies its
+// configuration block: module call names, mode, type and name. Instance
+// keys (count/for_each) all share one block.
+type configAddress struct {
+	modules []string
+	kind    string // "resource" or "data"
+	typ     string
+	name    string
+}
+
+// parseConfigAddress parses addresses such as
+// module.app["blue"].aws_instance.web[2] or data.aws_ami.ubuntu
+func parseConfigAddress(address string) (configAddress, error) {
+	parts, err := splitAddress(address)
+	if err != nil {
+		return configAddress{}, err
+	}
+
+	var addr configAddress
+	for len(parts) >= 2 && parts[0] == "module" {
+		addr.modules = append(addr.modules, parts[1])
+		parts = parts[2:]
+	}
+
+	addr.kind = "resource"
+	if len(parts) == 3 && parts[0] == "data" {
+		addr.kind = "data"
+		parts = parts[1:]
+	}
+	if len(parts) != 2 {
+		return configAddress{}, fmt.Errorf("invalid resource address %q", address)
+	}
+	addr.typ, addr.name = parts[0], parts[1]
+	return addr, nil
+}
+
+// splitAddress splits an address on dots, dropping [index] segments. Dots
+// inside quoted for_each keys are not separators.
+func splitAddress(address string) ([]string, error) {
+	var parts []string
+	var cur strings.Builder
+	depth := 0
+	inString := false
+
+	for i := 0; i < len(address); i++ {
+		c := address[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"' && depth > 0:
+			inString = true
+		case c == '[':
+			depth++
+		case c == ']':
+			if depth == 0 {
+				return nil, fmt.Errorf("invalid resource address %q", address)
+			}
+			depth--
+		case depth > 0:
+		case c == '.':
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if depth != 0 || inString {
+		return nil, fmt.Errorf("invalid resource address %q", address)
+	}
+	parts = append(parts, cur.String())
+
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("invalid resource address %q", address)
+		}
+	}
+	return parts, nil
+}
+
+// moduleDir follows module calls from the root module. Only local module
+// sources can be patched; registry and git modules live elsewhere.
+func (s *SourcePatcher) moduleDir(modules []string) (string, error) {
+	dir := s.root
+	for _, name := range modules {
+		_, _, block, err := findBlock(dir, configAddress{kind: "module", name: name})
+		if err != nil {
+			return "", fmt.Errorf("module %q: %w", name, err)
+		}
+
+		attr := block.Body().GetAttribute("source")
+		if attr == nil {
+			return "", fmt.Errorf("module %q has no source", name)
+		}
+		source, ok := literalString(attr.Expr().BuildTokens(nil))
+		if !ok {
+			return "", fmt.Errorf("module %q source is not a string literal", name)
+		}
+		if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+			return "", fmt.Errorf("module %q uses non-local source %q", name, source)
+		}
+		dir = filepath.Join(dir, filepath.FromSlash(source))
+	}
+	return dir, nil
+}
+
+// findBlock parses the .tf files in dir and returns the block for addr
+// along with its file and the file's name
+func findBlock(dir string, addr configAddress) (*hclwrite.File, string, *hclwrite.Block, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".tf") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	labels := []string{addr.typ, addr.name}
+	if addr.kind == "module" {
+		labels = []string{addr.name}
+	}
+
+	for _, name := range names {
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		file, diags := hclwrite.ParseConfig(src, name, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, "", nil, fmt.Errorf("failed to parse %s: %s", filepath.Join(dir, name), diags.Error())
+		}
+		if block := file.Body().FirstMatchingBlock(addr.kind, labels); block != nil {
+			return file, name, block, nil
+		}
+	}
+
+	return nil, "", nil, fmt.Errorf("%s %s in %s: %w", addr.kind, strings.Join(labels, "."), dir, ErrResourceNotFound)
+}
+
+// setAttribute writes value to attribute. An argument written as nested
+// blocks (e.g. ingress { ... }) is rewritten as blocks. A block expanded
+// with count/for_each, or an argument computed from an expression, is
+// refused: the cloud value of one instance would replace the expression
+// for all of them.
+func setAttribute(body *hclwrite.Body, attribute string, value interface{}) error {
+	for _, meta := range []string{"count", "for_each"} {
+		if body.GetAttribute(meta) != nil {
+			return fmt.Errorf("block uses %s; its instances share the configuration", meta)
+		}
+	}
+	if attr := body.GetAttribute(attribute); attr != nil && !isLiteral(attr.Expr().BuildTokens(nil)) {
+		return fmt.Errorf("%s is set by an expression, not a literal value", attribute)
+	}
+
+	if body.GetAttribute(attribute) == nil {
+		if items, ok := blockItems(value); ok && hasBlocks(body, attribute) {
+			for _, b := range body.Blocks() {
+				if b.Type() == attribute {
+					body.RemoveBlock(b)
+				}
+			}
+			for _, item := range items {
+				nested := body.AppendNewBlock(attribute, nil).Body()
+				for _, k := range sortedKeys(item) {
+					v, err := toCty(item[k])
+					if err != nil {
+						return fmt.Errorf("%s.%s: %w", attribute, k, err)
+					}
+					nested.SetAttributeValue(k, v)
+				}
+			}
+			return nil
+		}
+	}
+
+	v, err := toCty(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", attribute, err)
+	}
+	body.SetAttributeValue(attribute, v)
+	return nil
+}
+
+// addIgnoreChanges adds attribute to lifecycle.ignore_changes, creating the
+// lifecycle block or the list as needed. Existing entries and formatting are
+// kept.
+func addIgnoreChanges(body *hclwrite.Body, attribute string) {
+	lifecycle := body.FirstMatchingBlock("lifecycle", nil)
+	if lifecycle == nil {
+		lifecycle = body.AppendNewBlock("lifecycle", nil)
+	}
+
+	attr := lifecycle.Body().GetAttribute("ignore_changes")
+	if attr == nil {
+		lifecycle.Body().SetAttributeRaw("ignore_changes", hclwrite.TokensForTuple([]hclwrite.Tokens{
+			hclwrite.TokensForIdentifier(attribute),
+		}))
+		return
+	}
+
+	tokens := attr.Expr().BuildTokens(nil)
+	closing := -1
+	for i, t := range tokens {
+		switch t.Type {
+		case hclsyntax.TokenIdent:
+			// ignore_changes = all already covers everything
+			if string(t.Bytes) == "all" && len(tokens) == 1 {
+				return
+			}
+			if string(t.Bytes) == attribute && isListEntryStart(tokens, i) {
+				return
+			}
+		case hclsyntax.TokenCBrack:
+			closing = i
+		}
+	}
+	if closing < 0 {
+		// Not a list literal (e.g. a variable); leave it to a human
+		return
+	}
+
+	prev := closing - 1
+	for prev >= 0 && tokens[prev].Type == hclsyntax.TokenNewline {
+		prev--
+	}
+	comma := &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")}
+	newline := &hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")}
+	afterSeparator := prev >= 0 && (tokens[prev].Type == hclsyntax.TokenOBrack || tokens[prev].Type == hclsyntax.TokenComma)
+
+	var insert hclwrite.Tokens
+	if !afterSeparator {
+		insert = append(insert, comma)
+	}
+	if prev < closing-1 {
+		// Multi-line list: one entry per line with a trailing comma
+		insert = append(insert, newline)
+		insert = append(insert, hclwrite.TokensForIdentifier(attribute)...)
+		insert = append(insert, comma)
+	} else {
+		insert = append(insert, hclwrite.TokensForIdentifier(attribute)...)
+	}
+
+	patched := append(hclwrite.Tokens{}, tokens[:prev+1]...)
+	patched = append(patched, insert...)
+	patched = append(patched, tokens[prev+1:]...)
+	lifecycle.Body().SetAttributeRaw("ignore_changes", patched)
+}
+
+// isListEntryStart reports whether tokens[i] starts a list element, so that
+// tags in [tags["Owner"]] is not mistaken for an entry ignoring all tags
+func isListEntryStart(tokens hclwrite.Tokens, i int) bool {
+	prev := i - 1
+	for prev >= 0 && tokens[prev].Type == hclsyntax.TokenNewline {
+		prev--
+	}
+	if prev < 0 || (tokens[prev].Type != hclsyntax.TokenOBrack && tokens[prev].Type != hclsyntax.TokenComma) {
+		return false
+	}
+	next := i + 1
+	return next >= len(tokens) || (tokens[next].Type != hclsyntax.TokenOBrack && tokens[next].Type != hclsyntax.TokenDot)
+}
+
+func hasBlocks(body *hclwrite.Body, blockType string) bool {
+	for _, b := range body.Blocks() {
+		if b.Type() == blockType {
+			return true
+		}
+	}
+	return false
+}
+
+// blockItems returns value as a list of objects, the shape of a nested
+// block argument in state
+func blockItems(value interface{}) ([]map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}, true
+	case []interface{}:
+		items := make([]map[string]interface{}, 0, len(v))
+		for _, e := range v {
+			m, ok := e.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			items = append(items, m)
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// isLiteral reports whether tokens form an expression that evaluates
+// without variables or function calls, such as "x", 3 or { Name = "web" }
+func isLiteral(tokens hclwrite.Tokens) bool {
+	expr, diags := hclsyntax.ParseExpression(tokens.Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return false
+	}
+	_, diags = expr.Value(nil)
+	return !diags.HasErrors()
+}
+
+// literalString returns the value of a plain quoted string expression
+func literalString(tokens hclwrite.Tokens) (string, bool) {
+	if len(tokens) == 3 && tokens[0].Type == hclsyntax.TokenOQuote && tokens[1].Type == hclsyntax.TokenQuotedLit && tokens[2].Type == hclsyntax.TokenCQuote {
+		return string(tokens[1].Bytes), true
+	}
+	return "", false
+}
+
+// toCty converts a JSON-decoded value to cty for hclwrite
+func toCty(v interface{}) (cty.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	case string:
+		return cty.StringVal(val), nil
+	case bool:
+		return cty.BoolVal(val), nil
+	case int:
+		return cty.NumberIntVal(int64(val)), nil
+	case int64:
+		return cty.NumberIntVal(val), nil
+	case float64:
+		return cty.NumberFloatVal(val), nil
+	case []interface{}:
+		if len(val) == 0 {
+			return cty.EmptyTupleVal, nil
+		}
+		elems := make([]cty.Value, 0, len(val))
+		for _, e := range val {
+			ev, err := toCty(e)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			elems = append(elems, ev)
+		}
+		return cty.TupleVal(elems), nil
+	case map[string]interface{}:
+		if len(val) == 0 {
+			return cty.EmptyObjectVal, nil
+		}
+		attrs := make(map[string]cty.Value, len(val))
+		for k, e := range val {
+			ev, err := toCty(e)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			attrs[k] = ev
+		}
+		return cty.ObjectVal(attrs), nil
+	default:
+		return cty.NilVal, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
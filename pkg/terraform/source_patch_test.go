@@ -0,0 +1,241 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTree writes files (slash paths relative to dir) and returns dir
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+	return dir
+}
+
+const patchMainTF = `# Web tier
+resource "aws_instance" "web" {
+  ami           = "ami-123" # pinned
+  instance_type = "t3.micro"
+
+  tags = {
+    Name = "web"
+  }
+}
+
+resource "aws_security_group" "web" {
+  name = "web"
+
+  ingress {
+    from_port   = 80
+    to_port     = 80
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+}
+`
+
+func TestSourcePatcher_UpdateValue(t *testing.T) {
+	dir := writeTree(t, map[string]string{"main.tf": patchMainTF, "variables.tf": "variable \"env\" {}\n"})
+	patcher := NewSourcePatcher(dir, "envs/prod")
+
+	patch, err := patcher.PatchAttribute("aws_instance.web[0]", "instance_type", "t3.large", PatchUpdateValue)
+	require.NoError(t, err)
+
+	assert.Equal(t, "envs/prod/main.tf", patch.Path)
+	assert.True(t, patch.Changed())
+	assert.Equal(t, patchMainTF, string(patch.Original))
+	want := strings.Replace(patchMainTF, `instance_type = "t3.micro"`, `instance_type = "t3.large"`, 1)
+	assert.Equal(t, want, string(patch.Patched), "only the drifted attribute changes; comments survive")
+	assert.Contains(t, patch.Block, `instance_type = "t3.large"`)
+
+	// Map values and new attributes
+	patch, err = patcher.PatchAttribute("aws_instance.web", "tags", map[string]interface{}{"Name": "web", "Owner": "ops"}, PatchUpdateValue)
+	require.NoError(t, err)
+	assert.Contains(t, string(patch.Patched), "Owner = \"ops\"")
+
+	patch, err = patcher.PatchAttribute("aws_instance.web", "monitoring", true, PatchUpdateValue)
+	require.NoError(t, err)
+	assert.Contains(t, string(patch.Patched), "  monitoring = true\n}")
+}
+
+func TestSourcePatcher_UpdateNestedBlocks(t *testing.T) {
+	dir := writeTree(t, map[string]string{"main.tf": patchMainTF})
+	patcher := NewSourcePatcher(dir, "")
+
+	rules := []interface{}{
+		map[string]interface{}{"from_port": float64(443), "to_port": float64(443), "protocol": "tcp", "cidr_blocks": []interface{}{"0.0.0.0/0"}},
+	}
+	patch, err := patcher.PatchAttribute("aws_security_group.web", "ingress", rules, PatchUpdateValue)
+	require.NoError(t, err)
+
+	assert.Equal(t, "main.tf", patch.Path)
+	patched := string(patch.Patched)
+	assert.NotContains(t, patched, "ingress =", "block arguments stay blocks")
+	assert.Contains(t, patched, "from_port   = 443")
+	assert.NotContains(t, patched, "10.0.0.0/8")
+}
+
+func TestSourcePatcher_UpdateValueRefusesExpressions(t *testing.T) {
+	dir := writeTree(t, map[string]string{"main.tf": `resource "aws_instance" "web" {
+  ami           = var.ami
+  instance_type = local.size
+  user_data     = file("init.sh")
+  subnet_id     = "subnet-${var.az}"
+  tags          = { Name = "web" }
+}
+`})
+	patcher := NewSourcePatcher(dir, "")
+
+	for _, attribute := range []string{"ami", "instance_type", "user_data", "subnet_id"} {
+		_, err := patcher.PatchAttribute("aws_instance.web", attribute, "x", PatchUpdateValue)
+		assert.ErrorContains(t, err, "expression", attribute)
+	}
+
+	// Literal values, including objects, are still patched
+	patch, err := patcher.PatchAttribute("aws_instance.web", "tags", map[string]interface{}{"Name": "api"}, PatchUpdateValue)
+	require.NoError(t, err)
+	assert.Contains(t, string(patch.Patched), `Name = "api"`)
+
+	// ignore_changes keeps the expression
+	_, err = patcher.PatchAttribute("aws_instance.web", "ami", nil, PatchIgnoreChanges)
+	assert.NoError(t, err)
+}
+
+func TestSourcePatcher_UpdateValueRefusesRepeatedBlocks(t *testing.T) {
+	dir := writeTree(t, map[string]string{"main.tf": `resource "aws_instance" "web" {
+  count         = 2
+  instance_type = "t3.micro"
+}
+
+resource "aws_instance" "api" {
+  for_each      = toset(["a", "b"])
+  instance_type = each.value
+}
+`})
+	patcher := NewSourcePatcher(dir, "")
+
+	_, err := patcher.PatchAttribute("aws_instance.web[1]", "instance_type", "t3.large", PatchUpdateValue)
+	assert.ErrorContains(t, err, "count")
+	_, err = patcher.PatchAttribute(`aws_instance.api["a"]`, "instance_type", "t3.large", PatchUpdateValue)
+	assert.ErrorContains(t, err, "for_each")
+
+	_, err = patcher.PatchAttribute("aws_instance.web[1]", "instance_type", nil, PatchIgnoreChanges)
+	assert.NoError(t, err)
+}
+
+func TestSourcePatcher_IgnoreChanges(t *testing.T) {
+	dir := writeTree(t, map[string]string{"main.tf": patchMainTF})
+	patcher := NewSourcePatcher(dir, "")
+
+	patch, err := patcher.PatchAttribute("aws_instance.web", "tags", nil, PatchIgnoreChanges)
+	require.NoError(t, err)
+	assert.Equal(t, PatchIgnoreChanges, patch.Mode)
+	assert.Contains(t, string(patch.Patched), "lifecycle {\n    ignore_changes = [tags]\n  }")
+	assert.Contains(t, string(patch.Patched), `instance_type = "t3.micro"`, "configured value is kept")
+}
+
+func TestAddIgnoreChanges_ExistingList(t *testing.T) {
+	tests := []struct {
+		name, lifecycle, want string
+	}{
+		{"single line", "ignore_changes = [ami]", "ignore_changes = [ami, tags]"},
+		{"empty", "ignore_changes = []", "ignore_changes = [tags]"},
+		{"multi line", "ignore_changes = [\n      ami,\n    ]", "ignore_changes = [\n      ami,\n      tags,\n    ]"},
+		{"multi line without trailing comma", "ignore_changes = [\n      ami\n    ]", "ignore_changes = [\n      ami,\n      tags,\n    ]"},
+		{"already present", "ignore_changes = [tags]", "ignore_changes = [tags]"},
+		{"nested key is not the whole attribute", `ignore_changes = [tags["Owner"]]`, `ignore_changes = [tags["Owner"], tags]`},
+		{"all", "ignore_changes = all", "ignore_changes = all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "resource \"aws_instance\" \"web\" {\n  ami = \"ami-1\"\n\n  lifecycle {\n    " + tt.lifecycle + "\n  }\n}\n"
+			dir := writeTree(t, map[string]string{"main.tf": src})
+
+			patch, err := NewSourcePatcher(dir, "").PatchAttribute("aws_instance.web", "tags", nil, PatchIgnoreChanges)
+			require.NoError(t, err)
+			assert.Contains(t, string(patch.Patched), tt.want)
+			assert.Equal(t, tt.lifecycle != tt.want, patch.Changed())
+		})
+	}
+}
+
+func TestSourcePatcher_LocalModules(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"main.tf": `module "app" {
+  source   = "./modules/app"
+  for_each = toset(["blue", "green"])
+}
+
+module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+`,
+		"modules/app/main.tf": `module "db" {
+  source = "../db"
+}
+`,
+		"modules/db/rds.tf": `resource "aws_db_instance" "this" {
+  instance_class = "db.t3.micro"
+}
+`,
+	})
+	patcher := NewSourcePatcher(dir, "infra")
+
+	patch, err := patcher.PatchAttribute(`module.app["blue"].module.db.aws_db_instance.this`, "instance_class", "db.t3.small", PatchUpdateValue)
+	require.NoError(t, err)
+	assert.Equal(t, "infra/modules/db/rds.tf", patch.Path)
+	assert.Contains(t, string(patch.Patched), `instance_class = "db.t3.small"`)
+
+	_, err = patcher.PatchAttribute("module.vpc.aws_vpc.this[0]", "cidr_block", "10.0.0.0/16", PatchUpdateValue)
+	assert.ErrorContains(t, err, "non-local source")
+}
+
+func TestSourcePatcher_Errors(t *testing.T) {
+	dir := writeTree(t, map[string]string{"main.tf": patchMainTF})
+	patcher := NewSourcePatcher(dir, "")
+
+	_, err := patcher.PatchAttribute("aws_instance.api", "ami", "ami-2", PatchUpdateValue)
+	assert.ErrorIs(t, err, ErrResourceNotFound)
+
+	_, err = patcher.PatchAttribute("aws_instance.web", "tags.Owner", "ops", PatchUpdateValue)
+	assert.ErrorContains(t, err, "top-level argument")
+
+	_, err = patcher.PatchAttribute("aws_instance", "ami", "ami-2", PatchUpdateValue)
+	assert.ErrorContains(t, err, "invalid resource address")
+
+	broken := writeTree(t, map[string]string{"main.tf": "resource \"aws_instance\" \"web\" {\n"})
+	_, err = NewSourcePatcher(broken, "").PatchAttribute("aws_instance.web", "ami", "x", PatchUpdateValue)
+	assert.ErrorContains(t, err, "failed to parse")
+}
+
+func TestParseConfigAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    configAddress
+	}{
+		{"aws_instance.web", configAddress{kind: "resource", typ: "aws_instance", name: "web"}},
+		{`aws_instance.web["a.b"]`, configAddress{kind: "resource", typ: "aws_instance", name: "web"}},
+		{"data.aws_ami.ubuntu", configAddress{kind: "data", typ: "aws_ami", name: "ubuntu"}},
+		{`module.app["x.y"].module.db[1].aws_db_instance.this`, configAddress{modules: []string{"app", "db"}, kind: "resource", typ: "aws_db_instance", name: "this"}},
+	}
+	for _, tt := range tests {
+		got, err := parseConfigAddress(tt.address)
+		require.NoError(t, err, tt.address)
+		assert.Equal(t, tt.want, got, tt.address)
+	}
+
+	for _, bad := range []string{"aws_instance", "aws_instance.web[0", "a..b", "module.app"} {
+		_, err := parseConfigAddress(bad)
+		assert.Error(t, err, bad)
+	}
+}
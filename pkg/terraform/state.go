@@ -4,19 +4,68 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/keitahigaki/tfdrift-falco/pkg/config"
 	"github.com/keitahigaki/tfdrift-falco/pkg/terraform/backend"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform/encryption"
 	log "github.com/sirupsen/logrus"
 )
 
-// StateManager manages Terraform state
+// StateManager manages Terraform state. It may be backed by several state
+// files (one per configured entry, or per file matched by a key_pattern);
+// their resources are merged into one index.
 type StateManager struct {
-	cfg           config.TerraformStateConfig
+	cfgs          []config.TerraformStateConfig
 	resources     map[string]*Resource
 	stateMetadata *StateMetadata
+	sources       []StateSource
+	keyIndex      map[string]*Resource
+	keyFuncs      []KeyFunc
 	mu            sync.RWMutex
+
+	// backends are kept across loads so backends that remember what they
+	// served last (ETag, state version) can skip unchanged state
+	backends   map[config.TerraformStateConfig]backend.Backend
+	backendsMu sync.Mutex
+
+	// decrypter decrypts OpenTofu-encrypted state; nil rejects it
+	decrypter *encryption.Decrypter
+
+	// loaded keeps the last load of every concrete state so unchanged state
+	// is neither transferred nor parsed and indexed again. loadMu serializes
+	// loads; reindex forces the next load to rebuild the index.
+	loaded   map[config.TerraformStateConfig]loadedState
+	order    []config.TerraformStateConfig
+	loadMu   sync.Mutex
+	reindex  bool
+	onChange []func(StateDelta)
+
+	// since is when the current index's newest state was written. history
+	// holds up to historySize earlier indexes, oldest first, so events can be
+	// compared with the state of their own time (see GetResourceAt).
+	since           time.Time
+	history         []stateIndex
+	archive         []archivedIndex
+	historySize     int
+	backendVersions bool
+
+	// sensitive are configured patterns of sensitive attributes, on top of
+	// those every state instance marks
+	sensitive []sensitivePattern
+}
+
+// StateSource describes one concrete state file loaded by a StateManager
+type StateSource struct {
+	Name          string `json:"name"`
+	Location      string `json:"location"` // backend location, whatever the name
+	Backend       string `json:"backend"`
+	Workspace     string `json:"workspace"`
+	Serial        int    `json:"serial"`
+	Lineage       string `json:"lineage"`
+	ResourceCount int    `json:"resource_count"`
 }
 
 // StateMetadata contains metadata about the Terraform state
@@ -34,6 +83,24 @@ type Resource struct {
 	Name       string                 `json:"name"`
 	Provider   string                 `json:"provider"`
 	Attributes map[string]interface{} `json:"attributes"`
+
+	// Module is the module path ("module.app[\"blue\"]"), empty for the root module
+	Module string `json:"module,omitempty"`
+	// IndexKey is the count (number) or for_each (string) key of the instance
+	IndexKey interface{} `json:"index_key,omitempty"`
+	// Address is the full resource address, e.g. module.app["blue"].aws_instance.web[2]
+	Address string `json:"address"`
+
+	// Origin of the resource when the manager holds several states
+	StateName string `json:"state_name,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+	Backend   string `json:"backend,omitempty"`
+
+	// SensitivePaths are the attribute paths the state marks sensitive, in
+	// dotted form ("password", "connection.0.secret"). See IsSensitive.
+	SensitivePaths []string `json:"sensitive_paths,omitempty"`
+	// sensitive are the configured sensitive_attributes patterns
+	sensitive []sensitivePattern
 }
 
 // State represents a Terraform state file
@@ -48,6 +115,7 @@ type State struct {
 
 // ResourceDefinition represents a resource in the state file
 type ResourceDefinition struct {
+	Module    string             `json:"module,omitempty"`
 	Mode      string             `json:"mode"`
 	Type      string             `json:"type"`
 	Name      string             `json:"name"`
@@ -57,77 +125,385 @@ type ResourceDefinition struct {
 
 // ResourceInstance represents an instance of a resource
 type ResourceInstance struct {
+	IndexKey   interface{}            `json:"index_key,omitempty"`
 	Attributes map[string]interface{} `json:"attributes"`
+
+	// SensitiveAttributes lists the paths of sensitive attributes as
+	// Terraform writes them, one list of get_attr/index steps per path
+	SensitiveAttributes json.RawMessage `json:"sensitive_attributes,omitempty"`
 }
 
 // NewStateManager creates a new StateManager
 func NewStateManager(cfg config.TerraformStateConfig) (*StateManager, error) {
+	return NewMultiStateManager([]config.TerraformStateConfig{cfg})
+}
+
+// NewMultiStateManager creates a StateManager over several state entries
+func NewMultiStateManager(cfgs []config.TerraformStateConfig) (*StateManager, error) {
+	if len(cfgs) == 0 {
+		return nil, fmt.Errorf("at least one state configuration is required")
+	}
 	return &StateManager{
-		cfg:       cfg,
+		cfgs:      cfgs,
 		resources: make(map[string]*Resource),
 	}, nil
 }
 
+// SetDecrypter sets the decrypter for OpenTofu-encrypted state. It applies
+// from the next Load/Refresh.
+func (sm *StateManager) SetDecrypter(d *encryption.Decrypter) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.decrypter = d
+}
+
+// OnChange registers fn to be called with the resource-level delta of every
+// state whose serial changed on a Load/Refresh. The first load of a state
+// reports no delta.
+func (sm *StateManager) OnChange(fn func(StateDelta)) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.onChange = append(sm.onChange, fn)
+}
+
+// loadedState is a parsed state together with where it came from
+type loadedState struct {
+	source StateSource
+	state  State
+	// version is the backend's version of the state (ETag, generation, ...)
+	version string
+	// modified is when the state was written, zero when unknown
+	modified time.Time
+}
+
 // Load loads the Terraform state
 func (sm *StateManager) Load(ctx context.Context) error {
-	// Create backend
-	be, err := backend.NewBackend(ctx, sm.cfg)
+	_, err := sm.Reload(ctx)
+	return err
+}
+
+// Reload loads every state and reports whether any of them changed since
+// the previous load. States a backend reports unchanged (see
+// backend.ConditionalBackend) are not transferred, and states whose serial
+// and lineage are unchanged are not parsed again. The index is only rebuilt
+// when something changed.
+func (sm *StateManager) Reload(ctx context.Context) (bool, error) {
+	sm.loadMu.Lock()
+	defer sm.loadMu.Unlock()
+
+	var (
+		loaded  []loadedState
+		deltas  []StateDelta
+		changed bool
+	)
+	current := make(map[config.TerraformStateConfig]loadedState)
+	var order []config.TerraformStateConfig
+	for _, entry := range sm.cfgs {
+		concrete, err := backend.ExpandStateConfig(ctx, entry)
+		if err != nil {
+			return false, fmt.Errorf("failed to resolve state %s: %w", backend.Location(entry), err)
+		}
+		for _, cfg := range concrete {
+			prev, hadPrev := sm.loaded[cfg]
+			var previous *loadedState
+			if hadPrev {
+				previous = &prev
+			}
+			ls, err := sm.loadState(ctx, cfg, previous)
+			if err != nil {
+				return false, fmt.Errorf("state %s: %w", backend.Location(cfg), err)
+			}
+			if !hadPrev || ls.version != prev.version || ls.state.Serial != prev.state.Serial || ls.state.Lineage != prev.state.Lineage {
+				changed = true
+			}
+			if hadPrev && (ls.state.Serial != prev.state.Serial || ls.state.Lineage != prev.state.Lineage) {
+				deltas = append(deltas, diffStates(prev, ls))
+			}
+			current[cfg] = ls
+			order = append(order, cfg)
+			loaded = append(loaded, ls)
+		}
+	}
+
+	if len(current) != len(sm.loaded) {
+		changed = true
+	}
+	sm.loaded = current
+	sm.order = order
+
+	sm.mu.Lock()
+	if sm.reindex {
+		changed = true
+		sm.reindex = false
+	}
+	callbacks := sm.onChange
+	sm.mu.Unlock()
+
+	if !changed {
+		log.Debug("Terraform state unchanged; keeping the current index")
+		return false, nil
+	}
+	if err := sm.indexStates(loaded); err != nil {
+		return true, err
+	}
+	for _, delta := range deltas {
+		log.Infof("State %s changed from serial %d to %d: %d added, %d removed, %d changed",
+			delta.State, delta.PreviousSerial, delta.Serial, len(delta.Added), len(delta.Removed), len(delta.Changed))
+		for _, fn := range callbacks {
+			fn(delta)
+		}
+	}
+	return true, nil
+}
+
+// loadState fetches and parses a single concrete state. previous is the last
+// load of the same state, if any; it is returned as is when the backend
+// reports the state unchanged or its serial and lineage are the same.
+func (sm *StateManager) loadState(ctx context.Context, cfg config.TerraformStateConfig, previous *loadedState) (loadedState, error) {
+	var ls loadedState
+
+	be, err := sm.backendFor(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create backend: %w", err)
+		return ls, fmt.Errorf("failed to create backend: %w", err)
 	}
 
 	log.Infof("Loading state from %s backend", be.Name())
 
-	// Load state data
-	data, err := be.Load(ctx)
+	// Load state data, conditionally when the backend supports it
+	var data []byte
+	conditional, isConditional := be.(backend.ConditionalBackend)
+	if isConditional {
+		var version string
+		if previous != nil {
+			version = previous.version
+		}
+		sd, err := conditional.LoadIfChanged(ctx, version)
+		if err != nil {
+			return ls, fmt.Errorf("failed to load state from %s backend: %w", be.Name(), err)
+		}
+		if sd == nil {
+			return *previous, nil
+		}
+		data, ls.version = sd.Data, sd.Version
+		ls.modified, _ = time.Parse(time.RFC3339, sd.LastModified)
+	} else {
+		data, err = be.Load(ctx)
+		if err != nil {
+			return ls, fmt.Errorf("failed to load state from %s backend: %w", be.Name(), err)
+		}
+	}
+	// Without a write time, a changed state counts from when it was seen
+	if ls.modified.IsZero() && previous != nil {
+		ls.modified = time.Now()
+	}
+
+	sm.mu.RLock()
+	decrypter := sm.decrypter
+	sm.mu.RUnlock()
+	data, err = decrypter.Decrypt(data)
 	if err != nil {
-		return fmt.Errorf("failed to load state from %s backend: %w", be.Name(), err)
+		return ls, err
+	}
+
+	// Backends that can't tell whether the state changed still transfer it,
+	// but an unchanged serial and lineage spare parsing it
+	if !isConditional && previous != nil {
+		if serial, lineage, ok := stateHeader(data); ok && serial == previous.state.Serial && lineage == previous.state.Lineage {
+			log.Debugf("State %s unchanged at serial %d", backend.Location(cfg), serial)
+			return *previous, nil
+		}
 	}
 
 	// Parse state
-	var state State
-	if err := json.Unmarshal(data, &state); err != nil {
-		return fmt.Errorf("failed to parse state file: %w", err)
+	if err := json.Unmarshal(data, &ls.state); err != nil {
+		return ls, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	ls.source = StateSource{
+		Name:      backend.Location(cfg),
+		Location:  stateLocation(cfg),
+		Backend:   backendLabel(cfg.Backend),
+		Workspace: cfg.Workspace,
+		Serial:    ls.state.Serial,
+		Lineage:   ls.state.Lineage,
 	}
+	return ls, nil
+}
 
-	return sm.indexState(state)
+// backendFor returns the backend of a concrete state, creating it on first use
+func (sm *StateManager) backendFor(ctx context.Context, cfg config.TerraformStateConfig) (backend.Backend, error) {
+	sm.backendsMu.Lock()
+	defer sm.backendsMu.Unlock()
+
+	if be, ok := sm.backends[cfg]; ok {
+		return be, nil
+	}
+	be, err := backend.NewBackend(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if sm.backends == nil {
+		sm.backends = make(map[config.TerraformStateConfig]backend.Backend)
+	}
+	sm.backends[cfg] = be
+	return be, nil
+}
+
+func backendLabel(b string) string {
+	if b == "" {
+		return "local"
+	}
+	return b
 }
 
-// indexState indexes the state resources for quick lookup
+// stateLocation returns where a state is stored (s3://bucket/key, a local
+// path, ...), ignoring the name it may be given in the configuration
+func stateLocation(cfg config.TerraformStateConfig) string {
+	cfg.Name = ""
+	return backend.Location(cfg)
+}
+
+// indexState indexes a single state's resources for quick lookup
 func (sm *StateManager) indexState(state State) error {
+	var cfg config.TerraformStateConfig
+	if len(sm.cfgs) > 0 {
+		cfg = sm.cfgs[0]
+	}
+	return sm.indexStates([]loadedState{{
+		source: StateSource{
+			Name:      backend.Location(cfg),
+			Location:  stateLocation(cfg),
+			Backend:   backendLabel(cfg.Backend),
+			Workspace: cfg.Workspace,
+			Serial:    state.Serial,
+			Lineage:   state.Lineage,
+		},
+		state: state,
+	}})
+}
+
+// indexStates merges the resources of every loaded state into one index,
+// which becomes the current one. The previous index is kept in the history.
+func (sm *StateManager) indexStates(loaded []loadedState) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	// Store state metadata
-	sm.stateMetadata = &StateMetadata{
-		Version:          state.Version,
-		TerraformVersion: state.TerraformVersion,
-		Serial:           state.Serial,
-		Lineage:          state.Lineage,
+	sm.rememberCurrent()
+	idx := sm.buildIndex(loaded)
+	sm.resources = idx.resources
+	sm.keyIndex = idx.keyIndex
+	sm.sources = idx.sources
+	sm.stateMetadata = idx.metadata
+	sm.since = idx.since
+
+	if len(sm.sources) > 1 {
+		log.Infof("Indexed %d resources from %d Terraform states", len(sm.resources), len(sm.sources))
+	} else {
+		log.Infof("Indexed %d resources from Terraform state", len(sm.resources))
 	}
+	return nil
+}
+
+// stateIndex is the resource index of one combination of state versions
+type stateIndex struct {
+	// since is when the newest of the states was written
+	since     time.Time
+	resources map[string]*Resource
+	keyIndex  map[string]*Resource
+	sources   []StateSource
+	metadata  *StateMetadata
+}
 
-	sm.resources = make(map[string]*Resource)
+// buildIndex merges the resources of the loaded states. When two states
+// contain the same resource ID the later one wins. Callers must hold sm.mu.
+func (sm *StateManager) buildIndex(loaded []loadedState) stateIndex {
+	idx := stateIndex{
+		resources: make(map[string]*Resource),
+		sources:   make([]StateSource, 0, len(loaded)),
+	}
 
-	for _, resDef := range state.Resources {
-		for _, instance := range resDef.Instances {
-			resource := &Resource{
-				Mode:       resDef.Mode,
-				Type:       resDef.Type,
-				Name:       resDef.Name,
-				Provider:   resDef.Provider,
-				Attributes: instance.Attributes,
+	for _, ls := range loaded {
+		state := ls.state
+		if ls.modified.After(idx.since) {
+			idx.since = ls.modified
+		}
+
+		// Metadata reflects the first (primary) state
+		if idx.metadata == nil {
+			idx.metadata = &StateMetadata{
+				Version:          state.Version,
+				TerraformVersion: state.TerraformVersion,
+				Serial:           state.Serial,
+				Lineage:          state.Lineage,
 			}
+		}
 
-			// Generate resource ID based on attributes
-			resourceID := sm.extractResourceID(resource)
-			if resourceID != "" {
-				sm.resources[resourceID] = resource
+		source := ls.source
+		for _, resDef := range state.Resources {
+			for _, instance := range resDef.Instances {
+				resource := &Resource{
+					Mode:       resDef.Mode,
+					Type:       resDef.Type,
+					Name:       resDef.Name,
+					Provider:   resDef.Provider,
+					Attributes: instance.Attributes,
+					Module:     resDef.Module,
+					IndexKey:   instance.IndexKey,
+					Address:    ResourceAddress(resDef.Module, resDef.Mode, resDef.Type, resDef.Name, instance.IndexKey),
+					StateName:  source.Name,
+					Workspace:  source.Workspace,
+					Backend:    source.Backend,
+
+					SensitivePaths: sensitivePaths(instance.SensitiveAttributes),
+					sensitive:      sm.sensitive,
+				}
+
+				// Generate resource ID based on attributes
+				resourceID := sm.extractResourceID(resource)
+				if resourceID == "" {
+					continue
+				}
+				if prev, exists := idx.resources[resourceID]; exists && prev.StateName != source.Name {
+					log.Debugf("Resource %s appears in states %s and %s; using %s", resourceID, prev.StateName, source.Name, source.Name)
+				}
+				idx.resources[resourceID] = resource
+				source.ResourceCount++
 			}
 		}
+		idx.sources = append(idx.sources, source)
 	}
 
-	log.Infof("Indexed %d resources from Terraform state", len(sm.resources))
-	return nil
+	idx.keyIndex = sm.buildKeyIndex(idx.resources)
+	return idx
+}
+
+// ResourceAddress builds the Terraform address of a resource instance, e.g.
+// module.app["blue"].aws_instance.web[2] or data.aws_ami.ubuntu.
+func ResourceAddress(module, mode, resourceType, name string, indexKey interface{}) string {
+	var b strings.Builder
+	if module != "" {
+		b.WriteString(module)
+		b.WriteString(".")
+	}
+	if mode == "data" {
+		b.WriteString("data.")
+	}
+	b.WriteString(resourceType)
+	b.WriteString(".")
+	b.WriteString(name)
+
+	switch k := indexKey.(type) {
+	case nil:
+	case string:
+		b.WriteString(fmt.Sprintf("[%q]", k))
+	case float64:
+		// JSON numbers decode as float64; count indexes are integers
+		b.WriteString(fmt.Sprintf("[%d]", int64(k)))
+	default:
+		b.WriteString(fmt.Sprintf("[%v]", k))
+	}
+	return b.String()
 }
 
 // extractResourceID extracts a unique resource ID from attributes
@@ -150,13 +526,16 @@ func (sm *StateManager) extractResourceID(resource *Resource) string {
 	return ""
 }
 
-// GetResource retrieves a resource by ID
+// GetResource retrieves a resource by ID. Besides the primary ID it matches
+// any secondary key (ARN, name, self link, ...), see Lookup.
 func (sm *StateManager) GetResource(resourceID string) (*Resource, bool) {
-	sm.mu.RLock()
-	defer sm.mu.RUnlock()
+	return sm.Lookup(resourceID)
+}
 
-	resource, exists := sm.resources[resourceID]
-	return resource, exists
+// GetResourceOfType retrieves a resource of the given type by ID, see
+// LookupType
+func (sm *StateManager) GetResourceOfType(resourceType, resourceID string) (*Resource, bool) {
+	return sm.LookupType(resourceType, resourceID)
 }
 
 // ResourceCount returns the number of resources in the state
@@ -167,10 +546,11 @@ func (sm *StateManager) ResourceCount() int {
 	return len(sm.resources)
 }
 
-// Refresh reloads the Terraform state
+// Refresh reloads the Terraform state. See Reload.
 func (sm *StateManager) Refresh(ctx context.Context) error {
 	log.Info("Refreshing Terraform state...")
-	return sm.Load(ctx)
+	_, err := sm.Reload(ctx)
+	return err
 }
 
 // GetStateMetadata returns the state metadata
@@ -181,6 +561,16 @@ func (sm *StateManager) GetStateMetadata() *StateMetadata {
 	return sm.stateMetadata
 }
 
+// GetStates returns the concrete states loaded by the last Load, in load order
+func (sm *StateManager) GetStates() []StateSource {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	states := make([]StateSource, len(sm.sources))
+	copy(states, sm.sources)
+	return states
+}
+
 // GetAllResources returns all resources in the state
 func (sm *StateManager) GetAllResources() []*Resource {
 	sm.mu.RLock()
@@ -0,0 +1,131 @@
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// StateDelta is the resource-level difference between two serials of one
+// state. Resources are identified by address.
+type StateDelta struct {
+	State          string   `json:"state"`
+	Backend        string   `json:"backend"`
+	Workspace      string   `json:"workspace"`
+	Lineage        string   `json:"lineage"`
+	PreviousSerial int      `json:"previous_serial"`
+	Serial         int      `json:"serial"`
+	Added          []string `json:"added"`
+	Removed        []string `json:"removed"`
+	Changed        []string `json:"changed"`
+}
+
+// diffStates compares the resource instances of two loads of a state. An
+// instance whose attributes differ in any way is reported as changed.
+func diffStates(prev, next loadedState) StateDelta {
+	delta := StateDelta{
+		State:          next.source.Name,
+		Backend:        next.source.Backend,
+		Workspace:      next.source.Workspace,
+		Lineage:        next.state.Lineage,
+		PreviousSerial: prev.state.Serial,
+		Serial:         next.state.Serial,
+		Added:          []string{},
+		Removed:        []string{},
+		Changed:        []string{},
+	}
+
+	before := instancesByAddress(prev.state)
+	after := instancesByAddress(next.state)
+	for address, attrs := range after {
+		old, ok := before[address]
+		switch {
+		case !ok:
+			delta.Added = append(delta.Added, address)
+		case !reflect.DeepEqual(old, attrs):
+			delta.Changed = append(delta.Changed, address)
+		}
+	}
+	for address := range before {
+		if _, ok := after[address]; !ok {
+			delta.Removed = append(delta.Removed, address)
+		}
+	}
+
+	sort.Strings(delta.Added)
+	sort.Strings(delta.Removed)
+	sort.Strings(delta.Changed)
+	return delta
+}
+
+// instancesByAddress maps every resource instance address to its attributes
+func instancesByAddress(state State) map[string]map[string]interface{} {
+	instances := make(map[string]map[string]interface{})
+	for _, resDef := range state.Resources {
+		for _, instance := range resDef.Instances {
+			address := ResourceAddress(resDef.Module, resDef.Mode, resDef.Type, resDef.Name, instance.IndexKey)
+			instances[address] = instance.Attributes
+		}
+	}
+	return instances
+}
+
+// stateHeader reads the top-level serial and lineage of a state without
+// decoding its resources. Terraform writes both before "resources", so
+// reading stops there for a typical state.
+func stateHeader(data []byte) (serial int, lineage string, ok bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return 0, "", false
+	}
+
+	var haveSerial, haveLineage bool
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return 0, "", false
+		}
+		key, _ := tok.(string)
+		switch key {
+		case "serial":
+			if err := dec.Decode(&serial); err != nil {
+				return 0, "", false
+			}
+			haveSerial = true
+		case "lineage":
+			if err := dec.Decode(&lineage); err != nil {
+				return 0, "", false
+			}
+			haveLineage = true
+		default:
+			if err := skipValue(dec); err != nil {
+				return 0, "", false
+			}
+		}
+		if haveSerial && haveLineage {
+			return serial, lineage, true
+		}
+	}
+	return 0, "", false
+}
+
+// skipValue consumes the next JSON value token by token, without building it
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
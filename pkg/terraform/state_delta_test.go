@@ -0,0 +1,145 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateHeader(t *testing.T) {
+	serial, lineage, ok := stateHeader([]byte(`{"version":4,"terraform_version":"1.9.0","serial":12,"lineage":"abc","resources":[{"instances":[{}]}]}`))
+	require.True(t, ok)
+	assert.Equal(t, 12, serial)
+	assert.Equal(t, "abc", lineage)
+
+	// Keys after nested values are still found
+	serial, lineage, ok = stateHeader([]byte(`{"outputs":{"a":{"value":[1,{"b":2}]}},"lineage":"x","serial":3}`))
+	require.True(t, ok)
+	assert.Equal(t, 3, serial)
+	assert.Equal(t, "x", lineage)
+
+	_, _, ok = stateHeader([]byte(`{"version":4}`))
+	assert.False(t, ok)
+	_, _, ok = stateHeader([]byte(`not json`))
+	assert.False(t, ok)
+}
+
+func TestDiffStates(t *testing.T) {
+	instance := func(attrs map[string]interface{}) []ResourceInstance {
+		return []ResourceInstance{{Attributes: attrs}}
+	}
+	prev := loadedState{state: State{Serial: 4, Resources: []ResourceDefinition{
+		{Mode: "managed", Type: "aws_instance", Name: "web", Instances: instance(map[string]interface{}{"id": "i-1", "instance_type": "t3.micro"})},
+		{Mode: "managed", Type: "aws_s3_bucket", Name: "logs", Instances: instance(map[string]interface{}{"id": "logs"})},
+		{Mode: "managed", Type: "aws_iam_role", Name: "ci", Instances: instance(map[string]interface{}{"id": "ci"})},
+	}}}
+	next := loadedState{
+		source: StateSource{Name: "s3://b/prod.tfstate", Backend: "s3", Workspace: "default"},
+		state: State{Serial: 5, Lineage: "l", Resources: []ResourceDefinition{
+			{Mode: "managed", Type: "aws_instance", Name: "web", Instances: instance(map[string]interface{}{"id": "i-1", "instance_type": "t3.large"})},
+			{Mode: "managed", Type: "aws_iam_role", Name: "ci", Instances: instance(map[string]interface{}{"id": "ci"})},
+			{Module: "module.net", Mode: "managed", Type: "aws_vpc", Name: "main", Instances: instance(map[string]interface{}{"id": "vpc-1"})},
+		}},
+	}
+
+	delta := diffStates(prev, next)
+	assert.Equal(t, "s3://b/prod.tfstate", delta.State)
+	assert.Equal(t, 4, delta.PreviousSerial)
+	assert.Equal(t, 5, delta.Serial)
+	assert.Equal(t, []string{"module.net.aws_vpc.main"}, delta.Added)
+	assert.Equal(t, []string{"aws_s3_bucket.logs"}, delta.Removed)
+	assert.Equal(t, []string{"aws_instance.web"}, delta.Changed)
+}
+
+func writeLocalState(t *testing.T, path string, serial int, ids ...string) {
+	t.Helper()
+	instances := ""
+	for i, id := range ids {
+		if i > 0 {
+			instances += ","
+		}
+		instances += fmt.Sprintf(`{"index_key":%d,"attributes":{"id":%q}}`, i, id)
+	}
+	content := fmt.Sprintf(`{"version":4,"serial":%d,"lineage":"l","resources":[{"mode":"managed","type":"aws_instance","name":"web","instances":[%s]}]}`, serial, instances)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	// Make the modification time differ even on coarse-grained filesystems
+	modified := time.Now().Add(time.Duration(serial) * time.Second)
+	require.NoError(t, os.Chtimes(path, modified, modified))
+}
+
+func TestStateManager_Reload_SkipsUnchangedAndReportsDelta(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	writeLocalState(t, path, 1, "i-1", "i-2")
+
+	sm, err := NewStateManager(config.TerraformStateConfig{Backend: "local", LocalPath: path})
+	require.NoError(t, err)
+	var deltas []StateDelta
+	sm.OnChange(func(d StateDelta) { deltas = append(deltas, d) })
+	ctx := context.Background()
+
+	changed, err := sm.Reload(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Empty(t, deltas, "the first load has nothing to compare with")
+
+	changed, err = sm.Reload(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed, "unchanged modification time skips the reload")
+	assert.Equal(t, 2, sm.ResourceCount())
+
+	writeLocalState(t, path, 2, "i-1", "i-3", "i-4")
+	changed, err = sm.Reload(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 3, sm.ResourceCount())
+	_, ok := sm.GetResource("i-3")
+	assert.True(t, ok)
+
+	require.Len(t, deltas, 1)
+	assert.Equal(t, 1, deltas[0].PreviousSerial)
+	assert.Equal(t, 2, deltas[0].Serial)
+	assert.Equal(t, "local", deltas[0].Backend)
+	assert.Equal(t, []string{"aws_instance.web[2]"}, deltas[0].Added)
+	assert.Empty(t, deltas[0].Removed)
+	assert.Equal(t, []string{"aws_instance.web[1]"}, deltas[0].Changed)
+}
+
+func TestStateManager_Reload_UnchangedSerialNotParsed(t *testing.T) {
+	// Consul can't answer conditionally: the state is transferred, but an
+	// unchanged serial and lineage keep the previous index
+	var serial atomic.Int64
+	serial.Store(1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"version":4,"serial":%d,"lineage":"l","resources":[{"mode":"managed","type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-%d"}}]}]}`, serial.Load(), serial.Load())
+	}))
+	defer srv.Close()
+
+	sm, err := NewStateManager(config.TerraformStateConfig{Backend: "consul", ConsulAddress: srv.URL, ConsulPath: "tf/network"})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	changed, err := sm.Reload(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	changed, err = sm.Reload(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	serial.Store(2)
+	changed, err = sm.Reload(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	_, ok := sm.GetResource("i-2")
+	assert.True(t, ok)
+}
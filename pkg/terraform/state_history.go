@@ -0,0 +1,183 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform/backend"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultStateHistoryVersions is how many earlier state versions are kept
+// when state_history.versions is not set. Each one holds a full index of its
+// state, so the default covers an apply landing between an event and its
+// delivery without multiplying memory use.
+const DefaultStateHistoryVersions = 1
+
+// archivedIndex is an index of state versions read from the backend. It is
+// known to be current from since up to until.
+type archivedIndex struct {
+	stateIndex
+	until time.Time
+}
+
+// SetHistory configures how many earlier state versions are kept and
+// whether older ones are read from the backend. See GetResourceAt.
+func (sm *StateManager) SetHistory(cfg config.StateHistoryConfig) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	switch {
+	case cfg.Disabled:
+		sm.historySize = 0
+	case cfg.Versions == 0:
+		sm.historySize = DefaultStateHistoryVersions
+	default:
+		sm.historySize = cfg.Versions
+	}
+	sm.backendVersions = cfg.BackendVersions && !cfg.Disabled
+	sm.trimHistory()
+}
+
+// rememberCurrent moves the current index into the history before it is
+// replaced. Callers must hold sm.mu.
+func (sm *StateManager) rememberCurrent() {
+	if sm.historySize == 0 || sm.stateMetadata == nil {
+		return
+	}
+	sm.history = append(sm.history, stateIndex{
+		since:     sm.since,
+		resources: sm.resources,
+		keyIndex:  sm.keyIndex,
+		sources:   sm.sources,
+		metadata:  sm.stateMetadata,
+	})
+	sm.trimHistory()
+}
+
+// trimHistory drops the oldest indexes beyond the configured size. Callers
+// must hold sm.mu.
+func (sm *StateManager) trimHistory() {
+	if n := len(sm.history) - sm.historySize; n > 0 {
+		sm.history = append([]stateIndex(nil), sm.history[n:]...)
+	}
+	if n := len(sm.archive) - sm.historySize; n > 0 {
+		sm.archive = append([]archivedIndex(nil), sm.archive[n:]...)
+	}
+}
+
+// GetResourceAt retrieves a resource like GetResource, from the state that
+// was current at the given time. Events newer than the last write of the
+// current state use it; older events use the version from the history.
+// Versions older than the history are read from the backend (S3 object
+// versions, GCS generations) when backend versions are enabled; otherwise,
+// or when that fails, the oldest known version is used. A zero time uses the
+// current state. Like LookupType, a non-empty resourceType only matches
+// resources of that type.
+func (sm *StateManager) GetResourceAt(ctx context.Context, resourceType, resourceID string, at time.Time) (*Resource, bool) {
+	keys := []string{resourceID}
+
+	sm.mu.RLock()
+	if at.IsZero() || !at.Before(sm.since) || (sm.historySize == 0 && !sm.backendVersions) {
+		defer sm.mu.RUnlock()
+		return lookupIndex(sm.resources, sm.keyIndex, resourceType, keys)
+	}
+	for i := len(sm.history) - 1; i >= 0; i-- {
+		if idx := sm.history[i]; !at.Before(idx.since) {
+			sm.mu.RUnlock()
+			return lookupIndex(idx.resources, idx.keyIndex, resourceType, keys)
+		}
+	}
+	for _, idx := range sm.archive {
+		if !at.Before(idx.since) && !at.After(idx.until) {
+			sm.mu.RUnlock()
+			return lookupIndex(idx.resources, idx.keyIndex, resourceType, keys)
+		}
+	}
+	oldest := stateIndex{since: sm.since, resources: sm.resources, keyIndex: sm.keyIndex}
+	if len(sm.history) > 0 {
+		oldest = sm.history[0]
+	}
+	backendVersions := sm.backendVersions
+	sm.mu.RUnlock()
+
+	if backendVersions {
+		idx, err := sm.indexAt(ctx, at)
+		if err == nil {
+			return lookupIndex(idx.resources, idx.keyIndex, resourceType, keys)
+		}
+		log.Warnf("Could not read the Terraform state current at %s from the backend: %v", at.UTC().Format(time.RFC3339), err)
+	}
+	log.Debugf("No state version known at %s; using the version written %s", at.UTC().Format(time.RFC3339), oldest.since.UTC().Format(time.RFC3339))
+	return lookupIndex(oldest.resources, oldest.keyIndex, resourceType, keys)
+}
+
+// indexAt indexes the versions of every state that were current at the
+// given time, reading those newer than at from the backend
+func (sm *StateManager) indexAt(ctx context.Context, at time.Time) (stateIndex, error) {
+	sm.loadMu.Lock()
+	order, loaded := sm.order, sm.loaded
+	sm.loadMu.Unlock()
+
+	sm.mu.RLock()
+	decrypter := sm.decrypter
+	sm.mu.RUnlock()
+
+	states := make([]loadedState, 0, len(order))
+	for _, cfg := range order {
+		current := loaded[cfg]
+		if !current.modified.After(at) {
+			states = append(states, current)
+			continue
+		}
+
+		be, err := sm.backendFor(ctx, cfg)
+		if err != nil {
+			return stateIndex{}, fmt.Errorf("failed to create backend: %w", err)
+		}
+		versioned, ok := be.(backend.VersionedBackend)
+		if !ok {
+			return stateIndex{}, fmt.Errorf("the %s backend of state %s keeps no earlier versions", be.Name(), current.source.Name)
+		}
+		sd, err := versioned.LoadAt(ctx, at)
+		if err != nil {
+			return stateIndex{}, err
+		}
+		data, err := decrypter.Decrypt(sd.Data)
+		if err != nil {
+			return stateIndex{}, err
+		}
+
+		ls := loadedState{source: current.source, version: sd.Version}
+		if err := json.Unmarshal(data, &ls.state); err != nil {
+			return stateIndex{}, fmt.Errorf("failed to parse state version %s of %s: %w", sd.Version, current.source.Name, err)
+		}
+		ls.modified, _ = time.Parse(time.RFC3339, sd.LastModified)
+		ls.source.Serial = ls.state.Serial
+		ls.source.Lineage = ls.state.Lineage
+		ls.source.ResourceCount = 0
+		states = append(states, ls)
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	idx := sm.buildIndex(states)
+	for i := range sm.archive {
+		// The same versions, now known to be current up to at as well
+		if sm.archive[i].since.Equal(idx.since) {
+			if at.After(sm.archive[i].until) {
+				sm.archive[i].until = at
+			}
+			return sm.archive[i].stateIndex, nil
+		}
+	}
+	sm.archive = append(sm.archive, archivedIndex{stateIndex: idx, until: at})
+	sort.SliceStable(sm.archive, func(i, j int) bool { return sm.archive[i].since.Before(sm.archive[j].since) })
+	sm.trimHistory()
+	return idx, nil
+}
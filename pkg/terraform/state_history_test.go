@@ -0,0 +1,129 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func instanceState(serial int, instanceType string) string {
+	return fmt.Sprintf(`{"version":4,"serial":%d,"lineage":"l","resources":[{"mode":"managed","type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-1","instance_type":%q}}]}]}`, serial, instanceType)
+}
+
+func instanceTypeAt(t *testing.T, sm *StateManager, at time.Time) string {
+	t.Helper()
+	r, ok := sm.GetResourceAt(context.Background(), "", "i-1", at)
+	require.True(t, ok)
+	return r.Attributes["instance_type"].(string)
+}
+
+func TestStateManager_GetResourceAt_History(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	base := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	write := func(serial int, instanceType string) {
+		require.NoError(t, os.WriteFile(path, []byte(instanceState(serial, instanceType)), 0o600))
+		modified := base.Add(time.Duration(serial) * time.Hour)
+		require.NoError(t, os.Chtimes(path, modified, modified))
+	}
+
+	sm, err := NewStateManager(config.TerraformStateConfig{Backend: "local", LocalPath: path})
+	require.NoError(t, err)
+	sm.SetHistory(config.StateHistoryConfig{Versions: 2})
+	ctx := context.Background()
+
+	write(1, "t3.micro") // 11:00
+	require.NoError(t, sm.Load(ctx))
+	write(2, "t3.small") // 12:00
+	require.NoError(t, sm.Refresh(ctx))
+	write(3, "t3.large") // 13:00
+	require.NoError(t, sm.Refresh(ctx))
+
+	assert.Equal(t, "t3.large", instanceTypeAt(t, sm, time.Time{}), "no event time: current state")
+	assert.Equal(t, "t3.large", instanceTypeAt(t, sm, base.Add(3*time.Hour+time.Minute)))
+	assert.Equal(t, "t3.small", instanceTypeAt(t, sm, base.Add(2*time.Hour+30*time.Minute)))
+	assert.Equal(t, "t3.micro", instanceTypeAt(t, sm, base.Add(time.Hour+30*time.Minute)))
+	assert.Equal(t, "t3.micro", instanceTypeAt(t, sm, base), "older than the history: oldest known version")
+
+	// By default only the newest version before the current one is kept
+	sm.SetHistory(config.StateHistoryConfig{})
+	assert.Equal(t, "t3.small", instanceTypeAt(t, sm, base.Add(time.Hour+30*time.Minute)))
+
+	sm.SetHistory(config.StateHistoryConfig{Disabled: true})
+	assert.Equal(t, "t3.large", instanceTypeAt(t, sm, base.Add(2*time.Hour+30*time.Minute)))
+}
+
+type stateVersion struct {
+	at   time.Time
+	data string
+}
+
+// versionedBackend serves a list of state versions, the last one current
+type versionedBackend struct {
+	versions    []stateVersion
+	loadAtCalls int
+}
+
+func (b *versionedBackend) Name() string { return "versioned" }
+
+func (b *versionedBackend) Load(ctx context.Context) ([]byte, error) {
+	sd, err := b.LoadIfChanged(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return sd.Data, nil
+}
+
+func (b *versionedBackend) LoadIfChanged(_ context.Context, _ string) (*backend.StateData, error) {
+	v := b.versions[len(b.versions)-1]
+	return &backend.StateData{Data: []byte(v.data), LastModified: v.at.Format(time.RFC3339), Version: v.at.String()}, nil
+}
+
+func (b *versionedBackend) LoadAt(_ context.Context, at time.Time) (*backend.StateData, error) {
+	b.loadAtCalls++
+	for i := len(b.versions) - 1; i >= 0; i-- {
+		if v := b.versions[i]; !v.at.After(at) {
+			return &backend.StateData{Data: []byte(v.data), LastModified: v.at.Format(time.RFC3339), Version: v.at.String()}, nil
+		}
+	}
+	return nil, fmt.Errorf("no version at %s", at)
+}
+
+func TestStateManager_GetResourceAt_BackendVersions(t *testing.T) {
+	base := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	be := &versionedBackend{versions: []stateVersion{
+		{base, instanceState(1, "t3.micro")},
+		{base.Add(2 * time.Hour), instanceState(2, "t3.large")},
+	}}
+
+	cfg := config.TerraformStateConfig{Backend: "s3", S3Bucket: "b", S3Key: "prod.tfstate"}
+	sm, err := NewStateManager(cfg)
+	require.NoError(t, err)
+	concrete, err := backend.ExpandStateConfig(context.Background(), cfg)
+	require.NoError(t, err)
+	require.Len(t, concrete, 1)
+	sm.backends = map[config.TerraformStateConfig]backend.Backend{concrete[0]: be}
+	require.NoError(t, sm.Load(context.Background()))
+
+	// Without backend versions the oldest known version is all there is
+	sm.SetHistory(config.StateHistoryConfig{})
+	assert.Equal(t, "t3.large", instanceTypeAt(t, sm, base.Add(time.Hour)))
+	assert.Equal(t, 0, be.loadAtCalls)
+
+	sm.SetHistory(config.StateHistoryConfig{BackendVersions: true})
+	assert.Equal(t, "t3.micro", instanceTypeAt(t, sm, base.Add(time.Hour)))
+	assert.Equal(t, "t3.micro", instanceTypeAt(t, sm, base.Add(30*time.Minute)), "known to be current from 10:00 to 11:00")
+	assert.Equal(t, 1, be.loadAtCalls)
+	assert.Equal(t, "t3.large", instanceTypeAt(t, sm, base.Add(3*time.Hour)))
+	assert.Equal(t, 1, be.loadAtCalls)
+
+	// No version that early: fall back to the oldest known
+	assert.Equal(t, "t3.large", instanceTypeAt(t, sm, base.Add(-time.Hour)))
+}
@@ -0,0 +1,128 @@
+package terraform
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// KeyFunc returns additional identifiers a resource of the given type may be
+// referenced by in cloud events (ARNs, names, URLs, API paths, ...). Provider
+// packages supply these for their resource types; see AddKeyFunc.
+type KeyFunc func(resourceType string, attributes map[string]interface{}) []string
+
+// defaultKeyAttributes are indexed for every resource
+var defaultKeyAttributes = []string{"id", "arn", "name", "self_link"}
+
+// AddKeyFunc registers a provider-specific key rule. Keys are indexed on the
+// next Load/Refresh.
+func (sm *StateManager) AddKeyFunc(fn KeyFunc) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.keyFuncs = append(sm.keyFuncs, fn)
+	sm.reindex = true
+}
+
+// Lookup finds a resource by any of the given keys. Each key is tried against
+// the primary ID index first, then against the secondary key index. Keys that
+// are shared by several resources (e.g. a name reused across types) are
+// ambiguous and never match through the secondary index.
+func (sm *StateManager) Lookup(keys ...string) (*Resource, bool) {
+	return sm.LookupType("", keys...)
+}
+
+// LookupType finds a resource of the given Terraform type by any of the given
+// keys, like Lookup. Secondary keys are scoped by type, so a name only
+// ambiguous across types still matches, and a resource of another type
+// never does. An empty type matches any.
+func (sm *StateManager) LookupType(resourceType string, keys ...string) (*Resource, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	return lookupIndex(sm.resources, sm.keyIndex, resourceType, keys)
+}
+
+// lookupIndex implements LookupType over one index
+func lookupIndex(resources, keyIndex map[string]*Resource, resourceType string, keys []string) (*Resource, bool) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if resource, ok := resources[key]; ok && (resourceType == "" || resource.Type == resourceType) {
+			return resource, true
+		}
+		if resource := keyIndex[typedKey(resourceType, normalizeKey(key))]; resource != nil {
+			return resource, true
+		}
+	}
+	return nil, false
+}
+
+// typedKey scopes a secondary key to a resource type. The empty type is the
+// unscoped key.
+func typedKey(resourceType, key string) string {
+	if resourceType == "" {
+		return key
+	}
+	return resourceType + "\x00" + key
+}
+
+// buildKeyIndex builds the secondary index of resources, each key once
+// unscoped and once scoped by the resource's type. Callers must hold sm.mu.
+func (sm *StateManager) buildKeyIndex(resources map[string]*Resource) map[string]*Resource {
+	keyIndex := make(map[string]*Resource)
+
+	ambiguous := 0
+	add := func(key string, resource *Resource) {
+		prev, seen := keyIndex[key]
+		switch {
+		case !seen:
+			keyIndex[key] = resource
+		case prev != nil && prev != resource:
+			// nil marks the key as ambiguous
+			keyIndex[key] = nil
+			ambiguous++
+		}
+	}
+	for _, resource := range resources {
+		for _, key := range sm.resourceKeys(resource) {
+			key = normalizeKey(key)
+			add(key, resource)
+			add(typedKey(resource.Type, key), resource)
+		}
+	}
+
+	if ambiguous > 0 {
+		log.Debugf("%d secondary state keys are shared by several resources and will not be matched", ambiguous)
+	}
+	return keyIndex
+}
+
+// resourceKeys collects the default and provider-specific keys of a resource
+func (sm *StateManager) resourceKeys(resource *Resource) []string {
+	var keys []string
+	for _, attr := range defaultKeyAttributes {
+		if v, ok := resource.Attributes[attr].(string); ok && v != "" {
+			keys = append(keys, v)
+		}
+	}
+	for _, fn := range sm.keyFuncs {
+		for _, k := range fn(resource.Type, resource.Attributes) {
+			if k != "" {
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
+// normalizeKey canonicalizes keys that the cloud treats case-insensitively.
+// Azure Resource Manager IDs ("/subscriptions/...") differ in casing between
+// Terraform state and Activity Log events.
+func normalizeKey(key string) string {
+	if len(key) >= len("/subscriptions/") && strings.EqualFold(key[:len("/subscriptions/")], "/subscriptions/") {
+		return strings.ToLower(key)
+	}
+	return key
+}
@@ -0,0 +1,99 @@
+package terraform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func indexedStateManager(t *testing.T, keyFuncs ...KeyFunc) *StateManager {
+	t.Helper()
+	sm := &StateManager{}
+	for _, fn := range keyFuncs {
+		sm.AddKeyFunc(fn)
+	}
+	require.NoError(t, sm.indexState(State{Resources: []ResourceDefinition{
+		{Mode: "managed", Type: "aws_iam_role", Name: "deploy", Instances: []ResourceInstance{{
+			Attributes: map[string]interface{}{"id": "deploy", "arn": "arn:aws:iam::123:role/deploy", "name": "deploy"},
+		}}},
+		{Mode: "managed", Type: "aws_sns_topic", Name: "alerts", Instances: []ResourceInstance{{
+			Attributes: map[string]interface{}{"id": "arn:aws:sns:us-east-1:123:alerts", "name": "shared"},
+		}}},
+		{Mode: "managed", Type: "aws_sqs_queue", Name: "jobs", Instances: []ResourceInstance{{
+			Attributes: map[string]interface{}{"id": "https://sqs.us-east-1.amazonaws.com/123/jobs", "name": "shared"},
+		}}},
+		{Mode: "managed", Type: "google_compute_network", Name: "vpc", Instances: []ResourceInstance{{
+			Attributes: map[string]interface{}{
+				"id":        "projects/p/global/networks/vpc",
+				"self_link": "https://www.googleapis.com/compute/v1/projects/p/global/networks/vpc",
+			},
+		}}},
+		{Mode: "managed", Type: "azurerm_resource_group", Name: "rg", Instances: []ResourceInstance{{
+			Attributes: map[string]interface{}{"id": "/subscriptions/SUB-1/resourceGroups/Prod-RG"},
+		}}},
+	}}))
+	return sm
+}
+
+func TestStateManager_LookupSecondaryKeys(t *testing.T) {
+	sm := indexedStateManager(t)
+
+	r, ok := sm.GetResource("arn:aws:iam::123:role/deploy")
+	require.True(t, ok, "an ARN must resolve a resource indexed by id")
+	assert.Equal(t, "aws_iam_role", r.Type)
+
+	r, ok = sm.GetResource("https://www.googleapis.com/compute/v1/projects/p/global/networks/vpc")
+	require.True(t, ok)
+	assert.Equal(t, "google_compute_network", r.Type)
+
+	r, ok = sm.GetResource("/subscriptions/sub-1/resourcegroups/prod-rg")
+	require.True(t, ok, "Azure resource IDs match case-insensitively")
+	assert.Equal(t, "azurerm_resource_group", r.Type)
+
+	_, ok = sm.GetResource("shared")
+	assert.False(t, ok, "a name shared by two resources is ambiguous and must not match")
+
+	r, ok = sm.Lookup("", "unknown", "deploy")
+	require.True(t, ok, "Lookup tries every key in order")
+	assert.Equal(t, "aws_iam_role", r.Type)
+
+	assert.Equal(t, 5, sm.ResourceCount(), "secondary keys do not change the resource count")
+}
+
+func TestStateManager_LookupTypeScopesKeys(t *testing.T) {
+	sm := indexedStateManager(t)
+
+	// An event on an unmanaged bucket named like a managed role must not
+	// resolve to the role, through the primary ID or a secondary key
+	_, ok := sm.LookupType("aws_s3_bucket", "deploy")
+	assert.False(t, ok)
+	_, ok = sm.LookupType("aws_s3_bucket", "arn:aws:iam::123:role/deploy")
+	assert.False(t, ok)
+
+	r, ok := sm.LookupType("aws_iam_role", "arn:aws:iam::123:role/deploy")
+	require.True(t, ok)
+	assert.Equal(t, "deploy", r.Name)
+
+	// A name shared across types is unambiguous within one type
+	r, ok = sm.LookupType("aws_sqs_queue", "shared")
+	require.True(t, ok)
+	assert.Equal(t, "jobs", r.Name)
+	_, ok = sm.LookupType("aws_s3_bucket", "shared")
+	assert.False(t, ok)
+}
+
+func TestStateManager_AddKeyFunc(t *testing.T) {
+	sm := indexedStateManager(t, func(resourceType string, attributes map[string]interface{}) []string {
+		if resourceType != "aws_sns_topic" {
+			return nil
+		}
+		arn := attributes["id"].(string)
+		return []string{arn[strings.LastIndex(arn, ":")+1:]}
+	})
+
+	r, ok := sm.GetResource("alerts")
+	require.True(t, ok)
+	assert.Equal(t, "aws_sns_topic", r.Type)
+}
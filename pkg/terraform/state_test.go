@@ -2,11 +2,14 @@ package terraform
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/keitahigaki/tfdrift-falco/pkg/config"
+	"github.com/keitahigaki/tfdrift-falco/pkg/terraform/encryption"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -21,8 +24,8 @@ func TestNewStateManager(t *testing.T) {
 
 	require.NoError(t, err)
 	require.NotNil(t, sm)
-	assert.Equal(t, "local", sm.cfg.Backend)
-	assert.Equal(t, "./terraform.tfstate", sm.cfg.LocalPath)
+	assert.Equal(t, "local", sm.cfgs[0].Backend)
+	assert.Equal(t, "./terraform.tfstate", sm.cfgs[0].LocalPath)
 	assert.NotNil(t, sm.resources)
 }
 
@@ -688,3 +691,154 @@ func TestStateManager_ConcurrentGetResource(t *testing.T) {
 		<-done
 	}
 }
+
+func TestStateManager_LoadMultipleStates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, content string) string {
+		p := filepath.Join(dir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+		return p
+	}
+
+	network := write("network/terraform.tfstate", `{"version":4,"serial":3,"lineage":"net","resources":[
+		{"mode":"managed","type":"aws_vpc","name":"main","instances":[{"attributes":{"id":"vpc-1"}}]}]}`)
+	write("app/terraform.tfstate.d/prod/terraform.tfstate", `{"version":4,"serial":7,"lineage":"app","resources":[
+		{"mode":"managed","type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-1"}}]}]}`)
+	write("app/terraform.tfstate.d/dev/terraform.tfstate", `{"version":4,"serial":2,"lineage":"app","resources":[
+		{"mode":"managed","type":"aws_instance","name":"web","instances":[{"attributes":{"id":"i-2"}}]}]}`)
+
+	sm, err := NewMultiStateManager([]config.TerraformStateConfig{
+		{Name: "network", Backend: "local", LocalPath: network},
+		{Backend: "local", KeyPattern: filepath.ToSlash(dir) + "/app/terraform.tfstate.d/*/terraform.tfstate"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, sm.Load(context.Background()))
+
+	assert.Equal(t, 3, sm.ResourceCount())
+
+	vpc, ok := sm.GetResource("vpc-1")
+	require.True(t, ok)
+	assert.Equal(t, "network", vpc.StateName)
+	assert.Equal(t, "default", vpc.Workspace)
+	assert.Equal(t, "local", vpc.Backend)
+
+	prod, ok := sm.GetResource("i-1")
+	require.True(t, ok)
+	assert.Equal(t, "prod", prod.Workspace)
+	assert.Contains(t, prod.StateName, "terraform.tfstate.d/prod")
+
+	dev, ok := sm.GetResource("i-2")
+	require.True(t, ok)
+	assert.Equal(t, "dev", dev.Workspace)
+
+	states := sm.GetStates()
+	require.Len(t, states, 3)
+	assert.Equal(t, "network", states[0].Name)
+	assert.Equal(t, 1, states[0].ResourceCount)
+	assert.Equal(t, 3, sm.GetStateMetadata().Serial, "metadata comes from the first state")
+}
+
+func TestStateManager_LoadMultipleStates_ErrorNamesState(t *testing.T) {
+	sm, err := NewMultiStateManager([]config.TerraformStateConfig{
+		{Name: "missing", Backend: "local", LocalPath: filepath.Join(t.TempDir(), "nope.tfstate")},
+	})
+	require.NoError(t, err)
+
+	err = sm.Load(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing")
+}
+
+func TestNewMultiStateManager_RequiresConfig(t *testing.T) {
+	_, err := NewMultiStateManager(nil)
+	assert.Error(t, err)
+}
+
+func TestResourceAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		module   string
+		mode     string
+		indexKey interface{}
+		want     string
+	}{
+		{"root", "", "managed", nil, "aws_instance.web"},
+		{"count", "", "managed", float64(2), "aws_instance.web[2]"},
+		{"for_each", "", "managed", "blue", `aws_instance.web["blue"]`},
+		{"module", `module.app["blue"]`, "managed", float64(2), `module.app["blue"].aws_instance.web[2]`},
+		{"data", "module.net", "data", nil, "module.net.data.aws_instance.web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ResourceAddress(tt.module, tt.mode, "aws_instance", "web", tt.indexKey))
+		})
+	}
+}
+
+func TestStateManager_IndexState_Addresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":4,"resources":[
+		{"module":"module.app[\"blue\"]","mode":"managed","type":"aws_instance","name":"web","instances":[
+			{"index_key":0,"attributes":{"id":"i-0"}},
+			{"index_key":1,"attributes":{"id":"i-1"}}]},
+		{"mode":"managed","type":"aws_s3_bucket","name":"logs","instances":[
+			{"index_key":"eu","attributes":{"id":"logs-eu"}}]}]}`), 0o600))
+
+	sm, err := NewStateManager(config.TerraformStateConfig{Backend: "local", LocalPath: path})
+	require.NoError(t, err)
+	require.NoError(t, sm.Load(context.Background()))
+
+	r, ok := sm.GetResource("i-1")
+	require.True(t, ok)
+	assert.Equal(t, `module.app["blue"].aws_instance.web[1]`, r.Address)
+	assert.Equal(t, `module.app["blue"]`, r.Module)
+
+	r, ok = sm.GetResource("logs-eu")
+	require.True(t, ok)
+	assert.Equal(t, `aws_s3_bucket.logs["eu"]`, r.Address)
+	assert.Equal(t, "eu", r.IndexKey)
+}
+
+func TestStateManager_Refresh_ReusesBackend(t *testing.T) {
+	var fullResponses int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullResponses++
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"version":4,"serial":3,"lineage":"l","resources":[]}`))
+	}))
+	defer srv.Close()
+
+	sm, err := NewStateManager(config.TerraformStateConfig{Backend: "http", HTTPAddress: srv.URL + "/network"})
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, sm.Load(ctx))
+	require.NoError(t, sm.Refresh(ctx))
+
+	assert.Equal(t, 1, fullResponses, "the refresh revalidates with the ETag of the first load")
+	assert.Equal(t, 3, sm.GetStateMetadata().Serial)
+}
+
+func TestStateManager_Load_EncryptedState(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "terraform.tfstate")
+	envelope := `{"meta":{"key_provider.pbkdf2.main":"e30="},"encrypted_data":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA","encryption_version":"v0"}`
+	require.NoError(t, os.WriteFile(statePath, []byte(envelope), 0o600))
+
+	sm, err := NewStateManager(config.TerraformStateConfig{Backend: "local", LocalPath: statePath})
+	require.NoError(t, err)
+	err = sm.Load(context.Background())
+	assert.ErrorIs(t, err, encryption.ErrEncryptedState, "encrypted state is not parsed as an empty state")
+
+	decrypter, err := encryption.NewDecrypter(config.StateEncryptionConfig{KeyProviders: []config.StateKeyProviderConfig{
+		{Type: "pbkdf2", Name: "main", Passphrase: "correct horse battery staple"},
+	}})
+	require.NoError(t, err)
+	sm.SetDecrypter(decrypter)
+	err = sm.Load(context.Background())
+	assert.ErrorIs(t, err, encryption.ErrDecryptionFailed)
+	assert.Contains(t, err.Error(), statePath)
+}
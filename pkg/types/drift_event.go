@@ -84,6 +84,36 @@ func NewDriftEvent(provider, resourceType, resourceID, changeType string) *Drift
 	}
 }
 
+// NewDriftEventFromAlert builds a DriftEvent for a detected drift alert,
+// carrying over the state context of the resource. Sensitive values are
+// redacted.
+func NewDriftEventFromAlert(alert *DriftAlert) *DriftEvent {
+	alert = alert.Redacted()
+	changeType := ChangeTypeModified
+	if alert.AlertType == "unmanaged" {
+		changeType = ChangeTypeCreated
+	}
+
+	e := NewDriftEvent(alert.Provider, alert.ResourceType, alert.ResourceID, changeType).
+		WithStateContext(alert.TerraformWorkspace, alert.StateBackend).
+		WithUser(alert.UserIdentity.UserName).
+		WithAccountID(alert.UserIdentity.AccountID)
+	if alert.Severity != "" {
+		e.WithSeverity(alert.Severity)
+	}
+	if alert.Attribute != "" {
+		e.WithDiff(
+			map[string]interface{}{alert.Attribute: alert.OldValue},
+			map[string]interface{}{alert.Attribute: alert.NewValue},
+			nil,
+		)
+	}
+	if alert.StateName != "" {
+		e.WithLabel("terraform_state", alert.StateName)
+	}
+	return e
+}
+
 // ToJSON serializes the event to JSON
 func (e *DriftEvent) ToJSON() ([]byte, error) {
 	return json.Marshal(e)
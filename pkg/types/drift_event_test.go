@@ -270,3 +270,36 @@ func TestSeverityConstants(t *testing.T) {
 	assert.Equal(t, "low", SeverityLow)
 	assert.Equal(t, "info", SeverityInfo)
 }
+
+func TestNewDriftEventFromAlert(t *testing.T) {
+	alert := &DriftAlert{
+		Severity:           SeverityHigh,
+		ResourceType:       "aws_instance",
+		ResourceID:         "i-123",
+		Attribute:          "instance_type",
+		OldValue:           "t3.micro",
+		NewValue:           "t3.large",
+		UserIdentity:       UserIdentity{UserName: "alice", AccountID: "111"},
+		AlertType:          "drift",
+		Provider:           "aws",
+		StateName:          "s3://state/env:/prod/app.tfstate",
+		TerraformWorkspace: "prod",
+		StateBackend:       "s3",
+	}
+
+	event := NewDriftEventFromAlert(alert)
+
+	assert.Equal(t, "aws", event.Provider)
+	assert.Equal(t, ChangeTypeModified, event.ChangeType)
+	assert.Equal(t, SeverityHigh, event.Severity)
+	assert.Equal(t, "prod", event.TerraformWorkspace)
+	assert.Equal(t, "s3", event.StateBackend)
+	assert.Equal(t, "alice", event.User)
+	assert.Equal(t, "s3://state/env:/prod/app.tfstate", event.Labels["terraform_state"])
+	assert.Equal(t, map[string]interface{}{"instance_type": "t3.micro"}, event.Expected)
+
+	alert.Attribute, alert.Sensitive = "password", true
+	event = NewDriftEventFromAlert(alert)
+	assert.Equal(t, map[string]interface{}{"password": RedactedValue}, event.Expected)
+	assert.Equal(t, map[string]interface{}{"password": RedactedValue}, event.Actual)
+}
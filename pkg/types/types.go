@@ -1,6 +1,8 @@
 // Package types defines core data structures used throughout TFDrift-Falco.
 package types
 
+import "strings"
+
 // Event represents a cloud event that might indicate drift
 type Event struct {
 	Provider     string
@@ -12,7 +14,7 @@ type Event struct {
 	RawEvent     interface{}
 
 	// Metadata holds provider-specific fields in a unified way.
-	// AWS examples:  "region" -> "us-east-1"
+	// AWS examples:  "region" -> "us-east-1", "user_agent" -> "APN/1.0 HashiCorp/1.0 Terraform/1.9.0 ..."
 	// GCP examples:  "project_id" -> "my-project", "zone" -> "us-central1-a", "service_name" -> "compute.googleapis.com"
 	// Azure examples: "subscription_id" -> "...", "resource_group" -> "...", "region" -> "eastus"
 	Metadata map[string]string
@@ -65,6 +67,56 @@ type DriftAlert struct {
 	MatchedRules []string
 	Timestamp    string
 	AlertType    string // "drift" or "unmanaged"
+
+	// ResourceAddress is the full Terraform address including module path and
+	// count/for_each key (e.g. module.app["blue"].aws_instance.web[2])
+	ResourceAddress string
+
+	// Terraform state the resource was found in (empty for unmanaged resources)
+	Provider           string
+	StateName          string
+	TerraformWorkspace string
+	StateBackend       string
+
+	// Sensitive marks an attribute the state or the sensitive_attributes
+	// configuration declares sensitive. Its values never leave the detector;
+	// see Redacted.
+	Sensitive bool
+}
+
+// RedactedValue stands in for sensitive values, as in Terraform plans
+const RedactedValue = "(sensitive value)"
+
+// Redacted returns the alert as notifications, APIs and formatters show it:
+// a copy with OldValue and NewValue replaced by RedactedValue when the alert
+// is sensitive, else the alert itself.
+func (a *DriftAlert) Redacted() *DriftAlert {
+	if !a.Sensitive {
+		return a
+	}
+	redacted := *a
+	redacted.OldValue = RedactedValue
+	redacted.NewValue = RedactedValue
+	return &redacted
+}
+
+// Address returns the resource's Terraform address, falling back to
+// <type>.<name> when the full address is unknown.
+func (a *DriftAlert) Address() string {
+	if a.ResourceAddress != "" {
+		return a.ResourceAddress
+	}
+	return a.ResourceType + "." + a.ResourceName
+}
+
+// ShellAddress returns Address quoted for use as a shell argument. Addresses
+// with count/for_each keys contain brackets and quotes the shell would mangle.
+func (a *DriftAlert) ShellAddress() string {
+	addr := a.Address()
+	if !strings.ContainsAny(addr, "[]\"' ") {
+		return addr
+	}
+	return "'" + strings.ReplaceAll(addr, "'", `'\''`) + "'"
 }
 
 // DiscoveredResource represents a resource found in a cloud provider.
@@ -122,6 +174,9 @@ type FieldDiff struct {
 	Field          string      `json:"field"`
 	TerraformValue interface{} `json:"terraform_value"`
 	ActualValue    interface{} `json:"actual_value"`
+	// Sensitive is set when the field is sensitive; both values are then
+	// redacted
+	Sensitive bool `json:"sensitive,omitempty"`
 }
 
 // UnmanagedResourceAlert represents a resource not managed by Terraform
@@ -154,6 +209,13 @@ type RemediationProposal struct {
 	PRNumber      int                    `json:"pr_number,omitempty"`
 	CreatedAt     string                 `json:"created_at"`
 	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	// PatchedFiles holds in-place edits to the Terraform source that owns
+	// the resource (repository path -> new content). When the source was
+	// located, these are committed instead of TerraformCode.
+	PatchedFiles map[string]string `json:"patched_files,omitempty"`
+	// PatchMode is how the source was edited: "update_value" or
+	// "ignore_changes". Empty when the source was not located.
+	PatchMode string `json:"patch_mode,omitempty"`
 }
 
 const (
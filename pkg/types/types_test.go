@@ -416,3 +416,25 @@ func TestAlertType_Values(t *testing.T) {
 		})
 	}
 }
+
+func TestDriftAlert_Address(t *testing.T) {
+	a := &DriftAlert{ResourceType: "aws_instance", ResourceName: "web"}
+	assert.Equal(t, "aws_instance.web", a.Address())
+	assert.Equal(t, "aws_instance.web", a.ShellAddress())
+
+	a.ResourceAddress = `module.app["blue"].aws_instance.web[2]`
+	assert.Equal(t, `module.app["blue"].aws_instance.web[2]`, a.Address())
+	assert.Equal(t, `'module.app["blue"].aws_instance.web[2]'`, a.ShellAddress())
+}
+
+func TestDriftAlert_Redacted(t *testing.T) {
+	a := &DriftAlert{Attribute: "instance_type", OldValue: "t3.micro", NewValue: "t3.large"}
+	assert.Same(t, a, a.Redacted())
+
+	a = &DriftAlert{Attribute: "password", OldValue: "old-secret", NewValue: "new-secret", Sensitive: true}
+	r := a.Redacted()
+	assert.Equal(t, RedactedValue, r.OldValue)
+	assert.Equal(t, RedactedValue, r.NewValue)
+	assert.Equal(t, "password", r.Attribute)
+	assert.Equal(t, "old-secret", a.OldValue, "the original alert is left intact")
+}
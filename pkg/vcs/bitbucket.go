@@ -0,0 +1,228 @@
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// BitbucketClient opens pull requests through the Bitbucket Server / Data
+// Center REST API (1.0), authenticating with an HTTP access token.
+//
+// Bitbucket Server has no multi-file commit API, so CommitFiles creates one
+// commit per file.
+type BitbucketClient struct {
+	api        *restClient
+	repoPath   string // "/projects/{key}/repos/{slug}"
+	baseBranch string
+}
+
+// Compile-time interface check
+var _ Provider = (*BitbucketClient)(nil)
+
+type bitbucketRef struct {
+	ID string `json:"id"`
+}
+
+type bitbucketBranchRequest struct {
+	Name       string `json:"name"`
+	StartPoint string `json:"startPoint"`
+}
+
+type bitbucketCommit struct {
+	ID string `json:"id"`
+}
+
+type bitbucketPRRequest struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	FromRef     bitbucketRef `json:"fromRef"`
+	ToRef       bitbucketRef `json:"toRef"`
+}
+
+type bitbucketPR struct {
+	ID      int          `json:"id"`
+	FromRef bitbucketRef `json:"fromRef"`
+	ToRef   bitbucketRef `json:"toRef"`
+	Links   struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+type bitbucketCommentRequest struct {
+	Text string `json:"text"`
+}
+
+// bitbucketPage is the envelope of paged Bitbucket Server responses
+type bitbucketPage[T any] struct {
+	Values     []T  `json:"values"`
+	IsLastPage bool `json:"isLastPage"`
+}
+
+// NewBitbucketClient creates a Bitbucket Server client for the instance at
+// serverURL. projectKey is the project key (e.g. "INFRA") and repo the
+// repository slug.
+func NewBitbucketClient(serverURL, projectKey, repo, baseBranch, token string) *BitbucketClient {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+
+	return &BitbucketClient{
+		api:        newRESTClient(KindBitbucket, strings.TrimRight(serverURL, "/")+"/rest/api/1.0", header),
+		repoPath:   fmt.Sprintf("/projects/%s/repos/%s", url.PathEscape(projectKey), url.PathEscape(repo)),
+		baseBranch: baseBranch,
+	}
+}
+
+// Name implements Provider
+func (c *BitbucketClient) Name() string { return KindBitbucket }
+
+// CreateBranch implements Provider
+func (c *BitbucketClient) CreateBranch(ctx context.Context, branch string) error {
+	return c.api.doJSON(ctx, http.MethodPost, c.repoPath+"/branches",
+		bitbucketBranchRequest{Name: branch, StartPoint: branchRef(c.baseBranch)}, nil,
+		http.StatusOK, http.StatusCreated)
+}
+
+// CommitFiles implements Provider
+func (c *BitbucketClient) CommitFiles(ctx context.Context, branch, message string, files map[string]string) error {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	head, err := c.headCommit(ctx, branch)
+	if err != nil {
+		return fmt.Errorf("failed to get branch head: %w", err)
+	}
+
+	for _, p := range paths {
+		exists, err := c.fileExists(ctx, branch, p)
+		if err != nil {
+			return err
+		}
+		// Editing an existing file must name the commit it is based on;
+		// new files must not
+		sourceCommit := ""
+		if exists {
+			sourceCommit = head
+		}
+		head, err = c.putFile(ctx, branch, message, p, files[p], sourceCommit)
+		if err != nil {
+			return fmt.Errorf("failed to commit %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// CreatePullRequest implements Provider
+func (c *BitbucketClient) CreatePullRequest(ctx context.Context, req *PRRequest) (*PRResult, error) {
+	var pr bitbucketPR
+	err := c.api.doJSON(ctx, http.MethodPost, c.repoPath+"/pull-requests", bitbucketPRRequest{
+		Title:       req.Title,
+		Description: req.Body,
+		FromRef:     bitbucketRef{ID: branchRef(req.BranchName)},
+		ToRef:       bitbucketRef{ID: branchRef(c.baseBranch)},
+	}, &pr, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	return pr.result(), nil
+}
+
+// Comment implements Provider
+func (c *BitbucketClient) Comment(ctx context.Context, number int, body string) error {
+	path := fmt.Sprintf("%s/pull-requests/%d/comments", c.repoPath, number)
+	return c.api.doJSON(ctx, http.MethodPost, path, bitbucketCommentRequest{Text: body}, nil, http.StatusCreated)
+}
+
+// FindPullRequest implements Provider
+func (c *BitbucketClient) FindPullRequest(ctx context.Context, branch string) (*PRResult, error) {
+	query := url.Values{
+		"state":     {"OPEN"},
+		"direction": {"OUTGOING"},
+		"at":        {branchRef(branch)},
+	}
+	var page bitbucketPage[bitbucketPR]
+	if err := c.api.doJSON(ctx, http.MethodGet, c.repoPath+"/pull-requests?"+query.Encode(), nil, &page, http.StatusOK); err != nil {
+		return nil, err
+	}
+	for _, pr := range page.Values {
+		if pr.ToRef.ID == branchRef(c.baseBranch) {
+			return pr.result(), nil
+		}
+	}
+	return nil, nil
+}
+
+func (pr *bitbucketPR) result() *PRResult {
+	result := &PRResult{Number: pr.ID}
+	if len(pr.Links.Self) > 0 {
+		result.URL = pr.Links.Self[0].Href
+	}
+	return result
+}
+
+// headCommit returns the commit ID branch points to
+func (c *BitbucketClient) headCommit(ctx context.Context, branch string) (string, error) {
+	var page bitbucketPage[bitbucketCommit]
+	query := url.Values{"until": {branchRef(branch)}, "limit": {"1"}}
+	if err := c.api.doJSON(ctx, http.MethodGet, c.repoPath+"/commits?"+query.Encode(), nil, &page, http.StatusOK); err != nil {
+		return "", err
+	}
+	if len(page.Values) == 0 {
+		return "", fmt.Errorf("branch %s has no commits", branch)
+	}
+	return page.Values[0].ID, nil
+}
+
+// fileExists reports whether filePath exists on branch
+func (c *BitbucketClient) fileExists(ctx context.Context, branch, filePath string) (bool, error) {
+	query := url.Values{"at": {branchRef(branch)}, "type": {"true"}}
+	path := fmt.Sprintf("%s/browse/%s?%s", c.repoPath, escapeFilePath(filePath), query.Encode())
+	err := c.api.doJSON(ctx, http.MethodGet, path, nil, nil, http.StatusOK)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", filePath, err)
+	}
+	return true, nil
+}
+
+// putFile commits content to filePath on branch and returns the new commit ID
+func (c *BitbucketClient) putFile(ctx context.Context, branch, message, filePath, content, sourceCommit string) (string, error) {
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	fields := [][2]string{{"branch", branch}, {"message", message}, {"content", content}}
+	if sourceCommit != "" {
+		fields = append(fields, [2]string{"sourceCommitId", sourceCommit})
+	}
+	for _, f := range fields {
+		if err := form.WriteField(f[0], f[1]); err != nil {
+			return "", fmt.Errorf("failed to build form: %w", err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("failed to build form: %w", err)
+	}
+
+	var commit bitbucketCommit
+	path := fmt.Sprintf("%s/browse/%s", c.repoPath, escapeFilePath(filePath))
+	if err := c.api.do(ctx, http.MethodPut, path, form.FormDataContentType(), &buf, &commit, http.StatusOK); err != nil {
+		return "", err
+	}
+	return commit.ID, nil
+}
+
+// branchRef returns the fully qualified ref of a branch
+func branchRef(branch string) string {
+	return "refs/heads/" + branch
+}
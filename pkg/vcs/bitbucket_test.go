@@ -0,0 +1,149 @@
+package vcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bitbucketPut struct {
+	branch, path, content, sourceCommit string
+}
+
+// fakeBitbucket is a minimal in-memory Bitbucket Server API for one repo
+type fakeBitbucket struct {
+	heads    map[string]string          // branch -> commit
+	files    map[string]map[string]bool // branch -> paths
+	puts     []bitbucketPut
+	prs      []bitbucketPR
+	comments []string
+}
+
+func newFakeBitbucket(t *testing.T) (*fakeBitbucket, *httptest.Server) {
+	t.Helper()
+	f := &fakeBitbucket{
+		heads: map[string]string{"master": "c0"},
+		files: map[string]map[string]bool{"master": {"remediation/existing.tf": true}},
+	}
+	const repo = "/rest/api/1.0/projects/INFRA/repos/network"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if !strings.HasPrefix(r.URL.Path, repo) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, repo)
+		q := r.URL.Query()
+
+		switch {
+		case r.Method == http.MethodPost && path == "/branches":
+			var req bitbucketBranchRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			from := strings.TrimPrefix(req.StartPoint, "refs/heads/")
+			f.heads[req.Name] = f.heads[from]
+			f.files[req.Name] = map[string]bool{}
+			for p := range f.files[from] {
+				f.files[req.Name][p] = true
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"id": branchRef(req.Name)})
+		case r.Method == http.MethodGet && path == "/commits":
+			branch := strings.TrimPrefix(q.Get("until"), "refs/heads/")
+			_ = json.NewEncoder(w).Encode(bitbucketPage[bitbucketCommit]{Values: []bitbucketCommit{{ID: f.heads[branch]}}, IsLastPage: true})
+		case r.Method == http.MethodGet && strings.HasPrefix(path, "/browse/"):
+			branch := strings.TrimPrefix(q.Get("at"), "refs/heads/")
+			if !f.files[branch][strings.TrimPrefix(path, "/browse/")] {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"type":"FILE"}`))
+		case r.Method == http.MethodPut && strings.HasPrefix(path, "/browse/"):
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			put := bitbucketPut{
+				branch:       r.FormValue("branch"),
+				path:         strings.TrimPrefix(path, "/browse/"),
+				content:      r.FormValue("content"),
+				sourceCommit: r.FormValue("sourceCommitId"),
+			}
+			exists := f.files[put.branch][put.path]
+			if exists != (put.sourceCommit != "") || (exists && put.sourceCommit != f.heads[put.branch]) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			f.puts = append(f.puts, put)
+			f.files[put.branch][put.path] = true
+			f.heads[put.branch] = fmt.Sprintf("c%d", len(f.puts))
+			_ = json.NewEncoder(w).Encode(bitbucketCommit{ID: f.heads[put.branch]})
+		case r.Method == http.MethodPost && path == "/pull-requests":
+			var req bitbucketPRRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			pr := bitbucketPR{ID: len(f.prs) + 1, FromRef: req.FromRef, ToRef: req.ToRef}
+			pr.Links.Self = append(pr.Links.Self, struct {
+				Href string `json:"href"`
+			}{Href: fmt.Sprintf("https://bitbucket.example.com/projects/INFRA/repos/network/pull-requests/%d", pr.ID)})
+			f.prs = append(f.prs, pr)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(pr)
+		case r.Method == http.MethodGet && path == "/pull-requests":
+			assert.Equal(t, "OPEN", q.Get("state"))
+			page := bitbucketPage[bitbucketPR]{IsLastPage: true}
+			for _, pr := range f.prs {
+				if pr.FromRef.ID == q.Get("at") {
+					page.Values = append(page.Values, pr)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(page)
+		case r.Method == http.MethodPost && strings.HasSuffix(path, "/comments"):
+			var req bitbucketCommentRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			f.comments = append(f.comments, req.Text)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func TestBitbucketClient_OpenPullRequest(t *testing.T) {
+	fake, srv := newFakeBitbucket(t)
+	client := NewBitbucketClient(srv.URL, "INFRA", "network", "master", "secret")
+
+	req := &PRRequest{
+		Title:      "fix: remediate drift",
+		BranchName: "remediation/sg-1",
+		Files: map[string]string{
+			"remediation/new.tf":      "new",
+			"remediation/existing.tf": "updated",
+		},
+		CommitMsg: "fix: auto-remediation",
+	}
+
+	result, err := OpenPullRequest(context.Background(), client, req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Number)
+	assert.Equal(t, "https://bitbucket.example.com/projects/INFRA/repos/network/pull-requests/1", result.URL)
+	assert.Equal(t, branchRef("master"), fake.prs[0].ToRef.ID)
+
+	// One commit per file, each chained on the previous one
+	assert.Equal(t, []bitbucketPut{
+		{branch: "remediation/sg-1", path: "remediation/existing.tf", content: "updated", sourceCommit: "c0"},
+		{branch: "remediation/sg-1", path: "remediation/new.tf", content: "new"},
+	}, fake.puts)
+
+	req.Files = map[string]string{"remediation/new.tf": "newer"}
+	result, err = OpenPullRequest(context.Background(), client, req)
+	require.NoError(t, err)
+	assert.True(t, result.Existing)
+	assert.Len(t, fake.prs, 1)
+	assert.Equal(t, "c2", fake.puts[2].sourceCommit)
+	assert.Len(t, fake.comments, 1)
+}
@@ -0,0 +1,172 @@
+package vcs
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// giteaPageSize is the page size used when listing open pull requests
+const giteaPageSize = 50
+
+// GiteaClient opens pull requests through the Gitea (and Forgejo) REST API.
+// Committing several files at once needs Gitea 1.20 or later.
+type GiteaClient struct {
+	api        *restClient
+	repoPath   string // "/repos/{owner}/{repo}"
+	baseBranch string
+}
+
+// Compile-time interface check
+var _ Provider = (*GiteaClient)(nil)
+
+type giteaBranchRequest struct {
+	NewBranchName string `json:"new_branch_name"`
+	OldBranchName string `json:"old_branch_name"`
+}
+
+type giteaFileOperation struct {
+	Operation string `json:"operation"`
+	Path      string `json:"path"`
+	Content   string `json:"content"` // base64
+	SHA       string `json:"sha,omitempty"`
+}
+
+type giteaChangeFilesRequest struct {
+	Branch  string               `json:"branch"`
+	Message string               `json:"message"`
+	Files   []giteaFileOperation `json:"files"`
+}
+
+type giteaContent struct {
+	SHA string `json:"sha"`
+}
+
+type giteaPRRequest struct {
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type giteaPR struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+// NewGiteaClient creates a Gitea client for the instance at serverURL.
+func NewGiteaClient(serverURL, owner, repo, baseBranch, token string) *GiteaClient {
+	header := http.Header{}
+	header.Set("Authorization", "token "+token)
+
+	return &GiteaClient{
+		api:        newRESTClient(KindGitea, strings.TrimRight(serverURL, "/")+"/api/v1", header),
+		repoPath:   fmt.Sprintf("/repos/%s/%s", url.PathEscape(owner), url.PathEscape(repo)),
+		baseBranch: baseBranch,
+	}
+}
+
+// Name implements Provider
+func (c *GiteaClient) Name() string { return KindGitea }
+
+// CreateBranch implements Provider
+func (c *GiteaClient) CreateBranch(ctx context.Context, branch string) error {
+	return c.api.doJSON(ctx, http.MethodPost, c.repoPath+"/branches",
+		giteaBranchRequest{NewBranchName: branch, OldBranchName: c.baseBranch}, nil, http.StatusCreated)
+}
+
+// CommitFiles implements Provider. All files land in a single commit.
+func (c *GiteaClient) CommitFiles(ctx context.Context, branch, message string, files map[string]string) error {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	change := giteaChangeFilesRequest{Branch: branch, Message: message}
+	for _, p := range paths {
+		op := giteaFileOperation{
+			Operation: "create",
+			Path:      p,
+			Content:   base64.StdEncoding.EncodeToString([]byte(files[p])),
+		}
+		// Updates must name the blob being replaced
+		sha, err := c.fileSHA(ctx, branch, p)
+		if err != nil {
+			return err
+		}
+		if sha != "" {
+			op.Operation = "update"
+			op.SHA = sha
+		}
+		change.Files = append(change.Files, op)
+	}
+
+	return c.api.doJSON(ctx, http.MethodPost, c.repoPath+"/contents", change, nil, http.StatusCreated)
+}
+
+// CreatePullRequest implements Provider
+func (c *GiteaClient) CreatePullRequest(ctx context.Context, req *PRRequest) (*PRResult, error) {
+	var pr giteaPR
+	err := c.api.doJSON(ctx, http.MethodPost, c.repoPath+"/pulls", giteaPRRequest{
+		Head:  req.BranchName,
+		Base:  c.baseBranch,
+		Title: req.Title,
+		Body:  req.Body,
+	}, &pr, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	return &PRResult{URL: pr.HTMLURL, Number: pr.Number}, nil
+}
+
+// Comment implements Provider. Pull request comments are issue comments.
+func (c *GiteaClient) Comment(ctx context.Context, number int, body string) error {
+	path := fmt.Sprintf("%s/issues/%d/comments", c.repoPath, number)
+	return c.api.doJSON(ctx, http.MethodPost, path, commentRequest{Body: body}, nil, http.StatusCreated)
+}
+
+// FindPullRequest implements Provider. Gitea cannot filter the pull request
+// list by head branch, so open pull requests are paged through.
+func (c *GiteaClient) FindPullRequest(ctx context.Context, branch string) (*PRResult, error) {
+	for page := 1; ; page++ {
+		var prs []giteaPR
+		path := fmt.Sprintf("%s/pulls?state=open&limit=%d&page=%d", c.repoPath, giteaPageSize, page)
+		if err := c.api.doJSON(ctx, http.MethodGet, path, nil, &prs, http.StatusOK); err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			if pr.Head.Ref == branch && pr.Base.Ref == c.baseBranch {
+				return &PRResult{URL: pr.HTMLURL, Number: pr.Number}, nil
+			}
+		}
+		if len(prs) < giteaPageSize {
+			return nil, nil
+		}
+	}
+}
+
+// fileSHA returns the blob SHA of filePath on branch, or "" if it does not
+// exist
+func (c *GiteaClient) fileSHA(ctx context.Context, branch, filePath string) (string, error) {
+	var content giteaContent
+	path := fmt.Sprintf("%s/contents/%s?ref=%s", c.repoPath, escapeFilePath(filePath), url.QueryEscape(branch))
+	err := c.api.doJSON(ctx, http.MethodGet, path, nil, &content, http.StatusOK)
+	if isNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check %s: %w", filePath, err)
+	}
+	return content.SHA, nil
+}
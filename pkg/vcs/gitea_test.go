@@ -0,0 +1,133 @@
+package vcs
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitea is a minimal in-memory Gitea API for one repo
+type fakeGitea struct {
+	files    map[string]string // "branch:path" -> blob sha
+	changes  []giteaChangeFilesRequest
+	prs      []giteaPR
+	comments []string
+}
+
+func newFakeGitea(t *testing.T, unrelatedPRs int) (*fakeGitea, *httptest.Server) {
+	t.Helper()
+	f := &fakeGitea{files: map[string]string{"main:remediation/existing.tf": "sha-existing"}}
+	for i := 0; i < unrelatedPRs; i++ {
+		pr := giteaPR{Number: i + 1}
+		pr.Head.Ref = fmt.Sprintf("feature-%d", i)
+		pr.Base.Ref = "main"
+		f.prs = append(f.prs, pr)
+	}
+	const repo = "/api/v1/repos/ops/network"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		path := strings.TrimPrefix(r.URL.Path, repo)
+		q := r.URL.Query()
+
+		switch {
+		case r.Method == http.MethodPost && path == "/branches":
+			var req giteaBranchRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			for k, v := range f.files {
+				if p, ok := strings.CutPrefix(k, req.OldBranchName+":"); ok {
+					f.files[req.NewBranchName+":"+p] = v
+				}
+			}
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && strings.HasPrefix(path, "/contents/"):
+			sha, ok := f.files[q.Get("ref")+":"+strings.TrimPrefix(path, "/contents/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(giteaContent{SHA: sha})
+		case r.Method == http.MethodPost && path == "/contents":
+			var req giteaChangeFilesRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			for _, op := range req.Files {
+				key := req.Branch + ":" + op.Path
+				if op.Operation == "update" && f.files[key] != op.SHA {
+					w.WriteHeader(http.StatusConflict)
+					return
+				}
+				f.files[key] = "sha-" + op.Path
+			}
+			f.changes = append(f.changes, req)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPost && path == "/pulls":
+			var req giteaPRRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			pr := giteaPR{Number: len(f.prs) + 1, HTMLURL: "https://gitea.example.com/ops/network/pulls/x"}
+			pr.Head.Ref, pr.Base.Ref = req.Head, req.Base
+			f.prs = append(f.prs, pr)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(pr)
+		case r.Method == http.MethodGet && path == "/pulls":
+			var page, limit int
+			_, _ = fmt.Sscan(q.Get("page"), &page)
+			_, _ = fmt.Sscan(q.Get("limit"), &limit)
+			start := min((page-1)*limit, len(f.prs))
+			end := min(start+limit, len(f.prs))
+			_ = json.NewEncoder(w).Encode(f.prs[start:end])
+		case r.Method == http.MethodPost && strings.HasPrefix(path, "/issues/"):
+			var req commentRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			f.comments = append(f.comments, path+" "+req.Body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func TestGiteaClient_OpenPullRequest(t *testing.T) {
+	// Enough unrelated pull requests that the lookup has to page
+	fake, srv := newFakeGitea(t, giteaPageSize+5)
+	client := NewGiteaClient(srv.URL, "ops", "network", "main", "secret")
+
+	req := &PRRequest{
+		Title:      "fix: remediate drift",
+		BranchName: "remediation/sg-1",
+		Files: map[string]string{
+			"remediation/new.tf":      "new",
+			"remediation/existing.tf": "updated",
+		},
+		CommitMsg: "fix: auto-remediation",
+	}
+
+	result, err := OpenPullRequest(context.Background(), client, req)
+	require.NoError(t, err)
+	assert.Equal(t, giteaPageSize+6, result.Number)
+	assert.False(t, result.Existing)
+
+	require.Len(t, fake.changes, 1)
+	ops := fake.changes[0].Files
+	require.Len(t, ops, 2)
+	assert.Equal(t, "update", ops[0].Operation)
+	assert.Equal(t, "sha-existing", ops[0].SHA)
+	assert.Equal(t, "create", ops[1].Operation)
+	content, err := base64.StdEncoding.DecodeString(ops[1].Content)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+
+	result, err = OpenPullRequest(context.Background(), client, req)
+	require.NoError(t, err)
+	assert.True(t, result.Existing)
+	assert.Equal(t, []string{fmt.Sprintf("/issues/%d/comments Updated by tfdrift-falco: fix: auto-remediation", giteaPageSize+6)}, fake.comments)
+}
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -41,6 +43,9 @@ type PRRequest struct {
 type PRResult struct {
 	URL    string
 	Number int
+	// Existing is set when OpenPullRequest updated a pull request that was
+	// already open instead of creating one
+	Existing bool
 }
 
 // GitHub API response types
@@ -101,6 +106,13 @@ type prResponse struct {
 	CreatedAt string `json:"created_at"`
 }
 
+type commentRequest struct {
+	Body string `json:"body"`
+}
+
+// Compile-time interface check
+var _ Provider = (*GitHubClient)(nil)
+
 // NewGitHubClient creates a new GitHub API client.
 func NewGitHubClient(owner, repo, baseBranch, token string) *GitHubClient {
 	return &GitHubClient{
@@ -113,6 +125,17 @@ func NewGitHubClient(owner, repo, baseBranch, token string) *GitHubClient {
 	}
 }
 
+// NewGitHubEnterpriseClient creates a client for a GitHub Enterprise Server
+// instance at serverURL (e.g. https://github.example.com).
+func NewGitHubEnterpriseClient(serverURL, owner, repo, baseBranch, token string) *GitHubClient {
+	c := NewGitHubClient(owner, repo, baseBranch, token)
+	c.apiBase = strings.TrimRight(serverURL, "/") + "/api/v3"
+	return c
+}
+
+// Name implements Provider
+func (c *GitHubClient) Name() string { return KindGitHub }
+
 // CreatePR creates a new pull request with the specified files and changes.
 // It follows this workflow:
 // 1. Get the SHA of the base branch
@@ -120,20 +143,8 @@ func NewGitHubClient(owner, repo, baseBranch, token string) *GitHubClient {
 // 3. Create/update files in the new branch
 // 4. Create a pull request
 func (c *GitHubClient) CreatePR(ctx context.Context, req *PRRequest) (*PRResult, error) {
-	if req == nil {
-		return nil, fmt.Errorf("pr request cannot be nil")
-	}
-
-	if req.BranchName == "" {
-		return nil, fmt.Errorf("branch name is required")
-	}
-
-	if req.Title == "" {
-		return nil, fmt.Errorf("title is required")
-	}
-
-	if req.CommitMsg == "" {
-		return nil, fmt.Errorf("commit message is required")
+	if err := validatePRRequest(req); err != nil {
+		return nil, err
 	}
 
 	// Step 1: Get the SHA of the base branch
@@ -163,9 +174,98 @@ func (c *GitHubClient) CreatePR(ctx context.Context, req *PRRequest) (*PRResult,
 	return prResult, nil
 }
 
+// CreateBranch implements Provider.
+func (c *GitHubClient) CreateBranch(ctx context.Context, branch string) error {
+	baseSHA, err := c.getBaseBranchSHA(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get base branch SHA: %w", err)
+	}
+	return c.createBranch(ctx, branch, baseSHA)
+}
+
+// CommitFiles implements Provider. The commit is created on top of the
+// branch's current head.
+func (c *GitHubClient) CommitFiles(ctx context.Context, branch, message string, files map[string]string) error {
+	headSHA, err := c.getBranchSHA(ctx, branch)
+	if err != nil {
+		return fmt.Errorf("failed to get branch SHA: %w", err)
+	}
+	treeSHA, err := c.createTree(ctx, files, headSHA)
+	if err != nil {
+		return fmt.Errorf("failed to create tree: %w", err)
+	}
+	commitSHA, err := c.createCommit(ctx, message, treeSHA, []string{headSHA})
+	if err != nil {
+		return fmt.Errorf("failed to create commit: %w", err)
+	}
+	return c.updateRef(ctx, branch, commitSHA)
+}
+
+// CreatePullRequest implements Provider.
+func (c *GitHubClient) CreatePullRequest(ctx context.Context, req *PRRequest) (*PRResult, error) {
+	return c.createPullRequest(ctx, req)
+}
+
+// Comment implements Provider. Pull request comments are issue comments.
+func (c *GitHubClient) Comment(ctx context.Context, number int, body string) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", c.apiBase, c.owner, c.repo, number)
+
+	data, err := json.Marshal(commentRequest{Body: body})
+	if err != nil {
+		return fmt.Errorf("failed to marshal comment: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodPost, url, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		bodyData, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("github api error: %d - %s", resp.StatusCode, string(bodyData))
+	}
+	return nil
+}
+
+// FindPullRequest implements Provider.
+func (c *GitHubClient) FindPullRequest(ctx context.Context, branch string) (*PRResult, error) {
+	query := neturl.Values{
+		"state": {"open"},
+		"head":  {c.owner + ":" + branch},
+		"base":  {c.baseBranch},
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls?%s", c.apiBase, c.owner, c.repo, query.Encode())
+
+	resp, err := c.doRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyData, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("github api error: %d - %s", resp.StatusCode, string(bodyData))
+	}
+
+	var prs []prResponse
+	if err := json.NewDecoder(resp.Body).Decode(&prs); err != nil {
+		return nil, fmt.Errorf("failed to decode pr list: %w", err)
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return &PRResult{URL: prs[0].HTMLURL, Number: prs[0].Number}, nil
+}
+
 // getBaseBranchSHA retrieves the commit SHA of the base branch.
 func (c *GitHubClient) getBaseBranchSHA(ctx context.Context) (string, error) {
-	url := fmt.Sprintf("%s/repos/%s/%s/git/ref/heads/%s", c.apiBase, c.owner, c.repo, c.baseBranch)
+	return c.getBranchSHA(ctx, c.baseBranch)
+}
+
+// getBranchSHA retrieves the commit SHA a branch points to.
+func (c *GitHubClient) getBranchSHA(ctx context.Context, branch string) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/git/ref/heads/%s", c.apiBase, c.owner, c.repo, branch)
 
 	resp, err := c.doRequest(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -0,0 +1,154 @@
+package vcs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const defaultGitLabURL = "https://gitlab.com"
+
+// GitLabClient opens merge requests through the GitLab REST API (v4).
+type GitLabClient struct {
+	api        *restClient
+	project    string // URL-escaped "namespace/repo", usable as :id
+	baseBranch string
+}
+
+// Compile-time interface check
+var _ Provider = (*GitLabClient)(nil)
+
+type gitlabCommitAction struct {
+	Action   string `json:"action"`
+	FilePath string `json:"file_path"`
+	Content  string `json:"content"`
+}
+
+type gitlabCommitRequest struct {
+	Branch        string               `json:"branch"`
+	CommitMessage string               `json:"commit_message"`
+	Actions       []gitlabCommitAction `json:"actions"`
+}
+
+type gitlabMRRequest struct {
+	SourceBranch       string `json:"source_branch"`
+	TargetBranch       string `json:"target_branch"`
+	Title              string `json:"title"`
+	Description        string `json:"description"`
+	RemoveSourceBranch bool   `json:"remove_source_branch"`
+}
+
+type gitlabMR struct {
+	IID    int    `json:"iid"`
+	WebURL string `json:"web_url"`
+}
+
+// NewGitLabClient creates a GitLab client. serverURL defaults to
+// https://gitlab.com; namespace may contain subgroups ("group/sub").
+func NewGitLabClient(serverURL, namespace, repo, baseBranch, token string) *GitLabClient {
+	if serverURL == "" {
+		serverURL = defaultGitLabURL
+	}
+	header := http.Header{}
+	header.Set("PRIVATE-TOKEN", token)
+
+	return &GitLabClient{
+		api:        newRESTClient(KindGitLab, strings.TrimRight(serverURL, "/")+"/api/v4", header),
+		project:    url.PathEscape(namespace + "/" + repo),
+		baseBranch: baseBranch,
+	}
+}
+
+// Name implements Provider
+func (c *GitLabClient) Name() string { return KindGitLab }
+
+// CreateBranch implements Provider
+func (c *GitLabClient) CreateBranch(ctx context.Context, branch string) error {
+	query := url.Values{"branch": {branch}, "ref": {c.baseBranch}}
+	path := fmt.Sprintf("/projects/%s/repository/branches?%s", c.project, query.Encode())
+	return c.api.doJSON(ctx, http.MethodPost, path, nil, nil, http.StatusCreated)
+}
+
+// CommitFiles implements Provider. All files land in a single commit.
+func (c *GitLabClient) CommitFiles(ctx context.Context, branch, message string, files map[string]string) error {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	commit := gitlabCommitRequest{Branch: branch, CommitMessage: message}
+	for _, p := range paths {
+		// The commits API rejects "create" for existing files and "update"
+		// for missing ones
+		exists, err := c.fileExists(ctx, branch, p)
+		if err != nil {
+			return err
+		}
+		action := "create"
+		if exists {
+			action = "update"
+		}
+		commit.Actions = append(commit.Actions, gitlabCommitAction{Action: action, FilePath: p, Content: files[p]})
+	}
+
+	path := fmt.Sprintf("/projects/%s/repository/commits", c.project)
+	return c.api.doJSON(ctx, http.MethodPost, path, commit, nil, http.StatusCreated)
+}
+
+// CreatePullRequest implements Provider by opening a merge request
+func (c *GitLabClient) CreatePullRequest(ctx context.Context, req *PRRequest) (*PRResult, error) {
+	var mr gitlabMR
+	path := fmt.Sprintf("/projects/%s/merge_requests", c.project)
+	err := c.api.doJSON(ctx, http.MethodPost, path, gitlabMRRequest{
+		SourceBranch:       req.BranchName,
+		TargetBranch:       c.baseBranch,
+		Title:              req.Title,
+		Description:        req.Body,
+		RemoveSourceBranch: true,
+	}, &mr, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	return &PRResult{URL: mr.WebURL, Number: mr.IID}, nil
+}
+
+// Comment implements Provider by adding a merge request note
+func (c *GitLabClient) Comment(ctx context.Context, number int, body string) error {
+	path := fmt.Sprintf("/projects/%s/merge_requests/%d/notes", c.project, number)
+	return c.api.doJSON(ctx, http.MethodPost, path, commentRequest{Body: body}, nil, http.StatusCreated)
+}
+
+// FindPullRequest implements Provider
+func (c *GitLabClient) FindPullRequest(ctx context.Context, branch string) (*PRResult, error) {
+	query := url.Values{
+		"state":         {"opened"},
+		"source_branch": {branch},
+		"target_branch": {c.baseBranch},
+	}
+	var mrs []gitlabMR
+	path := fmt.Sprintf("/projects/%s/merge_requests?%s", c.project, query.Encode())
+	if err := c.api.doJSON(ctx, http.MethodGet, path, nil, &mrs, http.StatusOK); err != nil {
+		return nil, err
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+	return &PRResult{URL: mrs[0].WebURL, Number: mrs[0].IID}, nil
+}
+
+// fileExists reports whether filePath exists on branch
+func (c *GitLabClient) fileExists(ctx context.Context, branch, filePath string) (bool, error) {
+	path := fmt.Sprintf("/projects/%s/repository/files/%s?ref=%s", c.project, url.PathEscape(filePath), url.QueryEscape(branch))
+	err := c.api.doJSON(ctx, http.MethodHead, path, nil, nil, http.StatusOK)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", filePath, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,163 @@
+package vcs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitLab is a minimal in-memory GitLab API for one project
+type fakeGitLab struct {
+	branches map[string]bool
+	files    map[string]string // "branch:path" -> content
+	mrs      []gitlabMR
+	mrSource map[int]string
+	notes    map[int][]string
+	commits  []gitlabCommitRequest
+}
+
+func newFakeGitLab(t *testing.T) (*fakeGitLab, *httptest.Server) {
+	t.Helper()
+	f := &fakeGitLab{
+		branches: map[string]bool{"main": true},
+		files:    map[string]string{"main:remediation/existing.tf": "old"},
+		mrSource: map[int]string{},
+		notes:    map[int][]string{},
+	}
+	const project = "/api/v4/projects/infra%2Fnetwork"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		path := r.URL.EscapedPath()
+		if !strings.HasPrefix(path, project) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		path = strings.TrimPrefix(path, project)
+		q := r.URL.Query()
+
+		switch {
+		case r.Method == http.MethodPost && path == "/repository/branches":
+			if !f.branches[q.Get("ref")] {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			f.branches[q.Get("branch")] = true
+			for k, v := range f.files {
+				if strings.HasPrefix(k, q.Get("ref")+":") {
+					f.files[q.Get("branch")+":"+strings.TrimPrefix(k, q.Get("ref")+":")] = v
+				}
+			}
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodHead && strings.HasPrefix(path, "/repository/files/"):
+			// The file path must arrive as a single escaped segment
+			file := strings.ReplaceAll(strings.TrimPrefix(path, "/repository/files/"), "%2F", "/")
+			if _, ok := f.files[q.Get("ref")+":"+file]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPost && path == "/repository/commits":
+			var req gitlabCommitRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			for _, a := range req.Actions {
+				_, exists := f.files[req.Branch+":"+a.FilePath]
+				if (a.Action == "create") == exists {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				f.files[req.Branch+":"+a.FilePath] = a.Content
+			}
+			f.commits = append(f.commits, req)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"abc"}`))
+		case r.Method == http.MethodPost && path == "/merge_requests":
+			var req gitlabMRRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mr := gitlabMR{IID: len(f.mrs) + 1, WebURL: "https://gitlab.example.com/infra/network/-/merge_requests/1"}
+			f.mrs = append(f.mrs, mr)
+			f.mrSource[mr.IID] = req.SourceBranch
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(mr)
+		case r.Method == http.MethodGet && path == "/merge_requests":
+			assert.Equal(t, "opened", q.Get("state"))
+			assert.Equal(t, "main", q.Get("target_branch"))
+			out := []gitlabMR{}
+			for _, mr := range f.mrs {
+				if f.mrSource[mr.IID] == q.Get("source_branch") {
+					out = append(out, mr)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(out)
+		case r.Method == http.MethodPost && strings.HasSuffix(path, "/notes"):
+			var req commentRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			f.notes[1] = append(f.notes[1], req.Body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func TestGitLabClient_OpenPullRequest(t *testing.T) {
+	fake, srv := newFakeGitLab(t)
+	client := NewGitLabClient(srv.URL, "infra", "network", "main", "secret")
+
+	req := &PRRequest{
+		Title:      "fix: remediate drift",
+		Body:       "details",
+		BranchName: "remediation/sg-1",
+		Files: map[string]string{
+			"remediation/new.tf":      "new",
+			"remediation/existing.tf": "updated",
+		},
+		CommitMsg: "fix: auto-remediation",
+	}
+
+	result, err := OpenPullRequest(context.Background(), client, req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Number)
+	assert.False(t, result.Existing)
+	assert.True(t, fake.branches["remediation/sg-1"])
+
+	require.Len(t, fake.commits, 1, "all files land in one commit")
+	assert.Equal(t, []gitlabCommitAction{
+		{Action: "update", FilePath: "remediation/existing.tf", Content: "updated"},
+		{Action: "create", FilePath: "remediation/new.tf", Content: "new"},
+	}, fake.commits[0].Actions)
+
+	// A second run for the same branch updates the open merge request
+	req.Files = map[string]string{"remediation/new.tf": "newer"}
+	result, err = OpenPullRequest(context.Background(), client, req)
+	require.NoError(t, err)
+	assert.True(t, result.Existing)
+	assert.Len(t, fake.mrs, 1)
+	assert.Equal(t, "newer", fake.files["remediation/sg-1:remediation/new.tf"])
+	assert.Len(t, fake.notes[1], 1)
+}
+
+func TestGitLabClient_APIError(t *testing.T) {
+	_, srv := newFakeGitLab(t)
+	client := NewGitLabClient(srv.URL, "infra", "network", "does-not-exist", "secret")
+
+	err := client.CreateBranch(context.Background(), "x")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Contains(t, err.Error(), "gitlab api error: 400")
+}
+
+func TestNewGitLabClient_DefaultURL(t *testing.T) {
+	client := NewGitLabClient("", "group/sub", "repo", "main", "t")
+	assert.Equal(t, "https://gitlab.com/api/v4", client.api.apiBase)
+	assert.Equal(t, "group%2Fsub%2Frepo", client.project)
+}
@@ -0,0 +1,259 @@
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Supported provider kinds
+const (
+	KindGitHub    = "github"
+	KindGitLab    = "gitlab"
+	KindBitbucket = "bitbucket"
+	KindGitea     = "gitea"
+)
+
+// Provider is a code host that remediation proposals can be opened against.
+// GitLab merge requests and Bitbucket/Gitea pull requests are all treated
+// as pull requests, identified by their per-repository number.
+type Provider interface {
+	// Name returns the provider kind, e.g. "gitlab"
+	Name() string
+	// CreateBranch creates branch from the configured base branch
+	CreateBranch(ctx context.Context, branch string) error
+	// CommitFiles creates or updates files (path -> content) on branch
+	CommitFiles(ctx context.Context, branch, message string, files map[string]string) error
+	// CreatePullRequest opens a pull request from req.BranchName into the
+	// base branch
+	CreatePullRequest(ctx context.Context, req *PRRequest) (*PRResult, error)
+	// Comment adds a comment to an open pull request
+	Comment(ctx context.Context, number int, body string) error
+	// FindPullRequest returns the open pull request from branch into the
+	// base branch, or nil if there is none
+	FindPullRequest(ctx context.Context, branch string) (*PRResult, error)
+}
+
+// Options configures a Provider
+type Options struct {
+	// Kind is one of the Kind* constants; empty means GitHub
+	Kind string
+	// URL is the server root of a self-hosted instance, e.g.
+	// https://gitlab.example.com. Required for Bitbucket Server and Gitea.
+	URL string
+	// Owner is the GitHub/Gitea owner, the GitLab namespace (group/subgroup)
+	// or the Bitbucket project key
+	Owner string
+	// Repo is the repository name (Bitbucket: repository slug)
+	Repo string
+	// BaseBranch is the branch pull requests target
+	BaseBranch string
+	Token      string
+}
+
+// NewProvider creates the Provider selected by opts.Kind
+func NewProvider(opts Options) (Provider, error) {
+	if opts.Owner == "" || opts.Repo == "" {
+		return nil, fmt.Errorf("vcs owner and repo are required")
+	}
+	if opts.BaseBranch == "" {
+		opts.BaseBranch = "main"
+	}
+
+	switch opts.Kind {
+	case "", KindGitHub:
+		if opts.URL == "" {
+			return NewGitHubClient(opts.Owner, opts.Repo, opts.BaseBranch, opts.Token), nil
+		}
+		return NewGitHubEnterpriseClient(opts.URL, opts.Owner, opts.Repo, opts.BaseBranch, opts.Token), nil
+	case KindGitLab:
+		return NewGitLabClient(opts.URL, opts.Owner, opts.Repo, opts.BaseBranch, opts.Token), nil
+	case KindBitbucket:
+		if opts.URL == "" {
+			return nil, fmt.Errorf("bitbucket server url is required")
+		}
+		return NewBitbucketClient(opts.URL, opts.Owner, opts.Repo, opts.BaseBranch, opts.Token), nil
+	case KindGitea:
+		if opts.URL == "" {
+			return nil, fmt.Errorf("gitea url is required")
+		}
+		return NewGiteaClient(opts.URL, opts.Owner, opts.Repo, opts.BaseBranch, opts.Token), nil
+	default:
+		return nil, fmt.Errorf("unsupported vcs provider %q", opts.Kind)
+	}
+}
+
+// OpenPullRequest commits req.Files to req.BranchName and opens a pull
+// request for it. If an open pull request from that branch already exists,
+// the files are committed on top of it and a comment is added instead, and
+// the result has Existing set.
+func OpenPullRequest(ctx context.Context, p Provider, req *PRRequest) (*PRResult, error) {
+	if err := validatePRRequest(req); err != nil {
+		return nil, err
+	}
+
+	existing, err := p.FindPullRequest(ctx, req.BranchName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up existing pull request: %w", err)
+	}
+
+	if existing != nil {
+		if len(req.Files) > 0 {
+			if err := p.CommitFiles(ctx, req.BranchName, req.CommitMsg, req.Files); err != nil {
+				return nil, fmt.Errorf("failed to commit files: %w", err)
+			}
+		}
+		comment := fmt.Sprintf("Updated by tfdrift-falco: %s", firstLine(req.CommitMsg))
+		if err := p.Comment(ctx, existing.Number, comment); err != nil {
+			return nil, fmt.Errorf("failed to comment on pull request: %w", err)
+		}
+		existing.Existing = true
+		return existing, nil
+	}
+
+	if err := p.CreateBranch(ctx, req.BranchName); err != nil {
+		return nil, fmt.Errorf("failed to create branch: %w", err)
+	}
+	if len(req.Files) > 0 {
+		if err := p.CommitFiles(ctx, req.BranchName, req.CommitMsg, req.Files); err != nil {
+			return nil, fmt.Errorf("failed to commit files: %w", err)
+		}
+	}
+
+	result, err := p.CreatePullRequest(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return result, nil
+}
+
+// validatePRRequest checks the fields every provider needs
+func validatePRRequest(req *PRRequest) error {
+	if req == nil {
+		return fmt.Errorf("pr request cannot be nil")
+	}
+	if req.BranchName == "" {
+		return fmt.Errorf("branch name is required")
+	}
+	if req.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if req.CommitMsg == "" {
+		return fmt.Errorf("commit message is required")
+	}
+	return nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// escapeFilePath escapes each segment of a slash-separated repository path
+func escapeFilePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// APIError is a non-success response from a provider's API
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s api error: %d - %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// isNotFound reports whether err is a 404 from the provider's API
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// restClient is the JSON-over-HTTP plumbing shared by the GitLab, Bitbucket
+// and Gitea clients
+type restClient struct {
+	provider   string
+	apiBase    string
+	header     http.Header
+	httpClient *http.Client
+}
+
+func newRESTClient(provider, apiBase string, header http.Header) *restClient {
+	header.Set("Accept", "application/json")
+	return &restClient{
+		provider:   provider,
+		apiBase:    strings.TrimRight(apiBase, "/"),
+		header:     header,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// doJSON sends in (if non-nil) as JSON to apiBase+path and decodes the
+// response into out (if non-nil). Any status other than want is an
+// *APIError.
+func (c *restClient) doJSON(ctx context.Context, method, path string, in, out interface{}, want ...int) error {
+	var body io.Reader
+	contentType := ""
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+	return c.do(ctx, method, path, contentType, body, out, want...)
+}
+
+// do sends body with the given content type and decodes a JSON response
+// into out (if non-nil)
+func (c *restClient) do(ctx context.Context, method, path, contentType string, body io.Reader, out interface{}, want ...int) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.apiBase+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("http request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	ok := false
+	for _, code := range want {
+		if resp.StatusCode == code {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &APIError{Provider: c.provider, StatusCode: resp.StatusCode, Body: string(data)}
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode %s response: %w", c.provider, err)
+		}
+	}
+	return nil
+}
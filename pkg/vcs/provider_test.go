@@ -0,0 +1,173 @@
+package vcs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider records the calls OpenPullRequest makes
+type fakeProvider struct {
+	existing *PRResult
+	calls    []string
+	comments []string
+	failOn   string
+}
+
+func (f *fakeProvider) Name() string { return "fake" }
+
+func (f *fakeProvider) record(call string) error {
+	f.calls = append(f.calls, call)
+	if call == f.failOn {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func (f *fakeProvider) CreateBranch(_ context.Context, branch string) error {
+	return f.record("branch:" + branch)
+}
+
+func (f *fakeProvider) CommitFiles(_ context.Context, branch, _ string, _ map[string]string) error {
+	return f.record("commit:" + branch)
+}
+
+func (f *fakeProvider) CreatePullRequest(_ context.Context, req *PRRequest) (*PRResult, error) {
+	if err := f.record("pr:" + req.BranchName); err != nil {
+		return nil, err
+	}
+	return &PRResult{URL: "https://example.com/pr/7", Number: 7}, nil
+}
+
+func (f *fakeProvider) Comment(_ context.Context, number int, body string) error {
+	f.comments = append(f.comments, body)
+	return f.record("comment")
+}
+
+func (f *fakeProvider) FindPullRequest(_ context.Context, branch string) (*PRResult, error) {
+	if err := f.record("find:" + branch); err != nil {
+		return nil, err
+	}
+	return f.existing, nil
+}
+
+func testPRRequest() *PRRequest {
+	return &PRRequest{
+		Title:      "fix: remediate drift",
+		Body:       "body",
+		BranchName: "remediation/sg-1",
+		Files:      map[string]string{"remediation/sg.tf": "resource {}"},
+		CommitMsg:  "fix: auto-remediation\n\ndetails",
+	}
+}
+
+func TestOpenPullRequest_CreatesNew(t *testing.T) {
+	p := &fakeProvider{}
+	result, err := OpenPullRequest(context.Background(), p, testPRRequest())
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, result.Number)
+	assert.False(t, result.Existing)
+	assert.Equal(t, []string{
+		"find:remediation/sg-1",
+		"branch:remediation/sg-1",
+		"commit:remediation/sg-1",
+		"pr:remediation/sg-1",
+	}, p.calls)
+}
+
+func TestOpenPullRequest_UpdatesExisting(t *testing.T) {
+	p := &fakeProvider{existing: &PRResult{URL: "https://example.com/pr/3", Number: 3}}
+	result, err := OpenPullRequest(context.Background(), p, testPRRequest())
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Number)
+	assert.True(t, result.Existing)
+	assert.Equal(t, []string{"find:remediation/sg-1", "commit:remediation/sg-1", "comment"}, p.calls)
+	assert.Equal(t, []string{"Updated by tfdrift-falco: fix: auto-remediation"}, p.comments)
+}
+
+func TestOpenPullRequest_Errors(t *testing.T) {
+	_, err := OpenPullRequest(context.Background(), &fakeProvider{}, nil)
+	assert.EqualError(t, err, "pr request cannot be nil")
+
+	_, err = OpenPullRequest(context.Background(), &fakeProvider{}, &PRRequest{BranchName: "b", CommitMsg: "m"})
+	assert.EqualError(t, err, "title is required")
+
+	p := &fakeProvider{failOn: "branch:remediation/sg-1"}
+	_, err = OpenPullRequest(context.Background(), p, testPRRequest())
+	assert.ErrorContains(t, err, "failed to create branch")
+	assert.NotContains(t, p.calls, "pr:remediation/sg-1")
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		want    string
+		wantErr string
+	}{
+		{"default is github", Options{Owner: "o", Repo: "r"}, KindGitHub, ""},
+		{"github enterprise", Options{Kind: KindGitHub, URL: "https://ghe.example.com", Owner: "o", Repo: "r"}, KindGitHub, ""},
+		{"gitlab.com", Options{Kind: KindGitLab, Owner: "group/sub", Repo: "r"}, KindGitLab, ""},
+		{"bitbucket", Options{Kind: KindBitbucket, URL: "https://bb.example.com", Owner: "INFRA", Repo: "r"}, KindBitbucket, ""},
+		{"gitea", Options{Kind: KindGitea, URL: "https://gitea.example.com", Owner: "o", Repo: "r"}, KindGitea, ""},
+		{"bitbucket needs url", Options{Kind: KindBitbucket, Owner: "o", Repo: "r"}, "", "url is required"},
+		{"gitea needs url", Options{Kind: KindGitea, Owner: "o", Repo: "r"}, "", "url is required"},
+		{"owner required", Options{Kind: KindGitLab, Repo: "r"}, "", "owner and repo are required"},
+		{"unknown kind", Options{Kind: "svn", Owner: "o", Repo: "r"}, "", "unsupported vcs provider"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProvider(tt.opts)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p.Name())
+		})
+	}
+
+	p, err := NewProvider(Options{URL: "https://ghe.example.com/", Owner: "o", Repo: "r"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://ghe.example.com/api/v3", p.(*GitHubClient).apiBase)
+}
+
+func TestGitHubClient_FindPullRequestAndComment(t *testing.T) {
+	var commented string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/owner/repo/pulls":
+			assert.Equal(t, "owner:remediation/sg-1", r.URL.Query().Get("head"))
+			assert.Equal(t, "main", r.URL.Query().Get("base"))
+			assert.Equal(t, "open", r.URL.Query().Get("state"))
+			_ = json.NewEncoder(w).Encode([]prResponse{{Number: 9, HTMLURL: "https://github.com/owner/repo/pull/9"}})
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/owner/repo/issues/9/comments":
+			var req commentRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			commented = req.Body
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("owner", "repo", "main", "token")
+	client.apiBase = server.URL
+
+	pr, err := client.FindPullRequest(context.Background(), "remediation/sg-1")
+	require.NoError(t, err)
+	require.NotNil(t, pr)
+	assert.Equal(t, 9, pr.Number)
+
+	require.NoError(t, client.Comment(context.Background(), 9, "hello"))
+	assert.Equal(t, "hello", commented)
+}
@@ -82,6 +82,13 @@ reason := "S3 bucket public access detected — auto-remediate" if {
 	_is_public_access(input.new_value)
 }
 
+# How a remediation PR reconciles the source when remediation.source_dir is
+# set: "update_code" (default) writes the cloud value into the resource
+# block, "ignore_changes" adds the attribute to lifecycle.ignore_changes.
+# For example, to accept tag edits made outside Terraform:
+#
+#   remediation := "ignore_changes" if input.attribute == "tags"
+
 # ---------- DENY rules (policy violations that must be escalated) ----------
 
 # IAM policy changes by unknown users